@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
@@ -86,6 +87,10 @@ func requestDogstatsdStats() error {
 
 		if len(errMap["error_type"]) > 0 {
 			fmt.Println(e)
+			// the context limiter stats are available without the metrics stats
+			if limiterStats := requestContextLimiterStats(c, ipcAddress); limiterStats != "" {
+				fmt.Printf("\n%s\n", limiterStats)
+			}
 			return nil
 		}
 
@@ -107,6 +112,9 @@ func requestDogstatsdStats() error {
 			fmt.Printf("Could not format the statistics, the data must be inconsistent. You may want to try the JSON output. Contact the support if you continue having issues.\n")
 			return nil
 		}
+		if limiterStats := requestContextLimiterStats(c, ipcAddress); limiterStats != "" {
+			s += "\n\n" + limiterStats
+		}
 	}
 
 	if dsdStatsFilePath == "" {
@@ -130,3 +138,18 @@ func requestDogstatsdStats() error {
 
 	return nil
 }
+
+// requestContextLimiterStats returns the formatted statistics of the dogstatsd context limiter, or
+// an empty string if they can't be retrieved.
+func requestContextLimiterStats(c *http.Client, ipcAddress string) string {
+	urlstr := fmt.Sprintf("https://%v:%v/agent/dogstatsd-context-limiter", ipcAddress, config.Datadog.GetInt("cmd_port"))
+	r, err := util.DoGet(c, urlstr, util.LeaveConnectionOpen)
+	if err != nil {
+		return ""
+	}
+	s, err := dogstatsd.FormatContextLimiterStats(r)
+	if err != nil {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/grok"
+)
+
+var (
+	parseTestPattern     string
+	parseTestDefinitions []string
+	parseTestList        bool
+)
+
+func init() {
+	AgentCmd.AddCommand(logsCmd)
+	logsCmd.AddCommand(parseTestCmd)
+	parseTestCmd.Flags().StringVarP(&parseTestPattern, "pattern", "p", "", "grok pattern of a grok_parser processing rule, e.g. '%{NGINX_ACCESS}'")
+	parseTestCmd.Flags().StringArrayVarP(&parseTestDefinitions, "definition", "d", nil, "definition of a pattern referenced by the pattern, as NAME=PATTERN, can be repeated")
+	parseTestCmd.Flags().BoolVarP(&parseTestList, "list", "l", false, "list the built-in patterns")
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [command]",
+	Short: "Logs collection related commands",
+	Long:  ``,
+}
+
+var parseTestCmd = &cobra.Command{
+	Use:   "parse-test [file...]",
+	Short: "Test a grok parsing rule on sample log lines",
+	Long: `Parse the lines of the given files, or of the standard input, with the pattern of a
+grok_parser processing rule and print the attributes extracted from each line.
+It does not need a running agent nor any configuration.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if parseTestList {
+			return listPatterns(cmd.OutOrStdout())
+		}
+		if parseTestPattern == "" {
+			return fmt.Errorf("a pattern must be provided with --pattern")
+		}
+		definitions := make(map[string]string, len(parseTestDefinitions))
+		for _, definition := range parseTestDefinitions {
+			parts := strings.SplitN(definition, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				return fmt.Errorf("invalid definition %s, it must be NAME=PATTERN", definition)
+			}
+			definitions[parts[0]] = parts[1]
+		}
+		pattern, err := grok.CompileWithDefinitions(parseTestPattern, definitions)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			return parseTest(pattern, cmd.InOrStdin(), cmd.OutOrStdout())
+		}
+		for _, path := range args {
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			err = parseTest(pattern, file, cmd.OutOrStdout())
+			file.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// parseTest prints the attributes extracted by the pattern from each line of the input.
+func parseTest(pattern *grok.Pattern, input io.Reader, output io.Writer) error {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lines, matched := 0, 0
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		lines++
+		fmt.Fprintf(output, "line %d: %s\n", lines, line)
+		attributes, ok := pattern.Parse(line)
+		if !ok {
+			fmt.Fprintln(output, "no match")
+			continue
+		}
+		matched++
+		encoded, err := json.MarshalIndent(attributes, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(output, "%s\n", encoded)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "\n%d/%d lines matched\n", matched, lines)
+	return nil
+}
+
+func listPatterns(output io.Writer) error {
+	names := make([]string, 0, len(grok.Patterns))
+	for name := range grok.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(output, "%s: %s\n", name, grok.Patterns[name])
+	}
+	return nil
+}
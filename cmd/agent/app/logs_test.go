@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/grok"
+)
+
+func TestParseTest(t *testing.T) {
+	pattern, err := grok.Compile(`%{WORD:http.method} %{INT:http.status_code:int}`)
+	require.NoError(t, err)
+
+	input := strings.NewReader("GET 200\n\nnot a request\n")
+	var output bytes.Buffer
+	require.NoError(t, parseTest(pattern, input, &output))
+	assert.Equal(t, `line 1: GET 200
+{
+  "http": {
+    "method": "GET",
+    "status_code": 200
+  }
+}
+line 2: not a request
+no match
+
+1/2 lines matched
+`, output.String())
+}
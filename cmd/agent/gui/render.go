@@ -145,6 +145,9 @@ func lastErrorMessage(value string) string {
 }
 
 func displayStatus(check map[string]interface{}) template.HTML {
+	if check["Quarantine"] != nil {
+		return template.HTML("[<span class=\"error\">QUARANTINED</span>]")
+	}
 	if check["LastError"].(string) != "" {
 		return template.HTML("[<span class=\"error\">ERROR</span>]")
 	}
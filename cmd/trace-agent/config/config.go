@@ -28,6 +28,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/config/features"
+	"github.com/DataDog/datadog-agent/pkg/trace/filters"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/DataDog/datadog-agent/pkg/util/fargate"
 	"github.com/DataDog/datadog-agent/pkg/util/grpc"
@@ -208,6 +209,38 @@ func applyDatadogConfig(c *config.AgentConfig) error {
 		c.MaxRemoteTPS = coreconfig.Datadog.GetFloat64("apm_config.max_remote_traces_per_second")
 	}
 
+	if k := "apm_config.extra_aggregation_tags"; coreconfig.Datadog.IsSet(k) {
+		tags := coreconfig.Datadog.GetStringSlice(k)
+		if len(tags) > config.MaxExtraAggregationTags {
+			log.Warnf("%s: only the first %d of the %d configured tags are used as stats dimensions", k, config.MaxExtraAggregationTags, len(tags))
+			tags = tags[:config.MaxExtraAggregationTags]
+		}
+		c.ExtraAggregationTags = tags
+	}
+	if k := "apm_config.extra_aggregation_tags_max_cardinality"; coreconfig.Datadog.IsSet(k) {
+		if n := coreconfig.Datadog.GetInt(k); n > 0 {
+			c.ExtraAggregationTagsMaxCardinality = n
+		} else {
+			log.Warnf("%s must be positive, using the default of %d", k, c.ExtraAggregationTagsMaxCardinality)
+		}
+	}
+
+	c.TailSampling.Enabled = coreconfig.Datadog.GetBool("apm_config.tail_sampling.enabled")
+	if k := "apm_config.tail_sampling.decision_wait_seconds"; coreconfig.Datadog.IsSet(k) {
+		c.TailSampling.DecisionWait = getDuration(coreconfig.Datadog.GetInt(k))
+	}
+	if k := "apm_config.tail_sampling.max_buffer_bytes"; coreconfig.Datadog.IsSet(k) {
+		c.TailSampling.MaxBufferBytes = coreconfig.Datadog.GetInt64(k)
+	}
+	if k := "apm_config.tail_sampling.policies"; coreconfig.Datadog.IsSet(k) {
+		var policies []*config.TailSamplingPolicy
+		if err := coreconfig.Datadog.UnmarshalKey(k, &policies); err != nil {
+			log.Errorf("Bad format for %q: %v", k, err)
+		} else {
+			c.TailSampling.Policies = policies
+		}
+	}
+
 	if k := "apm_config.ignore_resources"; coreconfig.Datadog.IsSet(k) {
 		c.Ignore["resource"] = coreconfig.Datadog.GetStringSlice(k)
 	}
@@ -226,6 +259,17 @@ func applyDatadogConfig(c *config.AgentConfig) error {
 			c.ReplaceTags = rt
 		}
 	}
+	if k := "apm_config.scrub_rules"; coreconfig.Datadog.IsSet(k) {
+		var rules []*config.ScrubRule
+		if err := coreconfig.Datadog.UnmarshalKey(k, &rules); err != nil {
+			log.Errorf("Bad format for %q it should be a list of rules of the form '{\"name\": \"rule_name\",\"action\":\"action\",\"key\":\"pattern\"}', error: %v", k, err)
+		} else {
+			if err := filters.CompileScrubRules(rules); err != nil {
+				osutil.Exitf("scrub_rules: %s", err)
+			}
+			c.ScrubRules = rules
+		}
+	}
 
 	if coreconfig.Datadog.IsSet("bind_host") || coreconfig.Datadog.IsSet("apm_config.apm_non_local_traffic") {
 		if coreconfig.Datadog.IsSet("bind_host") {
@@ -260,6 +304,12 @@ func applyDatadogConfig(c *config.AgentConfig) error {
 		SpanNameRemappings:     coreconfig.Datadog.GetStringMapString("otlp_config.traces.span_name_remappings"),
 		SpanNameAsResourceName: coreconfig.Datadog.GetBool("otlp_config.traces.span_name_as_resource_name"),
 	}
+	c.Zipkin.Enabled = coreconfig.Datadog.GetBool("apm_config.zipkin.enabled")
+	c.Jaeger = config.JaegerConfig{
+		Enabled:  coreconfig.Datadog.GetBool("apm_config.jaeger.enabled"),
+		BindHost: c.ReceiverHost,
+		GRPCPort: coreconfig.Datadog.GetInt("apm_config.jaeger.grpc_port"),
+	}
 
 	if coreconfig.Datadog.GetBool("apm_config.telemetry.enabled") {
 		c.TelemetryConfig.Enabled = true
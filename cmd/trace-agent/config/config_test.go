@@ -466,6 +466,22 @@ func TestFullYamlConfig(t *testing.T) {
 
 	assert.EqualValues([]string{"/health", "/500"}, c.Ignore["resource"])
 
+	assert.Equal(config.TailSamplingConfig{
+		Enabled:        true,
+		DecisionWait:   10 * time.Second,
+		MaxBufferBytes: 100 * 1024 * 1024,
+		Policies: []*config.TailSamplingPolicy{
+			{Name: "slow", Type: "latency", ThresholdMs: 500},
+			{Type: "tag", Key: "customer_tier", Values: []string{"gold"}},
+		},
+	}, c.TailSampling)
+
+	assert.Equal([]*config.ScrubRule{
+		{Name: "drop_tag", Action: "drop_tag", Key: "^secret\\.", KeyRe: regexp.MustCompile("^secret\\.")},
+		{Name: "user_ids", Action: "hash", Key: "^usr\\.id$", KeyRe: regexp.MustCompile("^usr\\.id$"), HMACKey: "key"},
+		{Name: "mask_pii", Action: "mask_pii", PII: []string{"email"}},
+	}, c.ScrubRules)
+
 	o := c.Obfuscation
 	assert.NotNil(o)
 	assert.True(o.ES.Enabled)
@@ -477,6 +493,11 @@ func TestFullYamlConfig(t *testing.T) {
 	assert.True(o.RemoveStackTraces)
 	assert.True(o.Redis.Enabled)
 	assert.True(o.Memcached.Enabled)
+	assert.True(o.GraphQL.Enabled)
+	assert.EqualValues([]string{"locale"}, o.GraphQL.KeepValues)
+	assert.True(o.JSONBody.Enabled)
+	assert.EqualValues([]string{"TableName"}, o.JSONBody.KeepValues)
+	assert.EqualValues([]string{"Statement"}, o.JSONBody.ObfuscateSQLValues)
 	assert.True(o.CreditCards.Enabled)
 	assert.True(o.CreditCards.Luhn)
 }
@@ -817,6 +838,37 @@ func TestLoadEnv(t *testing.T) {
 		assert.Contains(cfg.ReplaceTags, rule2)
 	})
 
+	env = "DD_APM_SCRUB_RULES"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		err := os.Setenv(env, `[{"action":"truncate","key":"^sql\\.","max_length":100}, {"action":"drop_span","service":"web","operation":"redis.command"}]`)
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		cfg, err := LoadConfigFile("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal([]*config.ScrubRule{
+			{Name: "truncate", Action: "truncate", Key: "^sql\\.", KeyRe: regexp.MustCompile("^sql\\."), MaxLength: 100},
+			{Name: "drop_span", Action: "drop_span", Service: "web", Operation: "redis.command"},
+		}, cfg.ScrubRules)
+	})
+
+	env = "DD_APM_EXTRA_AGGREGATION_TAGS"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		err := os.Setenv(env, "a b c d e f g")
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		err = os.Setenv("DD_APM_EXTRA_AGGREGATION_TAGS_MAX_CARDINALITY", "20")
+		assert.NoError(err)
+		defer os.Unsetenv("DD_APM_EXTRA_AGGREGATION_TAGS_MAX_CARDINALITY")
+		cfg, err := LoadConfigFile("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal([]string{"a", "b", "c", "d", "e"}, cfg.ExtraAggregationTags)
+		assert.Equal(20, cfg.ExtraAggregationTagsMaxCardinality)
+	})
+
 	env = "DD_APM_FILTER_TAGS_REQUIRE"
 	t.Run(env, func(t *testing.T) {
 		defer cleanConfig()()
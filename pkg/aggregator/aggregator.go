@@ -188,6 +188,7 @@ func init() {
 	tagsetTlm = newTagsetTelemetry([]uint64{90, 100})
 
 	aggregatorExpvars.Set("MetricTags", expvar.Func(expMetricTags))
+	aggregatorExpvars.Set("DogstatsdContextLimiter", expvar.Func(expContextLimiter))
 }
 
 // InitAggregator returns the Singleton instance
@@ -223,6 +224,7 @@ type BufferedAggregator struct {
 	MetricSamplePool *metrics.MetricSamplePool
 
 	tagsStore              *tags.Store
+	relabelRules           *relabelRules // applied by the check samplers and the time samplers
 	checkSamplers          map[check.ID]*CheckSampler
 	serviceChecks          metrics.ServiceChecks
 	events                 metrics.Events
@@ -296,6 +298,7 @@ func NewBufferedAggregator(s serializer.MetricSerializer, eventPlatformForwarder
 		contLcycleStopper: make(chan struct{}),
 
 		tagsStore:                   tagsStore,
+		relabelRules:                newRelabelRulesFromConfig(),
 		checkSamplers:               make(map[check.ID]*CheckSampler),
 		flushInterval:               flushInterval,
 		serializer:                  s,
@@ -355,6 +358,7 @@ func (agg *BufferedAggregator) registerSender(id check.ID) error {
 		config.Datadog.GetBool("check_sampler_expire_metrics"),
 		config.Datadog.GetDuration("check_sampler_stateful_metric_expiration_time"),
 		agg.tagsStore,
+		agg.relabelRules,
 	)
 	return nil
 }
@@ -25,23 +25,38 @@ type CheckSampler struct {
 	metrics         metrics.CheckMetrics
 	sketchMap       sketchMap
 	lastBucketValue map[ckey.ContextKey]int64
+	// relabeler applies the metric relabel rules to the samples, if set.
+	relabeler *relabeler
 }
 
-// newCheckSampler returns a newly initialized CheckSampler
-func newCheckSampler(expirationCount int, expireMetrics bool, statefulTimeout time.Duration, cache *tags.Store) *CheckSampler {
+// newCheckSampler returns a newly initialized CheckSampler, whose samples are relabeled by
+// relabelRules if not nil.
+func newCheckSampler(expirationCount int, expireMetrics bool, statefulTimeout time.Duration, cache *tags.Store, relabelRules *relabelRules) *CheckSampler {
+	relabeler := relabelRules.newRelabeler()
 	return &CheckSampler{
 		series:          make([]*metrics.Serie, 0),
 		sketches:        make(metrics.SketchSeriesList, 0),
-		contextResolver: newCountBasedContextResolver(expirationCount, cache),
+		contextResolver: newCountBasedContextResolver(expirationCount, cache, relabeler),
 		metrics:         metrics.NewCheckMetrics(expireMetrics, statefulTimeout),
 		sketchMap:       make(sketchMap),
 		lastBucketValue: make(map[ckey.ContextKey]int64),
+		relabeler:       relabeler,
 	}
 }
 
 func (cs *CheckSampler) addSample(metricSample *metrics.MetricSample) {
+	if cs.relabeler != nil && !cs.relabeler.relabelSample(metricSample) {
+		return
+	}
+
 	contextKey := cs.contextResolver.trackContext(metricSample)
 
+	// the checks don't submit distributions, but the relabel rules may turn their metrics into ones
+	if metricSample.Mtype == metrics.DistributionType {
+		cs.sketchMap.insert(int64(metricSample.Timestamp), contextKey, metricSample.Value, metricSample.SampleRate)
+		return
+	}
+
 	if err := cs.metrics.AddSample(contextKey, metricSample, metricSample.Timestamp, 1); err != nil {
 		log.Debugf("Ignoring sample '%s' on host '%s' and tags '%s': %s", metricSample.Name, metricSample.Host, metricSample.Tags, err)
 	}
@@ -80,6 +95,10 @@ func (cs *CheckSampler) addBucket(bucket *metrics.HistogramBucket) {
 		return
 	}
 
+	if cs.relabeler != nil && cs.relabeler.dropped(bucket.Name) {
+		return
+	}
+
 	contextKey := cs.contextResolver.trackContext(bucket)
 
 	// if the bucket is monotonic and we have already seen the bucket we only send the delta
@@ -0,0 +1,350 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// contextLimitDrop drops the samples of the new contexts over the limits.
+	contextLimitDrop = "drop"
+	// contextLimitOverflow rewrites the values of the tags of the new contexts over the limits to
+	// overflowTagValue, so that their samples are aggregated in a single context.
+	contextLimitOverflow = "overflow"
+
+	overflowTagValue = "overflow"
+
+	// maxContextLimiterOffenders is the number of worst offenders reported in the stats.
+	maxContextLimiterOffenders = 10
+	// maxContextLimiterRejected is the number of limited contexts remembered, so that each of
+	// them is counted once rather than once per sample. The set is cleared when full.
+	maxContextLimiterRejected = 100000
+)
+
+var (
+	tlmDogstatsdContextsLimited = telemetry.NewCounter("aggregator", "dogstatsd_contexts_limited",
+		[]string{"reason", "action"}, "Count the number of new dogstatsd contexts over the context limits, by reason and action")
+
+	// globalContextLimiter is the limiter of the running demultiplexer, reported in the expvars.
+	globalContextLimiter   *contextLimiter
+	globalContextLimiterMu sync.Mutex
+)
+
+// contextLimiterConfig is the configuration of the context limiter, in the
+// `dogstatsd_context_limits` section of the configuration.
+type contextLimiterConfig struct {
+	// PerMetric is the maximum number of contexts of each metric name, 0 for no limit.
+	PerMetric int `mapstructure:"per_metric"`
+	// Metrics overrides PerMetric for the given metric names.
+	Metrics map[string]int `mapstructure:"metrics"`
+	// PerTagKey is the maximum number of distinct values of the given tag keys for each metric name,
+	// 0 for no limit.
+	PerTagKey map[string]int `mapstructure:"per_tag_key"`
+	// Action is either contextLimitDrop or contextLimitOverflow.
+	Action string `mapstructure:"action"`
+}
+
+// contextLimiter limits the number of contexts of each metric name, and the number of distinct
+// values of some tag keys for each metric name, so that a tag with an unbounded number of values
+// does not create an unbounded number of contexts.
+//
+// The limiter is shared by the time samplers, each of them tracking a shard of the contexts of
+// every metric name. As the samplers admit their new contexts concurrently, the limits may be
+// exceeded by at most the number of samplers.
+type contextLimiter struct {
+	mu sync.Mutex
+
+	perMetric    int
+	metricLimits map[string]int
+	tagKeyLimits map[string]int
+	overflow     bool
+
+	metrics map[string]*metricContexts
+
+	// rejected holds the keys of the contexts already limited, to count them once.
+	rejected map[ckey.ContextKey]struct{}
+	// limited holds the number of contexts limited, by metric name and tag key.
+	limited    map[ContextLimiterOffender]uint64
+	dropped    uint64
+	overflowed uint64
+}
+
+// metricContexts holds the contexts tracked for a metric name.
+type metricContexts struct {
+	count int
+	// tagValues holds the number of contexts by value of the limited tag keys.
+	tagValues map[string]map[string]int
+}
+
+// newContextLimiter returns a context limiter, or nil if no limit is configured.
+func newContextLimiter(cfg contextLimiterConfig) *contextLimiter {
+	if cfg.PerMetric <= 0 && len(cfg.Metrics) == 0 && len(cfg.PerTagKey) == 0 {
+		return nil
+	}
+	switch cfg.Action {
+	case contextLimitDrop, contextLimitOverflow:
+	default:
+		log.Warnf("Unknown dogstatsd_context_limits.action %q, the contexts over the limits will be dropped", cfg.Action)
+	}
+	return &contextLimiter{
+		perMetric:    cfg.PerMetric,
+		metricLimits: cfg.Metrics,
+		tagKeyLimits: cfg.PerTagKey,
+		overflow:     cfg.Action == contextLimitOverflow,
+		metrics:      make(map[string]*metricContexts),
+		rejected:     make(map[ckey.ContextKey]struct{}),
+		limited:      make(map[ContextLimiterOffender]uint64),
+	}
+}
+
+// newContextLimiterFromConfig returns the context limiter configured in the agent configuration,
+// or nil if no limit is configured, and reports it in the expvars.
+func newContextLimiterFromConfig() *contextLimiter {
+	var cfg contextLimiterConfig
+	if err := config.Datadog.UnmarshalKey("dogstatsd_context_limits", &cfg); err != nil {
+		log.Errorf("Invalid dogstatsd_context_limits, the contexts will not be limited: %s", err)
+		return nil
+	}
+	l := newContextLimiter(cfg)
+	globalContextLimiterMu.Lock()
+	globalContextLimiter = l
+	globalContextLimiterMu.Unlock()
+	return l
+}
+
+func (l *contextLimiter) metricLimit(name string) int {
+	if limit, ok := l.metricLimits[name]; ok {
+		return limit
+	}
+	return l.perMetric
+}
+
+// admit reports whether a new context of the given key and metric name, with the tags of the
+// given buffers, is admitted. In the overflow mode, the contexts over the limits are admitted
+// after the values of their offending tags are rewritten in the buffers: the caller must then
+// generate the context key again.
+// The samples of a context over the limits are all limited, but the context is counted once.
+func (l *contextLimiter) admit(key ckey.ContextKey, name string, taggerBuffer, metricBuffer *tagset.HashingTagsAccumulator) (admitted, rewritten bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	m := l.metrics[name]
+	offender := ContextLimiterOffender{Metric: name}
+	reason := "metric_limit"
+	if limit := l.metricLimit(name); limit <= 0 || m == nil || m.count < limit {
+		// the number of contexts is below the limit, check the tag values
+		var overLimit map[string]bool // offending tags
+		for _, buf := range [...]*tagset.HashingTagsAccumulator{taggerBuffer, metricBuffer} {
+			for _, tag := range buf.Get() {
+				if key, value := splitTag(tag); l.tagValueOverLimit(m, key, value) {
+					if overLimit == nil {
+						overLimit = make(map[string]bool)
+						offender.TagKey = key
+					}
+					overLimit[tag] = true
+				}
+			}
+		}
+		if overLimit == nil {
+			delete(l.rejected, key)
+			return true, false
+		}
+		reason = "tag_limit"
+		if l.overflow {
+			rewriteTags(taggerBuffer, overLimit)
+			rewriteTags(metricBuffer, overLimit)
+		}
+	} else if l.overflow {
+		rewriteTags(taggerBuffer, nil)
+		rewriteTags(metricBuffer, nil)
+	}
+
+	if l.firstRejection(key) {
+		l.limited[offender]++
+		if l.overflow {
+			l.overflowed++
+			tlmDogstatsdContextsLimited.Inc(reason, contextLimitOverflow)
+		} else {
+			l.dropped++
+			tlmDogstatsdContextsLimited.Inc(reason, contextLimitDrop)
+		}
+	}
+	return l.overflow, l.overflow
+}
+
+// firstRejection records the rejection of the context key, and reports whether it is new.
+func (l *contextLimiter) firstRejection(key ckey.ContextKey) bool {
+	if _, ok := l.rejected[key]; ok {
+		return false
+	}
+	if len(l.rejected) >= maxContextLimiterRejected {
+		l.rejected = make(map[ckey.ContextKey]struct{})
+	}
+	l.rejected[key] = struct{}{}
+	return true
+}
+
+// tagValueOverLimit reports whether the tag value is new for the metric, while its key has
+// reached its limit of distinct values.
+func (l *contextLimiter) tagValueOverLimit(m *metricContexts, key, value string) bool {
+	limit, ok := l.tagKeyLimits[key]
+	if !ok || limit <= 0 || m == nil || value == overflowTagValue {
+		return false
+	}
+	values := m.tagValues[key]
+	if _, ok := values[value]; ok {
+		return false
+	}
+	return len(values) >= limit
+}
+
+// track records a new context of the metric name.
+func (l *contextLimiter) track(name string, tags tagset.CompositeTags) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	m, ok := l.metrics[name]
+	if !ok {
+		m = &metricContexts{}
+		l.metrics[name] = m
+	}
+	m.count++
+	tags.ForEach(func(tag string) {
+		key, value := splitTag(tag)
+		if _, ok := l.tagKeyLimits[key]; !ok || value == overflowTagValue {
+			return
+		}
+		if m.tagValues == nil {
+			m.tagValues = make(map[string]map[string]int)
+		}
+		if m.tagValues[key] == nil {
+			m.tagValues[key] = make(map[string]int)
+		}
+		m.tagValues[key][value]++
+	})
+}
+
+// untrack forgets an expired context of the metric name.
+func (l *contextLimiter) untrack(name string, tags tagset.CompositeTags) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	m, ok := l.metrics[name]
+	if !ok {
+		return
+	}
+	if m.count--; m.count <= 0 {
+		delete(l.metrics, name)
+		return
+	}
+	tags.ForEach(func(tag string) {
+		key, value := splitTag(tag)
+		values, ok := m.tagValues[key]
+		if !ok {
+			return
+		}
+		if values[value]--; values[value] <= 0 {
+			delete(values, value)
+		}
+	})
+}
+
+// ContextLimiterStats holds the statistics of the dogstatsd context limiter.
+type ContextLimiterStats struct {
+	Action     string
+	Dropped    uint64
+	Overflowed uint64
+	// Offenders are the metrics with the most contexts limited.
+	Offenders []ContextLimiterOffender
+}
+
+// ContextLimiterOffender is a metric whose new contexts were limited, because of its number of
+// contexts or because of the number of distinct values of one of its tag keys.
+type ContextLimiterOffender struct {
+	Metric string
+	TagKey string `json:",omitempty"`
+	// Limited is the number of contexts limited.
+	Limited uint64
+}
+
+func (l *contextLimiter) stats() *ContextLimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := &ContextLimiterStats{
+		Action:     contextLimitDrop,
+		Dropped:    l.dropped,
+		Overflowed: l.overflowed,
+		Offenders:  make([]ContextLimiterOffender, 0, len(l.limited)),
+	}
+	if l.overflow {
+		s.Action = contextLimitOverflow
+	}
+	for o, n := range l.limited {
+		o.Limited = n
+		s.Offenders = append(s.Offenders, o)
+	}
+	sort.Slice(s.Offenders, func(i, j int) bool {
+		if s.Offenders[i].Limited != s.Offenders[j].Limited {
+			return s.Offenders[i].Limited > s.Offenders[j].Limited
+		}
+		return s.Offenders[i].Metric < s.Offenders[j].Metric
+	})
+	if len(s.Offenders) > maxContextLimiterOffenders {
+		s.Offenders = s.Offenders[:maxContextLimiterOffenders]
+	}
+	return s
+}
+
+// GetContextLimiterStats returns the statistics of the dogstatsd context limiter, or nil if the
+// contexts are not limited.
+func GetContextLimiterStats() *ContextLimiterStats {
+	globalContextLimiterMu.Lock()
+	l := globalContextLimiter
+	globalContextLimiterMu.Unlock()
+	if l == nil {
+		return nil
+	}
+	return l.stats()
+}
+
+func expContextLimiter() interface{} {
+	return GetContextLimiterStats()
+}
+
+// rewriteTags replaces the values of the given tags of buf, or of all its tags if tags is nil,
+// with overflowTagValue.
+func rewriteTags(buf *tagset.HashingTagsAccumulator, tags map[string]bool) {
+	old := append([]string(nil), buf.Get()...)
+	buf.Reset()
+	for _, tag := range old {
+		if tags == nil || tags[tag] {
+			if key, _ := splitTag(tag); key != "" {
+				tag = key + ":" + overflowTagValue
+			} else {
+				tag = overflowTagValue
+			}
+		}
+		buf.Append(tag)
+	}
+}
+
+// splitTag splits a tag into its key and value. The tags without a colon have no key.
+func splitTag(tag string) (key, value string) {
+	if i := strings.IndexByte(tag, ':'); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return "", tag
+}
@@ -0,0 +1,214 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+// +build test
+
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+func limitedSample(name string, tags ...string) *metrics.MetricSample {
+	return &metrics.MetricSample{
+		Name:       name,
+		Value:      1,
+		Mtype:      metrics.GaugeType,
+		Tags:       tags,
+		SampleRate: 1,
+	}
+}
+
+func testContextLimiterDrop(t *testing.T, store *tags.Store) {
+	limiter := newContextLimiter(contextLimiterConfig{
+		PerMetric: 2,
+		Metrics:   map[string]int{"unlimited": 0},
+		Action:    contextLimitDrop,
+	})
+	cr := newTimestampContextResolver(store, limiter, nil)
+
+	_, ok := cr.trackContext(limitedSample("foo", "a:1"), 10)
+	assert.True(t, ok)
+	_, ok = cr.trackContext(limitedSample("foo", "a:2"), 10)
+	assert.True(t, ok)
+	_, ok = cr.trackContext(limitedSample("foo", "a:3"), 10)
+	assert.False(t, ok)
+	// the limited contexts are counted once
+	_, ok = cr.trackContext(limitedSample("foo", "a:3"), 10)
+	assert.False(t, ok)
+	// the known contexts are still tracked
+	_, ok = cr.trackContext(limitedSample("foo", "a:1"), 20)
+	assert.True(t, ok)
+	// the limits are per metric name
+	_, ok = cr.trackContext(limitedSample("bar", "a:3"), 10)
+	assert.True(t, ok)
+	for _, value := range []string{"a:1", "a:2", "a:3"} {
+		_, ok = cr.trackContext(limitedSample("unlimited", value), 10)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, 6, cr.length())
+
+	// the expired contexts make room for new ones
+	cr.expireContexts(15)
+	_, ok = cr.trackContext(limitedSample("foo", "a:3"), 20)
+	assert.True(t, ok)
+
+	stats := limiter.stats()
+	assert.Equal(t, contextLimitDrop, stats.Action)
+	assert.Equal(t, uint64(1), stats.Dropped)
+	assert.Equal(t, uint64(0), stats.Overflowed)
+	assert.Equal(t, []ContextLimiterOffender{{Metric: "foo", Limited: 1}}, stats.Offenders)
+}
+
+func TestContextLimiterDrop(t *testing.T) {
+	testWithTagsStore(t, testContextLimiterDrop)
+}
+
+func testContextLimiterTagKeyDrop(t *testing.T, store *tags.Store) {
+	limiter := newContextLimiter(contextLimiterConfig{
+		PerTagKey: map[string]int{"user": 2},
+		Action:    contextLimitDrop,
+	})
+	cr := newTimestampContextResolver(store, limiter, nil)
+
+	for _, tags := range [][]string{
+		{"user:alice", "env:a"},
+		{"user:bob", "env:a"},
+		// known values of the limited tag key
+		{"user:alice", "env:b"},
+		{"user:bob", "env:c"},
+	} {
+		_, ok := cr.trackContext(limitedSample("foo", tags...), 10)
+		assert.True(t, ok, "%v", tags)
+	}
+	_, ok := cr.trackContext(limitedSample("foo", "user:carol", "env:a"), 10)
+	assert.False(t, ok)
+	_, ok = cr.trackContext(limitedSample("bar", "user:carol", "env:a"), 10)
+	assert.True(t, ok)
+
+	stats := limiter.stats()
+	assert.Equal(t, uint64(1), stats.Dropped)
+	assert.Equal(t, []ContextLimiterOffender{{Metric: "foo", TagKey: "user", Limited: 1}}, stats.Offenders)
+}
+
+func TestContextLimiterTagKeyDrop(t *testing.T) {
+	testWithTagsStore(t, testContextLimiterTagKeyDrop)
+}
+
+func testContextLimiterOverflow(t *testing.T, store *tags.Store) {
+	limiter := newContextLimiter(contextLimiterConfig{
+		PerMetric: 3,
+		PerTagKey: map[string]int{"user": 1},
+		Action:    contextLimitOverflow,
+	})
+	cr := newTimestampContextResolver(store, limiter, nil)
+
+	key, ok := cr.trackContext(limitedSample("foo", "user:alice", "env:a"), 10)
+	require.True(t, ok)
+	context, _ := cr.get(key)
+	assertContext(t, context, "foo", []string{"user:alice", "env:a"}, "")
+
+	// only the offending tag is rewritten
+	key, ok = cr.trackContext(limitedSample("foo", "user:bob", "env:a"), 10)
+	require.True(t, ok)
+	context, _ = cr.get(key)
+	assertContext(t, context, "foo", []string{"user:overflow", "env:a"}, "")
+	overflowKey := key
+
+	// the overflow context is reused
+	key, ok = cr.trackContext(limitedSample("foo", "user:carol", "env:a"), 10)
+	require.True(t, ok)
+	assert.Equal(t, overflowKey, key)
+	// the limited contexts are counted once
+	key, ok = cr.trackContext(limitedSample("foo", "user:carol", "env:a"), 10)
+	require.True(t, ok)
+	assert.Equal(t, overflowKey, key)
+
+	key, ok = cr.trackContext(limitedSample("foo", "user:alice", "env:b"), 10)
+	require.True(t, ok)
+	assert.NotEqual(t, overflowKey, key)
+
+	// all the tags are rewritten once the metric limit is reached
+	key, ok = cr.trackContext(limitedSample("foo", "user:alice", "env:c", "standalone"), 10)
+	require.True(t, ok)
+	context, _ = cr.get(key)
+	assertContext(t, context, "foo", []string{"user:overflow", "env:overflow", "overflow"}, "")
+	assert.Equal(t, 4, cr.length())
+
+	stats := limiter.stats()
+	assert.Equal(t, contextLimitOverflow, stats.Action)
+	assert.Equal(t, uint64(0), stats.Dropped)
+	assert.Equal(t, uint64(3), stats.Overflowed)
+	assert.Equal(t, []ContextLimiterOffender{
+		{Metric: "foo", TagKey: "user", Limited: 2},
+		{Metric: "foo", Limited: 1},
+	}, stats.Offenders)
+}
+
+func TestContextLimiterOverflow(t *testing.T) {
+	testWithTagsStore(t, testContextLimiterOverflow)
+}
+
+func TestContextLimiterTimeSampler(t *testing.T) {
+	limiter := newContextLimiter(contextLimiterConfig{PerMetric: 1, Action: contextLimitDrop})
+	sampler := NewTimeSampler(TimeSamplerID(0), 10, tags.NewStore(false, "test"), limiter, nil)
+
+	sampler.sample(limitedSample("foo", "a:1"), 12345)
+	sampler.sample(limitedSample("foo", "a:2"), 12345)
+	series, _ := flushSerie(sampler, 12360)
+
+	require.Len(t, series, 1)
+	assert.Equal(t, []string{"a:1"}, series[0].Tags.UnsafeToReadOnlySliceString())
+}
+
+func TestContextLimiterStatsOffenders(t *testing.T) {
+	limiter := newContextLimiter(contextLimiterConfig{PerMetric: 1})
+	for i := 0; i < maxContextLimiterOffenders+5; i++ {
+		name := string(rune('a' + i))
+		limiter.track(name, tagset.CompositeTags{})
+		for j := 0; j <= i; j++ {
+			admitted, _ := limiter.admit(ckey.ContextKey(i<<8|j), name, nil, nil)
+			assert.False(t, admitted)
+		}
+	}
+
+	stats := limiter.stats()
+	require.Len(t, stats.Offenders, maxContextLimiterOffenders)
+	assert.Equal(t, ContextLimiterOffender{Metric: "o", Limited: 15}, stats.Offenders[0])
+	assert.Equal(t, ContextLimiterOffender{Metric: "f", Limited: 6}, stats.Offenders[maxContextLimiterOffenders-1])
+}
+
+func TestNewContextLimiterFromConfig(t *testing.T) {
+	defer config.Datadog.Set("dogstatsd_context_limits", nil)
+	defer newContextLimiterFromConfig()
+
+	config.Datadog.Set("dogstatsd_context_limits", map[string]interface{}{})
+	assert.Nil(t, newContextLimiterFromConfig())
+	assert.Nil(t, GetContextLimiterStats())
+
+	config.Datadog.Set("dogstatsd_context_limits", map[string]interface{}{
+		"per_metric":  100,
+		"metrics":     map[string]interface{}{"foo": 1000},
+		"per_tag_key": map[string]interface{}{"user": 10},
+		"action":      "overflow",
+	})
+	limiter := newContextLimiterFromConfig()
+	require.NotNil(t, limiter)
+	assert.Equal(t, 100, limiter.perMetric)
+	assert.Equal(t, map[string]int{"foo": 1000}, limiter.metricLimits)
+	assert.Equal(t, map[string]int{"user": 10}, limiter.tagKeyLimits)
+	assert.True(t, limiter.overflow)
+	assert.Equal(t, &ContextLimiterStats{Action: contextLimitOverflow, Offenders: []ContextLimiterOffender{}}, GetContextLimiterStats())
+}
@@ -41,6 +41,10 @@ type contextResolver struct {
 	keyGenerator  *ckey.KeyGenerator
 	taggerBuffer  *tagset.HashingTagsAccumulator
 	metricBuffer  *tagset.HashingTagsAccumulator
+	// limiter limits the number of new contexts, if set.
+	limiter *contextLimiter
+	// relabeler rewrites the tags of the metrics before their contexts are resolved, if set.
+	relabeler *relabeler
 }
 
 // generateContextKey generates the contextKey associated with the context of the metricSample
@@ -59,27 +63,56 @@ func newContextResolver(cache *tags.Store) *contextResolver {
 	}
 }
 
-// trackContext returns the contextKey associated with the context of the metricSample and tracks that context
-func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) ckey.ContextKey {
-	metricSampleContext.GetTags(cr.taggerBuffer, cr.metricBuffer)                  // tags here are not sorted and can contain duplicates
+// trackContext returns the contextKey associated with the context of the metricSample and tracks that context.
+// It returns false if the context is new and rejected by the limiter.
+func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) (ckey.ContextKey, bool) {
+	metricSampleContext.GetTags(cr.taggerBuffer, cr.metricBuffer) // tags here are not sorted and can contain duplicates
+	if cr.relabeler != nil {
+		cr.relabeler.relabelTags(metricSampleContext.GetName(), cr.taggerBuffer, cr.metricBuffer)
+	}
 	contextKey, taggerKey, metricKey := cr.generateContextKey(metricSampleContext) // the generator will remove duplicates (and doesn't mind the order)
 
+	tracked := true
 	if _, ok := cr.contextsByKey[contextKey]; !ok {
-		mtype := metricSampleContext.GetMetricType()
-		cr.contextsByKey[contextKey] = &Context{
-			Name:       metricSampleContext.GetName(),
-			taggerTags: cr.tagsCache.Insert(taggerKey, cr.taggerBuffer),
-			metricTags: cr.tagsCache.Insert(metricKey, cr.metricBuffer),
-			Host:       metricSampleContext.GetHost(),
-			mtype:      mtype,
-		}
-		cr.countsByMtype[mtype]++
+		contextKey, tracked = cr.newContext(metricSampleContext, contextKey, taggerKey, metricKey)
 	}
 
 	cr.taggerBuffer.Reset()
 	cr.metricBuffer.Reset()
 
-	return contextKey
+	return contextKey, tracked
+}
+
+// newContext tracks a new context, unless it is rejected by the limiter. In the overflow mode of the
+// limiter, the context may be rewritten to an overflow context, whose key is returned.
+func (cr *contextResolver) newContext(metricSampleContext metrics.MetricSampleContext, contextKey ckey.ContextKey, taggerKey, metricKey ckey.TagsKey) (ckey.ContextKey, bool) {
+	if cr.limiter != nil {
+		admitted, rewritten := cr.limiter.admit(contextKey, metricSampleContext.GetName(), cr.taggerBuffer, cr.metricBuffer)
+		if !admitted {
+			return contextKey, false
+		}
+		if rewritten {
+			contextKey, taggerKey, metricKey = cr.generateContextKey(metricSampleContext)
+			if _, ok := cr.contextsByKey[contextKey]; ok {
+				return contextKey, true
+			}
+		}
+	}
+
+	mtype := metricSampleContext.GetMetricType()
+	context := &Context{
+		Name:       metricSampleContext.GetName(),
+		taggerTags: cr.tagsCache.Insert(taggerKey, cr.taggerBuffer),
+		metricTags: cr.tagsCache.Insert(metricKey, cr.metricBuffer),
+		Host:       metricSampleContext.GetHost(),
+		mtype:      mtype,
+	}
+	cr.contextsByKey[contextKey] = context
+	cr.countsByMtype[mtype]++
+	if cr.limiter != nil {
+		cr.limiter.track(context.Name, context.Tags())
+	}
+	return contextKey, true
 }
 
 func (cr *contextResolver) get(key ckey.ContextKey) (*Context, bool) {
@@ -98,6 +131,9 @@ func (cr *contextResolver) removeKeys(expiredContextKeys []ckey.ContextKey) {
 
 		if context != nil {
 			cr.countsByMtype[context.mtype]--
+			if cr.limiter != nil {
+				cr.limiter.untrack(context.Name, context.Tags())
+			}
 			context.release()
 		}
 	}
@@ -115,9 +151,12 @@ type timestampContextResolver struct {
 	lastSeenByKey map[ckey.ContextKey]float64
 }
 
-func newTimestampContextResolver(cache *tags.Store) *timestampContextResolver {
+func newTimestampContextResolver(cache *tags.Store, limiter *contextLimiter, relabeler *relabeler) *timestampContextResolver {
+	resolver := newContextResolver(cache)
+	resolver.limiter = limiter
+	resolver.relabeler = relabeler
 	return &timestampContextResolver{
-		resolver:      newContextResolver(cache),
+		resolver:      resolver,
 		lastSeenByKey: make(map[ckey.ContextKey]float64),
 	}
 }
@@ -133,11 +172,14 @@ func (cr *timestampContextResolver) updateTrackedContext(contextKey ckey.Context
 	return nil
 }
 
-// trackContext returns the contextKey associated with the context of the metricSample and tracks that context
-func (cr *timestampContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64) ckey.ContextKey {
-	contextKey := cr.resolver.trackContext(metricSampleContext)
-	cr.lastSeenByKey[contextKey] = currentTimestamp
-	return contextKey
+// trackContext returns the contextKey associated with the context of the metricSample and tracks that context.
+// It returns false if the context is new and rejected by the limiter.
+func (cr *timestampContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64) (ckey.ContextKey, bool) {
+	contextKey, ok := cr.resolver.trackContext(metricSampleContext)
+	if ok {
+		cr.lastSeenByKey[contextKey] = currentTimestamp
+	}
+	return contextKey, ok
 }
 
 func (cr *timestampContextResolver) length() int {
@@ -183,9 +225,11 @@ type countBasedContextResolver struct {
 	expireCountInterval int64
 }
 
-func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store) *countBasedContextResolver {
+func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store, relabeler *relabeler) *countBasedContextResolver {
+	resolver := newContextResolver(cache)
+	resolver.relabeler = relabeler
 	return &countBasedContextResolver{
-		resolver:            newContextResolver(cache),
+		resolver:            resolver,
 		expireCountByKey:    make(map[ckey.ContextKey]int64),
 		expireCount:         0,
 		expireCountInterval: int64(expireCountInterval),
@@ -194,7 +238,7 @@ func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store) *c
 
 // trackContext returns the contextKey associated with the context of the metricSample and tracks that context
 func (cr *countBasedContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) ckey.ContextKey {
-	contextKey := cr.resolver.trackContext(metricSampleContext)
+	contextKey, _ := cr.resolver.trackContext(metricSampleContext) // no limiter
 	cr.expireCountByKey[contextKey] = cr.expireCount
 	return contextKey
 }
@@ -142,11 +142,13 @@ func initAgentDemultiplexer(options DemultiplexerOptions, hostname string) *Agen
 	log.Debug("the Demultiplexer will use", statsdPipelinesCount, "pipelines")
 
 	statsdWorkers := make([]*timeSamplerWorker, statsdPipelinesCount)
+	// the context limiter is shared by the samplers, as the contexts of a metric are spread over them
+	contextLimiter := newContextLimiterFromConfig()
 
 	for i := 0; i < statsdPipelinesCount; i++ {
 		// the sampler
 		tagsStore := tags.NewStore(config.Datadog.GetBool("aggregator_use_tags_store"), fmt.Sprintf("timesampler #%d", i))
-		statsdSampler := NewTimeSampler(TimeSamplerID(i), bucketSize, tagsStore)
+		statsdSampler := NewTimeSampler(TimeSamplerID(i), bucketSize, tagsStore, contextLimiter, agg.relabelRules)
 
 		// its worker (process loop + flush/serialization mechanism)
 
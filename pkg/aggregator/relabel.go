@@ -0,0 +1,291 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	relabelMatchTypeWildcard = "wildcard"
+	relabelMatchTypeRegex    = "regex"
+
+	// maxRelabelCacheSize is the number of metric names whose matching rules are cached by
+	// each relabeler.
+	maxRelabelCacheSize = 1000
+)
+
+var (
+	tlmRelabelDroppedSamples = telemetry.NewCounter("aggregator", "relabel_dropped_samples",
+		nil, "Count the number of samples dropped by the metric relabel rules")
+
+	// relabelTypes are the metric types a rule can change the type of the metrics to.
+	relabelTypes = map[string]metrics.MetricType{
+		"gauge":        metrics.GaugeType,
+		"histogram":    metrics.HistogramType,
+		"distribution": metrics.DistributionType,
+		"set":          metrics.SetType,
+	}
+)
+
+// relabelRuleConfig is a metric relabel rule, in the `metric_relabel_rules` list of the
+// configuration.
+type relabelRuleConfig struct {
+	// Match is the pattern of the names of the metrics the rule applies to, all the metrics if
+	// empty.
+	Match string `mapstructure:"match"`
+	// MatchType is either relabelMatchTypeWildcard (the default) or relabelMatchTypeRegex.
+	MatchType string `mapstructure:"match_type"`
+	// Drop drops the matching metrics.
+	Drop bool `mapstructure:"drop"`
+	// DropTags removes the tags with the given keys.
+	DropTags []string `mapstructure:"drop_tags"`
+	// KeepTags removes the tags whose keys are not listed.
+	KeepTags []string `mapstructure:"keep_tags"`
+	// RenameTags renames the tag keys.
+	RenameTags map[string]string `mapstructure:"rename_tags"`
+	// Type is the new type of the matching metrics, one of the keys of relabelTypes.
+	Type string `mapstructure:"type"`
+}
+
+// relabelRule is a compiled relabelRuleConfig.
+type relabelRule struct {
+	regex      *regexp.Regexp
+	drop       bool
+	dropTags   map[string]struct{}
+	keepTags   map[string]struct{}
+	renameTags map[string]string
+	mtype      metrics.MetricType
+	setType    bool
+}
+
+// relabelRules are the metric relabel rules, applied in order to the samples of the checks and
+// of DogStatsD before their aggregation, so that their high-cardinality tags can be dropped on
+// the host.
+type relabelRules struct {
+	rules []*relabelRule
+}
+
+// newRelabelRules compiles the relabel rules, and returns nil if there are none.
+func newRelabelRules(configs []relabelRuleConfig) (*relabelRules, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	rr := &relabelRules{}
+	for i, cfg := range configs {
+		rule, err := newRelabelRule(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %s", i, err)
+		}
+		rr.rules = append(rr.rules, rule)
+	}
+	return rr, nil
+}
+
+func newRelabelRule(cfg relabelRuleConfig) (*relabelRule, error) {
+	rule := &relabelRule{
+		drop:       cfg.Drop,
+		dropTags:   toSet(cfg.DropTags),
+		keepTags:   toSet(cfg.KeepTags),
+		renameTags: cfg.RenameTags,
+	}
+
+	if cfg.Match != "" {
+		pattern := cfg.Match
+		switch cfg.MatchType {
+		case "", relabelMatchTypeWildcard:
+			pattern = strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1)
+		case relabelMatchTypeRegex:
+		default:
+			return nil, fmt.Errorf("invalid match type %q, must be `wildcard` or `regex`", cfg.MatchType)
+		}
+		regex, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid match %q: %s", cfg.Match, err)
+		}
+		rule.regex = regex
+	}
+
+	if cfg.Type != "" {
+		mtype, ok := relabelTypes[cfg.Type]
+		if !ok {
+			return nil, fmt.Errorf("invalid type %q, must be `gauge`, `histogram`, `distribution` or `set`", cfg.Type)
+		}
+		rule.mtype = mtype
+		rule.setType = true
+	}
+
+	if !rule.drop && !rule.setType && !rule.rewritesTags() {
+		return nil, fmt.Errorf("no action, one of `drop`, `drop_tags`, `keep_tags`, `rename_tags` or `type` is required")
+	}
+	return rule, nil
+}
+
+// newRelabelRulesFromConfig returns the relabel rules of the agent configuration, or nil if
+// there are none or if they are invalid.
+func newRelabelRulesFromConfig() *relabelRules {
+	var configs []relabelRuleConfig
+	if err := config.Datadog.UnmarshalKey("metric_relabel_rules", &configs); err != nil {
+		log.Errorf("Invalid metric_relabel_rules, the metrics will not be relabeled: %s", err)
+		return nil
+	}
+	rr, err := newRelabelRules(configs)
+	if err != nil {
+		log.Errorf("Invalid metric_relabel_rules, the metrics will not be relabeled: %s", err)
+		return nil
+	}
+	return rr
+}
+
+// newRelabeler returns a relabeler applying the rules, or nil if rr is nil.
+func (rr *relabelRules) newRelabeler() *relabeler {
+	if rr == nil {
+		return nil
+	}
+	return &relabeler{
+		rules: rr.rules,
+		cache: make(map[string][]*relabelRule),
+	}
+}
+
+func (r *relabelRule) matches(name string) bool {
+	return r.regex == nil || r.regex.MatchString(name)
+}
+
+func (r *relabelRule) rewritesTags() bool {
+	return len(r.dropTags) > 0 || len(r.keepTags) > 0 || len(r.renameTags) > 0
+}
+
+// relabeler applies the relabel rules to the samples of a sampler. It caches the rules matching
+// the metric names, and is not safe for concurrent use.
+type relabeler struct {
+	rules []*relabelRule
+	cache map[string][]*relabelRule
+}
+
+// match returns the rules matching the metric name.
+func (r *relabeler) match(name string) []*relabelRule {
+	if rules, ok := r.cache[name]; ok {
+		return rules
+	}
+	var rules []*relabelRule
+	for _, rule := range r.rules {
+		if rule.matches(name) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(r.cache) >= maxRelabelCacheSize {
+		r.cache = make(map[string][]*relabelRule)
+	}
+	r.cache[name] = rules
+	return rules
+}
+
+// relabelSample applies the type changes of the rules to the sample, and returns false if the
+// sample is dropped.
+func (r *relabeler) relabelSample(sample *metrics.MetricSample) bool {
+	for _, rule := range r.match(sample.Name) {
+		if rule.drop {
+			tlmRelabelDroppedSamples.Inc()
+			return false
+		}
+		if rule.setType {
+			sample.Mtype = rule.mtype
+		}
+	}
+	return true
+}
+
+// dropped returns true if the metric is dropped by the rules.
+func (r *relabeler) dropped(name string) bool {
+	for _, rule := range r.match(name) {
+		if rule.drop {
+			tlmRelabelDroppedSamples.Inc()
+			return true
+		}
+	}
+	return false
+}
+
+// relabelTags applies the tag rules to the tags of the metric, in the buffers.
+func (r *relabeler) relabelTags(name string, taggerBuffer, metricBuffer *tagset.HashingTagsAccumulator) {
+	for _, rule := range r.match(name) {
+		if rule.rewritesTags() {
+			rule.relabelTags(taggerBuffer)
+			rule.relabelTags(metricBuffer)
+		}
+	}
+}
+
+// TagsRelabeler applies the tag rules of the metric relabel rules to the tags of the DogStatsD
+// samples before they are sharded between the time samplers, so that the samples aggregated in
+// the same context are sent to the same time sampler. It is not safe for concurrent use.
+type TagsRelabeler struct {
+	relabeler *relabeler
+}
+
+// NewTagsRelabeler returns a TagsRelabeler applying the relabel rules of the aggregator, or nil
+// if there are none.
+func (agg *BufferedAggregator) NewTagsRelabeler() *TagsRelabeler {
+	if agg.relabelRules == nil {
+		return nil
+	}
+	return &TagsRelabeler{relabeler: agg.relabelRules.newRelabeler()}
+}
+
+// RelabelTags applies the tag rules to the tags of the metric, in the buffer.
+func (r *TagsRelabeler) RelabelTags(name string, buf *tagset.HashingTagsAccumulator) {
+	for _, rule := range r.relabeler.match(name) {
+		if rule.rewritesTags() {
+			rule.relabelTags(buf)
+		}
+	}
+}
+
+func (r *relabelRule) relabelTags(buf *tagset.HashingTagsAccumulator) {
+	old := append([]string(nil), buf.Get()...)
+	buf.Reset()
+	for _, tag := range old {
+		key, value := splitTag(tag)
+		hasValue := strings.IndexByte(tag, ':') >= 0
+		if !hasValue {
+			// the tags without a value are handled as keys
+			key = value
+		}
+		if _, ok := r.dropTags[key]; ok {
+			continue
+		}
+		if _, ok := r.keepTags[key]; len(r.keepTags) > 0 && !ok {
+			continue
+		}
+		if newKey, ok := r.renameTags[key]; ok {
+			tag = newKey
+			if hasValue {
+				tag += ":" + value
+			}
+		}
+		buf.Append(tag)
+	}
+}
+
+func toSet(values []string) map[string]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
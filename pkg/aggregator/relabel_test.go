@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+// +build test
+
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func testRelabelRules(t *testing.T, configs ...relabelRuleConfig) *relabelRules {
+	rr, err := newRelabelRules(configs)
+	require.NoError(t, err)
+	return rr
+}
+
+func TestNewRelabelRulesInvalid(t *testing.T) {
+	for name, cfg := range map[string]relabelRuleConfig{
+		"no action":  {Match: "foo"},
+		"match type": {Match: "foo", MatchType: "glob", Drop: true},
+		"regex":      {Match: "foo(", MatchType: relabelMatchTypeRegex, Drop: true},
+		"type":       {Match: "foo", Type: "count"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := newRelabelRules([]relabelRuleConfig{cfg})
+			assert.Error(t, err)
+		})
+	}
+
+	rr, err := newRelabelRules(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, rr)
+	assert.Nil(t, rr.newRelabeler())
+}
+
+func TestRelabelerMatch(t *testing.T) {
+	r := testRelabelRules(t,
+		relabelRuleConfig{Match: "app.*", Drop: true},
+		relabelRuleConfig{Match: `app\.(requests|errors)`, MatchType: relabelMatchTypeRegex, Type: "distribution"},
+		relabelRuleConfig{DropTags: []string{"pod"}},
+	).newRelabeler()
+
+	assert.Len(t, r.match("app.requests"), 3)
+	// the wildcards match the dots
+	assert.Len(t, r.match("app.db.queries"), 2)
+	// the patterns match the whole name
+	assert.Len(t, r.match("myapp.requests"), 1)
+	assert.Len(t, r.match("app.requests.total"), 2)
+	assert.Len(t, r.cache, 4)
+}
+
+func testRelabelTags(t *testing.T, store *tags.Store) {
+	r := testRelabelRules(t,
+		relabelRuleConfig{Match: "foo", DropTags: []string{"request_id", "debug"}},
+		relabelRuleConfig{Match: "foo", RenameTags: map[string]string{"svc": "service", "canary": "is_canary"}},
+		relabelRuleConfig{Match: "bar", KeepTags: []string{"env", "service"}},
+	).newRelabeler()
+	cr := newTimestampContextResolver(store, nil, r)
+
+	key1, _ := cr.trackContext(limitedSample("foo", "request_id:1", "debug", "svc:web", "canary", "env:prod"), 10)
+	key2, _ := cr.trackContext(limitedSample("foo", "request_id:2", "svc:web", "canary", "env:prod"), 10)
+	// the contexts only differing by the dropped tags are aggregated together
+	assert.Equal(t, key1, key2)
+	context, _ := cr.get(key1)
+	assertContext(t, context, "foo", []string{"service:web", "is_canary", "env:prod"}, "")
+
+	key, _ := cr.trackContext(limitedSample("bar", "env:prod", "service:web", "pod:web-1", "version"), 10)
+	context, _ = cr.get(key)
+	assertContext(t, context, "bar", []string{"env:prod", "service:web"}, "")
+
+	key, _ = cr.trackContext(limitedSample("baz", "request_id:1"), 10)
+	context, _ = cr.get(key)
+	assertContext(t, context, "baz", []string{"request_id:1"}, "")
+}
+
+func TestRelabelTags(t *testing.T) {
+	testWithTagsStore(t, testRelabelTags)
+}
+
+func TestRelabelTimeSampler(t *testing.T) {
+	rr := testRelabelRules(t,
+		relabelRuleConfig{Match: "debug.*", Drop: true},
+		relabelRuleConfig{Match: "latency", Type: "distribution", DropTags: []string{"user"}},
+	)
+	sampler := NewTimeSampler(TimeSamplerID(0), 10, tags.NewStore(false, "test"), nil, rr)
+
+	sampler.sample(&metrics.MetricSample{Name: "debug.queue", Value: 1, Mtype: metrics.GaugeType, SampleRate: 1}, 12345)
+	for i, user := range []string{"alice", "bob", "carol"} {
+		sampler.sample(&metrics.MetricSample{
+			Name:       "latency",
+			Value:      float64(i),
+			Mtype:      metrics.HistogramType,
+			Tags:       []string{"user:" + user, "env:prod"},
+			SampleRate: 1,
+		}, 12345)
+	}
+	series, sketches := flushSerie(sampler, 12360)
+
+	assert.Empty(t, series)
+	require.Len(t, sketches, 1)
+	assert.Equal(t, "latency", sketches[0].Name)
+	assert.Equal(t, []string{"env:prod"}, sketches[0].Tags.UnsafeToReadOnlySliceString())
+	require.Len(t, sketches[0].Points, 1)
+	assert.Equal(t, int64(3), sketches[0].Points[0].Sketch.Basic.Cnt)
+}
+
+func testRelabelCheckSampler(t *testing.T, store *tags.Store) {
+	rr := testRelabelRules(t,
+		relabelRuleConfig{Match: "check.debug", Drop: true},
+		relabelRuleConfig{Match: "check.latency", Type: "distribution"},
+	)
+	checkSampler := newCheckSampler(1, true, 1*time.Second, store, rr)
+
+	checkSampler.addSample(&metrics.MetricSample{Name: "check.debug", Value: 1, Mtype: metrics.GaugeType, SampleRate: 1, Timestamp: 12345})
+	checkSampler.addSample(&metrics.MetricSample{Name: "check.latency", Value: 1, Mtype: metrics.HistogramType, SampleRate: 1, Timestamp: 12345})
+	checkSampler.addSample(&metrics.MetricSample{Name: "check.latency", Value: 3, Mtype: metrics.HistogramType, SampleRate: 1, Timestamp: 12345})
+	checkSampler.addBucket(&metrics.HistogramBucket{Name: "check.debug", Value: 2, LowerBound: 0, UpperBound: 1, Timestamp: 12345})
+	checkSampler.commit(12350)
+	series, sketches := checkSampler.flush()
+
+	assert.Empty(t, series)
+	require.Len(t, sketches, 1)
+	assert.Equal(t, "check.latency", sketches[0].Name)
+	require.Len(t, sketches[0].Points, 1)
+	assert.Equal(t, int64(2), sketches[0].Points[0].Sketch.Basic.Cnt)
+	assert.Equal(t, 4.0, sketches[0].Points[0].Sketch.Basic.Sum)
+}
+
+func TestRelabelCheckSampler(t *testing.T) {
+	testWithTagsStore(t, testRelabelCheckSampler)
+}
+
+func TestNewRelabelRulesFromConfig(t *testing.T) {
+	defer config.Datadog.Set("metric_relabel_rules", []interface{}{})
+
+	assert.Nil(t, newRelabelRulesFromConfig())
+
+	config.Datadog.Set("metric_relabel_rules", []interface{}{
+		map[string]interface{}{"match": "foo.*", "drop_tags": []interface{}{"user"}},
+		map[string]interface{}{"match": "bar", "type": "distribution", "rename_tags": map[string]interface{}{"svc": "service"}},
+	})
+	rr := newRelabelRulesFromConfig()
+	require.NotNil(t, rr)
+	require.Len(t, rr.rules, 2)
+	assert.Equal(t, map[string]struct{}{"user": {}}, rr.rules[0].dropTags)
+	assert.True(t, rr.rules[1].setType)
+	assert.Equal(t, metrics.DistributionType, rr.rules[1].mtype)
+	assert.Equal(t, map[string]string{"svc": "service"}, rr.rules[1].renameTags)
+
+	// the invalid rules are ignored
+	config.Datadog.Set("metric_relabel_rules", []interface{}{map[string]interface{}{"match": "foo"}})
+	assert.Nil(t, newRelabelRulesFromConfig())
+}
@@ -163,6 +163,16 @@ func GetDefaultSender() (Sender, error) {
 	return demultiplexerInstance.GetDefaultSender()
 }
 
+// AddTimeSample sends a metric sample to the time sampler of the demultiplexer, as the
+// DogStatsD samples, for the components which produce metrics from a stream of data.
+func AddTimeSample(sample metrics.MetricSample) error {
+	if demultiplexerInstance == nil {
+		return errors.New("Demultiplexer was not initialized")
+	}
+	demultiplexerInstance.AddTimeSample(sample)
+	return nil
+}
+
 // changeAllSendersDefaultHostname is to be called by the aggregator
 // when its hostname changes. All existing senders will have their
 // default hostname updated.
@@ -31,23 +31,29 @@ type TimeSampler struct {
 	counterLastSampledByContext map[ckey.ContextKey]float64
 	lastCutOffTime              int64
 	sketchMap                   sketchMap
+	// relabeler applies the metric relabel rules to the samples, if set.
+	relabeler *relabeler
 
 	// id is a number to differentiate multiple time samplers
 	// since we start running more than one with the demultiplexer introduction
 	id TimeSamplerID
 }
 
-// NewTimeSampler returns a newly initialized TimeSampler
-func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store) *TimeSampler {
+// NewTimeSampler returns a newly initialized TimeSampler. The number of its contexts is limited by
+// limiter, and its samples are relabeled by relabelRules, if not nil.
+func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store, limiter *contextLimiter, relabelRules *relabelRules) *TimeSampler {
 	if interval == 0 {
 		interval = bucketSize
 	}
 
 	log.Infof("Creating TimeSampler #%d", id)
 
+	relabeler := relabelRules.newRelabeler()
+
 	s := &TimeSampler{
 		interval:                    interval,
-		contextResolver:             newTimestampContextResolver(cache),
+		relabeler:                   relabeler,
+		contextResolver:             newTimestampContextResolver(cache, limiter, relabeler),
 		metricsByTimestamp:          map[int64]metrics.ContextMetrics{},
 		counterLastSampledByContext: map[ckey.ContextKey]float64{},
 		sketchMap:                   make(sketchMap),
@@ -71,8 +77,16 @@ func (s *TimeSampler) sample(metricSample *metrics.MetricSample, timestamp float
 		timestamp = metricSample.Timestamp
 	}
 
+	if s.relabeler != nil && !s.relabeler.relabelSample(metricSample) {
+		return
+	}
+
 	// Keep track of the context
-	contextKey := s.contextResolver.trackContext(metricSample, timestamp)
+	contextKey, ok := s.contextResolver.trackContext(metricSample, timestamp)
+	if !ok {
+		// the context limits are reached
+		return
+	}
 	bucketStart := s.calculateBucketStart(timestamp)
 
 	switch metricSample.Mtype {
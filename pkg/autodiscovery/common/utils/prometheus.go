@@ -18,10 +18,20 @@ import (
 )
 
 const (
-	openmetricsCheckName  = "openmetrics"
-	openmetricsInitConfig = "{}"
+	openmetricsCheckName      = "openmetrics"
+	openmetricsInitConfig     = "{}"
+	openmetricsCoreInitConfig = `{"loader":"core"}`
 )
 
+// buildInitConfig returns the init_config of the openmetrics checks, selecting the Go check when
+// `prometheus_scrape.use_core_check` is enabled
+func buildInitConfig() integration.Data {
+	if config.Datadog.GetBool("prometheus_scrape.use_core_check") {
+		return integration.Data(openmetricsCoreInitConfig)
+	}
+	return integration.Data(openmetricsInitConfig)
+}
+
 // buildInstances generates check config instances based on the Prometheus config and the object annotations
 // The second returned value is true if more than one instance is found
 func buildInstances(pc *types.PrometheusCheck, annotations map[string]string, namespacedName string) ([]integration.Data, bool) {
@@ -413,3 +413,38 @@ func TestConfigsForPod(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigsForPodCoreCheck(t *testing.T) {
+	defer config.Datadog.Set("prometheus_scrape.version", config.Datadog.Get("prometheus_scrape.version"))
+	defer config.Datadog.Set("prometheus_scrape.use_core_check", config.Datadog.Get("prometheus_scrape.use_core_check"))
+	config.Datadog.Set("prometheus_scrape.version", 2)
+	config.Datadog.Set("prometheus_scrape.use_core_check", true)
+
+	check := &types.PrometheusCheck{}
+	check.Init()
+	pod := &kubelet.Pod{
+		Metadata: kubelet.PodMetadata{
+			Name:        "foo-pod",
+			Annotations: map[string]string{"prometheus.io/scrape": "true", "prometheus.io/port": "8080"},
+		},
+		Status: kubelet.Status{
+			AllContainers: []kubelet.ContainerStatus{
+				{
+					Name: "foo-ctr",
+					ID:   "foo-ctr-id",
+				},
+			},
+		},
+	}
+
+	assert.ElementsMatch(t, []integration.Config{
+		{
+			Name:          "openmetrics",
+			InitConfig:    integration.Data(`{"loader":"core"}`),
+			Instances:     []integration.Data{integration.Data(`{"namespace":"","metrics":[".*"],"openmetrics_endpoint":"http://%%host%%:8080/metrics"}`)},
+			Provider:      names.PrometheusPods,
+			Source:        "prometheus_pods:foo-ctr-id",
+			ADIdentifiers: []string{"foo-ctr-id"},
+		},
+	}, ConfigsForPod(check, pod))
+}
@@ -103,6 +103,8 @@ type Config struct {
 // CommonInstanceConfig holds the reserved fields for the yaml instance data
 type CommonInstanceConfig struct {
 	MinCollectionInterval int      `yaml:"min_collection_interval"`
+	MaxRunTime            int      `yaml:"max_run_time"`
+	Priority              string   `yaml:"priority"`
 	EmptyDefaultHostname  bool     `yaml:"empty_default_hostname"`
 	Tags                  []string `yaml:"tags"`
 	Service               string   `yaml:"service"`
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RunTimeLimiter is implemented by the checks whose runs can be limited in time with the
+// `max_run_time` option of their instances
+type RunTimeLimiter interface {
+	// MaxRunTime returns the maximum duration of a run of the check, 0 if not configured
+	MaxRunTime() time.Duration
+}
+
+// Interrupter is implemented by the checks whose current run can be interrupted when it exceeds
+// its max run time: the exec plugin checks, the Python checks, and the core checks through their
+// CheckBase. Unlike Stop and Cancel, Interrupt doesn't unschedule the check: it keeps running at
+// its interval.
+type Interrupter interface {
+	// Interrupt interrupts the current run of the check, if any
+	Interrupt()
+}
+
+// RunTimeoutError is the error of a run that exceeded the max run time of the check
+type RunTimeoutError struct {
+	MaxRunTime time.Duration
+}
+
+func (e *RunTimeoutError) Error() string {
+	return fmt.Sprintf("the run exceeded the max run time of %s", e.MaxRunTime)
+}
+
+// PanicError is the error of a run that panicked
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error formats the error like the errors of the python checks, so that the status pages
+// display the stack of the panic as a traceback
+func (e *PanicError) Error() string {
+	message := fmt.Sprintf("the run panicked: %v", e.Value)
+	formatted, err := json.Marshal([]map[string]string{{
+		"message":   message,
+		"traceback": string(e.Stack),
+	}})
+	if err != nil {
+		return message
+	}
+	return string(formatted)
+}
@@ -0,0 +1,30 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package check
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTimeoutError(t *testing.T) {
+	err := &RunTimeoutError{MaxRunTime: 30 * time.Second}
+	assert.Equal(t, "the run exceeded the max run time of 30s", err.Error())
+}
+
+func TestPanicError(t *testing.T) {
+	err := &PanicError{Value: "boom", Stack: []byte("goroutine 1 [running]:\nmain.main()")}
+
+	var formatted []map[string]string
+	require.NoError(t, json.Unmarshal([]byte(err.Error()), &formatted))
+	require.Len(t, formatted, 1)
+	assert.Equal(t, "the run panicked: boom", formatted[0]["message"])
+	assert.Equal(t, "goroutine 1 [running]:\nmain.main()", formatted[0]["traceback"])
+}
@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package check
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Priority is the priority class of a check. The checks scheduled at the same time are
+// sent to the check runners by decreasing priority, and the high-priority checks can
+// run on the check runners reserved for them.
+type Priority int
+
+// Priority classes of the checks
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// ParsePriority parses the `priority` option of a check instance, normal if empty
+func ParsePriority(value string) (Priority, error) {
+	switch strings.ToLower(value) {
+	case "low":
+		return PriorityLow, nil
+	case "", "normal":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	default:
+		return PriorityNormal, fmt.Errorf("invalid priority %q, must be low, normal or high", value)
+	}
+}
+
+// Prioritized is implemented by the checks whose priority class can be configured with
+// the `priority` option of their instances
+type Prioritized interface {
+	// Priority returns the priority class of the check
+	Priority() Priority
+}
+
+// GetPriority returns the priority class of a check, normal if it can't be configured
+func GetPriority(c Check) Priority {
+	if prioritized, ok := c.(Prioritized); ok {
+		return prioritized.Priority()
+	}
+	return PriorityNormal
+}
@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package check
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type prioritizedCheck struct {
+	StubCheck
+	priority Priority
+}
+
+func (c *prioritizedCheck) Priority() Priority { return c.priority }
+
+func TestParsePriority(t *testing.T) {
+	for value, expected := range map[string]Priority{
+		"":       PriorityNormal,
+		"normal": PriorityNormal,
+		"low":    PriorityLow,
+		"High":   PriorityHigh,
+	} {
+		priority, err := ParsePriority(value)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, priority)
+	}
+
+	_, err := ParsePriority("urgent")
+	assert.EqualError(t, err, `invalid priority "urgent", must be low, normal or high`)
+}
+
+func TestPriorityString(t *testing.T) {
+	assert.Equal(t, "low", PriorityLow.String())
+	assert.Equal(t, "normal", PriorityNormal.String())
+	assert.Equal(t, "high", PriorityHigh.String())
+}
+
+func TestGetPriority(t *testing.T) {
+	assert.Equal(t, PriorityNormal, GetPriority(&StubCheck{}))
+	assert.Equal(t, PriorityHigh, GetPriority(&prioritizedCheck{priority: PriorityHigh}))
+}
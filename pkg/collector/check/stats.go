@@ -6,6 +6,7 @@
 package check
 
 import (
+	"errors"
 	"sync"
 	"time"
 
@@ -54,6 +55,17 @@ type SenderStats struct {
 	HistogramBuckets int64
 	// EventPlatformEvents tracks the number of events submitted for each eventType
 	EventPlatformEvents map[string]int64
+	// Subprocess holds the stats of the external process run by the check, if any
+	Subprocess *SubprocessStats
+}
+
+// SubprocessStats contains statistics about the external process run by a check
+type SubprocessStats struct {
+	ExitCode  int   // exit code of the process, -1 if it was killed
+	TimedOut  bool  // whether the process was killed after its timeout
+	Duration  int64 // wall-clock duration of the process, in milliseconds
+	UserCPU   int64 // user CPU time of the process, in milliseconds
+	SystemCPU int64 // system CPU time of the process, in milliseconds
 }
 
 // NewSenderStats creates a new SenderStats
@@ -70,15 +82,33 @@ func (s SenderStats) Copy() (result SenderStats) {
 	for k, v := range s.EventPlatformEvents {
 		result.EventPlatformEvents[k] = v
 	}
+	if s.Subprocess != nil {
+		subprocess := *s.Subprocess
+		result.Subprocess = &subprocess
+	}
 	return result
 }
 
+// ResourceStats contains the resources used by a run of a check
+type ResourceStats struct {
+	CPUTime int64 // CPU time of the thread running the check, in milliseconds
+}
+
+// QuarantineStats describes the quarantine of a check whose runs repeatedly timed out or panicked
+type QuarantineStats struct {
+	Reason string // error of the run that quarantined the check
+	Since  int64  // start of the quarantine, unix timestamp in seconds
+	Until  int64  // end of the quarantine, unix timestamp in seconds
+	Count  int    // number of quarantines since the last run that didn't time out or panic
+}
+
 // Stats holds basic runtime statistics about check instances
 type Stats struct {
 	CheckName                string
 	CheckVersion             string
 	CheckConfigSource        string
 	CheckID                  ID
+	CheckPriority            string
 	TotalRuns                uint64
 	TotalErrors              uint64
 	TotalWarnings            uint64
@@ -92,6 +122,14 @@ type Stats struct {
 	TotalHistogramBuckets    uint64
 	EventPlatformEvents      map[string]int64
 	TotalEventPlatformEvents map[string]int64
+	LastSubprocess           *SubprocessStats
+	TotalSubprocessTimeouts  uint64
+	TotalTimeouts            uint64
+	TotalPanics              uint64
+	LastResources            *ResourceStats
+	Quarantine               *QuarantineStats
+	LastLateness             int64     // delay of the start of the most recent run after its scheduled time, in milliseconds
+	MaxLateness              int64     // longest delay of the start of a run after its scheduled time, in milliseconds
 	ExecutionTimes           [32]int64 // circular buffer of recent run durations, most recent at [(TotalRuns+31) % 32]
 	AverageExecutionTime     int64     // average run duration
 	LastExecutionTime        int64     // most recent run duration, provided for convenience
@@ -110,6 +148,7 @@ func NewStats(c Check) *Stats {
 		CheckName:                c.String(),
 		CheckVersion:             c.Version(),
 		CheckConfigSource:        c.ConfigSource(),
+		CheckPriority:            GetPriority(c).String(),
 		telemetry:                telemetry_utils.IsCheckEnabled(c.String()),
 		EventPlatformEvents:      make(map[string]int64),
 		TotalEventPlatformEvents: make(map[string]int64),
@@ -149,6 +188,13 @@ func (cs *Stats) Add(t time.Duration, err error, warnings []error, metricStats S
 	cs.AverageExecutionTime = totalExecutionTime / int64(ringSize)
 	if err != nil {
 		cs.TotalErrors++
+		var timeoutErr *RunTimeoutError
+		var panicErr *PanicError
+		if errors.As(err, &timeoutErr) {
+			cs.TotalTimeouts++
+		} else if errors.As(err, &panicErr) {
+			cs.TotalPanics++
+		}
 		if cs.telemetry {
 			tlmRuns.Inc(cs.CheckName, runCheckFailureTag)
 		}
@@ -200,6 +246,12 @@ func (cs *Stats) Add(t time.Duration, err error, warnings []error, metricStats S
 			tlmHistogramBuckets.Add(float64(metricStats.HistogramBuckets), cs.CheckName)
 		}
 	}
+	if metricStats.Subprocess != nil {
+		cs.LastSubprocess = metricStats.Subprocess
+		if metricStats.Subprocess.TimedOut {
+			cs.TotalSubprocessTimeouts++
+		}
+	}
 	for k, v := range metricStats.EventPlatformEvents {
 		// translate event types into more descriptive names
 		if humanName, ok := EventPlatformNameTranslations[k]; ok {
@@ -210,6 +262,33 @@ func (cs *Stats) Add(t time.Duration, err error, warnings []error, metricStats S
 	}
 }
 
+// SetLastResources sets the resources used by the last run of the check
+func (cs *Stats) SetLastResources(resources *ResourceStats) {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	cs.LastResources = resources
+}
+
+// SetQuarantine sets the quarantine of the check, nil if it isn't quarantined
+func (cs *Stats) SetQuarantine(quarantine *QuarantineStats) {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	cs.Quarantine = quarantine
+}
+
+// SetLateness records the delay of the start of the check run after its scheduled time
+func (cs *Stats) SetLateness(lateness time.Duration) {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	cs.LastLateness = lateness.Nanoseconds() / 1e6
+	if cs.LastLateness > cs.MaxLateness {
+		cs.MaxLateness = cs.LastLateness
+	}
+}
+
 type aggStats struct {
 	EventPlatformEvents       map[string]interface{}
 	EventPlatformEventsErrors map[string]interface{}
@@ -6,9 +6,12 @@
 package check
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -138,3 +141,41 @@ func TestTranslateEventPlatformEventTypes(t *testing.T) {
 	assert.True(t, assert.ObjectsAreEqual(expected, result))
 	assert.EqualValues(t, expected, result)
 }
+
+func TestStatsAddSubprocess(t *testing.T) {
+	stats := NewStats(newMockCheck())
+
+	senderStats := NewSenderStats()
+	senderStats.Subprocess = &SubprocessStats{ExitCode: 2, TimedOut: true, Duration: 1000}
+	copied := senderStats.Copy()
+	senderStats.Subprocess.ExitCode = 0
+	assert.Equal(t, 2, copied.Subprocess.ExitCode)
+
+	stats.Add(time.Second, nil, nil, copied)
+	stats.Add(time.Second, nil, nil, NewSenderStats())
+	assert.Equal(t, &SubprocessStats{ExitCode: 2, TimedOut: true, Duration: 1000}, stats.LastSubprocess)
+	assert.Equal(t, uint64(1), stats.TotalSubprocessTimeouts)
+}
+
+func TestStatsAddTimeoutsAndPanics(t *testing.T) {
+	stats := NewStats(newMockCheck())
+
+	stats.Add(time.Second, &RunTimeoutError{MaxRunTime: time.Second}, nil, NewSenderStats())
+	stats.Add(time.Second, fmt.Errorf("wrapped: %w", &PanicError{Value: "boom"}), nil, NewSenderStats())
+	stats.Add(time.Second, errors.New("error"), nil, NewSenderStats())
+
+	assert.Equal(t, uint64(3), stats.TotalErrors)
+	assert.Equal(t, uint64(1), stats.TotalTimeouts)
+	assert.Equal(t, uint64(1), stats.TotalPanics)
+}
+
+func TestStatsSetLateness(t *testing.T) {
+	stats := NewStats(newMockCheck())
+	assert.Equal(t, "normal", stats.CheckPriority)
+
+	stats.SetLateness(300 * time.Millisecond)
+	stats.SetLateness(100 * time.Millisecond)
+
+	assert.Equal(t, int64(100), stats.LastLateness)
+	assert.Equal(t, int64(300), stats.MaxLateness)
+}
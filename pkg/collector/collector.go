@@ -41,6 +41,7 @@ type Collector struct {
 func NewCollector(paths ...string) *Collector {
 	run := runner.NewRunner()
 	sched := scheduler.NewScheduler(run.GetChan())
+	sched.SetPriorityChecksPipe(run.GetPriorityChan())
 
 	// let the runner some visibility into the scheduler
 	run.SetScheduler(sched)
@@ -7,6 +7,7 @@ package corechecks
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	yaml "gopkg.in/yaml.v2"
@@ -37,13 +38,26 @@ import (
 //
 // If custom tags are set in the instance configuration, they will
 // be automatically appended to each send done by this check.
+//
+// Checks whose runs can block should return when the channel returned
+// by Interrupted() is closed, so that the runs exceeding the max_run_time
+// of their instance stop.
 type CheckBase struct {
 	checkName      string
 	checkID        check.ID
 	latestWarnings []error
 	checkInterval  time.Duration
+	maxRunTime     time.Duration
+	priority       check.Priority
 	source         string
 	telemetry      bool
+	interruption   *interruption
+}
+
+// interruption holds the channel closed when the current run of a check is interrupted
+type interruption struct {
+	sync.Mutex
+	interrupted chan struct{}
 }
 
 // NewCheckBase returns a check base struct with a given check name
@@ -58,6 +72,7 @@ func NewCheckBaseWithInterval(name string, defaultInterval time.Duration) CheckB
 		checkID:       check.ID(name),
 		checkInterval: defaultInterval,
 		telemetry:     telemetry_utils.IsCheckEnabled(name),
+		interruption:  &interruption{interrupted: make(chan struct{})},
 	}
 }
 
@@ -118,6 +133,17 @@ func (c *CheckBase) CommonConfigure(instance integration.Data, source string) er
 		c.checkInterval = time.Duration(commonOptions.MinCollectionInterval) * time.Second
 	}
 
+	// See if a max run time was specified
+	if commonOptions.MaxRunTime > 0 {
+		c.maxRunTime = time.Duration(commonOptions.MaxRunTime) * time.Second
+	}
+
+	c.priority, err = check.ParsePriority(commonOptions.Priority)
+	if err != nil {
+		log.Errorf("invalid instance section for check %s: %s", string(c.ID()), err)
+		return err
+	}
+
 	// Disable default hostname if specified
 	if commonOptions.EmptyDefaultHostname {
 		s, err := c.GetSender()
@@ -186,12 +212,47 @@ func (c *CheckBase) CommonCancel() {
 	aggregator.DestroySender(c.checkID)
 }
 
+// Interrupt closes the channel returned by Interrupted, to stop the current
+// run of the check when it exceeds its max run time. The next runs get a
+// new channel.
+func (c *CheckBase) Interrupt() {
+	if c.interruption == nil {
+		return
+	}
+	c.interruption.Lock()
+	defer c.interruption.Unlock()
+	close(c.interruption.interrupted)
+	c.interruption.interrupted = make(chan struct{})
+}
+
+// Interrupted returns the channel closed when the current run of the check
+// is interrupted. Checks must get it at the start of their run.
+func (c *CheckBase) Interrupted() <-chan struct{} {
+	if c.interruption == nil {
+		return nil
+	}
+	c.interruption.Lock()
+	defer c.interruption.Unlock()
+	return c.interruption.interrupted
+}
+
 // Interval returns the scheduling time for the check.
 // Long-running checks should override to return 0.
 func (c *CheckBase) Interval() time.Duration {
 	return c.checkInterval
 }
 
+// MaxRunTime returns the max run time configured for the check instance, 0 if not configured.
+// The runs of long-running checks are never limited.
+func (c *CheckBase) MaxRunTime() time.Duration {
+	return c.maxRunTime
+}
+
+// Priority returns the priority class configured for the check instance
+func (c *CheckBase) Priority() check.Priority {
+	return c.priority
+}
+
 // String returns the name of the check, the same for every instance
 func (c *CheckBase) String() string {
 	return c.checkName
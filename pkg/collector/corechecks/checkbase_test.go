@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/collector/check/defaults"
 )
 
@@ -68,3 +69,57 @@ func TestCommonConfigureCustomID(t *testing.T) {
 	assert.Equal(t, string(mycheck.ID()), "test:foobar:bd63a7031add5db9")
 	mockSender.AssertExpectations(t)
 }
+
+func TestCommonConfigureMaxRunTime(t *testing.T) {
+	mycheck := &dummyCheck{
+		CheckBase: NewCheckBase("test"),
+	}
+	mocksender.NewMockSender(mycheck.ID())
+
+	err := mycheck.CommonConfigure([]byte(defaultsInstance), "test")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), mycheck.MaxRunTime())
+
+	err = mycheck.CommonConfigure([]byte("max_run_time: 30"), "test")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, mycheck.MaxRunTime())
+}
+
+func TestCommonConfigurePriority(t *testing.T) {
+	mycheck := &dummyCheck{
+		CheckBase: NewCheckBase("test"),
+	}
+	mocksender.NewMockSender(mycheck.ID())
+
+	err := mycheck.CommonConfigure([]byte(defaultsInstance), "test")
+	assert.NoError(t, err)
+	assert.Equal(t, check.PriorityNormal, mycheck.Priority())
+
+	err = mycheck.CommonConfigure([]byte("priority: high"), "test")
+	assert.NoError(t, err)
+	assert.Equal(t, check.PriorityHigh, mycheck.Priority())
+
+	err = mycheck.CommonConfigure([]byte("priority: urgent"), "test")
+	assert.Error(t, err)
+}
+
+func TestInterrupt(t *testing.T) {
+	mycheck := &dummyCheck{
+		CheckBase: NewCheckBase("test"),
+	}
+
+	interrupted := mycheck.Interrupted()
+	mycheck.Interrupt()
+	select {
+	case <-interrupted:
+	default:
+		assert.Fail(t, "the run wasn't interrupted")
+	}
+
+	// The next run isn't interrupted
+	select {
+	case <-mycheck.Interrupted():
+		assert.Fail(t, "the next run was interrupted")
+	default:
+	}
+}
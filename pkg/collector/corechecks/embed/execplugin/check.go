@@ -0,0 +1,287 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package execplugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+	defaultPrefix  = "nagios"
+
+	// maxOutputSize is the maximum number of bytes of the outputs of a plugin that are read.
+	maxOutputSize = 64 * 1024
+)
+
+// commandLine is a command and its arguments, configured either as a list or as a string split
+// on the whitespaces.
+type commandLine []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (c *commandLine) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var args []string
+	if err := unmarshal(&args); err == nil {
+		*c = args
+		return nil
+	}
+	var line string
+	if err := unmarshal(&line); err != nil {
+		return err
+	}
+	*c = strings.Fields(line)
+	return nil
+}
+
+type instanceConfig struct {
+	Command          commandLine       `yaml:"command"`
+	Timeout          int               `yaml:"timeout"`
+	Env              map[string]string `yaml:"env"`
+	MetricPrefix     string            `yaml:"metric_prefix"`
+	ServiceCheckName string            `yaml:"service_check_name"`
+}
+
+// execCheck runs an executable following the Nagios plugin API: its exit code is submitted as a
+// service check, and the performance data of its output as gauges.
+type execCheck struct {
+	core.CheckBase
+	config  instanceConfig
+	timeout time.Duration
+	env     []string
+
+	mu         sync.Mutex
+	cancel     context.CancelFunc // cancels the running process, if any
+	subprocess *check.SubprocessStats
+}
+
+func newExecCheck(name string) *execCheck {
+	return &execCheck{
+		CheckBase: core.NewCheckBase(name),
+	}
+}
+
+// Configure parses the instance configuration
+func (c *execCheck) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	var config instanceConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	if len(config.Command) == 0 || config.Command[0] == "" {
+		return errors.New("the command is required")
+	}
+	if config.MetricPrefix == "" {
+		config.MetricPrefix = defaultPrefix + "." + c.String()
+	}
+	config.MetricPrefix = strings.TrimSuffix(config.MetricPrefix, ".")
+	if config.ServiceCheckName == "" {
+		config.ServiceCheckName = defaultPrefix + "." + c.String()
+	}
+
+	c.BuildID(data, initConfig)
+	c.config = config
+	c.timeout = defaultTimeout
+	if config.Timeout > 0 {
+		c.timeout = time.Duration(config.Timeout) * time.Second
+	}
+	c.env = buildEnv(config.Env)
+
+	return c.CheckBase.Configure(data, initConfig, source)
+}
+
+// buildEnv returns the environment of the plugins: the PATH of the agent, and the variables of
+// the instance configuration. The other variables of the agent, like its API key, are not
+// passed to the plugins.
+func buildEnv(vars map[string]string) []string {
+	env := make([]string, 0, len(vars)+1)
+	if path, ok := os.LookupEnv("PATH"); ok {
+		if _, overridden := vars["PATH"]; !overridden {
+			env = append(env, "PATH="+path)
+		}
+	}
+	for k, v := range vars {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// Run runs the plugin, and submits its status and its performance data
+func (c *execCheck) Run() error {
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	c.mu.Lock()
+	c.cancel = cancel
+	c.mu.Unlock()
+
+	stdout, stderr, stats, err := c.runCommand(ctx)
+
+	c.mu.Lock()
+	c.cancel = nil
+	c.subprocess = stats
+	c.mu.Unlock()
+
+	if err != nil {
+		sender.ServiceCheck(c.config.ServiceCheckName, metrics.ServiceCheckUnknown, "", nil, err.Error())
+		sender.Commit()
+		return err
+	}
+
+	text, perf := parseOutput(stdout)
+	if text == "" {
+		text, _ = parseOutput(stderr)
+	}
+
+	var status metrics.ServiceCheckStatus
+	switch {
+	case stats.TimedOut:
+		status = metrics.ServiceCheckCritical
+		text = fmt.Sprintf("Plugin timed out after %s", c.timeout)
+	case stats.ExitCode < 0:
+		status = metrics.ServiceCheckUnknown
+		text = "Plugin was killed"
+	default:
+		status, err = metrics.GetServiceCheckStatus(stats.ExitCode)
+		if err != nil {
+			status = metrics.ServiceCheckUnknown
+			text = fmt.Sprintf("Plugin exited with code %d: %s", stats.ExitCode, text)
+		}
+	}
+
+	values, errs := parsePerfdata(perf)
+	for _, err := range errs {
+		c.Warnf("%s: %s", c.config.Command[0], err) //nolint:errcheck
+	}
+	for _, v := range values {
+		var tags []string
+		if v.uom != "" {
+			tags = []string{"unit:" + v.uom}
+		}
+		sender.Gauge(metricName(c.config.MetricPrefix, v.label), v.value, "", tags)
+	}
+
+	sender.ServiceCheck(c.config.ServiceCheckName, status, "", nil, text)
+	sender.Commit()
+
+	if stats.TimedOut {
+		return fmt.Errorf("%s timed out after %s", c.config.Command[0], c.timeout)
+	}
+	return nil
+}
+
+// runCommand runs the plugin until it exits or ctx is done, and returns its outputs. An error is
+// returned if the plugin can't be run, not if it exits with a non-zero code.
+func (c *execCheck) runCommand(ctx context.Context) (stdout, stderr string, stats *check.SubprocessStats, err error) {
+	var outBuf, errBuf limitedBuffer
+	cmd := exec.Command(c.config.Command[0], c.config.Command[1:]...)
+	cmd.Env = c.env
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	setProcessGroup(cmd)
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return "", "", nil, fmt.Errorf("can't run %s: %s", c.config.Command[0], err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	stats = &check.SubprocessStats{}
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		stats.TimedOut = ctx.Err() == context.DeadlineExceeded
+		if err := killProcessGroup(cmd); err != nil {
+			log.Debugf("Can't kill %s: %s", c.config.Command[0], err)
+		}
+		err = <-done
+	}
+	stats.Duration = time.Since(start).Milliseconds()
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return "", "", stats, fmt.Errorf("error while running %s: %s", c.config.Command[0], err)
+	}
+	stats.ExitCode = cmd.ProcessState.ExitCode()
+	stats.UserCPU = cmd.ProcessState.UserTime().Milliseconds()
+	stats.SystemCPU = cmd.ProcessState.SystemTime().Milliseconds()
+
+	return outBuf.String(), errBuf.String(), stats, nil
+}
+
+// Interrupt kills the running plugin, if any, when the run exceeds the max run time of the check
+func (c *execCheck) Interrupt() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// Stop kills the running plugin, if any
+func (c *execCheck) Stop() {
+	c.Interrupt()
+}
+
+// Cancel kills the running plugin, if any, and releases the resources of the check
+func (c *execCheck) Cancel() {
+	c.Stop()
+	c.CommonCancel()
+}
+
+// GetSenderStats returns the stats from the last run of the check, including the stats of the
+// plugin process
+func (c *execCheck) GetSenderStats() (check.SenderStats, error) {
+	stats, err := c.CheckBase.GetSenderStats()
+	if err != nil {
+		return stats, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.subprocess != nil {
+		subprocess := *c.subprocess
+		stats.Subprocess = &subprocess
+	}
+	return stats, nil
+}
+
+// limitedBuffer is a buffer discarding what is written after maxOutputSize bytes.
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+// Write implements io.Writer
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxOutputSize - b.Len(); room < len(p) {
+		if room > 0 {
+			b.Buffer.Write(p[:room]) //nolint:errcheck
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
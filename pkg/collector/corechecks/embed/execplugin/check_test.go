@@ -0,0 +1,142 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+// +build !windows
+
+package execplugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// writePlugin writes a shell script running body, and returns its path.
+func writePlugin(t *testing.T, body string) string {
+	path := filepath.Join(t.TempDir(), "check_test.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0700))
+	return path
+}
+
+func newTestCheck(t *testing.T, instance string) (*execCheck, *mocksender.MockSender) {
+	c := newExecCheck("test")
+	c.BuildID(integration.Data(instance), nil)
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	sender.On("GetSenderStats").Return()
+	require.NoError(t, c.Configure(integration.Data(instance), nil, "test"))
+	return c, sender
+}
+
+func TestConfigure(t *testing.T) {
+	c, _ := newTestCheck(t, "command: /usr/lib/nagios/plugins/check_disk -w 10%")
+	assert.Equal(t, commandLine{"/usr/lib/nagios/plugins/check_disk", "-w", "10%"}, c.config.Command)
+	assert.Equal(t, "nagios.test", c.config.MetricPrefix)
+	assert.Equal(t, "nagios.test", c.config.ServiceCheckName)
+	assert.Equal(t, defaultTimeout, c.timeout)
+
+	c, _ = newTestCheck(t, `
+command: [/usr/lib/nagios/plugins/check_disk, -p, "/var lib"]
+timeout: 3
+metric_prefix: custom.disk.
+service_check_name: custom.disk.can_check
+env:
+  PATH: /usr/bin
+  LANG: C
+`)
+	assert.Equal(t, commandLine{"/usr/lib/nagios/plugins/check_disk", "-p", "/var lib"}, c.config.Command)
+	assert.Equal(t, "custom.disk", c.config.MetricPrefix)
+	assert.Equal(t, "custom.disk.can_check", c.config.ServiceCheckName)
+	assert.Equal(t, "3s", c.timeout.String())
+	assert.Equal(t, []string{"LANG=C", "PATH=/usr/bin"}, c.env)
+
+	c = newExecCheck("test")
+	assert.Error(t, c.Configure(integration.Data("timeout: 3"), nil, "test"))
+}
+
+func TestRun(t *testing.T) {
+	plugin := writePlugin(t, `echo "DISK WARNING - free space: / 3326 MB (5%); | /=2643MB;5948;5958;0;5968 inodes=U"
+echo "/boot 68 MB (69%); | 'Boot Free'=31%"
+exit 1`)
+	c, sender := newTestCheck(t, "command: "+plugin)
+
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "Gauge", "nagios.test", 2643, "", []string{"unit:MB"})
+	sender.AssertMetric(t, "Gauge", "nagios.test.boot_free", 31, "", []string{"unit:%"})
+	sender.AssertNumberOfCalls(t, "Gauge", 2)
+	sender.AssertServiceCheck(t, "nagios.test", metrics.ServiceCheckWarning, "", nil, "DISK WARNING - free space: / 3326 MB (5%);")
+
+	stats, err := c.GetSenderStats()
+	require.NoError(t, err)
+	require.NotNil(t, stats.Subprocess)
+	assert.Equal(t, 1, stats.Subprocess.ExitCode)
+	assert.False(t, stats.Subprocess.TimedOut)
+}
+
+func TestRunExitCodes(t *testing.T) {
+	for code, status := range map[int]metrics.ServiceCheckStatus{
+		0: metrics.ServiceCheckOK,
+		1: metrics.ServiceCheckWarning,
+		2: metrics.ServiceCheckCritical,
+		3: metrics.ServiceCheckUnknown,
+		4: metrics.ServiceCheckUnknown,
+	} {
+		t.Run(fmt.Sprint(code), func(t *testing.T) {
+			plugin := writePlugin(t, fmt.Sprintf("echo output\nexit %d", code))
+			c, sender := newTestCheck(t, "command: "+plugin)
+
+			require.NoError(t, c.Run())
+			message := "output"
+			if code > 3 {
+				message = "Plugin exited with code 4: output"
+			}
+			sender.AssertServiceCheck(t, "nagios.test", status, "", nil, message)
+		})
+	}
+}
+
+func TestRunEnv(t *testing.T) {
+	plugin := writePlugin(t, `echo "$GREETING $HOME"`)
+	c, sender := newTestCheck(t, fmt.Sprintf(`
+command: [%s]
+env:
+  GREETING: hello
+`, plugin))
+
+	require.NoError(t, c.Run())
+	// the environment of the agent, besides its PATH, is not passed to the plugin
+	sender.AssertServiceCheck(t, "nagios.test", metrics.ServiceCheckOK, "", nil, "hello")
+}
+
+func TestRunTimeout(t *testing.T) {
+	// the children of the plugin are killed as well, or they would keep its output open
+	plugin := writePlugin(t, "sleep 10 &\nsleep 10")
+	c, sender := newTestCheck(t, fmt.Sprintf("command: %s\ntimeout: 1", plugin))
+
+	assert.Error(t, c.Run())
+	sender.AssertServiceCheck(t, "nagios.test", metrics.ServiceCheckCritical, "", nil, "Plugin timed out after 1s")
+
+	stats, err := c.GetSenderStats()
+	require.NoError(t, err)
+	require.NotNil(t, stats.Subprocess)
+	assert.True(t, stats.Subprocess.TimedOut)
+	assert.Less(t, stats.Subprocess.Duration, int64(5000))
+}
+
+func TestRunNotFound(t *testing.T) {
+	c, sender := newTestCheck(t, "command: "+filepath.Join(t.TempDir(), "missing"))
+
+	assert.Error(t, c.Run())
+	sender.AssertCalled(t, "ServiceCheck", "nagios.test", metrics.ServiceCheckUnknown, "", []string(nil), mocksender.AnythingBut(""))
+}
@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package execplugin
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/loaders"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// loaderName is the name of the loader, to be set as the `loader` of the instances or of
+	// the init_config of the exec checks.
+	loaderName = "exec"
+	// execCheckName is the name of the check whose instances are all exec checks.
+	execCheckName = "exec"
+)
+
+// ExecCheckLoader is a specific loader for the checks running external executables following
+// the Nagios plugin API
+type ExecCheckLoader struct{}
+
+// NewExecCheckLoader creates a loader for exec checks
+func NewExecCheckLoader() (*ExecCheckLoader, error) {
+	return &ExecCheckLoader{}, nil
+}
+
+// Name returns the exec loader name
+func (el *ExecCheckLoader) Name() string {
+	return loaderName
+}
+
+// Load returns an exec check
+func (el *ExecCheckLoader) Load(config integration.Config, instance integration.Data) (check.Check, error) {
+	var c check.Check
+
+	if !IsExecInstance(config.Name, instance, config.InitConfig) {
+		return c, errors.New("check is not an exec check, it is not named `exec` and its loader is not `exec`")
+	}
+
+	execCheck := newExecCheck(config.Name)
+	if err := execCheck.Configure(instance, config.InitConfig, config.Source); err != nil {
+		log.Errorf("exec.loader: could not configure check %s: %s", execCheck, err)
+		return c, fmt.Errorf("Could not configure check %s: %s", execCheck, err)
+	}
+
+	return execCheck, nil
+}
+
+func (el *ExecCheckLoader) String() string {
+	return "Exec Check Loader"
+}
+
+// IsExecInstance returns true if the instance is run by the exec loader: the check is named
+// `exec`, or the `loader` of its instance or of its init_config is `exec`. The instances of the
+// other checks are not run as exec checks even if they have a `command` option.
+func IsExecInstance(name string, instance integration.Data, initConfig integration.Data) bool {
+	if name == execCheckName {
+		return true
+	}
+
+	var config struct {
+		LoaderName string `yaml:"loader"`
+	}
+	if err := yaml.Unmarshal(instance, &config); err == nil && config.LoaderName != "" {
+		return config.LoaderName == loaderName
+	}
+	config.LoaderName = ""
+	if err := yaml.Unmarshal(initConfig, &config); err == nil {
+		return config.LoaderName == loaderName
+	}
+	return false
+}
+
+func init() {
+	factory := func() (check.Loader, error) {
+		return NewExecCheckLoader()
+	}
+
+	loaders.RegisterLoader(15, factory)
+}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package execplugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+func TestLoad(t *testing.T) {
+	// initializes the aggregator, from which the configured checks get their sender
+	mocksender.NewMockSender("")
+
+	loader, err := NewExecCheckLoader()
+	require.NoError(t, err)
+
+	config := integration.Config{
+		Name:       "disk",
+		InitConfig: integration.Data("{}"),
+		Instances:  []integration.Data{integration.Data("{loader: exec, command: [check_disk, -w, 10%]}")},
+	}
+	c, err := loader.Load(config, config.Instances[0])
+	require.NoError(t, err)
+	assert.Equal(t, "disk", c.String())
+
+	// the instances with an invalid command are rejected
+	_, err = loader.Load(config, integration.Data("{loader: exec, command: []}"))
+	assert.Error(t, err)
+	_, err = loader.Load(config, integration.Data("{loader: exec, host: localhost}"))
+	assert.Error(t, err)
+
+	// the loader of the init_config applies to all the instances
+	config.InitConfig = integration.Data("loader: exec")
+	_, err = loader.Load(config, integration.Data("command: [check_disk]"))
+	assert.NoError(t, err)
+
+	// all the instances of the exec check are exec checks
+	config = integration.Config{Name: "exec", InitConfig: integration.Data("{}")}
+	c, err = loader.Load(config, integration.Data("command: [check_load]"))
+	require.NoError(t, err)
+	assert.Equal(t, "exec", c.String())
+}
+
+func TestLoadIgnoresOtherChecksWithCommand(t *testing.T) {
+	loader, err := NewExecCheckLoader()
+	require.NoError(t, err)
+
+	// a python check with a `command` option is not claimed by the exec loader
+	config := integration.Config{
+		Name:       "custom_script",
+		InitConfig: integration.Data("{}"),
+		Instances:  []integration.Data{integration.Data("command: /usr/local/bin/collect.sh")},
+	}
+	_, err = loader.Load(config, config.Instances[0])
+	assert.Error(t, err)
+	assert.False(t, IsExecInstance(config.Name, config.Instances[0], config.InitConfig))
+
+	// nor is a check whose instance selects another loader
+	config.InitConfig = integration.Data("loader: exec")
+	assert.False(t, IsExecInstance(config.Name, integration.Data("{loader: python, command: foo}"), config.InitConfig))
+}
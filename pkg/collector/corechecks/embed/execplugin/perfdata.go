@@ -0,0 +1,135 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package execplugin
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var invalidMetricChars = regexp.MustCompile(`[^a-z0-9_.]+`)
+
+// perfdata is a performance data value of the output of a plugin, in the
+// 'label'=value[UOM];[warn];[crit];[min];[max] format. Only the value and its unit of measurement
+// are kept.
+type perfdata struct {
+	label string
+	value float64
+	uom   string
+}
+
+// parseOutput splits the output of a plugin into its text, made of its first line, and its
+// performance data. The performance data follow a `|` on the first line, and on any line of the
+// long text from the first one containing a `|`.
+func parseOutput(output string) (text string, perf string) {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+
+	text = lines[0]
+	var perfs []string
+	if i := strings.IndexByte(text, '|'); i >= 0 {
+		perfs = append(perfs, text[i+1:])
+		text = text[:i]
+	}
+
+	for n, line := range lines[1:] {
+		if i := strings.IndexByte(line, '|'); i >= 0 {
+			perfs = append(perfs, line[i+1:])
+			perfs = append(perfs, lines[n+2:]...)
+			break
+		}
+	}
+
+	return strings.TrimSpace(text), strings.Join(perfs, " ")
+}
+
+// parsePerfdata parses the performance data of a plugin. The values that can't be parsed are
+// reported as errors, and the unknown values (`U`) are skipped.
+func parsePerfdata(perf string) ([]perfdata, []error) {
+	var values []perfdata
+	var errs []error
+
+	for rest := strings.TrimSpace(perf); rest != ""; rest = strings.TrimSpace(rest) {
+		var label string
+		if rest[0] == '\'' {
+			// quoted label, in which a quote is escaped by another one
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest); i++ {
+				if rest[i] == '\'' {
+					if i+1 < len(rest) && rest[i+1] == '\'' {
+						b.WriteByte('\'')
+						i++
+						continue
+					}
+					break
+				}
+				b.WriteByte(rest[i])
+			}
+			if i < len(rest) {
+				i++ // closing quote
+			}
+			label = b.String()
+			rest = rest[i:]
+		} else {
+			i := strings.IndexAny(rest, "= ")
+			if i < 0 {
+				i = len(rest)
+			}
+			label = rest[:i]
+			rest = rest[i:]
+		}
+
+		var field string
+		if i := strings.IndexByte(rest, ' '); i >= 0 {
+			field, rest = rest[:i], rest[i:]
+		} else {
+			field, rest = rest, ""
+		}
+		if !strings.HasPrefix(field, "=") || label == "" {
+			errs = append(errs, fmt.Errorf("invalid performance data %q", label+field))
+			continue
+		}
+
+		value := strings.SplitN(field[1:], ";", 2)[0]
+		if value == "U" {
+			continue
+		}
+		p, err := parseValue(label, value)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		values = append(values, p)
+	}
+
+	return values, errs
+}
+
+func parseValue(label, value string) (perfdata, error) {
+	i := strings.IndexFunc(value, func(r rune) bool {
+		return !strings.ContainsRune("0123456789.-+", r)
+	})
+	if i < 0 {
+		i = len(value)
+	}
+	v, err := strconv.ParseFloat(value[:i], 64)
+	if err != nil {
+		return perfdata{}, fmt.Errorf("invalid value %q of performance data %q", value, label)
+	}
+	return perfdata{label: label, value: v, uom: value[i:]}, nil
+}
+
+// metricName returns the name of the metric of the label, with the given prefix. The labels
+// without any valid character, like the `/` of the root filesystem, are named after the prefix.
+func metricName(prefix, label string) string {
+	name := strings.Trim(invalidMetricChars.ReplaceAllString(strings.ToLower(label), "_"), "_.")
+	if name == "" {
+		return prefix
+	}
+	return prefix + "." + name
+}
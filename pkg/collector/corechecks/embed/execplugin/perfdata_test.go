@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package execplugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOutput(t *testing.T) {
+	for name, tc := range map[string]struct {
+		output, text, perf string
+	}{
+		"empty":     {"", "", ""},
+		"text only": {"DISK OK\n", "DISK OK", ""},
+		"single line": {
+			"DISK OK - free space: / 3326 MB (56%); | /=2643MB;5948;5958;0;5968\n",
+			"DISK OK - free space: / 3326 MB (56%);",
+			" /=2643MB;5948;5958;0;5968",
+		},
+		"long text": {
+			"DISK OK - free space: / 3326 MB (56%); | /=2643MB;5948;5958;0;5968\n" +
+				"/ 15272 MB (77%);\n" +
+				"/boot 68 MB (69%); | /boot=68MB;88;93;0;98\n" +
+				"/home=69357MB;253404;253409;0;253414\n",
+			"DISK OK - free space: / 3326 MB (56%);",
+			" /=2643MB;5948;5958;0;5968  /boot=68MB;88;93;0;98 /home=69357MB;253404;253409;0;253414",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			text, perf := parseOutput(tc.output)
+			assert.Equal(t, tc.text, text)
+			assert.Equal(t, tc.perf, perf)
+		})
+	}
+}
+
+func TestParsePerfdata(t *testing.T) {
+	values, errs := parsePerfdata(`time=0.06s;;;0.000000 'free space'=20.5% 'it''s'=3 size=512B;;;0 count=4;5;10 unknown=U;1;2 rta=-1.5ms`)
+	assert.Empty(t, errs)
+	assert.Equal(t, []perfdata{
+		{label: "time", value: 0.06, uom: "s"},
+		{label: "free space", value: 20.5, uom: "%"},
+		{label: "it's", value: 3},
+		{label: "size", value: 512, uom: "B"},
+		{label: "count", value: 4},
+		{label: "rta", value: -1.5, uom: "ms"},
+	}, values)
+
+	values, errs = parsePerfdata(`novalue ok=1 =2 bad=abc 'unterminated=1`)
+	assert.Len(t, errs, 4)
+	assert.Equal(t, []perfdata{{label: "ok", value: 1}}, values)
+}
+
+func TestMetricName(t *testing.T) {
+	assert.Equal(t, "nagios.disk.free_space", metricName("nagios.disk", "Free Space"))
+	assert.Equal(t, "nagios.disk.boot", metricName("nagios.disk", "/boot"))
+	assert.Equal(t, "nagios.disk", metricName("nagios.disk", "/"))
+	assert.Equal(t, "nagios.disk.var_log", metricName("nagios.disk", "/var/log"))
+	assert.Equal(t, "nagios.ping.rta.avg", metricName("nagios.ping", "rta.avg"))
+}
@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+)
+
+const (
+	probeCheckName      = "probe"
+	defaultProbeTimeout = 10 // seconds
+
+	probeTypeHTTP = "http"
+	probeTypeTCP  = "tcp"
+	probeTypeDNS  = "dns"
+)
+
+// ProbeCheck runs an active HTTP(S), TCP connect or DNS resolution probe, and submits its
+// latency and service checks of its results
+type ProbeCheck struct {
+	core.CheckBase
+	prober  prober
+	timeout time.Duration
+}
+
+// prober runs a probe
+type prober interface {
+	// probe runs the probe until ctx is done, and submits its metrics and service checks.
+	probe(ctx context.Context, sender aggregator.Sender)
+}
+
+type probeInstanceConfig struct {
+	Name    string `yaml:"name"`
+	Type    string `yaml:"type"`
+	Timeout int    `yaml:"timeout"`
+
+	// HTTP probes
+	URL                        string            `yaml:"url"`
+	Method                     string            `yaml:"method"`
+	Headers                    map[string]string `yaml:"headers"`
+	Data                       string            `yaml:"data"`
+	StatusCodes                patternList       `yaml:"http_response_status_code"`
+	ContentMatch               string            `yaml:"content_match"`
+	ReverseContentMatch        bool              `yaml:"reverse_content_match"`
+	AllowRedirects             *bool             `yaml:"allow_redirects"`
+	TLSVerify                  *bool             `yaml:"tls_verify"`
+	CheckCertificateExpiration *bool             `yaml:"check_certificate_expiration"`
+	DaysWarning                int               `yaml:"days_warning"`
+	DaysCritical               int               `yaml:"days_critical"`
+
+	// TCP probes
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+
+	// DNS probes
+	Hostname   string   `yaml:"hostname"`
+	Nameserver string   `yaml:"nameserver"`
+	RecordType string   `yaml:"record_type"`
+	ResolvesAs []string `yaml:"resolves_as"`
+}
+
+// patternList is a list of patterns, configured either as a list or as a single pattern.
+type patternList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (l *patternList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var patterns []string
+	if err := unmarshal(&patterns); err == nil {
+		*l = patterns
+		return nil
+	}
+	var pattern string
+	if err := unmarshal(&pattern); err != nil {
+		return err
+	}
+	*l = []string{pattern}
+	return nil
+}
+
+// probeType returns the type of the probe, guessed from the configured target if not set
+func (c *probeInstanceConfig) probeType() string {
+	switch {
+	case c.Type != "":
+		return c.Type
+	case c.URL != "":
+		return probeTypeHTTP
+	case c.Hostname != "":
+		return probeTypeDNS
+	default:
+		return probeTypeTCP
+	}
+}
+
+func newProber(config *probeInstanceConfig) (prober, error) {
+	if config.Name == "" {
+		return nil, errors.New("the name of the probe is required")
+	}
+	tags := []string{"instance:" + config.Name}
+
+	switch config.probeType() {
+	case probeTypeHTTP:
+		return newHTTPProber(config, tags)
+	case probeTypeTCP:
+		return newTCPProber(config, tags)
+	case probeTypeDNS:
+		return newDNSProber(config, tags)
+	default:
+		return nil, fmt.Errorf("invalid probe type %q, must be http, tcp or dns", config.Type)
+	}
+}
+
+// Configure parses the instance configuration
+func (c *ProbeCheck) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	var config probeInstanceConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return err
+	}
+
+	prober, err := newProber(&config)
+	if err != nil {
+		return err
+	}
+
+	c.BuildID(data, initConfig)
+	c.prober = prober
+	c.timeout = time.Duration(defaultProbeTimeout) * time.Second
+	if config.Timeout > 0 {
+		c.timeout = time.Duration(config.Timeout) * time.Second
+	}
+
+	return c.CheckBase.Configure(data, initConfig, source)
+}
+
+// Run runs the probe
+func (c *ProbeCheck) Run() error {
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	c.prober.probe(ctx, sender)
+	sender.Commit()
+
+	return nil
+}
+
+func probeFactory() check.Check {
+	return &ProbeCheck{
+		CheckBase: core.NewCheckBase(probeCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(probeCheckName, probeFactory)
+}
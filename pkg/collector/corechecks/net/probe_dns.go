@@ -0,0 +1,188 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+var dnsRecordTypes = map[string]uint16{
+	"A":     dns.TypeA,
+	"AAAA":  dns.TypeAAAA,
+	"CNAME": dns.TypeCNAME,
+	"MX":    dns.TypeMX,
+	"NS":    dns.TypeNS,
+	"TXT":   dns.TypeTXT,
+}
+
+// dnsProber checks that a hostname resolves, optionally to the expected values. It queries the
+// configured nameserver, or the resolver of the system.
+type dnsProber struct {
+	hostname   string
+	nameserver string
+	recordType string
+	resolvesAs []string
+	tags       []string
+}
+
+func newDNSProber(config *probeInstanceConfig, tags []string) (*dnsProber, error) {
+	if config.Hostname == "" {
+		return nil, errors.New("the hostname is required")
+	}
+	p := &dnsProber{
+		hostname:   config.Hostname,
+		recordType: "A",
+		tags:       append(tags, "resolved_hostname:"+config.Hostname),
+	}
+	if config.RecordType != "" {
+		p.recordType = strings.ToUpper(config.RecordType)
+	}
+	if _, found := dnsRecordTypes[p.recordType]; !found {
+		return nil, fmt.Errorf("invalid record type %q", config.RecordType)
+	}
+	p.tags = append(p.tags, "record_type:"+strings.ToLower(p.recordType))
+	if config.Nameserver != "" {
+		p.nameserver = config.Nameserver
+		if _, _, err := net.SplitHostPort(p.nameserver); err != nil {
+			p.nameserver = net.JoinHostPort(p.nameserver, "53")
+		}
+		p.tags = append(p.tags, "nameserver:"+config.Nameserver)
+	}
+	for _, value := range config.ResolvesAs {
+		p.resolvesAs = append(p.resolvesAs, p.normalize(value))
+	}
+	sort.Strings(p.resolvesAs)
+
+	return p, nil
+}
+
+func (p *dnsProber) probe(ctx context.Context, sender aggregator.Sender) {
+	start := time.Now()
+	var values []string
+	var err error
+	if p.nameserver != "" {
+		values, err = p.query(ctx)
+	} else {
+		values, err = p.lookup(ctx)
+	}
+	elapsed := time.Since(start)
+	if err == nil && len(values) == 0 {
+		err = fmt.Errorf("no %s record found for %s", p.recordType, p.hostname)
+	}
+	if err != nil {
+		sender.ServiceCheck("dns.can_resolve", metrics.ServiceCheckCritical, "", p.tags, err.Error())
+		return
+	}
+
+	sender.Gauge("dns.response_time", elapsed.Seconds(), "", p.tags)
+
+	for i, value := range values {
+		values[i] = p.normalize(value)
+	}
+	sort.Strings(values)
+	if len(p.resolvesAs) > 0 && strings.Join(values, ",") != strings.Join(p.resolvesAs, ",") {
+		message := fmt.Sprintf("%s resolved as %s, expected %s", p.hostname, strings.Join(values, ","), strings.Join(p.resolvesAs, ","))
+		sender.ServiceCheck("dns.can_resolve", metrics.ServiceCheckCritical, "", p.tags, message)
+		return
+	}
+	sender.ServiceCheck("dns.can_resolve", metrics.ServiceCheckOK, "", p.tags, "")
+}
+
+// lookup resolves the hostname with the resolver of the system
+func (p *dnsProber) lookup(ctx context.Context) ([]string, error) {
+	resolver := net.DefaultResolver
+	switch p.recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if p.recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, p.hostname)
+		values := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			values = append(values, ip.String())
+		}
+		return values, err
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, p.hostname)
+		return []string{cname}, err
+	case "MX":
+		records, err := resolver.LookupMX(ctx, p.hostname)
+		values := make([]string, 0, len(records))
+		for _, record := range records {
+			values = append(values, record.Host)
+		}
+		return values, err
+	case "NS":
+		records, err := resolver.LookupNS(ctx, p.hostname)
+		values := make([]string, 0, len(records))
+		for _, record := range records {
+			values = append(values, record.Host)
+		}
+		return values, err
+	default:
+		return resolver.LookupTXT(ctx, p.hostname)
+	}
+}
+
+// query resolves the hostname with the configured nameserver
+func (p *dnsProber) query(ctx context.Context) ([]string, error) {
+	qtype := dnsRecordTypes[p.recordType]
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(p.hostname), qtype)
+
+	var client dns.Client
+	resp, _, err := client.ExchangeContext(ctx, msg, p.nameserver)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("can't resolve %s: %s", p.hostname, dns.RcodeToString[resp.Rcode])
+	}
+
+	var values []string
+	for _, answer := range resp.Answer {
+		// the answers can include the CNAME records of the aliases of the hostname
+		if answer.Header().Rrtype != qtype {
+			continue
+		}
+		switch rr := answer.(type) {
+		case *dns.A:
+			values = append(values, rr.A.String())
+		case *dns.AAAA:
+			values = append(values, rr.AAAA.String())
+		case *dns.CNAME:
+			values = append(values, rr.Target)
+		case *dns.MX:
+			values = append(values, rr.Mx)
+		case *dns.NS:
+			values = append(values, rr.Ns)
+		case *dns.TXT:
+			values = append(values, strings.Join(rr.Txt, ""))
+		}
+	}
+	return values, nil
+}
+
+// normalize lowercases the resolved names and removes their trailing dot, to compare them with
+// the configured values
+func (p *dnsProber) normalize(value string) string {
+	if p.recordType == "TXT" {
+		return value
+	}
+	return strings.TrimSuffix(strings.ToLower(value), ".")
+}
@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package net
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// newTestNameserver starts a nameserver answering with the given records, and returns its address
+func newTestNameserver(t *testing.T, records ...string) string {
+	zone := make(map[dns.Question][]dns.RR)
+	for _, record := range records {
+		rr, err := dns.NewRR(record)
+		require.NoError(t, err)
+		question := dns.Question{Name: rr.Header().Name, Qtype: rr.Header().Rrtype, Qclass: dns.ClassINET}
+		zone[question] = append(zone[question], rr)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        conn,
+		NotifyStartedFunc: func() { close(started) },
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+			resp := new(dns.Msg)
+			resp.SetReply(req)
+			answers, found := zone[req.Question[0]]
+			if !found {
+				resp.Rcode = dns.RcodeNameError
+			}
+			// the CNAME records of the aliases are included in the answers, like recursive resolvers do
+			if req.Question[0].Qtype == dns.TypeA {
+				cname := dns.Question{Name: req.Question[0].Name, Qtype: dns.TypeCNAME, Qclass: dns.ClassINET}
+				answers = append(zone[cname], answers...)
+			}
+			resp.Answer = answers
+			w.WriteMsg(resp) //nolint:errcheck
+		}),
+	}
+	go server.ActivateAndServe() //nolint:errcheck
+	<-started
+	t.Cleanup(func() { server.Shutdown() }) //nolint:errcheck
+	return conn.LocalAddr().String()
+}
+
+func TestProbeDNSNameserver(t *testing.T) {
+	nameserver := newTestNameserver(t,
+		"www.example.com. 60 IN CNAME web.example.com.",
+		"www.example.com. 60 IN A 10.0.0.1",
+		"www.example.com. 60 IN A 10.0.0.2",
+		"example.com. 60 IN MX 10 Mail.Example.com.",
+	)
+	tags := []string{"instance:dns", "resolved_hostname:www.example.com", "record_type:a", "nameserver:" + nameserver}
+
+	c, sender := newTestProbeCheck(t, "name: dns\nhostname: www.example.com\nnameserver: "+nameserver+"\nresolves_as: [10.0.0.2, 10.0.0.1]")
+	require.NoError(t, c.Run())
+	sender.AssertCalled(t, "Gauge", "dns.response_time", mock.AnythingOfType("float64"), "", tags)
+	sender.AssertServiceCheck(t, "dns.can_resolve", metrics.ServiceCheckOK, "", tags, "")
+
+	c, sender = newTestProbeCheck(t, "name: dns\nhostname: www.example.com\nnameserver: "+nameserver+"\nresolves_as: [10.0.0.1]")
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "dns.can_resolve", metrics.ServiceCheckCritical, "", tags, "www.example.com resolved as 10.0.0.1,10.0.0.2, expected 10.0.0.1")
+
+	// the resolved names are compared without their case and trailing dot
+	mxTags := []string{"instance:dns", "resolved_hostname:example.com", "record_type:mx", "nameserver:" + nameserver}
+	c, sender = newTestProbeCheck(t, "name: dns\nhostname: example.com\nnameserver: "+nameserver+"\nrecord_type: mx\nresolves_as: [mail.example.com]")
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "dns.can_resolve", metrics.ServiceCheckOK, "", mxTags, "")
+
+	missingTags := []string{"instance:dns", "resolved_hostname:missing.example.com", "record_type:a", "nameserver:" + nameserver}
+	c, sender = newTestProbeCheck(t, "name: dns\nhostname: missing.example.com\nnameserver: "+nameserver)
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "dns.can_resolve", metrics.ServiceCheckCritical, "", missingTags, "can't resolve missing.example.com: NXDOMAIN")
+	sender.AssertNotCalled(t, "Gauge", "dns.response_time", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProbeDNSSystemResolver(t *testing.T) {
+	tags := []string{"instance:dns", "resolved_hostname:localhost", "record_type:a"}
+
+	c, sender := newTestProbeCheck(t, "name: dns\nhostname: localhost\nresolves_as: [127.0.0.1]")
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "dns.can_resolve", metrics.ServiceCheckOK, "", tags, "")
+	sender.AssertCalled(t, "Gauge", "dns.response_time", mocksender.IsGreaterOrEqual(0), "", tags)
+}
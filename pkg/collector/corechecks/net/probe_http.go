@@ -0,0 +1,198 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package net
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+)
+
+const (
+	defaultStatusCodes  = `(1|2|3)\d\d`
+	defaultDaysWarning  = 14
+	defaultDaysCritical = 7
+
+	// maxContentMatchSize is the maximum number of bytes of the responses matched by content_match
+	maxContentMatchSize = 1024 * 1024
+)
+
+// httpProber checks that a URL is reachable, answers with an expected status code and content,
+// and that its certificate doesn't expire soon.
+type httpProber struct {
+	url          string
+	method       string
+	headers      map[string]string
+	data         string
+	client       *http.Client
+	statusCodes  *regexp.Regexp
+	contentMatch *regexp.Regexp
+	reverseMatch bool
+	checkCert    bool
+	daysWarning  int
+	daysCritical int
+	tags         []string
+}
+
+func newHTTPProber(config *probeInstanceConfig, tags []string) (*httpProber, error) {
+	if u, err := url.Parse(config.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %q, must be an http or https url", config.URL)
+	}
+	p := &httpProber{
+		url:          config.URL,
+		method:       http.MethodGet,
+		headers:      config.Headers,
+		data:         config.Data,
+		reverseMatch: config.ReverseContentMatch,
+		checkCert:    config.CheckCertificateExpiration == nil || *config.CheckCertificateExpiration,
+		daysWarning:  defaultDaysWarning,
+		daysCritical: defaultDaysCritical,
+		tags:         append(tags, "url:"+config.URL),
+	}
+	if config.Method != "" {
+		p.method = strings.ToUpper(config.Method)
+	}
+	if config.DaysWarning > 0 {
+		p.daysWarning = config.DaysWarning
+	}
+	if config.DaysCritical > 0 {
+		p.daysCritical = config.DaysCritical
+	}
+
+	statusCodes := []string(config.StatusCodes)
+	if len(statusCodes) == 0 {
+		statusCodes = []string{defaultStatusCodes}
+	}
+	var err error
+	if p.statusCodes, err = regexp.Compile("^(?:" + strings.Join(statusCodes, "|") + ")$"); err != nil {
+		return nil, fmt.Errorf("invalid http_response_status_code %q: %s", statusCodes, err)
+	}
+	if config.ContentMatch != "" {
+		if p.contentMatch, err = regexp.Compile(config.ContentMatch); err != nil {
+			return nil, fmt.Errorf("invalid content_match %q: %s", config.ContentMatch, err)
+		}
+	}
+
+	transport := httputils.CreateHTTPTransport()
+	if config.TLSVerify != nil && !*config.TLSVerify {
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+	p.client = &http.Client{Transport: transport}
+	if config.AllowRedirects != nil && !*config.AllowRedirects {
+		p.client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	return p, nil
+}
+
+func (p *httpProber) probe(ctx context.Context, sender aggregator.Sender) {
+	var body io.Reader
+	if p.data != "" {
+		body = strings.NewReader(p.data)
+	}
+	req, err := http.NewRequestWithContext(ctx, p.method, p.url, body)
+	if err != nil {
+		sender.ServiceCheck("http.can_connect", metrics.ServiceCheckCritical, "", p.tags, err.Error())
+		return
+	}
+	for k, v := range p.headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		sender.Gauge("network.http.can_connect", 0, "", p.tags)
+		sender.ServiceCheck("http.can_connect", metrics.ServiceCheckCritical, "", p.tags, err.Error())
+		var certErr x509.CertificateInvalidError
+		if p.checkCert && errors.As(err, &certErr) && certErr.Reason == x509.Expired {
+			sender.ServiceCheck("http.ssl_cert", metrics.ServiceCheckCritical, "", p.tags, certErr.Error())
+		}
+		return
+	}
+	defer resp.Body.Close()
+
+	var content []byte
+	if p.contentMatch != nil {
+		content, err = io.ReadAll(io.LimitReader(resp.Body, maxContentMatchSize))
+	} else {
+		_, err = io.Copy(io.Discard, resp.Body)
+	}
+	elapsed := time.Since(start)
+	if err != nil {
+		sender.Gauge("network.http.can_connect", 0, "", p.tags)
+		sender.ServiceCheck("http.can_connect", metrics.ServiceCheckCritical, "", p.tags, fmt.Sprintf("can't read the response: %s", err))
+		return
+	}
+
+	sender.Gauge("network.http.can_connect", 1, "", p.tags)
+	sender.Gauge("network.http.response_time", elapsed.Seconds(), "", p.tags)
+	statusTags := append(append([]string{}, p.tags...), fmt.Sprintf("status_code:%d", resp.StatusCode))
+	sender.Gauge("network.http.status_code", float64(resp.StatusCode), "", statusTags)
+
+	if p.statusCodes.MatchString(fmt.Sprint(resp.StatusCode)) {
+		sender.ServiceCheck("http.can_connect", metrics.ServiceCheckOK, "", p.tags, "")
+	} else {
+		message := fmt.Sprintf("Incorrect HTTP return code for url %s. Expected %s, got %d.", p.url, p.statusCodes, resp.StatusCode)
+		sender.ServiceCheck("http.can_connect", metrics.ServiceCheckCritical, "", p.tags, message)
+	}
+
+	if p.contentMatch != nil {
+		p.submitContentMatch(sender, content)
+	}
+	if p.checkCert && resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		p.submitCertExpiration(sender, resp.TLS.PeerCertificates[0])
+	}
+}
+
+func (p *httpProber) submitContentMatch(sender aggregator.Sender, content []byte) {
+	matched := p.contentMatch.Match(content)
+	switch {
+	case matched && !p.reverseMatch:
+		sender.ServiceCheck("http.content_match", metrics.ServiceCheckOK, "", p.tags, "")
+	case !matched && p.reverseMatch:
+		sender.ServiceCheck("http.content_match", metrics.ServiceCheckOK, "", p.tags, "")
+	case matched:
+		sender.ServiceCheck("http.content_match", metrics.ServiceCheckCritical, "", p.tags, fmt.Sprintf("Content %q found in the response", p.contentMatch))
+	default:
+		sender.ServiceCheck("http.content_match", metrics.ServiceCheckCritical, "", p.tags, fmt.Sprintf("Content %q not found in the response", p.contentMatch))
+	}
+}
+
+func (p *httpProber) submitCertExpiration(sender aggregator.Sender, cert *x509.Certificate) {
+	daysLeft := time.Until(cert.NotAfter).Hours() / 24
+	sender.Gauge("http.ssl.days_left", daysLeft, "", p.tags)
+
+	status := metrics.ServiceCheckOK
+	message := fmt.Sprintf("Days left: %.1f", daysLeft)
+	switch {
+	case daysLeft < 0:
+		status = metrics.ServiceCheckCritical
+		message = fmt.Sprintf("The certificate expired on %s", cert.NotAfter.UTC().Format(time.RFC3339))
+	case daysLeft < float64(p.daysCritical):
+		status = metrics.ServiceCheckCritical
+	case daysLeft < float64(p.daysWarning):
+		status = metrics.ServiceCheckWarning
+	}
+	sender.ServiceCheck("http.ssl_cert", status, "", p.tags, message)
+}
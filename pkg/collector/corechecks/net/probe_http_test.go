@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package net
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func newProbeTestServer(t *testing.T, tls bool) *httptest.Server {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "ok"}`)) //nolint:errcheck
+	})
+	handler.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		io.Copy(w, r.Body) //nolint:errcheck
+	})
+	handler.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/health", http.StatusFound)
+	})
+	var server *httptest.Server
+	if tls {
+		server = httptest.NewTLSServer(handler)
+	} else {
+		server = httptest.NewServer(handler)
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProbeHTTP(t *testing.T) {
+	server := newProbeTestServer(t, false)
+	url := server.URL + "/health"
+	tags := []string{"instance:web", "url:" + url}
+
+	c, sender := newTestProbeCheck(t, "name: web\nurl: "+url+"\ncontent_match: '\"status\": \"ok\"'")
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "Gauge", "network.http.can_connect", 1, "", tags)
+	sender.AssertCalled(t, "Gauge", "network.http.response_time", mock.AnythingOfType("float64"), "", tags)
+	sender.AssertMetric(t, "Gauge", "network.http.status_code", 200, "", append(tags, "status_code:200"))
+	sender.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckOK, "", tags, "")
+	sender.AssertServiceCheck(t, "http.content_match", metrics.ServiceCheckOK, "", tags, "")
+	sender.AssertNotCalled(t, "ServiceCheck", "http.ssl_cert", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProbeHTTPContentMatch(t *testing.T) {
+	server := newProbeTestServer(t, false)
+	url := server.URL + "/echo"
+	tags := []string{"instance:web", "url:" + url}
+
+	c, sender := newTestProbeCheck(t, "name: web\nurl: "+url+"\nmethod: post\ndata: maintenance\ncontent_match: maintenance\nreverse_content_match: true")
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckOK, "", tags, "")
+	sender.AssertServiceCheck(t, "http.content_match", metrics.ServiceCheckCritical, "", tags, `Content "maintenance" found in the response`)
+
+	c, sender = newTestProbeCheck(t, "name: web\nurl: "+url+"\ncontent_match: ok")
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "http.content_match", metrics.ServiceCheckCritical, "", tags, `Content "ok" not found in the response`)
+}
+
+func TestProbeHTTPStatusCode(t *testing.T) {
+	server := newProbeTestServer(t, false)
+	url := server.URL + "/missing"
+	tags := []string{"instance:web", "url:" + url}
+
+	c, sender := newTestProbeCheck(t, "name: web\nurl: "+url)
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "Gauge", "network.http.can_connect", 1, "", tags)
+	sender.AssertMetric(t, "Gauge", "network.http.status_code", 404, "", append(tags, "status_code:404"))
+	sender.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckCritical, "", tags,
+		`Incorrect HTTP return code for url `+url+`. Expected ^(?:(1|2|3)\d\d)$, got 404.`)
+
+	c, sender = newTestProbeCheck(t, "name: web\nurl: "+url+"\nhttp_response_status_code: 404")
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckOK, "", tags, "")
+}
+
+func TestProbeHTTPRedirects(t *testing.T) {
+	server := newProbeTestServer(t, false)
+	url := server.URL + "/redirect"
+
+	c, sender := newTestProbeCheck(t, "name: web\nurl: "+url+"\nallow_redirects: false")
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "Gauge", "network.http.status_code", 302, "", []string{"instance:web", "url:" + url, "status_code:302"})
+}
+
+func TestProbeHTTPUnreachable(t *testing.T) {
+	server := newProbeTestServer(t, false)
+	url := server.URL + "/health"
+	server.Close()
+	tags := []string{"instance:web", "url:" + url}
+
+	c, sender := newTestProbeCheck(t, "name: web\nurl: "+url)
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "Gauge", "network.http.can_connect", 0, "", tags)
+	sender.AssertCalled(t, "ServiceCheck", "http.can_connect", metrics.ServiceCheckCritical, "", tags, mocksender.AnythingBut(""))
+	sender.AssertNotCalled(t, "Gauge", "network.http.response_time", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProbeHTTPCertificate(t *testing.T) {
+	server := newProbeTestServer(t, true)
+	url := server.URL + "/health"
+	tags := []string{"instance:web", "url:" + url}
+
+	// the certificate of the test server isn't signed by a trusted authority
+	c, sender := newTestProbeCheck(t, "name: web\nurl: "+url)
+	require.NoError(t, c.Run())
+	sender.AssertCalled(t, "ServiceCheck", "http.can_connect", metrics.ServiceCheckCritical, "", tags, mocksender.AnythingBut(""))
+
+	c, sender = newTestProbeCheck(t, "name: web\nurl: "+url+"\ntls_verify: false")
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckOK, "", tags, "")
+	sender.AssertCalled(t, "Gauge", "http.ssl.days_left", mocksender.IsGreaterOrEqual(365), "", tags)
+	sender.AssertCalled(t, "ServiceCheck", "http.ssl_cert", metrics.ServiceCheckOK, "", tags, mock.AnythingOfType("string"))
+
+	// the certificate expires in more than a year, and less than a thousand
+	c, sender = newTestProbeCheck(t, "name: web\nurl: "+url+"\ntls_verify: false\ndays_warning: 365000\ndays_critical: 365")
+	require.NoError(t, c.Run())
+	sender.AssertCalled(t, "ServiceCheck", "http.ssl_cert", metrics.ServiceCheckWarning, "", tags, mock.AnythingOfType("string"))
+
+	c, sender = newTestProbeCheck(t, "name: web\nurl: "+url+"\ntls_verify: false\ncheck_certificate_expiration: false")
+	require.NoError(t, c.Run())
+	sender.AssertNotCalled(t, "ServiceCheck", "http.ssl_cert", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.True(t, sender.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckOK, "", tags, ""))
+}
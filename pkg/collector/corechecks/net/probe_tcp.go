@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// tcpProber checks that a TCP port accepts connections.
+type tcpProber struct {
+	address string
+	tags    []string
+}
+
+func newTCPProber(config *probeInstanceConfig, tags []string) (*tcpProber, error) {
+	if config.Host == "" {
+		return nil, errors.New("the host is required")
+	}
+	if config.Port <= 0 || config.Port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", config.Port)
+	}
+	return &tcpProber{
+		address: net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		tags:    append(tags, "target_host:"+config.Host, fmt.Sprintf("port:%d", config.Port)),
+	}, nil
+}
+
+func (p *tcpProber) probe(ctx context.Context, sender aggregator.Sender) {
+	var dialer net.Dialer
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		sender.Gauge("network.tcp.can_connect", 0, "", p.tags)
+		sender.ServiceCheck("tcp.can_connect", metrics.ServiceCheckCritical, "", p.tags, err.Error())
+		return
+	}
+	elapsed := time.Since(start)
+	conn.Close()
+
+	sender.Gauge("network.tcp.can_connect", 1, "", p.tags)
+	sender.Gauge("network.tcp.response_time", elapsed.Seconds(), "", p.tags)
+	sender.ServiceCheck("tcp.can_connect", metrics.ServiceCheckOK, "", p.tags, "")
+}
@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package net
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func newTestProbeCheck(t *testing.T, instance string) (*ProbeCheck, *mocksender.MockSender) {
+	c := probeFactory().(*ProbeCheck)
+	c.BuildID(integration.Data(instance), nil)
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	require.NoError(t, c.Configure(integration.Data(instance), nil, "test"))
+	return c, sender
+}
+
+func TestProbeConfigure(t *testing.T) {
+	for instance, expected := range map[string]prober{
+		"name: web\nurl: http://localhost:8080/health":    &httpProber{},
+		"name: db\nhost: localhost\nport: 5432":           &tcpProber{},
+		"name: dns\nhostname: localhost":                  &dnsProber{},
+		"name: web\ntype: tcp\nhost: localhost\nport: 80": &tcpProber{},
+	} {
+		c, _ := newTestProbeCheck(t, instance)
+		assert.IsType(t, expected, c.prober, instance)
+	}
+
+	for name, instance := range map[string]string{
+		"no name":       "url: http://localhost:8080/health",
+		"type":          "name: web\ntype: icmp\nhost: localhost",
+		"url":           "name: web\nurl: localhost:8080",
+		"status code":   "name: web\nurl: http://localhost:8080\nhttp_response_status_code: '2(0'",
+		"content match": "name: web\nurl: http://localhost:8080\ncontent_match: '(ok'",
+		"no port":       "name: db\nhost: localhost",
+		"record type":   "name: dns\nhostname: localhost\nrecord_type: SRV",
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := probeFactory().(*ProbeCheck)
+			assert.Error(t, c.Configure(integration.Data(instance), nil, "test"))
+		})
+	}
+}
+
+func TestProbeStatusCodes(t *testing.T) {
+	for instance, expected := range map[string]string{
+		"name: web\nurl: http://localhost":                                        `^(?:(1|2|3)\d\d)$`,
+		"name: web\nurl: http://localhost\nhttp_response_status_code: 404":        `^(?:404)$`,
+		"name: web\nurl: http://localhost\nhttp_response_status_code: [2.., 404]": `^(?:2..|404)$`,
+	} {
+		c, _ := newTestProbeCheck(t, instance)
+		assert.Equal(t, expected, c.prober.(*httpProber).statusCodes.String())
+	}
+}
+
+func TestProbeTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	tags := []string{"instance:db", "target_host:127.0.0.1", fmt.Sprintf("port:%d", port)}
+
+	c, sender := newTestProbeCheck(t, fmt.Sprintf("name: db\nhost: 127.0.0.1\nport: %d", port))
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "Gauge", "network.tcp.can_connect", 1, "", tags)
+	sender.AssertCalled(t, "Gauge", "network.tcp.response_time", mock.AnythingOfType("float64"), "", tags)
+	sender.AssertServiceCheck(t, "tcp.can_connect", metrics.ServiceCheckOK, "", tags, "")
+
+	listener.Close()
+	sender.ResetCalls()
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "Gauge", "network.tcp.can_connect", 0, "", tags)
+	sender.AssertNotCalled(t, "Gauge", "network.tcp.response_time", mock.Anything, mock.Anything, mock.Anything)
+	sender.AssertCalled(t, "ServiceCheck", "tcp.can_connect", metrics.ServiceCheckCritical, "", tags, mocksender.AnythingBut(""))
+}
@@ -0,0 +1,286 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package openmetrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultTimeout         = 10 * time.Second
+	defaultBearerTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// instanceConfig is the configuration of an instance. It accepts the options of both versions of
+// the Python openmetrics check: the options of the version 2 take precedence over the ones of the
+// version 1 they supersede.
+type instanceConfig struct {
+	OpenMetricsEndpoint string `yaml:"openmetrics_endpoint"`
+	PrometheusURL       string `yaml:"prometheus_url"`
+	Namespace           string `yaml:"namespace"`
+
+	Metrics        []interface{}     `yaml:"metrics"`
+	ExcludeMetrics []string          `yaml:"exclude_metrics"`
+	IgnoreMetrics  []string          `yaml:"ignore_metrics"`
+	RawPrefix      string            `yaml:"raw_metric_prefix"`
+	PromPrefix     string            `yaml:"prometheus_metrics_prefix"`
+	TypeOverrides  map[string]string `yaml:"type_overrides"`
+
+	RenameLabels  map[string]string `yaml:"rename_labels"`
+	LabelsMapper  map[string]string `yaml:"labels_mapper"`
+	ExcludeLabels []string          `yaml:"exclude_labels"`
+
+	CollectHistogramBuckets         *bool `yaml:"collect_histogram_buckets"`
+	SendHistogramBuckets            *bool `yaml:"send_histograms_buckets"`
+	HistogramBucketsAsDistributions bool  `yaml:"histogram_buckets_as_distributions"`
+	SendDistributionBuckets         bool  `yaml:"send_distribution_buckets"`
+	EnableHealthCheck               *bool `yaml:"enable_health_service_check"`
+	HealthCheck                     *bool `yaml:"health_service_check"`
+
+	// options of the version 1 only
+	SendMonotonicCounter              *bool `yaml:"send_monotonic_counter"`
+	SendDistributionCountsAsMonotonic bool  `yaml:"send_distribution_counts_as_monotonic"`
+	SendDistributionSumsAsMonotonic   bool  `yaml:"send_distribution_sums_as_monotonic"`
+
+	Timeout         int               `yaml:"timeout"`
+	Headers         map[string]string `yaml:"headers"`
+	ExtraHeaders    map[string]string `yaml:"extra_headers"`
+	Username        string            `yaml:"username"`
+	Password        string            `yaml:"password"`
+	BearerTokenAuth bool              `yaml:"bearer_token_auth"`
+	BearerTokenPath string            `yaml:"bearer_token_path"`
+	TLSVerify       *bool             `yaml:"tls_verify"`
+	TLSCACert       string            `yaml:"tls_ca_cert"`
+	TLSCert         string            `yaml:"tls_cert"`
+	TLSPrivateKey   string            `yaml:"tls_private_key"`
+	SkipProxy       bool              `yaml:"skip_proxy"`
+}
+
+// scrapeConfig is the validated configuration of an instance.
+type scrapeConfig struct {
+	// legacy is true for the instances of the version 1, whose metrics are named and typed
+	// like the ones of the version 1 of the Python check.
+	legacy bool
+
+	endpoint  string
+	namespace string
+	rawPrefix string
+
+	metrics       []metricMatcher
+	exclude       *regexp.Regexp
+	typeOverrides map[string]dto.MetricType
+
+	renameLabels  map[string]string
+	excludeLabels map[string]struct{}
+
+	collectHistogramBuckets bool
+	bucketsAsDistributions  bool
+	healthCheck             bool
+
+	// the submission options of the version 1
+	monotonicCounter            bool
+	monotonicDistributionCounts bool
+	monotonicDistributionSums   bool
+
+	timeout         time.Duration
+	headers         map[string]string
+	username        string
+	password        string
+	bearerTokenPath string
+	tlsVerify       bool
+	tlsCACert       string
+	tlsCert         string
+	tlsPrivateKey   string
+	skipProxy       bool
+}
+
+// metricMatcher matches the raw names of the collected metrics, and optionally renames them or
+// overrides their type.
+type metricMatcher struct {
+	pattern *regexp.Regexp
+	name    string
+	mtype   *dto.MetricType
+}
+
+var metricTypes = map[string]dto.MetricType{
+	"gauge":   dto.MetricType_GAUGE,
+	"counter": dto.MetricType_COUNTER,
+	"untyped": dto.MetricType_UNTYPED,
+}
+
+func parseConfig(data []byte) (*scrapeConfig, error) {
+	var instance instanceConfig
+	if err := yaml.Unmarshal(data, &instance); err != nil {
+		return nil, err
+	}
+
+	// the patterns of the version 1 are wildcards, and the ones of the version 2 regexes
+	legacy := instance.OpenMetricsEndpoint == ""
+	c := &scrapeConfig{
+		legacy:                  legacy,
+		endpoint:                firstString(instance.OpenMetricsEndpoint, instance.PrometheusURL),
+		namespace:               strings.TrimSuffix(instance.Namespace, "."),
+		rawPrefix:               firstString(instance.RawPrefix, instance.PromPrefix),
+		renameLabels:            instance.RenameLabels,
+		excludeLabels:           make(map[string]struct{}, len(instance.ExcludeLabels)),
+		collectHistogramBuckets: firstBool(true, instance.CollectHistogramBuckets, instance.SendHistogramBuckets),
+		bucketsAsDistributions:  instance.HistogramBucketsAsDistributions || instance.SendDistributionBuckets,
+		healthCheck:             firstBool(true, instance.EnableHealthCheck, instance.HealthCheck),
+		timeout:                 defaultTimeout,
+		headers:                 make(map[string]string, len(instance.Headers)+len(instance.ExtraHeaders)),
+		username:                instance.Username,
+		password:                instance.Password,
+		tlsVerify:               firstBool(true, instance.TLSVerify),
+		tlsCACert:               instance.TLSCACert,
+		tlsCert:                 instance.TLSCert,
+		tlsPrivateKey:           instance.TLSPrivateKey,
+		skipProxy:               instance.SkipProxy,
+
+		monotonicCounter:            firstBool(true, instance.SendMonotonicCounter),
+		monotonicDistributionCounts: instance.SendDistributionCountsAsMonotonic,
+		monotonicDistributionSums:   instance.SendDistributionSumsAsMonotonic,
+	}
+	if c.endpoint == "" {
+		return nil, errors.New("the openmetrics_endpoint is required")
+	}
+	if c.renameLabels == nil {
+		c.renameLabels = instance.LabelsMapper
+	}
+	for _, label := range instance.ExcludeLabels {
+		c.excludeLabels[label] = struct{}{}
+	}
+	if instance.Timeout > 0 {
+		c.timeout = time.Duration(instance.Timeout) * time.Second
+	}
+	for k, v := range instance.Headers {
+		c.headers[k] = v
+	}
+	for k, v := range instance.ExtraHeaders {
+		c.headers[k] = v
+	}
+	if instance.BearerTokenAuth {
+		c.bearerTokenPath = firstString(instance.BearerTokenPath, defaultBearerTokenPath)
+	}
+
+	if len(instance.Metrics) == 0 {
+		return nil, errors.New("the metrics to collect are required, use `.*` to collect all of them")
+	}
+	for _, item := range instance.Metrics {
+		matchers, err := parseMetricItem(item, legacy)
+		if err != nil {
+			return nil, err
+		}
+		c.metrics = append(c.metrics, matchers...)
+	}
+
+	excludePatterns := instance.ExcludeMetrics
+	if excludePatterns == nil {
+		excludePatterns = instance.IgnoreMetrics
+	}
+	if len(excludePatterns) > 0 {
+		var err error
+		if c.exclude, err = compilePatterns(excludePatterns, legacy); err != nil {
+			return nil, err
+		}
+	}
+
+	c.typeOverrides = make(map[string]dto.MetricType, len(instance.TypeOverrides))
+	for name, typ := range instance.TypeOverrides {
+		mtype, found := metricTypes[typ]
+		if !found {
+			return nil, fmt.Errorf("invalid type %q of metric %q, must be gauge, counter or untyped", typ, name)
+		}
+		c.typeOverrides[name] = mtype
+	}
+
+	return c, nil
+}
+
+// parseMetricItem parses an item of the metrics list: either a pattern, or a mapping of raw
+// metric names to their new name, or to their new name and type.
+func parseMetricItem(item interface{}, legacy bool) ([]metricMatcher, error) {
+	switch item := item.(type) {
+	case string:
+		pattern, err := compilePatterns([]string{item}, legacy)
+		if err != nil {
+			return nil, err
+		}
+		return []metricMatcher{{pattern: pattern}}, nil
+	case map[interface{}]interface{}:
+		var matchers []metricMatcher
+		for raw, target := range item {
+			rawName, ok := raw.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid metric name %v", raw)
+			}
+			matcher := metricMatcher{
+				pattern: regexp.MustCompile("^" + regexp.QuoteMeta(rawName) + "$"),
+			}
+			switch target := target.(type) {
+			case string:
+				matcher.name = target
+			case map[interface{}]interface{}:
+				if name, ok := target["name"].(string); ok {
+					matcher.name = name
+				}
+				if typ, ok := target["type"].(string); ok {
+					mtype, found := metricTypes[typ]
+					if !found {
+						return nil, fmt.Errorf("invalid type %q of metric %q, must be gauge, counter or untyped", typ, rawName)
+					}
+					matcher.mtype = &mtype
+				}
+			default:
+				return nil, fmt.Errorf("invalid configuration of metric %q", rawName)
+			}
+			matchers = append(matchers, matcher)
+		}
+		return matchers, nil
+	default:
+		return nil, fmt.Errorf("invalid metrics item %v", item)
+	}
+}
+
+// compilePatterns compiles patterns matching whole metric names into a single regex.
+func compilePatterns(patterns []string, legacy bool) (*regexp.Regexp, error) {
+	quoted := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if legacy {
+			pattern = strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+		}
+		quoted = append(quoted, "(?:"+pattern+")")
+	}
+	re, err := regexp.Compile("^(?:" + strings.Join(quoted, "|") + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid metric patterns %q: %s", patterns, err)
+	}
+	return re, nil
+}
+
+func firstString(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func firstBool(defaultValue bool, values ...*bool) bool {
+	for _, v := range values {
+		if v != nil {
+			return *v
+		}
+	}
+	return defaultValue
+}
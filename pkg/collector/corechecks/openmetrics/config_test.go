@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package openmetrics
+
+import (
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigInvalid(t *testing.T) {
+	for name, instance := range map[string]string{
+		"no endpoint":   `metrics: [".*"]`,
+		"no metrics":    `openmetrics_endpoint: http://localhost:9090/metrics`,
+		"regex":         "openmetrics_endpoint: http://localhost:9090/metrics\nmetrics: [\"foo(\"]",
+		"type override": "openmetrics_endpoint: http://localhost:9090/metrics\nmetrics: [\".*\"]\ntype_overrides: {foo: histogram}",
+		"metric type":   "openmetrics_endpoint: http://localhost:9090/metrics\nmetrics: [{foo: {type: summary}}]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseConfig([]byte(instance))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseConfigLegacy(t *testing.T) {
+	config, err := parseConfig([]byte(`
+prometheus_url: http://localhost:9090/metrics
+namespace: app.
+prometheus_metrics_prefix: app_
+metrics:
+  - go_*
+  - process_cpu_seconds: cpu.time
+ignore_metrics: [go_memstats_*]
+labels_mapper: {pod: pod_name}
+send_histograms_buckets: false
+send_distribution_buckets: true
+health_service_check: false
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:9090/metrics", config.endpoint)
+	assert.Equal(t, "app", config.namespace)
+	assert.Equal(t, "app_", config.rawPrefix)
+	assert.Equal(t, map[string]string{"pod": "pod_name"}, config.renameLabels)
+	assert.False(t, config.collectHistogramBuckets)
+	assert.True(t, config.bucketsAsDistributions)
+	assert.False(t, config.healthCheck)
+
+	// the patterns of the version 1 are wildcards
+	assert.Equal(t, &metricTarget{name: "app.go_threads", mtype: dto.MetricType_GAUGE}, config.resolve("app_go_threads", dto.MetricType_GAUGE))
+	assert.Equal(t, &metricTarget{name: "app.cpu.time", mtype: dto.MetricType_COUNTER}, config.resolve("app_process_cpu_seconds_total", dto.MetricType_COUNTER))
+	assert.Nil(t, config.resolve("app_go_memstats_alloc_bytes", dto.MetricType_GAUGE))
+	assert.Nil(t, config.resolve("app_gosomething", dto.MetricType_GAUGE))
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := parseConfig([]byte(`
+openmetrics_endpoint: http://localhost:9090/metrics
+prometheus_url: http://localhost:9091/metrics
+namespace: app
+metrics:
+  - http_.*
+  - queue_depth: {name: queue.depth, type: gauge}
+  - jobs_running: {type: counter}
+exclude_metrics: [http_debug_.*]
+type_overrides: {http_in_flight: untyped}
+rename_labels: {pod: pod_name}
+labels_mapper: {pod: ignored}
+exclude_labels: [instance]
+headers: {X-Scope: a}
+extra_headers: {X-Scope: b, X-Other: c}
+timeout: 3
+bearer_token_auth: true
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:9090/metrics", config.endpoint)
+	assert.Equal(t, map[string]string{"pod": "pod_name"}, config.renameLabels)
+	assert.True(t, config.collectHistogramBuckets)
+	assert.False(t, config.bucketsAsDistributions)
+	assert.True(t, config.healthCheck)
+	assert.Equal(t, map[string]string{"X-Scope": "b", "X-Other": "c"}, config.headers)
+	assert.Equal(t, "3s", config.timeout.String())
+	assert.Equal(t, defaultBearerTokenPath, config.bearerTokenPath)
+
+	for rawName, expected := range map[string]*metricTarget{
+		"http_requests_total":   {name: "app.http_requests", mtype: dto.MetricType_COUNTER},
+		"http_in_flight":        {name: "app.http_in_flight", mtype: dto.MetricType_UNTYPED},
+		"queue_depth":           {name: "app.queue.depth", mtype: dto.MetricType_GAUGE},
+		"jobs_running":          {name: "app.jobs_running", mtype: dto.MetricType_COUNTER},
+		"http_debug_requests":   nil,
+		"grpc_requests_total":   nil,
+		"xhttp_requests_total":  nil,
+		"queue_depth_something": nil,
+	} {
+		mtype := dto.MetricType_GAUGE
+		if expected != nil && expected.name == "app.http_requests" {
+			mtype = dto.MetricType_COUNTER
+		}
+		assert.Equal(t, expected, config.resolve(rawName, mtype), rawName)
+	}
+}
+
+func TestTags(t *testing.T) {
+	config, err := parseConfig([]byte(`
+openmetrics_endpoint: http://localhost:9090/metrics
+metrics: [".*"]
+rename_labels: {pod: pod_name}
+exclude_labels: [instance]
+`))
+	require.NoError(t, err)
+
+	labels := []*dto.LabelPair{
+		{Name: strPtr("pod"), Value: strPtr("web-1")},
+		{Name: strPtr("instance"), Value: strPtr("10.0.0.1:9090")},
+		{Name: strPtr("code"), Value: strPtr("200")},
+		{Name: strPtr("empty"), Value: strPtr("")},
+	}
+	assert.Equal(t, []string{"pod_name:web-1", "code:200"}, config.tags(labels))
+}
+
+func strPtr(s string) *string {
+	return &s
+}
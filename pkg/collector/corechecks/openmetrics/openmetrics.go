@@ -0,0 +1,427 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package openmetrics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+)
+
+const (
+	checkName = "openmetrics"
+
+	// acceptHeader prefers the protobuf exposition format, which is the cheapest to parse
+	acceptHeader = `application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,text/plain;version=0.0.4;q=0.3,*/*;q=0.1`
+
+	// maxCacheSize is the maximum number of raw metric names whose name and type are cached
+	maxCacheSize = 10000
+)
+
+// Check scrapes an endpoint exposing metrics in the Prometheus text or protobuf formats. It is
+// a Go implementation of the Python openmetrics check, loaded instead of it when the `loader`
+// option of the configuration is `core`.
+type Check struct {
+	core.CheckBase
+	config *scrapeConfig
+	client *http.Client
+
+	// targets caches the names and types of the raw metric names, nil if not collected
+	targets map[string]*metricTarget
+}
+
+// metricTarget is the name and type of a collected metric
+type metricTarget struct {
+	name  string
+	mtype dto.MetricType
+}
+
+func newCheck() check.Check {
+	return &Check{
+		CheckBase: core.NewCheckBase(checkName),
+	}
+}
+
+// Configure parses the instance configuration
+func (c *Check) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	config, err := parseConfig(data)
+	if err != nil {
+		return err
+	}
+
+	transport := httputils.CreateHTTPTransport()
+	if config.skipProxy {
+		transport.Proxy = nil
+	}
+	if !config.tlsVerify {
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+	if err := configureTLS(transport.TLSClientConfig, config); err != nil {
+		return err
+	}
+
+	c.BuildID(data, initConfig)
+	c.config = config
+	c.client = &http.Client{Transport: transport, Timeout: config.timeout}
+	c.targets = make(map[string]*metricTarget)
+
+	return c.CheckBase.Configure(data, initConfig, source)
+}
+
+func configureTLS(tlsConfig *tls.Config, config *scrapeConfig) error {
+	if config.tlsCACert != "" {
+		pem, err := os.ReadFile(config.tlsCACert)
+		if err != nil {
+			return fmt.Errorf("can't read the CA certificate: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no valid certificate in %s", config.tlsCACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.tlsCert != "" {
+		keyFile := config.tlsPrivateKey
+		if keyFile == "" {
+			keyFile = config.tlsCert
+		}
+		cert, err := tls.LoadX509KeyPair(config.tlsCert, keyFile)
+		if err != nil {
+			return fmt.Errorf("can't load the client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return nil
+}
+
+// Run scrapes the endpoint and submits its metrics
+func (c *Check) Run() error {
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+
+	families, err := c.scrape()
+	if err != nil {
+		c.submitHealth(sender, metrics.ServiceCheckCritical, err.Error())
+		sender.Commit()
+		return err
+	}
+
+	for _, family := range families {
+		c.submitFamily(sender, family)
+	}
+	c.submitHealth(sender, metrics.ServiceCheckOK, "")
+	sender.Commit()
+
+	return nil
+}
+
+// scrape returns the metric families exposed by the endpoint
+func (c *Check) scrape() ([]*dto.MetricFamily, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", acceptHeader)
+	for k, v := range c.config.headers {
+		req.Header.Set(k, v)
+	}
+	if c.config.username != "" {
+		req.SetBasicAuth(c.config.username, c.config.password)
+	}
+	if c.config.bearerTokenPath != "" {
+		// the token is read at every run, as it can be rotated
+		token, err := os.ReadFile(c.config.bearerTokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("can't read the bearer token: %s", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body) //nolint:errcheck
+		return nil, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, c.config.endpoint)
+	}
+
+	var families []*dto.MetricFamily
+	decoder := expfmt.NewDecoder(resp.Body, expfmt.ResponseFormat(resp.Header))
+	for {
+		family := &dto.MetricFamily{}
+		if err := decoder.Decode(family); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("can't parse the metrics of %s: %s", c.config.endpoint, err)
+		}
+		families = append(families, family)
+	}
+	return families, nil
+}
+
+// target returns the name and type of the metrics of a family, or nil if they are not collected
+func (c *Check) target(family *dto.MetricFamily) *metricTarget {
+	rawName := family.GetName()
+	if target, found := c.targets[rawName]; found {
+		return target
+	}
+
+	target := c.config.resolve(rawName, family.GetType())
+	if len(c.targets) >= maxCacheSize {
+		c.targets = make(map[string]*metricTarget)
+	}
+	c.targets[rawName] = target
+	return target
+}
+
+// resolve returns the name and type of the metrics of a raw name, or nil if they are not collected
+func (c *scrapeConfig) resolve(rawName string, mtype dto.MetricType) *metricTarget {
+	name := strings.TrimPrefix(rawName, c.rawPrefix)
+	if mtype == dto.MetricType_COUNTER {
+		name = strings.TrimSuffix(name, "_total")
+	}
+	if c.exclude != nil && c.exclude.MatchString(name) {
+		return nil
+	}
+
+	for _, matcher := range c.metrics {
+		if !matcher.pattern.MatchString(name) {
+			continue
+		}
+		target := &metricTarget{name: name, mtype: mtype}
+		if matcher.name != "" {
+			target.name = matcher.name
+		}
+		if override, found := c.typeOverrides[name]; found {
+			target.mtype = override
+		}
+		if matcher.mtype != nil {
+			target.mtype = *matcher.mtype
+		}
+		if c.namespace != "" {
+			target.name = c.namespace + "." + target.name
+		}
+		return target
+	}
+	return nil
+}
+
+func (c *Check) submitFamily(sender aggregator.Sender, family *dto.MetricFamily) {
+	target := c.target(family)
+	if target == nil {
+		return
+	}
+
+	for _, metric := range family.GetMetric() {
+		tags := c.config.tags(metric.GetLabel())
+		if c.config.legacy {
+			c.submitLegacyMetric(sender, target, metric, tags)
+			continue
+		}
+
+		switch target.mtype {
+		case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+			if value, ok := scalarValue(metric); ok {
+				sender.Gauge(target.name, value, "", tags)
+			}
+		case dto.MetricType_COUNTER:
+			if value, ok := scalarValue(metric); ok {
+				sender.MonotonicCount(target.name+".count", value, "", tags)
+			}
+		case dto.MetricType_SUMMARY:
+			summary := metric.GetSummary()
+			if summary == nil {
+				continue
+			}
+			sender.MonotonicCount(target.name+".sum", summary.GetSampleSum(), "", tags)
+			sender.MonotonicCount(target.name+".count", float64(summary.GetSampleCount()), "", tags)
+			for _, quantile := range summary.GetQuantile() {
+				sender.Gauge(target.name+".quantile", quantile.GetValue(), "", appendTag(tags, "quantile", quantile.GetQuantile()))
+			}
+		case dto.MetricType_HISTOGRAM:
+			histogram := metric.GetHistogram()
+			if histogram == nil {
+				continue
+			}
+			if c.config.bucketsAsDistributions {
+				submitDistributionBuckets(sender, target.name, histogram, tags)
+				continue
+			}
+			sender.MonotonicCount(target.name+".sum", histogram.GetSampleSum(), "", tags)
+			sender.MonotonicCount(target.name+".count", float64(histogram.GetSampleCount()), "", tags)
+			if c.config.collectHistogramBuckets {
+				for _, bucket := range histogram.GetBucket() {
+					sender.MonotonicCount(target.name+".bucket", float64(bucket.GetCumulativeCount()), "", appendTag(tags, "upper_bound", bucket.GetUpperBound()))
+				}
+			}
+		}
+	}
+}
+
+// submitLegacyMetric submits a metric named and typed like the version 1 of the Python check: the
+// counters keep their name, and the counts, sums and buckets of the histograms and summaries
+// are gauges unless configured otherwise.
+func (c *Check) submitLegacyMetric(sender aggregator.Sender, target *metricTarget, metric *dto.Metric, tags []string) {
+	distributionCount := sender.Gauge
+	if c.config.monotonicDistributionCounts {
+		distributionCount = sender.MonotonicCount
+	}
+	distributionSum := sender.Gauge
+	if c.config.monotonicDistributionSums {
+		distributionSum = sender.MonotonicCount
+	}
+
+	switch target.mtype {
+	case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+		if value, ok := scalarValue(metric); ok {
+			sender.Gauge(target.name, value, "", tags)
+		}
+	case dto.MetricType_COUNTER:
+		value, ok := scalarValue(metric)
+		if !ok {
+			return
+		}
+		if c.config.monotonicCounter {
+			sender.MonotonicCount(target.name, value, "", tags)
+		} else {
+			sender.Gauge(target.name, value, "", tags)
+		}
+	case dto.MetricType_SUMMARY:
+		summary := metric.GetSummary()
+		if summary == nil {
+			return
+		}
+		distributionSum(target.name+".sum", summary.GetSampleSum(), "", tags)
+		distributionCount(target.name+".count", float64(summary.GetSampleCount()), "", tags)
+		for _, quantile := range summary.GetQuantile() {
+			sender.Gauge(target.name+".quantile", quantile.GetValue(), "", appendTag(tags, "quantile", quantile.GetQuantile()))
+		}
+	case dto.MetricType_HISTOGRAM:
+		histogram := metric.GetHistogram()
+		if histogram == nil {
+			return
+		}
+		if c.config.bucketsAsDistributions {
+			submitDistributionBuckets(sender, target.name, histogram, tags)
+			return
+		}
+		distributionSum(target.name+".sum", histogram.GetSampleSum(), "", tags)
+		distributionCount(target.name+".count", float64(histogram.GetSampleCount()), "", tags)
+		if c.config.collectHistogramBuckets {
+			for _, bucket := range histogram.GetBucket() {
+				distributionCount(target.name+".count", float64(bucket.GetCumulativeCount()), "", appendTag(tags, "upper_bound", bucket.GetUpperBound()))
+			}
+		}
+	}
+}
+
+// submitDistributionBuckets submits the cumulative buckets of a histogram as the non-cumulative
+// buckets from which the aggregator builds a distribution.
+func submitDistributionBuckets(sender aggregator.Sender, name string, histogram *dto.Histogram, tags []string) {
+	var lowerBound float64
+	var previousCount uint64
+	for i, bucket := range histogram.GetBucket() {
+		upperBound := bucket.GetUpperBound()
+		if i == 0 && upperBound < 0 {
+			lowerBound = upperBound
+		}
+		count := bucket.GetCumulativeCount()
+		sender.HistogramBucket(name, int64(count-previousCount), lowerBound, upperBound, true, "", tags, false)
+		lowerBound, previousCount = upperBound, count
+	}
+}
+
+// submitHealth submits the service check of the availability of the endpoint
+func (c *Check) submitHealth(sender aggregator.Sender, status metrics.ServiceCheckStatus, message string) {
+	if !c.config.healthCheck {
+		return
+	}
+	name := "openmetrics.health"
+	if c.config.legacy {
+		name = "prometheus.health"
+	}
+	if c.config.namespace != "" {
+		name = c.config.namespace + "." + name
+	}
+	sender.ServiceCheck(name, status, "", []string{"endpoint:" + c.config.endpoint}, message)
+}
+
+// tags returns the tags of the labels of a metric
+func (c *scrapeConfig) tags(labels []*dto.LabelPair) []string {
+	tags := make([]string, 0, len(labels))
+	for _, label := range labels {
+		key, value := label.GetName(), label.GetValue()
+		if value == "" {
+			continue
+		}
+		if _, excluded := c.excludeLabels[key]; excluded {
+			continue
+		}
+		if renamed, found := c.renameLabels[key]; found {
+			key = renamed
+		}
+		tags = append(tags, key+":"+value)
+	}
+	return tags
+}
+
+// scalarValue returns the value of a gauge, counter or untyped metric
+func scalarValue(metric *dto.Metric) (float64, bool) {
+	switch {
+	case metric.Gauge != nil:
+		return metric.Gauge.GetValue(), true
+	case metric.Counter != nil:
+		return metric.Counter.GetValue(), true
+	case metric.Untyped != nil:
+		return metric.Untyped.GetValue(), true
+	}
+	return 0, false
+}
+
+// appendTag returns a copy of tags with the tag of a float value
+func appendTag(tags []string, key string, value float64) []string {
+	result := make([]string, len(tags), len(tags)+1)
+	copy(result, tags)
+	return append(result, key+":"+formatFloat(value))
+}
+
+// formatFloat formats the bound of a bucket or a quantile like the Python openmetrics check
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func init() {
+	core.RegisterCheck(checkName, newCheck)
+}
@@ -0,0 +1,225 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package openmetrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+const textPayload = `# HELP http_requests_total The number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="get",code="200"} 1027
+http_requests_total{method="post",code="500"} 3
+# TYPE queue_depth gauge
+queue_depth{queue="jobs"} 12
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.1"} 5
+request_duration_seconds_bucket{le="0.5"} 8
+request_duration_seconds_bucket{le="+Inf"} 10
+request_duration_seconds_sum 3.5
+request_duration_seconds_count 10
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 0.05
+rpc_duration_seconds{quantile="0.99"} 0.3
+rpc_duration_seconds_sum 17.2
+rpc_duration_seconds_count 200
+# TYPE go_goroutines gauge
+go_goroutines 42
+`
+
+func newTestCheck(t *testing.T, instance string) (*Check, *mocksender.MockSender) {
+	c := newCheck().(*Check)
+	c.BuildID(integration.Data(instance), nil)
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	require.NoError(t, c.Configure(integration.Data(instance), nil, "test"))
+	return c, sender
+}
+
+func textServer(t *testing.T, payload string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", string(expfmt.FmtText))
+		w.Write([]byte(payload)) //nolint:errcheck
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRunText(t *testing.T) {
+	server := textServer(t, textPayload)
+	c, sender := newTestCheck(t, `
+openmetrics_endpoint: `+server.URL+`
+namespace: app
+metrics:
+  - http_requests
+  - queue_depth: queue.depth
+  - request_duration_seconds
+  - rpc_duration_seconds
+rename_labels: {code: status_code}
+exclude_labels: [method]
+`)
+
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "MonotonicCount", "app.http_requests.count", 1027, "", []string{"status_code:200"})
+	sender.AssertMetric(t, "MonotonicCount", "app.http_requests.count", 3, "", []string{"status_code:500"})
+	sender.AssertMetric(t, "Gauge", "app.queue.depth", 12, "", []string{"queue:jobs"})
+	sender.AssertMetric(t, "MonotonicCount", "app.request_duration_seconds.sum", 3.5, "", []string{})
+	sender.AssertMetric(t, "MonotonicCount", "app.request_duration_seconds.count", 10, "", []string{})
+	sender.AssertMetric(t, "MonotonicCount", "app.request_duration_seconds.bucket", 5, "", []string{"upper_bound:0.1"})
+	sender.AssertMetric(t, "MonotonicCount", "app.request_duration_seconds.bucket", 10, "", []string{"upper_bound:inf"})
+	sender.AssertMetric(t, "MonotonicCount", "app.rpc_duration_seconds.count", 200, "", []string{})
+	sender.AssertMetric(t, "Gauge", "app.rpc_duration_seconds.quantile", 0.3, "", []string{"quantile:0.99"})
+	sender.AssertNumberOfCalls(t, "Gauge", 3)
+	sender.AssertNumberOfCalls(t, "MonotonicCount", 9)
+	sender.AssertServiceCheck(t, "app.openmetrics.health", metrics.ServiceCheckOK, "", []string{"endpoint:" + server.URL}, "")
+}
+
+func TestRunTextLegacy(t *testing.T) {
+	server := textServer(t, textPayload)
+	c, sender := newTestCheck(t, `
+prometheus_url: `+server.URL+`
+namespace: app
+metrics:
+  - http_requests
+  - queue_depth: queue.depth
+  - request_duration_seconds
+  - rpc_duration_seconds
+labels_mapper: {code: status_code}
+exclude_labels: [method]
+send_distribution_sums_as_monotonic: true
+`)
+
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "MonotonicCount", "app.http_requests", 1027, "", []string{"status_code:200"})
+	sender.AssertMetric(t, "MonotonicCount", "app.http_requests", 3, "", []string{"status_code:500"})
+	sender.AssertMetric(t, "Gauge", "app.queue.depth", 12, "", []string{"queue:jobs"})
+	sender.AssertMetric(t, "MonotonicCount", "app.request_duration_seconds.sum", 3.5, "", []string{})
+	sender.AssertMetric(t, "Gauge", "app.request_duration_seconds.count", 10, "", []string{})
+	sender.AssertMetric(t, "Gauge", "app.request_duration_seconds.count", 5, "", []string{"upper_bound:0.1"})
+	sender.AssertMetric(t, "Gauge", "app.request_duration_seconds.count", 10, "", []string{"upper_bound:inf"})
+	sender.AssertMetric(t, "MonotonicCount", "app.rpc_duration_seconds.sum", 17.2, "", []string{})
+	sender.AssertMetric(t, "Gauge", "app.rpc_duration_seconds.count", 200, "", []string{})
+	sender.AssertMetric(t, "Gauge", "app.rpc_duration_seconds.quantile", 0.3, "", []string{"quantile:0.99"})
+	sender.AssertNumberOfCalls(t, "Gauge", 8)
+	sender.AssertNumberOfCalls(t, "MonotonicCount", 4)
+	sender.AssertServiceCheck(t, "app.prometheus.health", metrics.ServiceCheckOK, "", []string{"endpoint:" + server.URL}, "")
+}
+
+func TestRunProtobuf(t *testing.T) {
+	families, err := (&expfmt.TextParser{}).TextToMetricFamilies(strings.NewReader(textPayload))
+	require.NoError(t, err)
+	var accept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		w.Header().Set("Content-Type", string(expfmt.FmtProtoDelim))
+		encoder := expfmt.NewEncoder(w, expfmt.FmtProtoDelim)
+		for _, family := range families {
+			require.NoError(t, encoder.Encode(family))
+		}
+	}))
+	defer server.Close()
+	c, sender := newTestCheck(t, `
+openmetrics_endpoint: `+server.URL+`
+metrics: [".*"]
+exclude_metrics: [go_.*]
+type_overrides: {queue_depth: counter}
+`)
+
+	require.NoError(t, c.Run())
+	assert.Equal(t, acceptHeader, accept)
+	sender.AssertMetric(t, "MonotonicCount", "http_requests.count", 1027, "", []string{"method:get", "code:200"})
+	sender.AssertMetric(t, "MonotonicCount", "queue_depth.count", 12, "", []string{"queue:jobs"})
+	sender.AssertNotCalled(t, "Gauge", "go_goroutines", mock.Anything, mock.Anything, mock.Anything)
+	sender.AssertServiceCheck(t, "openmetrics.health", metrics.ServiceCheckOK, "", []string{"endpoint:" + server.URL}, "")
+}
+
+func TestRunHistogramAsDistributions(t *testing.T) {
+	server := textServer(t, textPayload)
+	c, sender := newTestCheck(t, `
+openmetrics_endpoint: `+server.URL+`
+metrics: [request_duration_seconds]
+histogram_buckets_as_distributions: true
+`)
+
+	require.NoError(t, c.Run())
+	sender.AssertCalled(t, "HistogramBucket", "request_duration_seconds", int64(5), 0.0, 0.1, true, "", []string{}, false)
+	sender.AssertCalled(t, "HistogramBucket", "request_duration_seconds", int64(3), 0.1, 0.5, true, "", []string{}, false)
+	sender.AssertCalled(t, "HistogramBucket", "request_duration_seconds", int64(2), 0.5, math.Inf(1), true, "", []string{}, false)
+	sender.AssertNumberOfCalls(t, "HistogramBucket", 3)
+	sender.AssertNotCalled(t, "MonotonicCount", "request_duration_seconds.count", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRunAuth(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("secret\n"), 0600))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Scope") != "tenant" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("go_goroutines 42\n")) //nolint:errcheck
+	}))
+	defer server.Close()
+	c, sender := newTestCheck(t, `
+openmetrics_endpoint: `+server.URL+`
+metrics: [".*"]
+bearer_token_auth: true
+bearer_token_path: `+tokenPath+`
+headers: {X-Scope: tenant}
+`)
+
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "Gauge", "go_goroutines", 42, "", []string{})
+}
+
+func TestRunError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	c, sender := newTestCheck(t, `
+openmetrics_endpoint: `+server.URL+`
+namespace: app
+metrics: [".*"]
+`)
+
+	assert.Error(t, c.Run())
+	sender.AssertCalled(t, "ServiceCheck", "app.openmetrics.health", metrics.ServiceCheckCritical, "", []string{"endpoint:" + server.URL}, mocksender.AnythingBut(""))
+	sender.AssertNotCalled(t, "Gauge", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTargetCache(t *testing.T) {
+	c, _ := newTestCheck(t, `
+openmetrics_endpoint: http://localhost:9090/metrics
+metrics: [foo]
+`)
+
+	name, mtype := "foo", dto.MetricType_GAUGE
+	family := &dto.MetricFamily{Name: &name, Type: &mtype}
+	assert.Equal(t, &metricTarget{name: "foo", mtype: dto.MetricType_GAUGE}, c.target(family))
+	assert.Contains(t, c.targets, "foo")
+
+	name = "bar"
+	assert.Nil(t, c.target(&dto.MetricFamily{Name: &name, Type: &mtype}))
+	// the metrics which are not collected are cached as well
+	assert.Contains(t, c.targets, "bar")
+}
@@ -44,6 +44,8 @@ type PythonCheck struct {
 	class        *C.rtloader_pyobject_t
 	ModuleName   string
 	interval     time.Duration
+	maxRunTime   time.Duration
+	priority     check.Priority
 	lastWarnings []error
 	source       string
 	telemetry    bool // whether or not the telemetry is enabled for this check
@@ -138,6 +140,20 @@ func (c *PythonCheck) Cancel() {
 	aggregator.DestroySender(c.id)
 }
 
+// Interrupt raises an exception in the thread running the current run of the check, if any,
+// to stop the runs exceeding their max run time. The exception is raised the next time the
+// thread executes Python code.
+func (c *PythonCheck) Interrupt() {
+	gstate, err := newStickyLock()
+	if err != nil {
+		log.Warnf("failed to interrupt check %s: %s", c.id, err)
+		return
+	}
+	defer gstate.unlock()
+
+	C.interrupt_check(rtloader, c.instance)
+}
+
 // String representation (for debug and logging)
 func (c *PythonCheck) String() string {
 	return c.ModuleName
@@ -227,6 +243,18 @@ func (c *PythonCheck) Configure(data integration.Data, initConfig integration.Da
 		c.interval = time.Duration(commonOptions.MinCollectionInterval) * time.Second
 	}
 
+	// See if a max run time was specified
+	if commonOptions.MaxRunTime > 0 {
+		c.maxRunTime = time.Duration(commonOptions.MaxRunTime) * time.Second
+	}
+
+	priority, err := check.ParsePriority(commonOptions.Priority)
+	if err != nil {
+		log.Errorf("invalid instance section for check %s: %s", string(c.id), err)
+		return err
+	}
+	c.priority = priority
+
 	// Disable default hostname if specified
 	if commonOptions.EmptyDefaultHostname {
 		s, err := aggregator.GetSender(c.id)
@@ -311,6 +339,16 @@ func (c *PythonCheck) Interval() time.Duration {
 	return c.interval
 }
 
+// MaxRunTime returns the max run time configured for the check instance, 0 if not configured
+func (c *PythonCheck) MaxRunTime() time.Duration {
+	return c.maxRunTime
+}
+
+// Priority returns the priority class configured for the check instance
+func (c *PythonCheck) Priority() check.Priority {
+	return c.priority
+}
+
 // ID returns the ID of the check
 func (c *PythonCheck) ID() check.ID {
 	return c.id
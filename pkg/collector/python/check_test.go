@@ -36,6 +36,10 @@ func TestCheckCancelWhenRuntimeUnloaded(t *testing.T) {
 	testCheckCancelWhenRuntimeUnloaded(t)
 }
 
+func TestCheckInterrupt(t *testing.T) {
+	testCheckInterrupt(t)
+}
+
 func TestFinalizer(t *testing.T) {
 	testFinalizer(t)
 }
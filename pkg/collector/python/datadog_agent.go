@@ -233,6 +233,8 @@ type sqlConfig struct {
 	CollectComments bool `json:"collect_comments"`
 	// ReplaceDigits specifies whether digits in table names and identifiers should be obfuscated.
 	ReplaceDigits bool `json:"replace_digits"`
+	// CollapseLists specifies whether the contents of IN lists and VALUES tuples should be collapsed into a single placeholder.
+	CollapseLists bool `json:"collapse_lists"`
 	// ReturnJSONMetadata specifies whether the stub will return metadata as JSON.
 	ReturnJSONMetadata bool `json:"return_json_metadata"`
 }
@@ -258,6 +260,7 @@ func ObfuscateSQL(rawQuery, opts *C.char, errResult **C.char) *C.char {
 		CollectCommands: sqlOpts.CollectCommands,
 		CollectComments: sqlOpts.CollectComments,
 		ReplaceDigits:   sqlOpts.ReplaceDigits,
+		CollapseLists:   sqlOpts.CollapseLists,
 	})
 	if err != nil {
 		// memory will be freed by caller
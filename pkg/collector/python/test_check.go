@@ -93,6 +93,14 @@ void cancel_check(rtloader_t *s, rtloader_pyobject_t *check) {
 	return;
 }
 
+int interrupt_check_calls = 0;
+rtloader_pyobject_t *interrupt_check_instance = NULL;
+void interrupt_check(rtloader_t *s, rtloader_pyobject_t *check) {
+	interrupt_check_instance = check;
+	interrupt_check_calls++;
+	return;
+}
+
 //
 // get_check MOCK
 //
@@ -173,6 +181,8 @@ void reset_check_mock() {
 	get_check_check = NULL;
 	cancel_check_calls = 0;
 	cancel_check_instance = NULL;
+	interrupt_check_calls = 0;
+	interrupt_check_instance = NULL;
 
 	get_check_deprecated_calls = 0;
 	get_check_deprecated_return = 0;
@@ -337,6 +347,30 @@ func testCheckCancelWhenRuntimeUnloaded(t *testing.T) {
 	assert.Equal(t, C.int(0), C.cancel_check_calls)
 }
 
+func testCheckInterrupt(t *testing.T) {
+	rtloader = newMockRtLoaderPtr()
+	defer func() { rtloader = nil }()
+
+	check, err := NewPythonFakeCheck()
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	C.reset_check_mock()
+	check.instance = newMockPyObjectPtr()
+
+	check.Interrupt()
+
+	// Check that the lock was acquired
+	assert.Equal(t, C.int(1), C.gil_locked_calls)
+	assert.Equal(t, C.int(1), C.gil_unlocked_calls)
+
+	// Check that the call was passed to C, without cancelling the check
+	assert.Equal(t, C.int(1), C.interrupt_check_calls)
+	assert.Equal(t, check.instance, C.interrupt_check_instance)
+	assert.Equal(t, C.int(0), C.cancel_check_calls)
+}
+
 func testFinalizer(t *testing.T) {
 	rtloader = newMockRtLoaderPtr()
 	defer func() {
@@ -6,6 +6,7 @@
 package expvars
 
 import (
+	"encoding/json"
 	"expvar"
 	"sync"
 	"time"
@@ -21,6 +22,7 @@ const (
 	// Nested keys
 	checksExpvarKey        = "Checks"
 	errorsExpvarKey        = "Errors"
+	quarantinedExpvarKey   = "Quarantined"
 	runningChecksExpvarKey = "RunningChecks"
 	runsExpvarKey          = "Runs"
 	runningExpvarKey       = "Running"
@@ -28,9 +30,10 @@ const (
 )
 
 var (
-	runnerStats        *expvar.Map
-	runningChecksStats *expvar.Map
-	checkStats         *expCheckStats
+	runnerStats            *expvar.Map
+	runningChecksStats     *expvar.Map
+	quarantinedChecksStats *expvar.Map
+	checkStats             *expCheckStats
 )
 
 // expCheckStats holds the stats from the running checks
@@ -41,10 +44,12 @@ type expCheckStats struct {
 
 func init() {
 	runningChecksStats = &expvar.Map{}
+	quarantinedChecksStats = &expvar.Map{}
 
 	runnerStats = expvar.NewMap(runnerExpvarKey)
 	runnerStats.Set(checksExpvarKey, expvar.Func(expCheckStatsFunc))
 	runnerStats.Set(runningExpvarKey, runningChecksStats)
+	runnerStats.Set(quarantinedExpvarKey, quarantinedChecksStats)
 
 	newWorkersExpvar(runnerStats)
 
@@ -71,8 +76,9 @@ func Reset() {
 		delete(checkStats.stats, key)
 	}
 
-	// Clear running checks map
+	// Clear running and quarantined checks maps
 	runningChecksStats.Init()
+	quarantinedChecksStats.Init()
 
 	// Clear top-level expvars on the runner
 	for _, key := range []string{
@@ -149,6 +155,8 @@ func RemoveCheckStats(checkID check.ID) {
 
 	log.Debugf("Removing stats for %s", string(checkID))
 
+	quarantinedChecksStats.Delete(string(checkID))
+
 	checkName := check.IDToCheckName(checkID)
 	stats, found := checkStats.stats[checkName]
 
@@ -164,6 +172,21 @@ func RemoveCheckStats(checkID check.ID) {
 	}
 }
 
+// SetCheckResources sets the resources used by the last run of a check in its stats
+func SetCheckResources(id check.ID, resources *check.ResourceStats) {
+	if stats, found := CheckStats(id); found {
+		stats.SetLastResources(resources)
+	}
+}
+
+// SetCheckLateness records the delay of the start of a check run after its scheduled time
+// in its stats
+func SetCheckLateness(id check.ID, lateness time.Duration) {
+	if stats, found := CheckStats(id); found {
+		stats.SetLateness(lateness)
+	}
+}
+
 // CheckStats returns the check stats of a check, if they can be found
 func CheckStats(id check.ID) (*check.Stats, bool) {
 	checkStats.statsLock.RLock()
@@ -206,6 +229,40 @@ func DeleteRunningStats(id check.ID) {
 	runningChecksStats.Delete(string(id))
 }
 
+// Functions relating to quarantined checks state map (`quarantinedChecksStats`)
+
+// quarantine is the expvar of the quarantine of a check
+type quarantine check.QuarantineStats
+
+func (q quarantine) String() string {
+	formatted, _ := json.Marshal(check.QuarantineStats(q))
+	return string(formatted)
+}
+
+// SetQuarantineStats sets the quarantine of a check, or clears it if nil, in the
+// quarantined checks map and in the stats of the check
+func SetQuarantineStats(id check.ID, q *check.QuarantineStats) {
+	if q == nil {
+		quarantinedChecksStats.Delete(string(id))
+	} else {
+		quarantinedChecksStats.Set(string(id), quarantine(*q))
+	}
+
+	if stats, found := CheckStats(id); found {
+		stats.SetQuarantine(q)
+	}
+}
+
+// GetQuarantineStats gets the quarantine of a check, nil if it isn't quarantined
+func GetQuarantineStats(id check.ID) *check.QuarantineStats {
+	quarantineExpvar := quarantinedChecksStats.Get(string(id))
+	if quarantineExpvar == nil {
+		return nil
+	}
+	q := check.QuarantineStats(quarantineExpvar.(quarantine))
+	return &q
+}
+
 // AddRunningCheckCount is used to increment and decrement the 'RunningChecks' expvar
 func AddRunningCheckCount(amount int) {
 	runnerStats.Add(runningChecksExpvarKey, int64(amount))
@@ -41,6 +41,15 @@ func getRunningChecksExpvarMap(t require.TestingT) *expvar.Map {
 	return runningChecksExpvar.(*expvar.Map)
 }
 
+func getQuarantinedChecksExpvarMap(t require.TestingT) *expvar.Map {
+	runnerMap := getRunnerExpvarMap(t)
+
+	quarantinedChecksExpvar := runnerMap.Get("Quarantined")
+	require.NotNil(t, quarantinedChecksExpvar)
+
+	return quarantinedChecksExpvar.(*expvar.Map)
+}
+
 func getCheckStatsExpvarMap(t require.TestingT) map[string]map[check.ID]*check.Stats {
 	runnerMap := getRunnerExpvarMap(t)
 
@@ -371,6 +380,78 @@ func TestExpvarsRunningStats(t *testing.T) {
 	}
 }
 
+func TestExpvarsQuarantineStats(t *testing.T) {
+	setUp()
+
+	quarantinedChecksMap := getQuarantinedChecksExpvarMap(t)
+	assert.Equal(t, 0, len(getExpvarMapKeys(quarantinedChecksMap)))
+
+	testCheck := newTestCheck("mycheck:123")
+	AddCheckStats(testCheck, time.Second, nil, nil, check.SenderStats{})
+
+	quarantine := &check.QuarantineStats{Reason: "the run panicked: boom", Since: 1234567890, Until: 1234567950, Count: 1}
+	SetQuarantineStats(testCheck.ID(), quarantine)
+
+	assert.Equal(t, quarantine, GetQuarantineStats(testCheck.ID()))
+	assert.JSONEq(
+		t,
+		`{"Reason": "the run panicked: boom", "Since": 1234567890, "Until": 1234567950, "Count": 1}`,
+		quarantinedChecksMap.Get("mycheck:123").String(),
+	)
+
+	stats, found := CheckStats(testCheck.ID())
+	require.True(t, found)
+	assert.Equal(t, quarantine, stats.Quarantine)
+
+	SetQuarantineStats(testCheck.ID(), nil)
+	assert.Nil(t, GetQuarantineStats(testCheck.ID()))
+	assert.Nil(t, stats.Quarantine)
+	assert.Equal(t, 0, len(getExpvarMapKeys(quarantinedChecksMap)))
+
+	// The quarantine is removed with the stats of the check
+	SetQuarantineStats(testCheck.ID(), quarantine)
+	RemoveCheckStats(testCheck.ID())
+	assert.Nil(t, GetQuarantineStats(testCheck.ID()))
+
+	// The quarantined checks are cleared on reset
+	SetQuarantineStats(testCheck.ID(), quarantine)
+	Reset()
+	assert.Equal(t, 0, len(getExpvarMapKeys(quarantinedChecksMap)))
+}
+
+func TestExpvarsCheckResources(t *testing.T) {
+	setUp()
+
+	testCheck := newTestCheck("mycheck:123")
+
+	// Noop if the check has no stats
+	SetCheckResources(testCheck.ID(), &check.ResourceStats{CPUTime: 10})
+
+	AddCheckStats(testCheck, time.Second, nil, nil, check.SenderStats{})
+	SetCheckResources(testCheck.ID(), &check.ResourceStats{CPUTime: 10})
+
+	stats, found := CheckStats(testCheck.ID())
+	require.True(t, found)
+	assert.Equal(t, &check.ResourceStats{CPUTime: 10}, stats.LastResources)
+}
+
+func TestExpvarsCheckLateness(t *testing.T) {
+	setUp()
+
+	testCheck := newTestCheck("mycheck:123")
+
+	// Noop if the check has no stats
+	SetCheckLateness(testCheck.ID(), time.Second)
+
+	AddCheckStats(testCheck, time.Second, nil, nil, check.SenderStats{})
+	SetCheckLateness(testCheck.ID(), 2*time.Second)
+
+	stats, found := CheckStats(testCheck.ID())
+	require.True(t, found)
+	assert.Equal(t, int64(2000), stats.LastLateness)
+	assert.Equal(t, int64(2000), stats.MaxLateness)
+}
+
 func TestExpvarsToplevelKeys(t *testing.T) {
 	setUp()
 
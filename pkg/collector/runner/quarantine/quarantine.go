@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package quarantine
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// Policy is an object that keeps a thread-safe track of the checks whose runs
+// repeatedly time out or panic, and quarantines them: their runs are skipped
+// for a backoff duration, which doubles every time a check is quarantined again
+// until one of its runs completes.
+type Policy struct {
+	threshold  int                      // Number of consecutive failed runs that quarantine a check, 0 to disable the policy
+	backoff    time.Duration            // Duration of the first quarantine of a check
+	maxBackoff time.Duration            // Maximum duration of a quarantine
+	checks     map[check.ID]*checkState // The state of the checks whose last run failed
+	accessLock sync.Mutex               // To control races on checks
+	now        func() time.Time
+}
+
+// checkState is the state of a check whose last run failed
+type checkState struct {
+	failures   int // Number of consecutive failed runs
+	quarantine *check.QuarantineStats
+	until      time.Time
+}
+
+// NewPolicy is a constructor for a Policy
+func NewPolicy(threshold int, backoff, maxBackoff time.Duration) *Policy {
+	if maxBackoff < backoff {
+		maxBackoff = backoff
+	}
+
+	return &Policy{
+		threshold:  threshold,
+		backoff:    backoff,
+		maxBackoff: maxBackoff,
+		checks:     make(map[check.ID]*checkState),
+		now:        time.Now,
+	}
+}
+
+// NewPolicyFromConfig returns a Policy configured with the `check_quarantine_*` options
+func NewPolicyFromConfig() *Policy {
+	return NewPolicy(
+		config.Datadog.GetInt("check_quarantine_threshold"),
+		config.Datadog.GetDuration("check_quarantine_backoff")*time.Second,
+		config.Datadog.GetDuration("check_quarantine_max_backoff")*time.Second,
+	)
+}
+
+// IsQuarantined returns true if the runs of the check must be skipped
+func (p *Policy) IsQuarantined(id check.ID) bool {
+	p.accessLock.Lock()
+	defer p.accessLock.Unlock()
+
+	state, found := p.checks[id]
+	return found && state.quarantine != nil && p.now().Before(state.until)
+}
+
+// Quarantine returns the current quarantine of the check, nil if it isn't quarantined
+func (p *Policy) Quarantine(id check.ID) *check.QuarantineStats {
+	p.accessLock.Lock()
+	defer p.accessLock.Unlock()
+
+	state, found := p.checks[id]
+	if !found || state.quarantine == nil {
+		return nil
+	}
+
+	quarantine := *state.quarantine
+	return &quarantine
+}
+
+// RecordFailure records a run of the check that timed out or panicked, and
+// quarantines the check if its runs failed `threshold` times in a row. Once
+// released, a check is quarantined again as soon as one of its runs fails. It
+// returns the new quarantine of the check, if any.
+func (p *Policy) RecordFailure(id check.ID, reason string) *check.QuarantineStats {
+	if p.threshold <= 0 {
+		return nil
+	}
+
+	p.accessLock.Lock()
+	defer p.accessLock.Unlock()
+
+	state, found := p.checks[id]
+	if !found {
+		state = &checkState{}
+		p.checks[id] = state
+	}
+
+	state.failures++
+	if state.failures < p.threshold {
+		return nil
+	}
+
+	count := 1
+	if state.quarantine != nil {
+		count = state.quarantine.Count + 1
+	}
+
+	backoff := p.backoff
+	for i := 1; i < count && backoff < p.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.maxBackoff {
+		backoff = p.maxBackoff
+	}
+
+	now := p.now()
+	state.until = now.Add(backoff)
+	state.quarantine = &check.QuarantineStats{
+		Reason: reason,
+		Since:  now.Unix(),
+		Until:  state.until.Unix(),
+		Count:  count,
+	}
+
+	quarantine := *state.quarantine
+	return &quarantine
+}
+
+// RecordSuccess records a run of the check that completed, successfully or
+// not, and releases the check from its quarantine.
+func (p *Policy) RecordSuccess(id check.ID) {
+	p.Remove(id)
+}
+
+// Remove forgets the state of the check, when it is unscheduled
+func (p *Policy) Remove(id check.ID) {
+	p.accessLock.Lock()
+	defer p.accessLock.Unlock()
+
+	delete(p.checks, id)
+}
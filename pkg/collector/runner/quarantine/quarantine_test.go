@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package quarantine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+func newTestPolicy(threshold int) (*Policy, *time.Time) {
+	now := time.Unix(1600000000, 0)
+	policy := NewPolicy(threshold, time.Minute, 5*time.Minute)
+	policy.now = func() time.Time { return now }
+	return policy, &now
+}
+
+func TestPolicyThreshold(t *testing.T) {
+	policy, now := newTestPolicy(3)
+	id := check.ID("mycheck")
+
+	assert.Nil(t, policy.RecordFailure(id, "timeout"))
+	assert.Nil(t, policy.RecordFailure(id, "timeout"))
+	assert.False(t, policy.IsQuarantined(id))
+	assert.Nil(t, policy.Quarantine(id))
+
+	quarantine := policy.RecordFailure(id, "panic")
+	require.NotNil(t, quarantine)
+	assert.Equal(t, &check.QuarantineStats{
+		Reason: "panic",
+		Since:  now.Unix(),
+		Until:  now.Add(time.Minute).Unix(),
+		Count:  1,
+	}, quarantine)
+	assert.True(t, policy.IsQuarantined(id))
+	assert.Equal(t, quarantine, policy.Quarantine(id))
+	assert.False(t, policy.IsQuarantined("othercheck"))
+
+	*now = now.Add(time.Minute)
+	assert.False(t, policy.IsQuarantined(id))
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	policy, now := newTestPolicy(2)
+	id := check.ID("mycheck")
+
+	policy.RecordFailure(id, "timeout")
+	for _, expected := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		// A released check is quarantined again as soon as a run fails
+		quarantine := policy.RecordFailure(id, "timeout")
+		require.NotNil(t, quarantine)
+		assert.Equal(t, now.Add(expected).Unix(), quarantine.Until)
+		*now = now.Add(expected)
+	}
+	assert.Equal(t, 5, policy.Quarantine(id).Count)
+}
+
+func TestPolicyRecordSuccess(t *testing.T) {
+	policy, _ := newTestPolicy(1)
+	id := check.ID("mycheck")
+
+	require.NotNil(t, policy.RecordFailure(id, "timeout"))
+	policy.RecordSuccess(id)
+	assert.False(t, policy.IsQuarantined(id))
+	assert.Nil(t, policy.Quarantine(id))
+
+	quarantine := policy.RecordFailure(id, "timeout")
+	require.NotNil(t, quarantine)
+	assert.Equal(t, 1, quarantine.Count)
+
+	policy.Remove(id)
+	assert.False(t, policy.IsQuarantined(id))
+}
+
+func TestPolicyDisabled(t *testing.T) {
+	policy, _ := newTestPolicy(0)
+	id := check.ID("mycheck")
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, policy.RecordFailure(id, "timeout"))
+	}
+	assert.False(t, policy.IsQuarantined(id))
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/runner/quarantine"
 	"github.com/DataDog/datadog-agent/pkg/collector/runner/tracker"
 	"github.com/DataDog/datadog-agent/pkg/collector/scheduler"
 	"github.com/DataDog/datadog-agent/pkg/collector/worker"
@@ -45,7 +46,11 @@ type Runner struct {
 	workersLock         sync.Mutex                    // Lock to prevent concurrent worker changes
 	isStaticWorkerCount bool                          // Flag indicating if numWorkers is dynamically updated
 	pendingChecksChan   chan check.Check              // The channel where checks come from
+	priorityChecksChan  chan check.Check              // The channel where high-priority checks come from
+	highPriorityWorkers int                           // Number of workers reserved for the high-priority checks
+	reservedWorkers     map[int]struct{}              // IDs of the workers reserved for the high-priority checks
 	checksTracker       *tracker.RunningChecksTracker // Tracker in charge of maintaining the running check list
+	quarantinePolicy    *quarantine.Policy            // Policy in charge of quarantining the checks that repeatedly time out or panic
 	scheduler           *scheduler.Scheduler          // Scheduler runner operates on
 	schedulerLock       sync.RWMutex                  // Lock around operations on the scheduler
 }
@@ -60,13 +65,26 @@ func NewRunner() *Runner {
 		workers:             make(map[int]*worker.Worker),
 		isStaticWorkerCount: numWorkers != 0,
 		pendingChecksChan:   make(chan check.Check),
+		priorityChecksChan:  make(chan check.Check),
+		reservedWorkers:     make(map[int]struct{}),
 		checksTracker:       tracker.NewRunningChecksTracker(),
+		quarantinePolicy:    quarantine.NewPolicyFromConfig(),
 	}
 
 	if !r.isStaticWorkerCount {
 		numWorkers = config.DefaultNumWorkers
 	}
 
+	// At least one worker has to process the checks that aren't high-priority
+	r.highPriorityWorkers = config.Datadog.GetInt("check_runners_high_priority")
+	if r.highPriorityWorkers < 0 {
+		r.highPriorityWorkers = 0
+	} else if r.highPriorityWorkers >= numWorkers {
+		log.Warnf("check_runners_high_priority (%d) must be lower than the number of check runners (%d), reserving %d of them",
+			r.highPriorityWorkers, numWorkers, numWorkers-1)
+		r.highPriorityWorkers = numWorkers - 1
+	}
+
 	r.ensureMinWorkers(numWorkers)
 
 	return r
@@ -86,9 +104,13 @@ func (r *Runner) ensureMinWorkers(desiredNumWorkers int) {
 
 	workersToAdd := desiredNumWorkers - currentWorkers
 	for idx := 0; idx < workersToAdd; idx++ {
-		worker, err := r.newWorker()
+		reserved := len(r.reservedWorkers) < r.highPriorityWorkers
+		worker, err := r.newWorker(reserved)
 		if err == nil {
 			r.workers[worker.ID] = worker
+			if reserved {
+				r.reservedWorkers[worker.ID] = struct{}{}
+			}
 		}
 	}
 
@@ -105,19 +127,28 @@ func (r *Runner) AddWorker() {
 	r.workersLock.Lock()
 	defer r.workersLock.Unlock()
 
-	worker, err := r.newWorker()
+	worker, err := r.newWorker(false)
 	if err == nil {
 		r.workers[worker.ID] = worker
 	}
 }
 
-// addWorker adds a new worker running in a separate goroutine
-func (r *Runner) newWorker() (*worker.Worker, error) {
+// addWorker adds a new worker running in a separate goroutine. A reserved worker
+// only processes the high-priority checks, the others process all the checks and
+// prefer the high-priority ones.
+func (r *Runner) newWorker(reserved bool) (*worker.Worker, error) {
+	pendingChecksChan, priorityChecksChan := r.pendingChecksChan, r.priorityChecksChan
+	if reserved {
+		pendingChecksChan, priorityChecksChan = r.priorityChecksChan, nil
+	}
+
 	worker, err := worker.NewWorker(
 		r.id,
 		int(atomic.AddUint64(&workerIDGenerator, 1)),
-		r.pendingChecksChan,
+		pendingChecksChan,
+		priorityChecksChan,
 		r.checksTracker,
+		r.quarantinePolicy,
 		r.ShouldAddCheckStats,
 	)
 	if err != nil {
@@ -139,6 +170,7 @@ func (r *Runner) removeWorker(id int) {
 	defer r.workersLock.Unlock()
 
 	delete(r.workers, id)
+	delete(r.reservedWorkers, id)
 }
 
 // UpdateNumWorkers checks if the current number of workers is reasonable,
@@ -167,8 +199,8 @@ func (r *Runner) UpdateNumWorkers(numChecks int64) {
 	r.ensureMinWorkers(desiredNumWorkers)
 }
 
-// Stop closes the pending channel so all workers will exit their loop and terminate
-// All publishers to the pending channel need to have stopped before Stop is called
+// Stop closes the pending channels so all workers will exit their loop and terminate
+// All publishers to the pending channels need to have stopped before Stop is called
 func (r *Runner) Stop() {
 	if !atomic.CompareAndSwapUint32(&r.isRunning, 1, 0) {
 		log.Debugf("Runner %d already stopped, nothing to do here...", r.id)
@@ -177,6 +209,7 @@ func (r *Runner) Stop() {
 
 	log.Infof("Runner %d is shutting down...", r.id)
 	close(r.pendingChecksChan)
+	close(r.priorityChecksChan)
 
 	wg := sync.WaitGroup{}
 
@@ -224,6 +257,11 @@ func (r *Runner) GetChan() chan<- check.Check {
 	return r.pendingChecksChan
 }
 
+// GetPriorityChan returns a write-only version of the high-priority pending channel
+func (r *Runner) GetPriorityChan() chan<- check.Check {
+	return r.priorityChecksChan
+}
+
 // SetScheduler sets the scheduler for the runner
 func (r *Runner) SetScheduler(s *scheduler.Scheduler) {
 	r.schedulerLock.Lock()
@@ -253,8 +291,10 @@ func (r *Runner) ShouldAddCheckStats(id check.ID) bool {
 }
 
 // StopCheck invokes the `Stop` method on a check if it's running. If the check
-// is not running, this is a noop
+// is not running, this is a noop. The quarantine of the check is forgotten.
 func (r *Runner) StopCheck(id check.ID) error {
+	r.quarantinePolicy.Remove(id)
+
 	done := make(chan bool)
 
 	stopFunc := func(c check.Check) {
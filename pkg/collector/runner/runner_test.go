@@ -449,3 +449,51 @@ func TestRunnerShouldAddCheckStats(t *testing.T) {
 	// If there's a scheduler with scheduled check, add the stats
 	require.True(t, r.ShouldAddCheckStats(testCheck.ID()))
 }
+
+func TestRunnerHighPriorityWorkers(t *testing.T) {
+	testSetUp(t)
+	config.Datadog.Set("check_runners", "3")
+	config.Datadog.Set("check_runners_high_priority", 2)
+	defer config.Datadog.Set("check_runners_high_priority", 0)
+
+	r := NewRunner()
+	require.NotNil(t, r)
+	defer r.Stop()
+
+	assertAsyncWorkerCount(t, 3)
+	assert.Len(t, r.reservedWorkers, 2)
+
+	// Keep the only general worker busy
+	blockedCheck := newCheck(t, "blockedcheck:123", false, nil)
+	blockedCheck.RunLock.Lock()
+	defer blockedCheck.RunLock.Unlock()
+	r.GetChan() <- blockedCheck
+	<-blockedCheck.StartedChan()
+
+	// The high-priority checks still run on the reserved workers
+	for idx := 0; idx < 4; idx++ {
+		priorityCheck := newCheck(t, fmt.Sprintf("prioritycheck_%d:123", idx), false, nil)
+		r.GetPriorityChan() <- priorityCheck
+		<-priorityCheck.StartedChan()
+	}
+}
+
+func TestRunnerHighPriorityWorkersLimit(t *testing.T) {
+	testSetUp(t)
+	config.Datadog.Set("check_runners", "2")
+	config.Datadog.Set("check_runners_high_priority", 5)
+	defer config.Datadog.Set("check_runners_high_priority", 0)
+
+	r := NewRunner()
+	require.NotNil(t, r)
+	defer r.Stop()
+
+	// At least one worker is left for the other checks
+	assertAsyncWorkerCount(t, 2)
+	assert.Len(t, r.reservedWorkers, 1)
+
+	// Added workers aren't reserved
+	r.AddWorker()
+	assertAsyncWorkerCount(t, 3)
+	assert.Len(t, r.reservedWorkers, 1)
+}
@@ -7,10 +7,13 @@ package scheduler
 
 import (
 	"fmt"
+	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/runner/expvars"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -58,8 +61,10 @@ func (jb *jobBucket) removeJob(id check.ID) bool {
 // scheduled at a certain interval.
 type jobQueue struct {
 	interval            time.Duration
-	stop                chan bool // to stop this queue
-	stopped             chan bool // signals that this queue has stopped
+	jitter              time.Duration              // maximum random delay of the start of the jobs
+	offsets             map[check.ID]time.Duration // delay of the jobs after the tick of their bucket
+	stop                chan bool                  // to stop this queue
+	stopped             chan bool                  // signals that this queue has stopped
 	buckets             []*jobBucket
 	bucketTicker        *time.Ticker
 	lastTick            time.Time
@@ -71,10 +76,17 @@ type jobQueue struct {
 	mu                  sync.RWMutex // to protect critical sections in struct's fields
 }
 
-// newJobQueue creates a new jobQueue instance
-func newJobQueue(interval time.Duration) *jobQueue {
+// newJobQueue creates a new jobQueue instance, the start of its jobs is delayed by up
+// to `jitter`, capped at `interval`
+func newJobQueue(interval time.Duration, jitter time.Duration) *jobQueue {
+	if jitter > interval {
+		jitter = interval
+	}
+
 	jq := &jobQueue{
 		interval:     interval,
+		jitter:       jitter,
+		offsets:      make(map[check.ID]time.Duration),
 		stop:         make(chan bool),
 		stopped:      make(chan bool),
 		health:       health.RegisterLiveness(fmt.Sprintf("collector-queue-%vs", interval.Seconds())),
@@ -118,17 +130,33 @@ func (jq *jobQueue) addJob(c check.Check) {
 	jq.mu.Lock()
 	defer jq.mu.Unlock()
 
-	// Checks scheduled to buckets scheduled with sparse round-robin
-	jq.buckets[jq.schedulingBucketIdx].addJob(c)
+	// Checks scheduled to buckets scheduled with sparse round-robin, the whole
+	// seconds of their start jitter shift them to a later bucket
+	bucketIdx := jq.schedulingBucketIdx
+	if jitter := jq.startJitter(c); jitter > 0 {
+		bucketIdx = (bucketIdx + uint(jitter/time.Second)) % uint(len(jq.buckets))
+		jq.offsets[c.ID()] = jitter % time.Second
+	}
+	jq.buckets[bucketIdx].addJob(c)
 	jq.schedulingBucketIdx = (jq.schedulingBucketIdx + jq.sparseStep) % uint(len(jq.buckets))
 }
 
+// startJitter picks the random delay of the start of a check, the high-priority
+// checks aren't delayed
+func (jq *jobQueue) startJitter(c check.Check) time.Duration {
+	if jq.jitter <= 0 || check.GetPriority(c) == check.PriorityHigh {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(jq.jitter)))
+}
+
 func (jq *jobQueue) removeJob(id check.ID) error {
 	jq.mu.Lock()
 	defer jq.mu.Unlock()
 
 	for _, bucket := range jq.buckets {
 		if found := bucket.removeJob(id); found {
+			delete(jq.offsets, id)
 			return nil
 		}
 	}
@@ -195,19 +223,43 @@ func (jq *jobQueue) process(s *Scheduler) bool {
 
 		log.Tracef("Jobs in bucket: %v", jobs)
 
+		jq.mu.RLock()
+		offsets := make(map[check.ID]time.Duration, len(jobs))
+		for _, c := range jobs {
+			offsets[c.ID()] = jq.offsets[c.ID()]
+		}
+		jq.mu.RUnlock()
+
+		sortJobs(jobs, offsets)
+
 		for _, check := range jobs {
 			if !s.IsCheckScheduled(check.ID()) {
 				continue
 			}
 
+			// wait for the start jitter of the check
+			scheduledTime := t.Add(offsets[check.ID()])
+			if wait := time.Until(scheduledTime); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-jq.stop:
+					timer.Stop()
+					jq.health.Deregister() //nolint:errcheck
+					return false
+				}
+			}
+
 			select {
 			// blocking, we'll be here as long as it takes
-			case s.checksPipe <- check:
+			case s.pipeFor(check) <- check:
 			case <-jq.stop:
 				jq.health.Deregister() //nolint:errcheck
 				return false
 			}
 
+			expvars.SetCheckLateness(check.ID(), time.Since(scheduledTime))
+
 			select {
 			// we were able to schedule a check so we're not stuck, therefore poll the health chan
 			case <-jq.health.C:
@@ -223,3 +275,15 @@ func (jq *jobQueue) process(s *Scheduler) bool {
 
 	return true
 }
+
+// sortJobs sorts the jobs of a bucket by decreasing priority, then by increasing
+// start jitter, keeping the order of the jobs otherwise
+func sortJobs(jobs []check.Check, offsets map[check.ID]time.Duration) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		iPriority, jPriority := check.GetPriority(jobs[i]), check.GetPriority(jobs[j])
+		if iPriority != jPriority {
+			return iPriority > jPriority
+		}
+		return offsets[jobs[i].ID()] < offsets[jobs[j].ID()]
+	})
+}
@@ -6,12 +6,14 @@
 package scheduler
 
 import (
+	"fmt"
 	"runtime"
 	"testing"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/util/testutil"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -65,3 +67,58 @@ func TestBucket_RemoveJob(t *testing.T) {
 	// use the bucket, just to keep it alive during the earlier GC run
 	bucket.addJob(&TestJobCheck{id: "here so the GC doesn't GC the entire bucket"})
 }
+
+type TestPriorityCheck struct {
+	TestJobCheck
+	priority check.Priority
+}
+
+func (c *TestPriorityCheck) Priority() check.Priority { return c.priority }
+
+func TestJobQueue_Jitter(t *testing.T) {
+	jq := newJobQueue(10*time.Second, 5*time.Second)
+
+	for i := 0; i < 20; i++ {
+		jq.addJob(&TestJobCheck{TestCheck: TestCheck{intl: 10 * time.Second}, id: fmt.Sprintf("check_%d", i)})
+	}
+	priorityCheck := &TestPriorityCheck{TestJobCheck: TestJobCheck{id: "priority"}, priority: check.PriorityHigh}
+	jq.addJob(priorityCheck)
+
+	// Only the sub-second part of the jitter is kept, the whole seconds shift the bucket
+	for _, offset := range jq.offsets {
+		assert.True(t, offset < time.Second)
+	}
+	_, found := jq.offsets[priorityCheck.ID()]
+	assert.False(t, found)
+
+	for id := range jq.offsets {
+		require.NoError(t, jq.removeJob(id))
+		_, found := jq.offsets[id]
+		assert.False(t, found)
+	}
+
+	// The jitter is capped at the interval
+	assert.Equal(t, 2*time.Second, newJobQueue(2*time.Second, time.Minute).jitter)
+}
+
+func TestSortJobs(t *testing.T) {
+	jobs := []check.Check{
+		&TestPriorityCheck{TestJobCheck: TestJobCheck{id: "low"}, priority: check.PriorityLow},
+		&TestJobCheck{id: "normal_late"},
+		&TestJobCheck{id: "normal_early"},
+		&TestJobCheck{id: "normal"},
+		&TestPriorityCheck{TestJobCheck: TestJobCheck{id: "high"}, priority: check.PriorityHigh},
+	}
+	offsets := map[check.ID]time.Duration{
+		"normal_late":  500 * time.Millisecond,
+		"normal_early": 100 * time.Millisecond,
+	}
+
+	sortJobs(jobs, offsets)
+
+	ids := []check.ID{}
+	for _, c := range jobs {
+		ids = append(ids, c.ID())
+	}
+	assert.Equal(t, []check.ID{"high", "normal", "normal_early", "normal_late", "low"}, ids)
+}
@@ -12,6 +12,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -41,6 +42,8 @@ func init() {
 type Scheduler struct {
 	running          uint32                      // Flag to see if the scheduler is running
 	checksPipe       chan<- check.Check          // The pipe the Runner pops the checks from, initially set to nil
+	priorityPipe     chan<- check.Check          // The pipe the Runner pops the high-priority checks from, checksPipe if nil
+	jitter           time.Duration               // Maximum random delay of the start of the checks
 	done             chan bool                   // Guard for the main loop
 	halted           chan bool                   // Used to internally communicate all queues are done
 	started          chan bool                   // Used to internally communicate the queues are up
@@ -63,6 +66,7 @@ type Scheduler struct {
 func NewScheduler(checksPipe chan<- check.Check) *Scheduler {
 	return &Scheduler{
 		checksPipe:       checksPipe,
+		jitter:           time.Duration(config.Datadog.GetFloat64("check_scheduling_jitter") * float64(time.Second)),
 		done:             make(chan bool),
 		halted:           make(chan bool),
 		started:          make(chan bool),
@@ -75,6 +79,20 @@ func NewScheduler(checksPipe chan<- check.Check) *Scheduler {
 	}
 }
 
+// SetPriorityChecksPipe sets the pipe the high-priority checks are sent to instead of
+// the checks pipe. It must be called before the checks are scheduled.
+func (s *Scheduler) SetPriorityChecksPipe(priorityPipe chan<- check.Check) {
+	s.priorityPipe = priorityPipe
+}
+
+// pipeFor returns the pipe a check is sent to
+func (s *Scheduler) pipeFor(c check.Check) chan<- check.Check {
+	if s.priorityPipe != nil && check.GetPriority(c) == check.PriorityHigh {
+		return s.priorityPipe
+	}
+	return s.checksPipe
+}
+
 // Enter schedules a `Check`s for execution accordingly to the `Check.Interval()` value.
 // If the interval is 0, the check is supposed to run only once.
 func (s *Scheduler) Enter(check check.Check) error {
@@ -95,7 +113,7 @@ func (s *Scheduler) Enter(check check.Check) error {
 	defer s.mu.Unlock()
 
 	if _, ok := s.jobQueues[check.Interval()]; !ok {
-		s.jobQueues[check.Interval()] = newJobQueue(check.Interval())
+		s.jobQueues[check.Interval()] = newJobQueue(check.Interval(), s.jitter)
 		s.startQueue(s.jobQueues[check.Interval()])
 		if check.IsTelemetryEnabled() {
 			tlmQueuesCount.Inc()
@@ -266,7 +284,7 @@ func (s *Scheduler) enqueueOnce(check check.Check) {
 	go func(cancelOneTime <-chan bool) {
 		defer s.wgOneTime.Done()
 		select {
-		case s.checksPipe <- check:
+		case s.pipeFor(check) <- check:
 		case <-cancelOneTime:
 		}
 	}(s.cancelOneTime)
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/runner/expvars"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -196,3 +197,68 @@ func TestStopOneTimeSchedule(t *testing.T) {
 	// sleep to make the runtime schedule the hanging goroutines, if there are any
 	time.Sleep(time.Millisecond)
 }
+
+func TestPriorityChecksPipe(t *testing.T) {
+	ch := make(chan check.Check, 10)
+	priorityCh := make(chan check.Check, 10)
+	s := NewScheduler(ch)
+	s.SetPriorityChecksPipe(priorityCh)
+
+	normalCheck := &TestJobCheck{TestCheck: TestCheck{intl: time.Second}, id: "normal"}
+	priorityCheck := &TestPriorityCheck{TestJobCheck: TestJobCheck{TestCheck: TestCheck{intl: time.Second}, id: "priority"}, priority: check.PriorityHigh}
+	s.Enter(normalCheck)
+	s.Enter(priorityCheck)
+	s.Run()
+	defer s.Stop()
+
+	assert.Equal(t, priorityCheck, <-priorityCh)
+	assert.Equal(t, normalCheck, <-ch)
+
+	// One-time checks are sent to the priority pipe too
+	oneTimeCheck := &TestPriorityCheck{TestJobCheck: TestJobCheck{id: "onetime"}, priority: check.PriorityHigh}
+	s.Enter(oneTimeCheck)
+	assert.Equal(t, oneTimeCheck, <-priorityCh)
+}
+
+func TestPriorityOrder(t *testing.T) {
+	ch := make(chan check.Check, 10)
+	s := NewScheduler(ch)
+
+	lowCheck := &TestPriorityCheck{TestJobCheck: TestJobCheck{TestCheck: TestCheck{intl: time.Second}, id: "low"}, priority: check.PriorityLow}
+	normalCheck := &TestJobCheck{TestCheck: TestCheck{intl: time.Second}, id: "normal"}
+	highCheck := &TestPriorityCheck{TestJobCheck: TestJobCheck{TestCheck: TestCheck{intl: time.Second}, id: "high"}, priority: check.PriorityHigh}
+	s.Enter(lowCheck)
+	s.Enter(normalCheck)
+	s.Enter(highCheck)
+	s.Run()
+	defer s.Stop()
+
+	// Without a priority pipe, the checks are all sent to the checks pipe by decreasing priority
+	assert.Equal(t, highCheck, <-ch)
+	assert.Equal(t, normalCheck, <-ch)
+	assert.Equal(t, lowCheck, <-ch)
+}
+
+func TestLateness(t *testing.T) {
+	expvars.Reset()
+	defer expvars.Reset()
+
+	ch := make(chan check.Check)
+	s := NewScheduler(ch)
+
+	c := &TestJobCheck{TestCheck: TestCheck{intl: time.Second}, id: "late"}
+	expvars.AddCheckStats(c, time.Second, nil, nil, check.SenderStats{})
+	s.Enter(c)
+	s.Run()
+
+	// The check is picked up well after the tick of its bucket
+	time.Sleep(1500 * time.Millisecond)
+	assert.Equal(t, c, <-ch)
+
+	// The lateness is recorded before the queue stops
+	s.Stop()
+	stats, found := expvars.CheckStats(c.ID())
+	assert.True(t, found)
+	assert.GreaterOrEqual(t, stats.MaxLateness, int64(300))
+	assert.Equal(t, stats.MaxLateness, stats.LastLateness)
+}
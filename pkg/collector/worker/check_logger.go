@@ -69,6 +69,11 @@ func (cl *CheckLogger) Error(checkErr error) {
 	log.Errorc(fmt.Sprintf("Error running check: %s", checkErr), "check", cl.Check)
 }
 
+// Warn is used to log a warning about the check
+func (cl *CheckLogger) Warn(message string) {
+	log.Warnc(message, "check", cl.Check)
+}
+
 // Debug is used to log a message for a check that may be useful in debugging
 func (cl *CheckLogger) Debug(message string) {
 	log.Debugc(message, "check", cl.Check)
@@ -0,0 +1,30 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package worker
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+// resourceMeter measures the resources used by a check run. It must be started and
+// stopped by the goroutine running the check, locked to its OS thread, so that the
+// CPU time of the thread is the CPU time of the check.
+type resourceMeter struct {
+	cpuTimeStart int64
+}
+
+func startResourceMeter() *resourceMeter {
+	return &resourceMeter{
+		cpuTimeStart: threadCPUTime(),
+	}
+}
+
+// stop returns the resources used since the meter was started
+func (m *resourceMeter) stop() *check.ResourceStats {
+	return &check.ResourceStats{
+		CPUTime: (threadCPUTime() - m.cpuTimeStart) / 1e6,
+	}
+}
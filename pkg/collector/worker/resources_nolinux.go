@@ -0,0 +1,14 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !linux
+// +build !linux
+
+package worker
+
+// threadCPUTime isn't supported on this platform, the CPU time of the checks is always 0
+func threadCPUTime() int64 {
+	return 0
+}
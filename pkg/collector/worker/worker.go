@@ -7,13 +7,18 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"runtime"
+	"runtime/debug"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/collector/runner/expvars"
+	"github.com/DataDog/datadog-agent/pkg/collector/runner/quarantine"
 	"github.com/DataDog/datadog-agent/pkg/collector/runner/tracker"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -28,25 +33,40 @@ const (
 )
 
 // Worker is an object that encapsulates the logic to manage a loop of processing
-// checks over the provided `PendingCheckChan`
+// checks over the provided `PendingCheckChan`. The checks received on the optional
+// `PriorityChecksChan` are processed first.
 type Worker struct {
 	ID   int
 	Name string
 
 	checksTracker           *tracker.RunningChecksTracker
 	getDefaultSenderFunc    func() (aggregator.Sender, error)
+	maxRunTime              time.Duration
 	pendingChecksChan       chan check.Check
+	priorityChecksChan      chan check.Check
+	quarantinePolicy        *quarantine.Policy
+	resourceAccounting      bool
 	runnerID                int
 	shouldAddCheckStatsFunc func(id check.ID) bool
 	utilizationTracker      UtilizationTracker
 }
 
-// NewWorker returns an instance of a `Worker` after parameter sanity checks are passed
+// runResult is the outcome of a check run
+type runResult struct {
+	err       error
+	resources *check.ResourceStats
+}
+
+// NewWorker returns an instance of a `Worker` after parameter sanity checks are passed.
+// `priorityChecksChan` can be nil if the worker only processes the checks of
+// `pendingChecksChan`.
 func NewWorker(
 	runnerID int,
 	ID int,
 	pendingChecksChan chan check.Check,
+	priorityChecksChan chan check.Check,
 	checksTracker *tracker.RunningChecksTracker,
+	quarantinePolicy *quarantine.Policy,
 	shouldAddCheckStatsFunc func(id check.ID) bool,
 ) (*Worker, error) {
 
@@ -54,6 +74,10 @@ func NewWorker(
 		return nil, fmt.Errorf("worker cannot initialize using a nil checksTracker")
 	}
 
+	if quarantinePolicy == nil {
+		return nil, fmt.Errorf("worker cannot initialize using a nil quarantinePolicy")
+	}
+
 	if pendingChecksChan == nil {
 		return nil, fmt.Errorf("worker cannot initialize using a nil pendingChecksChan")
 	}
@@ -66,7 +90,9 @@ func NewWorker(
 		runnerID,
 		ID,
 		pendingChecksChan,
+		priorityChecksChan,
 		checksTracker,
+		quarantinePolicy,
 		shouldAddCheckStatsFunc,
 		aggregator.GetDefaultSender,
 		windowSize,
@@ -81,7 +107,9 @@ func newWorkerWithOptions(
 	runnerID int,
 	ID int,
 	pendingChecksChan chan check.Check,
+	priorityChecksChan chan check.Check,
 	checksTracker *tracker.RunningChecksTracker,
+	quarantinePolicy *quarantine.Policy,
 	shouldAddCheckStatsFunc func(id check.ID) bool,
 	getDefaultSenderFunc func() (aggregator.Sender, error),
 	windowSize time.Duration,
@@ -102,7 +130,11 @@ func newWorkerWithOptions(
 		ID:                      ID,
 		Name:                    workerName,
 		checksTracker:           checksTracker,
+		maxRunTime:              time.Duration(config.Datadog.GetInt("check_max_run_time")) * time.Second,
 		pendingChecksChan:       pendingChecksChan,
+		priorityChecksChan:      priorityChecksChan,
+		quarantinePolicy:        quarantinePolicy,
+		resourceAccounting:      config.Datadog.GetBool("check_resource_accounting"),
 		runnerID:                runnerID,
 		shouldAddCheckStatsFunc: shouldAddCheckStatsFunc,
 		getDefaultSenderFunc:    getDefaultSenderFunc,
@@ -110,7 +142,24 @@ func newWorkerWithOptions(
 	}, nil
 }
 
-// Run waits for checks and run them as long as they arrive on the channel
+// nextCheck waits for the next check to run, preferring the checks of the priority
+// channel. It returns false once one of the channels is closed.
+func (w *Worker) nextCheck() (check.Check, bool) {
+	select {
+	case c, ok := <-w.priorityChecksChan:
+		return c, ok
+	default:
+	}
+
+	select {
+	case c, ok := <-w.priorityChecksChan:
+		return c, ok
+	case c, ok := <-w.pendingChecksChan:
+		return c, ok
+	}
+}
+
+// Run waits for checks and run them as long as they arrive on the channels
 func (w *Worker) Run() {
 	log.Debugf("Runner %d, worker %d: Ready to process checks...", w.runnerID, w.ID)
 
@@ -123,10 +172,20 @@ func (w *Worker) Run() {
 		}
 	}()
 
-	for check := range w.pendingChecksChan {
+	for {
+		check, ok := w.nextCheck()
+		if !ok {
+			break
+		}
+
 		checkLogger := CheckLogger{Check: check}
 		longRunning := check.Interval() == 0
 
+		if w.quarantinePolicy.IsQuarantined(check.ID()) {
+			checkLogger.Debug("Check is quarantined, skipping execution...")
+			continue
+		}
+
 		// Add check to tracker if it's not already running
 		if !w.checksTracker.AddCheck(check) {
 			checkLogger.Debug("Check is already running, skipping execution...")
@@ -143,14 +202,18 @@ func (w *Worker) Run() {
 		w.utilizationTracker.CheckStarted(longRunning)
 
 		// Run the check
-		var checkErr error
-		checkErr = check.Run()
+		result, finished := w.runCheck(check, w.checkMaxRunTime(check, longRunning))
+		checkErr := result.err
 
 		w.utilizationTracker.CheckFinished()
 
-		expvars.DeleteRunningStats(check.ID())
-
-		checkWarnings := check.GetWarnings()
+		// The cleanup of the run of a check still running after its max run
+		// time is done when it completes
+		var checkWarnings []error
+		if finished {
+			expvars.DeleteRunningStats(check.ID())
+			checkWarnings = check.GetWarnings()
+		}
 
 		// Use the default sender for the service checks
 		sender, err := w.getDefaultSenderFunc()
@@ -178,24 +241,139 @@ func (w *Worker) Run() {
 			sender.Commit()
 		}
 
-		// Remove the check from the running list
-		w.checksTracker.DeleteCheck(check.ID())
+		if finished {
+			// Remove the check from the running list
+			w.checksTracker.DeleteCheck(check.ID())
+			expvars.AddRunningCheckCount(-1)
+		}
 
 		// Publish statistics about this run
-		expvars.AddRunningCheckCount(-1)
 		expvars.AddRunsCount(1)
 
 		if !longRunning || len(checkWarnings) != 0 || checkErr != nil {
 			// If the scheduler isn't assigned (it should), just add stats
 			// otherwise only do so if the check is in the scheduler
 			if w.shouldAddCheckStatsFunc(check.ID()) {
-				sStats, _ := check.GetSenderStats()
+				sStats := senderStats(check, finished)
 				expvars.AddCheckStats(check, time.Since(checkStartTime), checkErr, checkWarnings, sStats)
+				if result.resources != nil {
+					expvars.SetCheckResources(check.ID(), result.resources)
+				}
 			}
 		}
 
+		w.updateQuarantine(check, checkLogger, checkErr)
+
 		checkLogger.CheckFinished()
 	}
 
 	log.Debugf("Runner %d, worker %d: Finished processing checks.", w.runnerID, w.ID)
 }
+
+// checkMaxRunTime returns the max run time of a check, 0 if its runs aren't limited
+func (w *Worker) checkMaxRunTime(c check.Check, longRunning bool) time.Duration {
+	if longRunning {
+		return 0
+	}
+	if limiter, ok := c.(check.RunTimeLimiter); ok && limiter.MaxRunTime() > 0 {
+		return limiter.MaxRunTime()
+	}
+	return w.maxRunTime
+}
+
+// senderStats returns the sender stats of a check run, empty if the check was
+// cancelled while running
+func senderStats(c check.Check, finished bool) check.SenderStats {
+	if !finished {
+		return check.NewSenderStats()
+	}
+	sStats, _ := c.GetSenderStats()
+	return sStats
+}
+
+// runCheck runs a check, and interrupts it if it exceeds its max run time. The check
+// isn't unscheduled and runs again at its next interval.
+// It returns false if the check is still running after its max run time, in which
+// case it's removed from the running checks once its run completes.
+func (w *Worker) runCheck(c check.Check, maxRunTime time.Duration) (runResult, bool) {
+	done := make(chan runResult, 1)
+	go w.run(c, done)
+
+	if maxRunTime <= 0 {
+		return <-done, true
+	}
+
+	timer := time.NewTimer(maxRunTime)
+	defer timer.Stop()
+
+	select {
+	case result := <-done:
+		return result, true
+	case <-timer.C:
+	}
+
+	if interrupter, ok := c.(check.Interrupter); ok {
+		go interrupter.Interrupt()
+	}
+
+	go func() {
+		<-done
+		expvars.DeleteRunningStats(c.ID())
+		w.checksTracker.DeleteCheck(c.ID())
+		expvars.AddRunningCheckCount(-1)
+	}()
+
+	return runResult{err: &check.RunTimeoutError{MaxRunTime: maxRunTime}}, false
+}
+
+// run runs a check, recovers from its panics and measures its resources if enabled
+func (w *Worker) run(c check.Check, done chan<- runResult) {
+	var result runResult
+	var meter *resourceMeter
+
+	if w.resourceAccounting {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		meter = startResourceMeter()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result.err = &check.PanicError{Value: r, Stack: debug.Stack()}
+		}
+		if meter != nil {
+			result.resources = meter.stop()
+		}
+		done <- result
+	}()
+
+	result.err = c.Run()
+}
+
+// updateQuarantine records the outcome of a check run in the quarantine policy,
+// and publishes the quarantine of the check
+func (w *Worker) updateQuarantine(c check.Check, checkLogger CheckLogger, checkErr error) {
+	var timeoutErr *check.RunTimeoutError
+	var panicErr *check.PanicError
+
+	var reason string
+	switch {
+	case errors.As(checkErr, &timeoutErr):
+		reason = timeoutErr.Error()
+	case errors.As(checkErr, &panicErr):
+		reason = fmt.Sprintf("the run panicked: %v", panicErr.Value)
+	default:
+		w.quarantinePolicy.RecordSuccess(c.ID())
+		expvars.SetQuarantineStats(c.ID(), nil)
+		return
+	}
+
+	if q := w.quarantinePolicy.RecordFailure(c.ID(), reason); q != nil {
+		checkLogger.Warn(fmt.Sprintf(
+			"Check is quarantined until %s: %s",
+			time.Unix(q.Until, 0).Format(time.RFC3339),
+			reason,
+		))
+		expvars.SetQuarantineStats(c.ID(), q)
+	}
+}
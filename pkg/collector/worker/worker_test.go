@@ -7,6 +7,7 @@ package worker
 
 import (
 	"fmt"
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -18,7 +19,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
 	"github.com/DataDog/datadog-agent/pkg/collector/runner/expvars"
+	"github.com/DataDog/datadog-agent/pkg/collector/runner/quarantine"
 	"github.com/DataDog/datadog-agent/pkg/collector/runner/tracker"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
@@ -27,18 +30,26 @@ import (
 type testCheck struct {
 	check.StubCheck
 	sync.Mutex
-	doErr       bool
-	doWarn      bool
-	id          string
-	longRunning bool
-	t           *testing.T
-	runFunc     func(id check.ID)
-	runCount    uint64
+	doErr          bool
+	doWarn         bool
+	doPanic        bool
+	id             string
+	longRunning    bool
+	maxRunTime     time.Duration
+	t              *testing.T
+	runFunc        func(id check.ID)
+	runCount       uint64
+	cancelCount    uint64
+	interruptCount uint64
 }
 
 func (c *testCheck) ID() check.ID   { return check.ID(c.id) }
 func (c *testCheck) String() string { return check.IDToCheckName(c.ID()) }
 func (c *testCheck) RunCount() int  { return int(c.runCount) }
+func (c *testCheck) Cancel()        { atomic.AddUint64(&c.cancelCount, 1) }
+func (c *testCheck) Interrupt()     { atomic.AddUint64(&c.interruptCount, 1) }
+
+func (c *testCheck) MaxRunTime() time.Duration { return c.maxRunTime }
 
 func (c *testCheck) Interval() time.Duration {
 	if c.longRunning {
@@ -70,6 +81,10 @@ func (c *testCheck) Run() error {
 		return fmt.Errorf("myerror")
 	}
 
+	if c.doPanic {
+		panic("mypanic")
+	}
+
 	return nil
 }
 
@@ -113,25 +128,30 @@ func assertErrorCount(t *testing.T, c check.Check, count int) {
 
 func TestWorkerInit(t *testing.T) {
 	checksTracker := &tracker.RunningChecksTracker{}
+	quarantinePolicy := quarantine.NewPolicyFromConfig()
 	pendingChecksChan := make(chan check.Check, 1)
 	mockShouldAddStatsFunc := func(id check.ID) bool { return true }
 
-	_, err := NewWorker(1, 2, nil, checksTracker, mockShouldAddStatsFunc)
+	_, err := NewWorker(1, 2, nil, nil, checksTracker, quarantinePolicy, mockShouldAddStatsFunc)
 	require.NotNil(t, err)
 
-	_, err = NewWorker(1, 2, pendingChecksChan, nil, mockShouldAddStatsFunc)
+	_, err = NewWorker(1, 2, pendingChecksChan, nil, nil, quarantinePolicy, mockShouldAddStatsFunc)
 	require.NotNil(t, err)
 
-	_, err = NewWorker(1, 2, pendingChecksChan, checksTracker, nil)
+	_, err = NewWorker(1, 2, pendingChecksChan, nil, checksTracker, nil, mockShouldAddStatsFunc)
 	require.NotNil(t, err)
 
-	worker, err := NewWorker(1, 2, pendingChecksChan, checksTracker, mockShouldAddStatsFunc)
+	_, err = NewWorker(1, 2, pendingChecksChan, nil, checksTracker, quarantinePolicy, nil)
+	require.NotNil(t, err)
+
+	worker, err := NewWorker(1, 2, pendingChecksChan, nil, checksTracker, quarantinePolicy, mockShouldAddStatsFunc)
 	assert.Nil(t, err)
 	assert.NotNil(t, worker)
 }
 
 func TestWorkerInitExpvarStats(t *testing.T) {
 	checksTracker := &tracker.RunningChecksTracker{}
+	quarantinePolicy := quarantine.NewPolicyFromConfig()
 	pendingChecksChan := make(chan check.Check, 1)
 	mockShouldAddStatsFunc := func(id check.ID) bool { return true }
 
@@ -144,7 +164,7 @@ func TestWorkerInitExpvarStats(t *testing.T) {
 		go func(idx int) {
 			defer wg.Done()
 
-			worker, err := NewWorker(1, idx, pendingChecksChan, checksTracker, mockShouldAddStatsFunc)
+			worker, err := NewWorker(1, idx, pendingChecksChan, nil, checksTracker, quarantinePolicy, mockShouldAddStatsFunc)
 			assert.Nil(t, err)
 
 			worker.Run()
@@ -161,12 +181,13 @@ func TestWorkerInitExpvarStats(t *testing.T) {
 
 func TestWorkerName(t *testing.T) {
 	checksTracker := &tracker.RunningChecksTracker{}
+	quarantinePolicy := quarantine.NewPolicyFromConfig()
 	pendingChecksChan := make(chan check.Check, 1)
 	mockShouldAddStatsFunc := func(id check.ID) bool { return true }
 
 	for _, id := range []int{1, 100, 500} {
 		expectedName := fmt.Sprintf("worker_%d", id)
-		worker, err := NewWorker(1, id, pendingChecksChan, checksTracker, mockShouldAddStatsFunc)
+		worker, err := NewWorker(1, id, pendingChecksChan, nil, checksTracker, quarantinePolicy, mockShouldAddStatsFunc)
 		assert.Nil(t, err)
 		assert.NotNil(t, worker)
 
@@ -181,6 +202,7 @@ func TestWorker(t *testing.T) {
 	var wg sync.WaitGroup
 
 	checksTracker := tracker.NewRunningChecksTracker()
+	quarantinePolicy := quarantine.NewPolicyFromConfig()
 	pendingChecksChan := make(chan check.Check, 10)
 	mockShouldAddStatsFunc := func(id check.ID) bool { return true }
 
@@ -218,7 +240,7 @@ func TestWorker(t *testing.T) {
 	pendingChecksChan <- testCheck1
 	close(pendingChecksChan)
 
-	worker, err := NewWorker(100, 200, pendingChecksChan, checksTracker, mockShouldAddStatsFunc)
+	worker, err := NewWorker(100, 200, pendingChecksChan, nil, checksTracker, quarantinePolicy, mockShouldAddStatsFunc)
 	require.Nil(t, err)
 
 	wg.Add(1)
@@ -257,6 +279,7 @@ func TestWorkerUtilizationExpvars(t *testing.T) {
 	var wg sync.WaitGroup
 
 	checksTracker := tracker.NewRunningChecksTracker()
+	quarantinePolicy := quarantine.NewPolicyFromConfig()
 	pendingChecksChan := make(chan check.Check, 10)
 	mockShouldAddStatsFunc := func(id check.ID) bool { return true }
 
@@ -270,7 +293,9 @@ func TestWorkerUtilizationExpvars(t *testing.T) {
 		1,
 		2,
 		pendingChecksChan,
+		nil,
 		checksTracker,
+		quarantinePolicy,
 		mockShouldAddStatsFunc,
 		func() (aggregator.Sender, error) { return nil, nil },
 		1000*time.Millisecond,
@@ -323,6 +348,7 @@ func TestWorkerErrorAndWarningHandling(t *testing.T) {
 	var wg sync.WaitGroup
 
 	checksTracker := tracker.NewRunningChecksTracker()
+	quarantinePolicy := quarantine.NewPolicyFromConfig()
 	pendingChecksChan := make(chan check.Check, 10)
 	mockShouldAddStatsFunc := func(id check.ID) bool { return true }
 
@@ -344,7 +370,7 @@ func TestWorkerErrorAndWarningHandling(t *testing.T) {
 	}
 	close(pendingChecksChan)
 
-	worker, err := NewWorker(100, 200, pendingChecksChan, checksTracker, mockShouldAddStatsFunc)
+	worker, err := NewWorker(100, 200, pendingChecksChan, nil, checksTracker, quarantinePolicy, mockShouldAddStatsFunc)
 	require.Nil(t, err)
 	AssertAsyncWorkerCount(t, 0)
 
@@ -376,6 +402,7 @@ func TestWorkerConcurrentCheckScheduling(t *testing.T) {
 	config.Datadog.Set("hostname", "myhost")
 
 	checksTracker := tracker.NewRunningChecksTracker()
+	quarantinePolicy := quarantine.NewPolicyFromConfig()
 	pendingChecksChan := make(chan check.Check, 10)
 	mockShouldAddStatsFunc := func(id check.ID) bool { return true }
 
@@ -389,7 +416,7 @@ func TestWorkerConcurrentCheckScheduling(t *testing.T) {
 	pendingChecksChan <- testCheck
 	close(pendingChecksChan)
 
-	worker, err := NewWorker(100, 200, pendingChecksChan, checksTracker, mockShouldAddStatsFunc)
+	worker, err := NewWorker(100, 200, pendingChecksChan, nil, checksTracker, quarantinePolicy, mockShouldAddStatsFunc)
 	require.Nil(t, err)
 
 	worker.Run()
@@ -405,6 +432,7 @@ func TestWorkerStatsAddition(t *testing.T) {
 	config.Datadog.Set("hostname", "myhost")
 
 	checksTracker := tracker.NewRunningChecksTracker()
+	quarantinePolicy := quarantine.NewPolicyFromConfig()
 	pendingChecksChan := make(chan check.Check, 10)
 
 	shouldAddStatsFunc := func(id check.ID) bool {
@@ -443,7 +471,7 @@ func TestWorkerStatsAddition(t *testing.T) {
 	pendingChecksChan <- squelchedStatsCheck
 	close(pendingChecksChan)
 
-	worker, err := NewWorker(100, 200, pendingChecksChan, checksTracker, shouldAddStatsFunc)
+	worker, err := NewWorker(100, 200, pendingChecksChan, nil, checksTracker, quarantinePolicy, shouldAddStatsFunc)
 	require.Nil(t, err)
 
 	worker.Run()
@@ -466,6 +494,7 @@ func TestWorkerServiceCheckSending(t *testing.T) {
 	var wg sync.WaitGroup
 
 	checksTracker := tracker.NewRunningChecksTracker()
+	quarantinePolicy := quarantine.NewPolicyFromConfig()
 	pendingChecksChan := make(chan check.Check, 10)
 	mockShouldAddStatsFunc := func(id check.ID) bool { return true }
 
@@ -488,7 +517,9 @@ func TestWorkerServiceCheckSending(t *testing.T) {
 		100,
 		200,
 		pendingChecksChan,
+		nil,
 		checksTracker,
+		quarantinePolicy,
 		mockShouldAddStatsFunc,
 		func() (aggregator.Sender, error) {
 			return mockSender, nil
@@ -549,6 +580,7 @@ func TestWorkerSenderNil(t *testing.T) {
 	config.Datadog.Set("hostname", "myhost")
 
 	checksTracker := tracker.NewRunningChecksTracker()
+	quarantinePolicy := quarantine.NewPolicyFromConfig()
 	pendingChecksChan := make(chan check.Check, 10)
 	mockShouldAddStatsFunc := func(id check.ID) bool { return true }
 
@@ -559,7 +591,9 @@ func TestWorkerSenderNil(t *testing.T) {
 		100,
 		200,
 		pendingChecksChan,
+		nil,
 		checksTracker,
+		quarantinePolicy,
 		mockShouldAddStatsFunc,
 		func() (aggregator.Sender, error) {
 			return nil, fmt.Errorf("testerr")
@@ -581,6 +615,7 @@ func TestWorkerServiceCheckSendingLongRunningTasks(t *testing.T) {
 	config.Datadog.Set("hostname", "myhost")
 
 	checksTracker := tracker.NewRunningChecksTracker()
+	quarantinePolicy := quarantine.NewPolicyFromConfig()
 	pendingChecksChan := make(chan check.Check, 10)
 	mockShouldAddStatsFunc := func(id check.ID) bool { return true }
 
@@ -599,7 +634,9 @@ func TestWorkerServiceCheckSendingLongRunningTasks(t *testing.T) {
 		100,
 		200,
 		pendingChecksChan,
+		nil,
 		checksTracker,
+		quarantinePolicy,
 		mockShouldAddStatsFunc,
 		func() (aggregator.Sender, error) {
 			return mockSender, nil
@@ -617,3 +654,322 @@ func TestWorkerServiceCheckSendingLongRunningTasks(t *testing.T) {
 	mockSender.AssertNumberOfCalls(t, "Commit", 0)
 	mockSender.AssertNumberOfCalls(t, "ServiceCheck", 0)
 }
+
+func newTestWorker(t *testing.T, pendingChecksChan chan check.Check, checksTracker *tracker.RunningChecksTracker, quarantinePolicy *quarantine.Policy) *Worker {
+	worker, err := newWorkerWithOptions(
+		100,
+		200,
+		pendingChecksChan,
+		nil,
+		checksTracker,
+		quarantinePolicy,
+		func(id check.ID) bool { return true },
+		func() (aggregator.Sender, error) { return nil, nil },
+		windowSize,
+		pollingInterval,
+	)
+	require.Nil(t, err)
+	return worker
+}
+
+func TestWorkerMaxRunTime(t *testing.T) {
+	expvars.Reset()
+	config.Datadog.Set("hostname", "myhost")
+
+	checksTracker := tracker.NewRunningChecksTracker()
+	pendingChecksChan := make(chan check.Check, 10)
+
+	release := make(chan struct{})
+	blockedCheck := newCheck(t, "blocked:123", false, func(check.ID) { <-release })
+	blockedCheck.maxRunTime = 100 * time.Millisecond
+	otherCheck := newCheck(t, "other:456", false, nil)
+
+	pendingChecksChan <- blockedCheck
+	pendingChecksChan <- otherCheck
+	close(pendingChecksChan)
+
+	worker := newTestWorker(t, pendingChecksChan, checksTracker, quarantine.NewPolicy(0, 0, 0))
+	worker.Run()
+
+	// The worker doesn't wait for the interrupted check to run the next checks
+	assert.Equal(t, 1, otherCheck.RunCount())
+	require.Eventually(t, func() bool {
+		return atomic.LoadUint64(&blockedCheck.interruptCount) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Zero(t, atomic.LoadUint64(&blockedCheck.cancelCount))
+
+	stats, found := expvars.CheckStats(blockedCheck.ID())
+	require.True(t, found)
+	assert.Equal(t, uint64(1), stats.TotalErrors)
+	assert.Equal(t, uint64(1), stats.TotalTimeouts)
+	assert.Equal(t, "the run exceeded the max run time of 100ms", stats.LastError)
+
+	// The interrupted check stays in the running checks until its run completes
+	_, running := checksTracker.Check(blockedCheck.ID())
+	assert.True(t, running)
+	assert.Equal(t, 1, int(expvars.GetRunningCheckCount()))
+
+	close(release)
+	require.Eventually(t, func() bool {
+		_, running := checksTracker.Check(blockedCheck.ID())
+		return !running
+	}, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return expvars.GetRunningCheckCount() == 0 && expvars.GetRunningStats(blockedCheck.ID()).IsZero()
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWorkerMaxRunTimeRunsAgain(t *testing.T) {
+	expvars.Reset()
+	config.Datadog.Set("hostname", "myhost")
+
+	checksTracker := tracker.NewRunningChecksTracker()
+	pendingChecksChan := make(chan check.Check, 10)
+
+	release := make(chan struct{})
+	var runs uint64
+	timedOutCheck := newCheck(t, "timedout:123", false, func(check.ID) {
+		if atomic.AddUint64(&runs, 1) == 1 {
+			<-release
+		}
+	})
+	timedOutCheck.maxRunTime = 100 * time.Millisecond
+
+	worker := newTestWorker(t, pendingChecksChan, checksTracker, quarantine.NewPolicy(0, 0, 0))
+	done := make(chan struct{})
+	go func() {
+		worker.Run()
+		close(done)
+	}()
+
+	pendingChecksChan <- timedOutCheck
+	require.Eventually(t, func() bool {
+		return atomic.LoadUint64(&timedOutCheck.interruptCount) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// The run completes after being interrupted, and the check runs again at its next interval
+	close(release)
+	require.Eventually(t, func() bool {
+		_, running := checksTracker.Check(timedOutCheck.ID())
+		return !running
+	}, time.Second, 10*time.Millisecond)
+
+	pendingChecksChan <- timedOutCheck
+	close(pendingChecksChan)
+	<-done
+
+	assert.Equal(t, 2, timedOutCheck.RunCount())
+	assert.Zero(t, atomic.LoadUint64(&timedOutCheck.cancelCount))
+
+	stats, found := expvars.CheckStats(timedOutCheck.ID())
+	require.True(t, found)
+	assert.Equal(t, uint64(2), stats.TotalRuns)
+	assert.Equal(t, uint64(1), stats.TotalErrors)
+	assert.Equal(t, uint64(1), stats.TotalTimeouts)
+	assert.Empty(t, stats.LastError)
+}
+
+// blockingCoreCheck is a core check whose runs block until they're interrupted
+type blockingCoreCheck struct {
+	core.CheckBase
+	stopped uint64
+}
+
+func (c *blockingCoreCheck) Run() error {
+	<-c.Interrupted()
+	atomic.AddUint64(&c.stopped, 1)
+	return nil
+}
+
+func TestWorkerMaxRunTimeInterruptsCoreCheck(t *testing.T) {
+	expvars.Reset()
+	config.Datadog.Set("hostname", "myhost")
+
+	checksTracker := tracker.NewRunningChecksTracker()
+	pendingChecksChan := make(chan check.Check, 10)
+
+	blockedCheck := &blockingCoreCheck{CheckBase: core.NewCheckBase("blocked")}
+	require.NoError(t, blockedCheck.CommonConfigure([]byte("max_run_time: 1"), "test"))
+	mocksender.NewMockSender(blockedCheck.ID()).SetupAcceptAll()
+
+	pendingChecksChan <- blockedCheck
+	close(pendingChecksChan)
+
+	worker := newTestWorker(t, pendingChecksChan, checksTracker, quarantine.NewPolicy(0, 0, 0))
+	worker.Run()
+
+	// The run stops once it's interrupted, without being released by the test
+	require.Eventually(t, func() bool {
+		return atomic.LoadUint64(&blockedCheck.stopped) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		_, running := checksTracker.Check(blockedCheck.ID())
+		return !running && expvars.GetRunningCheckCount() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	stats, found := expvars.CheckStats(blockedCheck.ID())
+	require.True(t, found)
+	assert.Equal(t, uint64(1), stats.TotalTimeouts)
+}
+
+func TestWorkerMaxRunTimeDefault(t *testing.T) {
+	config.Datadog.Set("check_max_run_time", 10)
+	defer config.Datadog.Set("check_max_run_time", 0)
+
+	worker := newTestWorker(t, make(chan check.Check), tracker.NewRunningChecksTracker(), quarantine.NewPolicy(0, 0, 0))
+
+	assert.Equal(t, 10*time.Second, worker.checkMaxRunTime(&testCheck{}, false))
+	assert.Equal(t, 5*time.Second, worker.checkMaxRunTime(&testCheck{maxRunTime: 5 * time.Second}, false))
+	assert.Equal(t, time.Duration(0), worker.checkMaxRunTime(&testCheck{maxRunTime: 5 * time.Second}, true))
+}
+
+func TestWorkerPanicRecovery(t *testing.T) {
+	expvars.Reset()
+	config.Datadog.Set("hostname", "myhost")
+
+	checksTracker := tracker.NewRunningChecksTracker()
+	pendingChecksChan := make(chan check.Check, 10)
+
+	panickingCheck := newCheck(t, "panicking:123", false, nil)
+	panickingCheck.doPanic = true
+
+	pendingChecksChan <- panickingCheck
+	close(pendingChecksChan)
+
+	// Implicit assertion that the panic doesn't crash the test
+	worker := newTestWorker(t, pendingChecksChan, checksTracker, quarantine.NewPolicy(0, 0, 0))
+	worker.Run()
+
+	stats, found := expvars.CheckStats(panickingCheck.ID())
+	require.True(t, found)
+	assert.Equal(t, uint64(1), stats.TotalErrors)
+	assert.Equal(t, uint64(1), stats.TotalPanics)
+	assert.Contains(t, stats.LastError, "the run panicked: mypanic")
+
+	assert.Equal(t, 0, int(expvars.GetRunningCheckCount()))
+	assert.Equal(t, 0, len(checksTracker.RunningChecks()))
+}
+
+func TestWorkerQuarantine(t *testing.T) {
+	expvars.Reset()
+	config.Datadog.Set("hostname", "myhost")
+
+	checksTracker := tracker.NewRunningChecksTracker()
+	pendingChecksChan := make(chan check.Check, 10)
+
+	panickingCheck := newCheck(t, "panicking:123", false, nil)
+	panickingCheck.doPanic = true
+	for i := 0; i < 4; i++ {
+		pendingChecksChan <- panickingCheck
+	}
+	close(pendingChecksChan)
+
+	worker := newTestWorker(t, pendingChecksChan, checksTracker, quarantine.NewPolicy(2, time.Hour, time.Hour))
+	worker.Run()
+
+	// The runs are skipped once the check is quarantined
+	assert.Equal(t, 2, panickingCheck.RunCount())
+
+	q := expvars.GetQuarantineStats(panickingCheck.ID())
+	require.NotNil(t, q)
+	assert.Equal(t, "the run panicked: mypanic", q.Reason)
+	assert.Equal(t, 1, q.Count)
+	assert.Equal(t, q.Since+3600, q.Until)
+
+	stats, found := expvars.CheckStats(panickingCheck.ID())
+	require.True(t, found)
+	assert.Equal(t, q, stats.Quarantine)
+	assert.Equal(t, uint64(2), stats.TotalPanics)
+}
+
+func TestWorkerQuarantineRelease(t *testing.T) {
+	expvars.Reset()
+	config.Datadog.Set("hostname", "myhost")
+
+	checksTracker := tracker.NewRunningChecksTracker()
+	pendingChecksChan := make(chan check.Check, 10)
+
+	// The check is released from its quarantine immediately, and its next run completes
+	policy := quarantine.NewPolicy(1, 0, 0)
+	flakyCheck := newCheck(t, "flaky:123", false, nil)
+	flakyCheck.doPanic = true
+	flakyCheck.runFunc = func(check.ID) { flakyCheck.doPanic = flakyCheck.RunCount() == 0 }
+
+	pendingChecksChan <- flakyCheck
+	pendingChecksChan <- flakyCheck
+	close(pendingChecksChan)
+
+	worker := newTestWorker(t, pendingChecksChan, checksTracker, policy)
+	worker.Run()
+
+	assert.Equal(t, 2, flakyCheck.RunCount())
+	assert.Nil(t, expvars.GetQuarantineStats(flakyCheck.ID()))
+
+	stats, found := expvars.CheckStats(flakyCheck.ID())
+	require.True(t, found)
+	assert.Nil(t, stats.Quarantine)
+	assert.Equal(t, uint64(1), stats.TotalPanics)
+	assert.Equal(t, "", stats.LastError)
+}
+
+func TestWorkerResourceAccounting(t *testing.T) {
+	expvars.Reset()
+	config.Datadog.Set("hostname", "myhost")
+	config.Datadog.Set("check_resource_accounting", true)
+	defer config.Datadog.Set("check_resource_accounting", false)
+
+	checksTracker := tracker.NewRunningChecksTracker()
+	pendingChecksChan := make(chan check.Check, 10)
+
+	busyCheck := newCheck(t, "busy:123", false, func(check.ID) {
+		for start := time.Now(); time.Since(start) < 50*time.Millisecond; {
+		}
+	})
+
+	pendingChecksChan <- busyCheck
+	close(pendingChecksChan)
+
+	worker := newTestWorker(t, pendingChecksChan, checksTracker, quarantine.NewPolicy(0, 0, 0))
+	worker.Run()
+
+	stats, found := expvars.CheckStats(busyCheck.ID())
+	require.True(t, found)
+	require.NotNil(t, stats.LastResources)
+	if runtime.GOOS == "linux" {
+		assert.Greater(t, stats.LastResources.CPUTime, int64(0))
+	}
+}
+
+func TestWorkerPriorityChecks(t *testing.T) {
+	expvars.Reset()
+	config.Datadog.Set("hostname", "myhost")
+
+	checksTracker := tracker.NewRunningChecksTracker()
+	pendingChecksChan := make(chan check.Check, 10)
+	priorityChecksChan := make(chan check.Check, 10)
+
+	var runOrder []check.ID
+	recordRun := func(id check.ID) { runOrder = append(runOrder, id) }
+
+	pendingChecksChan <- newCheck(t, "normal:1", false, recordRun)
+	pendingChecksChan <- newCheck(t, "normal:2", false, recordRun)
+	priorityChecksChan <- newCheck(t, "high:1", false, recordRun)
+	close(pendingChecksChan)
+
+	worker, err := newWorkerWithOptions(
+		100,
+		200,
+		pendingChecksChan,
+		priorityChecksChan,
+		checksTracker,
+		quarantine.NewPolicy(0, 0, 0),
+		func(id check.ID) bool { return true },
+		func() (aggregator.Sender, error) { return nil, nil },
+		windowSize,
+		pollingInterval,
+	)
+	require.Nil(t, err)
+	worker.Run()
+
+	assert.Equal(t, []check.ID{"high:1", "normal:1", "normal:2"}, runOrder)
+}
@@ -34,6 +34,11 @@ func setupAPM(config Config) {
 	config.SetKnown("apm_config.obfuscation.remove_stack_traces")
 	config.SetKnown("apm_config.obfuscation.redis.enabled")
 	config.SetKnown("apm_config.obfuscation.memcached.enabled")
+	config.SetKnown("apm_config.obfuscation.graphql.enabled")
+	config.SetKnown("apm_config.obfuscation.graphql.keep_values")
+	config.SetKnown("apm_config.obfuscation.json_body.enabled")
+	config.SetKnown("apm_config.obfuscation.json_body.keep_values")
+	config.SetKnown("apm_config.obfuscation.json_body.obfuscate_sql_values")
 	config.SetKnown("apm_config.filter_tags.require")
 	config.SetKnown("apm_config.filter_tags.reject")
 	config.SetKnown("apm_config.extra_sample_rate")
@@ -84,6 +89,7 @@ func setupAPM(config Config) {
 	config.BindEnv("apm_config.profiling_additional_endpoints", "DD_APM_PROFILING_ADDITIONAL_ENDPOINTS")
 	config.BindEnv("apm_config.additional_endpoints", "DD_APM_ADDITIONAL_ENDPOINTS")
 	config.BindEnv("apm_config.replace_tags", "DD_APM_REPLACE_TAGS")
+	config.BindEnv("apm_config.scrub_rules", "DD_APM_SCRUB_RULES")
 	config.BindEnv("apm_config.analyzed_spans", "DD_APM_ANALYZED_SPANS")
 	config.BindEnv("apm_config.ignore_resources", "DD_APM_IGNORE_RESOURCES", "DD_IGNORE_RESOURCE")
 	config.BindEnv("apm_config.receiver_socket", "DD_APM_RECEIVER_SOCKET")
@@ -99,6 +105,15 @@ func setupAPM(config Config) {
 	config.BindEnv("apm_config.telemetry.additional_endpoints", "DD_APM_TELEMETRY_ADDITIONAL_ENDPOINTS")
 	config.BindEnv("apm_config.obfuscation.credit_cards.enabled", "DD_APM_OBFUSCATION_CREDIT_CARDS_ENABLED")
 	config.BindEnv("apm_config.obfuscation.credit_cards.luhn", "DD_APM_OBFUSCATION_CREDIT_CARDS_LUHN")
+	config.BindEnvAndSetDefault("apm_config.zipkin.enabled", false, "DD_APM_ZIPKIN_ENABLED")
+	config.BindEnvAndSetDefault("apm_config.jaeger.enabled", false, "DD_APM_JAEGER_ENABLED")
+	config.BindEnvAndSetDefault("apm_config.jaeger.grpc_port", 0, "DD_APM_JAEGER_GRPC_PORT")
+	config.BindEnv("apm_config.extra_aggregation_tags", "DD_APM_EXTRA_AGGREGATION_TAGS")
+	config.BindEnvAndSetDefault("apm_config.tail_sampling.enabled", false, "DD_APM_TAIL_SAMPLING_ENABLED")
+	config.BindEnvAndSetDefault("apm_config.tail_sampling.decision_wait_seconds", 30, "DD_APM_TAIL_SAMPLING_DECISION_WAIT_SECONDS")
+	config.BindEnvAndSetDefault("apm_config.tail_sampling.max_buffer_bytes", 100*1024*1024, "DD_APM_TAIL_SAMPLING_MAX_BUFFER_BYTES")
+	config.BindEnv("apm_config.tail_sampling.policies", "DD_APM_TAIL_SAMPLING_POLICIES")
+	config.BindEnvAndSetDefault("apm_config.extra_aggregation_tags_max_cardinality", 100, "DD_APM_EXTRA_AGGREGATION_TAGS_MAX_CARDINALITY")
 
 	config.SetEnvKeyTransformer("apm_config.ignore_resources", func(in string) interface{} {
 		r, err := splitCSVString(in, ',')
@@ -113,6 +128,8 @@ func setupAPM(config Config) {
 
 	config.SetEnvKeyTransformer("apm_config.filter_tags.reject", parseKVList("apm_config.filter_tags.reject"))
 
+	config.SetEnvKeyTransformer("apm_config.extra_aggregation_tags", parseKVList("apm_config.extra_aggregation_tags"))
+
 	config.SetEnvKeyTransformer("apm_config.replace_tags", func(in string) interface{} {
 		var out []map[string]string
 		if err := json.Unmarshal([]byte(in), &out); err != nil {
@@ -121,6 +138,22 @@ func setupAPM(config Config) {
 		return out
 	})
 
+	config.SetEnvKeyTransformer("apm_config.scrub_rules", func(in string) interface{} {
+		var out []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.scrub_rules" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
+	config.SetEnvKeyTransformer("apm_config.tail_sampling.policies", func(in string) interface{} {
+		var out []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.tail_sampling.policies" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
 	config.SetEnvKeyTransformer("apm_config.analyzed_spans", func(in string) interface{} {
 		out, err := parseAnalyzedSpans(in)
 		if err != nil {
@@ -271,6 +271,13 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("enable_metadata_collection", true)
 	config.BindEnvAndSetDefault("enable_gohai", true)
 	config.BindEnvAndSetDefault("check_runners", int64(4))
+	config.BindEnvAndSetDefault("check_runners_high_priority", 0)
+	config.BindEnvAndSetDefault("check_scheduling_jitter", 0.0)
+	config.BindEnvAndSetDefault("check_max_run_time", 0)
+	config.BindEnvAndSetDefault("check_resource_accounting", false)
+	config.BindEnvAndSetDefault("check_quarantine_threshold", 3)
+	config.BindEnvAndSetDefault("check_quarantine_backoff", 60)
+	config.BindEnvAndSetDefault("check_quarantine_max_backoff", 3600)
 	config.BindEnvAndSetDefault("auth_token_file_path", "")
 	config.BindEnv("bind_host")
 	config.BindEnvAndSetDefault("ipc_address", "localhost")
@@ -497,7 +504,18 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("dogstatsd_queue_size", 1024)
 
 	config.BindEnvAndSetDefault("dogstatsd_non_local_traffic", false)
-	config.BindEnvAndSetDefault("dogstatsd_socket", "") // Notice: empty means feature disabled
+	config.BindEnvAndSetDefault("dogstatsd_socket", "")  // Notice: empty means feature disabled
+	config.BindEnvAndSetDefault("dogstatsd_tcp_port", 0) // Notice: 0 means TCP port closed
+	// Options are: newline, length_prefix
+	config.BindEnvAndSetDefault("dogstatsd_tcp_framing", "newline")
+	// TLS is enabled when a certificate and a key are set, mTLS when a client CA is set as well.
+	config.BindEnvAndSetDefault("dogstatsd_tcp_tls_cert_file", "")
+	config.BindEnvAndSetDefault("dogstatsd_tcp_tls_key_file", "")
+	config.BindEnvAndSetDefault("dogstatsd_tcp_tls_client_ca_file", "")
+	config.BindEnvAndSetDefault("dogstatsd_tcp_origin_detection", false)
+	config.BindEnvAndSetDefault("dogstatsd_tcp_client_tag", "dogstatsd_client") // Notice: empty means the metrics are not tagged with their client
+	config.BindEnvAndSetDefault("dogstatsd_tcp_max_connections", 1000)          // Notice: 0 means unlimited
+	config.BindEnvAndSetDefault("dogstatsd_tcp_idle_timeout", 300)              // in seconds, 0 means no timeout
 	config.BindEnvAndSetDefault("dogstatsd_pipeline_autoadjust", false)
 	config.BindEnvAndSetDefault("dogstatsd_pipeline_count", 1)
 	config.BindEnvAndSetDefault("dogstatsd_stats_port", 5000)
@@ -510,6 +528,13 @@ func InitConfig(config Config) {
 	// is 10s), otherwise we won't be able to sample unseen counter as
 	// contexts will be deleted (see 'dogstatsd_expiry_seconds').
 	config.BindEnvAndSetDefault("dogstatsd_context_expiry_seconds", 300)
+	// Limits on the number of dogstatsd contexts of each metric name (0 means no limit), and on the
+	// number of distinct values of some tag keys for each metric name. Options for the action on the
+	// new contexts over the limits are: drop, overflow
+	config.BindEnvAndSetDefault("dogstatsd_context_limits.per_metric", 0)
+	config.BindEnvAndSetDefault("dogstatsd_context_limits.metrics", map[string]int{})
+	config.BindEnvAndSetDefault("dogstatsd_context_limits.per_tag_key", map[string]int{})
+	config.BindEnvAndSetDefault("dogstatsd_context_limits.action", "drop")
 	config.BindEnvAndSetDefault("dogstatsd_origin_detection", false) // Only supported for socket traffic
 	config.BindEnvAndSetDefault("dogstatsd_origin_detection_client", false)
 	config.BindEnvAndSetDefault("dogstatsd_so_rcvbuf", 0)
@@ -536,6 +561,16 @@ func InitConfig(config Config) {
 		return mappings
 	})
 
+	// Rules dropping and rewriting the metrics of the checks and of DogStatsD before their aggregation
+	config.BindEnvAndSetDefault("metric_relabel_rules", []interface{}{})
+	config.SetEnvKeyTransformer("metric_relabel_rules", func(in string) interface{} {
+		var rules []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"metric_relabel_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
+
 	config.BindEnvAndSetDefault("statsd_forward_host", "")
 	config.BindEnvAndSetDefault("statsd_forward_port", 0)
 	config.BindEnvAndSetDefault("statsd_metric_namespace", "")
@@ -618,7 +653,19 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("prometheus_scrape.service_endpoints", false) // Enables Service Endpoints checks in the prometheus config provider
 	config.BindEnv("prometheus_scrape.checks")                                // Defines any extra prometheus/openmetrics check configurations to be handled by the prometheus config provider
 	config.SetEnvKeyTransformer("prometheus_scrape.checks", prometheusScrapeChecksTransformer)
-	config.BindEnvAndSetDefault("prometheus_scrape.version", 1) // Version of the openmetrics check to be scheduled by the Prometheus auto-discovery
+	config.BindEnvAndSetDefault("prometheus_scrape.version", 1)            // Version of the openmetrics check to be scheduled by the Prometheus auto-discovery
+	config.BindEnvAndSetDefault("prometheus_scrape.use_core_check", false) // Schedules the Go openmetrics core check instead of the Python one
+
+	// Prometheus remote-write receiver
+	config.BindEnvAndSetDefault("prometheus_remote_write.enabled", false)
+	config.BindEnvAndSetDefault("prometheus_remote_write.port", 9201)
+	config.BindEnv("prometheus_remote_write.bind_host")
+	config.BindEnvAndSetDefault("prometheus_remote_write.path", "/api/v1/write")
+	config.BindEnvAndSetDefault("prometheus_remote_write.namespace", "")
+	config.BindEnvAndSetDefault("prometheus_remote_write.labels_allowlist", []string{})
+	config.BindEnvAndSetDefault("prometheus_remote_write.labels_mapper", map[string]string{})
+	config.BindEnvAndSetDefault("prometheus_remote_write.tags", []string{})
+	config.BindEnvAndSetDefault("prometheus_remote_write.max_request_bytes", 10*1024*1024)
 
 	// Network Devices Monitoring
 	bindEnvAndSetLogsConfigKeys(config, "network_devices.metadata.")
@@ -809,6 +856,16 @@ func InitConfig(config Config) {
 	// This field lets you increase the read timeout to prevent the client from
 	// timing out too early in such a situation. Value in seconds.
 	config.BindEnvAndSetDefault("logs_config.docker_client_read_timeout", 30)
+	// Disk spool of the payloads that cannot be sent, disabled when its maximum size is 0.
+	// Its path defaults to `<logs_config.run_path>/spool`.
+	config.BindEnvAndSetDefault("logs_config.spool_max_size_in_bytes", 0)
+	config.BindEnvAndSetDefault("logs_config.spool_path", "")
+	// Collect the audit log of the Kubernetes API server, on the control plane nodes.
+	config.BindEnvAndSetDefault("logs_config.kubernetes_audit.enabled", false)
+	config.BindEnvAndSetDefault("logs_config.kubernetes_audit.path", "/var/log/kubernetes/audit/audit.log")
+	// Collect the events of the local containerd, optionally filtered, e.g. `topic~="/tasks/"`.
+	config.BindEnvAndSetDefault("logs_config.containerd_events.enabled", false)
+	config.BindEnvAndSetDefault("logs_config.containerd_events.filter", "")
 	// Internal Use Only: avoid modifying those configuration parameters, this could lead to unexpected results.
 	config.BindEnvAndSetDefault("logs_config.run_path", defaultRunPath)
 	// DEPRECATED in favor of `logs_config.force_use_http`.
@@ -35,6 +35,9 @@ type batcher struct {
 	tagsBuffer    *tagset.HashingTagsAccumulator
 	keyGenerator  *ckey.KeyGenerator
 	pipelineCount int
+	// relabeler rewrites the tags of the samples like the metric relabel rules before they
+	// are sharded, so that they are sharded on the context they are aggregated in.
+	relabeler *aggregator.TagsRelabeler
 }
 
 // Use fastrange instead of a modulo for better performance.
@@ -79,6 +82,7 @@ func newBatcher(demux aggregator.DemultiplexerWithAggregator) *batcher {
 		pipelineCount: pipelineCount,
 		tagsBuffer:    tagset.NewHashingTagsAccumulator(),
 		keyGenerator:  ckey.NewKeyGenerator(),
+		relabeler:     demux.Aggregator().NewTagsRelabeler(),
 	}
 }
 
@@ -111,6 +115,9 @@ func (b *batcher) appendSample(sample metrics.MetricSample) {
 		// it in the sample?) would reduce CPU usage, avoiding to recompute
 		// the tags hashes while generating the context key.
 		b.tagsBuffer.Append(sample.Tags...)
+		if b.relabeler != nil {
+			b.relabeler.RelabelTags(sample.Name, b.tagsBuffer)
+		}
 		h := b.keyGenerator.Generate(sample.Name, sample.Host, b.tagsBuffer)
 		b.tagsBuffer.Reset()
 		shardKey = fastrange(h, b.pipelineCount)
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestBatcherShardsRelabeledSamples(t *testing.T) {
+	defer config.Datadog.Set("dogstatsd_pipeline_autoadjust", config.Datadog.GetBool("dogstatsd_pipeline_autoadjust"))
+	defer config.Datadog.Set("dogstatsd_pipeline_count", config.Datadog.GetInt("dogstatsd_pipeline_count"))
+	defer config.Datadog.Set("metric_relabel_rules", []interface{}{})
+	config.Datadog.Set("dogstatsd_pipeline_autoadjust", false)
+	config.Datadog.Set("dogstatsd_pipeline_count", 4)
+	config.Datadog.Set("metric_relabel_rules", []interface{}{
+		map[string]interface{}{"match": "requests", "drop_tags": []interface{}{"user"}},
+	})
+
+	demux := mockDemultiplexer()
+	defer demux.Stop(false)
+	b := newBatcher(demux.(aggregator.DemultiplexerWithAggregator))
+	require.Len(t, b.samplesCount, 4)
+
+	shards := func(name string) []int {
+		var used []int
+		for i := 0; i < 20; i++ {
+			b.appendSample(metrics.MetricSample{
+				Name:  name,
+				Value: 1,
+				Mtype: metrics.CounterType,
+				Tags:  []string{"env:prod", fmt.Sprintf("user:%d", i)},
+			})
+		}
+		for shard, count := range b.samplesCount {
+			if count > 0 {
+				used = append(used, shard)
+			}
+			b.samplesCount[shard] = 0
+		}
+		return used
+	}
+
+	// the samples whose contexts only differ by a dropped tag are aggregated by the same time sampler
+	assert.Len(t, shards("requests"), 1)
+	// the other samples are spread between the time samplers
+	assert.Greater(t, len(shards("errors")), 1)
+}
@@ -0,0 +1,389 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// TCPFramingNewline is the framing of the TCP streams whose messages are separated by newlines.
+	TCPFramingNewline = "newline"
+	// TCPFramingLengthPrefix is the framing of the TCP streams whose frames are prefixed with their
+	// length, as a 32 bits little-endian integer. A frame may contain several newline separated messages.
+	TCPFramingLengthPrefix = "length_prefix"
+
+	// TCPOriginPrefix prefixes the origin of the packets read on a TCP connection.
+	TCPOriginPrefix = "tcp://"
+	// tcpAcceptRetryDelay is the delay before accepting connections again after an error.
+	tcpAcceptRetryDelay = 100 * time.Millisecond
+)
+
+var (
+	errTCPListenerStopped  = errors.New("the listener is stopped")
+	errTCPTooManyConns     = errors.New("too many connections")
+	tcpExpvars             = expvar.NewMap("dogstatsd-tcp")
+	tcpConnectionErrors    = expvar.Int{}
+	tcpConnections         = expvar.Int{}
+	tcpPacketReadingErrors = expvar.Int{}
+	tcpPackets             = expvar.Int{}
+	tcpBytes               = expvar.Int{}
+)
+
+func init() {
+	tcpExpvars.Set("ConnectionErrors", &tcpConnectionErrors)
+	tcpExpvars.Set("Connections", &tcpConnections)
+	tcpExpvars.Set("PacketReadingErrors", &tcpPacketReadingErrors)
+	tcpExpvars.Set("Packets", &tcpPackets)
+	tcpExpvars.Set("Bytes", &tcpBytes)
+}
+
+// TCPListener implements the StatsdListener interface for TCP protocol,
+// optionally over TLS. It accepts connections on a given TCP address and
+// sends back packets ready to be processed.
+// The messages of each connection are framed either by newlines or by a
+// length prefix. If origin detection is enabled, or if the client tag is
+// set, the packets of each connection have the client certificate common
+// name or the client IP address as origin.
+// The connections beyond the maximum connection count are closed, as are
+// the connections idle for longer than the idle timeout.
+type TCPListener struct {
+	listener                net.Listener
+	packetsBuffer           *packets.Buffer
+	sharedPacketPoolManager *packets.PoolManager
+	flushTimeout            time.Duration
+	bufferSize              int
+	framing                 string
+	originDetection         bool
+	maxConns                int
+	idleTimeout             time.Duration
+	trafficCapture          *replay.TrafficCapture // Currently ignored
+
+	mu      sync.Mutex
+	conns   map[net.Conn]struct{}
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewTCPListener returns an idle TCP Statsd listener
+func NewTCPListener(packetOut chan packets.Packets, sharedPacketPoolManager *packets.PoolManager, capture *replay.TrafficCapture) (*TCPListener, error) {
+	var url string
+
+	if config.Datadog.GetBool("dogstatsd_non_local_traffic") == true {
+		// Listen to all network interfaces
+		url = fmt.Sprintf(":%d", config.Datadog.GetInt("dogstatsd_tcp_port"))
+	} else {
+		url = net.JoinHostPort(config.GetBindHost(), config.Datadog.GetString("dogstatsd_tcp_port"))
+	}
+
+	framing := config.Datadog.GetString("dogstatsd_tcp_framing")
+	if framing != TCPFramingNewline && framing != TCPFramingLengthPrefix {
+		return nil, fmt.Errorf("dogstatsd-tcp: unknown framing %q, expected %q or %q", framing, TCPFramingNewline, TCPFramingLengthPrefix)
+	}
+
+	tlsConfig, err := tcpTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("dogstatsd-tcp: %s", err)
+	}
+
+	listener, err := net.Listen("tcp", url)
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	flushTimeout := config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout")
+	packetsBuffer := packets.NewBuffer(uint(config.Datadog.GetInt("dogstatsd_packet_buffer_size")), flushTimeout, packetOut)
+
+	l := &TCPListener{
+		listener:                listener,
+		packetsBuffer:           packetsBuffer,
+		sharedPacketPoolManager: sharedPacketPoolManager,
+		flushTimeout:            flushTimeout,
+		bufferSize:              config.Datadog.GetInt("dogstatsd_buffer_size"),
+		framing:                 framing,
+		originDetection:         config.Datadog.GetBool("dogstatsd_tcp_origin_detection"),
+		maxConns:                config.Datadog.GetInt("dogstatsd_tcp_max_connections"),
+		idleTimeout:             config.Datadog.GetDuration("dogstatsd_tcp_idle_timeout") * time.Second,
+		trafficCapture:          capture,
+		conns:                   make(map[net.Conn]struct{}),
+	}
+	log.Debugf("dogstatsd-tcp: %s successfully initialized (framing: %s, tls: %t)", listener.Addr(), framing, tlsConfig != nil)
+	return l, nil
+}
+
+// tcpTLSConfig returns the TLS configuration of the TCP listener, or nil if TLS is disabled.
+// The client certificates are required and verified if a client CA file is configured.
+func tcpTLSConfig() (*tls.Config, error) {
+	certFile := config.Datadog.GetString("dogstatsd_tcp_tls_cert_file")
+	keyFile := config.Datadog.GetString("dogstatsd_tcp_tls_key_file")
+	clientCAFile := config.Datadog.GetString("dogstatsd_tcp_tls_client_ca_file")
+	if certFile == "" && keyFile == "" {
+		if clientCAFile != "" {
+			return nil, errors.New("a client CA requires a TLS certificate and key")
+		}
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("can't load the TLS certificate: %s", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile != "" {
+		pem, err := ioutil.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read the client CA: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in the client CA file %s", clientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// Listen runs the intake loop. Should be called in its own goroutine
+func (l *TCPListener) Listen() {
+	log.Infof("dogstatsd-tcp: starting to listen on %s", l.listener.Addr())
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			// listener has been closed
+			if strings.HasSuffix(err.Error(), " use of closed network connection") {
+				return
+			}
+
+			log.Errorf("dogstatsd-tcp: error accepting connection: %v", err)
+			tcpConnectionErrors.Add(1)
+			tlmTCPConnectionErrors.Inc()
+			time.Sleep(tcpAcceptRetryDelay)
+			continue
+		}
+		if err := l.track(conn); err != nil {
+			conn.Close()
+			if err == errTCPListenerStopped {
+				return
+			}
+			log.Warnf("dogstatsd-tcp: refusing the connection from %s: %v (max: %d)", conn.RemoteAddr(), err, l.maxConns)
+			tcpConnectionErrors.Add(1)
+			tlmTCPConnectionErrors.Inc()
+			continue
+		}
+		go l.handleConnection(conn)
+	}
+}
+
+// track registers the connection, unless the listener is stopped or already has the maximum
+// number of connections.
+func (l *TCPListener) track(conn net.Conn) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopped {
+		return errTCPListenerStopped
+	}
+	if l.maxConns > 0 && len(l.conns) >= l.maxConns {
+		return errTCPTooManyConns
+	}
+	l.conns[conn] = struct{}{}
+	l.wg.Add(1)
+	tcpConnections.Add(1)
+	tlmTCPConnections.Inc()
+	return nil
+}
+
+// untrack closes and unregisters the connection.
+func (l *TCPListener) untrack(conn net.Conn) {
+	conn.Close()
+	l.mu.Lock()
+	delete(l.conns, conn)
+	l.mu.Unlock()
+	tcpConnections.Add(-1)
+	tlmTCPConnections.Dec()
+	l.wg.Done()
+}
+
+func (l *TCPListener) handleConnection(conn net.Conn) {
+	defer l.untrack(conn)
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		l.extendDeadline(conn)
+		if err := tlsConn.Handshake(); err != nil {
+			log.Warnf("dogstatsd-tcp: TLS handshake with %s failed: %v", conn.RemoteAddr(), err)
+			tcpConnectionErrors.Add(1)
+			tlmTCPConnectionErrors.Inc()
+			return
+		}
+	}
+
+	origin := packets.NoOrigin
+	if l.originDetection {
+		origin = tcpOrigin(conn)
+	}
+	// the packets of the connection are assembled separately, as they share its origin
+	assembler := packets.NewAssemblerWithOrigin(l.flushTimeout, l.packetsBuffer, l.sharedPacketPoolManager, packets.TCP, origin)
+	defer func() {
+		assembler.Flush()
+		assembler.Close()
+	}()
+
+	var err error
+	r := bufio.NewReaderSize(&idleReader{listener: l, conn: conn}, l.bufferSize)
+	if l.framing == TCPFramingLengthPrefix {
+		err = l.readFrames(r, assembler)
+	} else {
+		err = l.readLines(r, assembler)
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		log.Debugf("dogstatsd-tcp: closing the connection from %s, idle for %s", conn.RemoteAddr(), l.idleTimeout)
+		return
+	}
+	// the connection is closed by the client, or the listener is stopped
+	if err != nil && err != io.EOF && !strings.HasSuffix(err.Error(), " use of closed network connection") {
+		log.Errorf("dogstatsd-tcp: error reading from %s: %v", conn.RemoteAddr(), err)
+		tcpPacketReadingErrors.Add(1)
+		tlmTCPPackets.Inc("error")
+	}
+}
+
+// extendDeadline postpones the read deadline of conn by the idle timeout, if any.
+func (l *TCPListener) extendDeadline(conn net.Conn) {
+	if l.idleTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(l.idleTimeout)) //nolint:errcheck
+	}
+}
+
+// idleReader reads from a connection, whose read deadline is extended before each read.
+type idleReader struct {
+	listener *TCPListener
+	conn     net.Conn
+}
+
+func (r *idleReader) Read(b []byte) (int, error) {
+	r.listener.extendDeadline(r.conn)
+	return r.conn.Read(b)
+}
+
+// readLines reads the newline separated messages of r. The messages larger than the buffer are dropped.
+func (l *TCPListener) readLines(r *bufio.Reader, assembler *packets.Assembler) error {
+	truncated := false // true if the end of a dropped message is being read
+	for {
+		line, err := r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			if !truncated {
+				log.Debugf("dogstatsd-tcp: dropping a message larger than %d bytes", l.bufferSize)
+				tcpPacketReadingErrors.Add(1)
+				tlmTCPPackets.Inc("error")
+			}
+			truncated = true
+			continue
+		}
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return err
+		}
+		if truncated {
+			truncated = false
+			continue
+		}
+		l.addMessage(assembler, bytes.TrimRight(line, "\r\n"))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readFrames reads the length prefixed frames of r. The frames larger than the buffer are dropped.
+func (l *TCPListener) readFrames(r *bufio.Reader, assembler *packets.Assembler) error {
+	var size [4]byte
+	frame := make([]byte, l.bufferSize)
+	for {
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return err
+		}
+		n := int64(binary.LittleEndian.Uint32(size[:]))
+		if n > int64(len(frame)) {
+			log.Debugf("dogstatsd-tcp: dropping a frame of %d bytes, larger than %d bytes", n, len(frame))
+			tcpPacketReadingErrors.Add(1)
+			tlmTCPPackets.Inc("error")
+			if _, err := io.CopyN(ioutil.Discard, r, n); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := io.ReadFull(r, frame[:n]); err != nil {
+			return err
+		}
+		l.addMessage(assembler, frame[:n])
+	}
+}
+
+func (l *TCPListener) addMessage(assembler *packets.Assembler, message []byte) {
+	if len(message) == 0 {
+		return
+	}
+	t1 := time.Now()
+	tcpPackets.Add(1)
+	tlmTCPPackets.Inc("ok")
+	tcpBytes.Add(int64(len(message)))
+	tlmTCPPacketsBytes.Add(float64(len(message)))
+
+	// packetAssembler merges multiple packets together and sends them when its buffer is full
+	assembler.AddMessage(message)
+
+	tlmListener.Observe(float64(time.Since(t1).Nanoseconds()), "tcp")
+}
+
+// tcpOrigin returns the origin of the packets read on conn: the common name of the client
+// certificate if any, the IP address of the client otherwise.
+func tcpOrigin(conn net.Conn) string {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 && certs[0].Subject.CommonName != "" {
+			return TCPOriginPrefix + certs[0].Subject.CommonName
+		}
+	}
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return packets.NoOrigin
+	}
+	return TCPOriginPrefix + host
+}
+
+// Stop closes the TCP listener and its connections, and stops listening
+func (l *TCPListener) Stop() {
+	l.mu.Lock()
+	l.stopped = true
+	l.listener.Close()
+	for conn := range l.conns {
+		conn.Close()
+	}
+	l.mu.Unlock()
+	l.wg.Wait()
+	l.packetsBuffer.Close()
+}
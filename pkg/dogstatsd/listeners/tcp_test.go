@@ -0,0 +1,328 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+)
+
+var (
+	packetPoolTCP        = packets.NewPool(config.Datadog.GetInt("dogstatsd_buffer_size"))
+	packetPoolManagerTCP = packets.NewPoolManager(packetPoolTCP)
+)
+
+// setTCPConfig sets the TCP listener configuration for the duration of the test, on an available port.
+func setTCPConfig(t *testing.T, settings map[string]interface{}) int {
+	port, err := getAvailableTCPPort()
+	require.NoError(t, err)
+	settings["dogstatsd_tcp_port"] = port
+	settings["dogstatsd_non_local_traffic"] = false
+	for k, v := range settings {
+		prev := config.Datadog.Get(k)
+		config.Datadog.SetDefault(k, v)
+		k := k
+		t.Cleanup(func() { config.Datadog.SetDefault(k, prev) })
+	}
+	return port
+}
+
+func startTCPListener(t *testing.T) (*TCPListener, chan packets.Packets) {
+	packetChannel := make(chan packets.Packets, 16)
+	s, err := NewTCPListener(packetChannel, packetPoolManagerTCP, nil)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	go s.Listen()
+	t.Cleanup(s.Stop)
+	return s, packetChannel
+}
+
+func receivePacket(t *testing.T, packetChannel chan packets.Packets) *packets.Packet {
+	select {
+	case pkts := <-packetChannel:
+		require.Len(t, pkts, 1)
+		return pkts[0]
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "Timeout on receive channel")
+	}
+	return nil
+}
+
+func TestStartStopTCPListener(t *testing.T) {
+	port := setTCPConfig(t, map[string]interface{}{})
+	s, err := NewTCPListener(nil, packetPoolManagerTCP, nil)
+	require.NoError(t, err)
+	go s.Listen()
+
+	// Local port should be unavailable
+	_, err = net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	assert.Error(t, err)
+
+	// open connections do not prevent the listener from stopping
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer conn.Close()
+	s.Stop()
+
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err, "port is not available, it should be")
+	ln.Close()
+}
+
+func TestNewTCPListenerInvalidConfig(t *testing.T) {
+	setTCPConfig(t, map[string]interface{}{"dogstatsd_tcp_framing": "json"})
+	_, err := NewTCPListener(nil, packetPoolManagerTCP, nil)
+	assert.Error(t, err)
+
+	setTCPConfig(t, map[string]interface{}{
+		"dogstatsd_tcp_framing":            TCPFramingNewline,
+		"dogstatsd_tcp_tls_client_ca_file": "/etc/ca.pem",
+	})
+	_, err = NewTCPListener(nil, packetPoolManagerTCP, nil)
+	assert.Error(t, err)
+}
+
+func TestTCPReceiveNewline(t *testing.T) {
+	port := setTCPConfig(t, map[string]interface{}{"dogstatsd_tcp_framing": TCPFramingNewline})
+	_, packetChannel := startTCPListener(t)
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	// the messages larger than the buffer are dropped, and the last message may not be terminated
+	tooLarge := bytes.Repeat([]byte("x"), config.Datadog.GetInt("dogstatsd_buffer_size")+10)
+	conn.Write([]byte("daemon:666|g|#sometag1:somevalue1\r\n"))
+	conn.Write(append(tooLarge, '\n'))
+	conn.Write([]byte("\ndaemon:777|g"))
+	conn.Close()
+
+	packet := receivePacket(t, packetChannel)
+	assert.Equal(t, []byte("daemon:666|g|#sometag1:somevalue1\ndaemon:777|g"), packet.Contents)
+	assert.Equal(t, packets.NoOrigin, packet.Origin)
+	assert.Equal(t, packets.TCP, packet.Source)
+}
+
+func TestTCPReceiveLengthPrefix(t *testing.T) {
+	port := setTCPConfig(t, map[string]interface{}{"dogstatsd_tcp_framing": TCPFramingLengthPrefix})
+	_, packetChannel := startTCPListener(t)
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	frame := func(b []byte) []byte {
+		size := make([]byte, 4)
+		binary.LittleEndian.PutUint32(size, uint32(len(b)))
+		return append(size, b...)
+	}
+	tooLarge := bytes.Repeat([]byte("x"), config.Datadog.GetInt("dogstatsd_buffer_size")+10)
+	conn.Write(frame([]byte("daemon:666|g\ndaemon:777|g")))
+	conn.Write(frame(tooLarge))
+	conn.Write(frame([]byte("daemon:888|c")))
+	conn.Close()
+
+	packet := receivePacket(t, packetChannel)
+	assert.Equal(t, []byte("daemon:666|g\ndaemon:777|g\ndaemon:888|c"), packet.Contents)
+	assert.Equal(t, packets.TCP, packet.Source)
+}
+
+func TestTCPOriginDetection(t *testing.T) {
+	port := setTCPConfig(t, map[string]interface{}{
+		"dogstatsd_tcp_framing":          TCPFramingNewline,
+		"dogstatsd_tcp_origin_detection": true,
+	})
+	_, packetChannel := startTCPListener(t)
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	conn.Write([]byte("daemon:666|g\n"))
+	conn.Close()
+
+	packet := receivePacket(t, packetChannel)
+	assert.Equal(t, []byte("daemon:666|g"), packet.Contents)
+	assert.Equal(t, "tcp://127.0.0.1", packet.Origin)
+}
+
+func TestTCPMaxConnections(t *testing.T) {
+	port := setTCPConfig(t, map[string]interface{}{
+		"dogstatsd_tcp_framing":         TCPFramingNewline,
+		"dogstatsd_tcp_max_connections": 1,
+	})
+	_, packetChannel := startTCPListener(t)
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.Write([]byte("daemon:666|g\n"))
+
+	// the connections beyond the first one are closed
+	refused, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer refused.Close()
+	refused.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = refused.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err)
+
+	conn.Write([]byte("daemon:777|g\n"))
+	conn.Close()
+	packet := receivePacket(t, packetChannel)
+	assert.Equal(t, []byte("daemon:666|g\ndaemon:777|g"), packet.Contents)
+}
+
+func TestTCPIdleTimeout(t *testing.T) {
+	port := setTCPConfig(t, map[string]interface{}{
+		"dogstatsd_tcp_framing":      TCPFramingNewline,
+		"dogstatsd_tcp_idle_timeout": 1,
+	})
+	startTCPListener(t)
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.Write([]byte("daemon:666|g\n"))
+
+	// the connection is closed once idle for a second
+	start := time.Now()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err)
+	assert.True(t, time.Since(start) >= 900*time.Millisecond, "the connection was closed before it was idle")
+}
+
+func TestTCPMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newTestCertificate(t, nil, nil, "ca", true)
+	serverCert, serverKey := newTestCertificate(t, ca, caKey, "127.0.0.1", false)
+	clientCert, clientKey := newTestCertificate(t, ca, caKey, "client-1", false)
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", ca.Raw)
+	writePEM(t, filepath.Join(dir, "server.pem"), "CERTIFICATE", serverCert.Raw)
+	writePEM(t, filepath.Join(dir, "server.key"), "EC PRIVATE KEY", marshalKey(t, serverKey))
+
+	port := setTCPConfig(t, map[string]interface{}{
+		"dogstatsd_tcp_framing":            TCPFramingNewline,
+		"dogstatsd_tcp_origin_detection":   true,
+		"dogstatsd_tcp_tls_cert_file":      filepath.Join(dir, "server.pem"),
+		"dogstatsd_tcp_tls_key_file":       filepath.Join(dir, "server.key"),
+		"dogstatsd_tcp_tls_client_ca_file": filepath.Join(dir, "ca.pem"),
+	})
+	_, packetChannel := startTCPListener(t)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+
+	t.Run("client certificate", func(t *testing.T) {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{
+			RootCAs: roots,
+			Certificates: []tls.Certificate{{
+				Certificate: [][]byte{clientCert.Raw},
+				PrivateKey:  clientKey,
+			}},
+		})
+		require.NoError(t, err)
+		conn.Write([]byte("daemon:666|g\n"))
+		conn.Close()
+
+		packet := receivePacket(t, packetChannel)
+		assert.Equal(t, []byte("daemon:666|g"), packet.Contents)
+		assert.Equal(t, "tcp://client-1", packet.Origin)
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots})
+		if err == nil {
+			// with TLS 1.3, the client learns that its certificate was rejected on its first read
+			conn.Write([]byte("daemon:666|g\n"))
+			_, err = conn.Read(make([]byte, 1))
+			conn.Close()
+		}
+		assert.Error(t, err)
+
+		select {
+		case <-packetChannel:
+			assert.Fail(t, "the packets of unauthenticated clients should be rejected")
+		case <-time.After(300 * time.Millisecond):
+		}
+	})
+}
+
+// newTestCertificate returns a new certificate with the given common name, signed by parent, or
+// self-signed if parent is nil.
+func newTestCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, cn string, isCA bool) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if ip := net.ParseIP(cn); ip != nil {
+		tmpl.IPAddresses = []net.IP{ip}
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func marshalKey(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return der
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600))
+}
+
+// getAvailableTCPPort requests a random port number and makes sure it is available
+func getAvailableTCPPort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return -1, fmt.Errorf("can't find an available tcp port: %s", err)
+	}
+	defer ln.Close()
+
+	_, portString, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		return -1, fmt.Errorf("can't find an available tcp port: %s", err)
+	}
+	portInt, err := strconv.Atoi(portString)
+	if err != nil {
+		return -1, fmt.Errorf("can't convert tcp port: %s", err)
+	}
+
+	return portInt, nil
+}
@@ -24,6 +24,16 @@ var (
 	tlmUDSPacketsBytes = telemetry.NewCounter("dogstatsd", "uds_packets_bytes",
 		nil, "Dogstatsd UDS packets bytes")
 
+	// TCP
+	tlmTCPPackets = telemetry.NewCounter("dogstatsd", "tcp_packets",
+		[]string{"state"}, "Dogstatsd TCP packets count")
+	tlmTCPPacketsBytes = telemetry.NewCounter("dogstatsd", "tcp_packets_bytes",
+		nil, "Dogstatsd TCP packets bytes count")
+	tlmTCPConnections = telemetry.NewGauge("dogstatsd", "tcp_connections",
+		nil, "Dogstatsd TCP open connections")
+	tlmTCPConnectionErrors = telemetry.NewCounter("dogstatsd", "tcp_connection_errors",
+		nil, "Dogstatsd TCP connection errors count, including TLS handshake failures")
+
 	tlmListener            = telemetry.NewHistogramNoOp()
 	defaultListenerBuckets = []float64{300, 500, 1000, 1500, 2000, 2500, 3000, 10000, 20000, 50000}
 )
@@ -23,11 +23,17 @@ type Assembler struct {
 	flushTimer              *time.Ticker
 	closeChannel            chan struct{}
 	packetSourceType        SourceType
+	packetOrigin            string
 	sync.Mutex
 }
 
 // NewAssembler creates a new Assembler instance using the specified flush duration, buffer and pool manager
 func NewAssembler(flushTimer time.Duration, packetsBuffer *Buffer, sharedPacketPoolManager *PoolManager, packetSourceType SourceType) *Assembler {
+	return NewAssemblerWithOrigin(flushTimer, packetsBuffer, sharedPacketPoolManager, packetSourceType, NoOrigin)
+}
+
+// NewAssemblerWithOrigin creates a new Assembler instance like NewAssembler, whose packets have the given origin
+func NewAssemblerWithOrigin(flushTimer time.Duration, packetsBuffer *Buffer, sharedPacketPoolManager *PoolManager, packetSourceType SourceType, packetOrigin string) *Assembler {
 	packetAssembler := &Assembler{
 		// retrieve an available packet from the packet pool,
 		// which will be pushed back by the server when processed.
@@ -36,6 +42,7 @@ func NewAssembler(flushTimer time.Duration, packetsBuffer *Buffer, sharedPacketP
 		packetsBuffer:           packetsBuffer,
 		flushTimer:              time.NewTicker(flushTimer),
 		packetSourceType:        packetSourceType,
+		packetOrigin:            packetOrigin,
 		closeChannel:            make(chan struct{}),
 	}
 	go packetAssembler.flushLoop()
@@ -77,6 +84,7 @@ func (p *Assembler) flush() {
 	}
 	p.packet.Contents = p.packet.Buffer[:p.packetLength]
 	p.packet.Source = p.packetSourceType
+	p.packet.Origin = p.packetOrigin
 	p.packetsBuffer.Append(p.packet)
 	// retrieve an available packet from the packet pool,
 	// which will be pushed back by the server when processed.
@@ -84,6 +92,13 @@ func (p *Assembler) flush() {
 	p.packetLength = 0
 }
 
+// Flush sends the messages added since the last flush
+func (p *Assembler) Flush() {
+	p.Lock()
+	p.flush()
+	p.Unlock()
+}
+
 // Close closes the packet assembler
 func (p *Assembler) Close() {
 	p.Lock()
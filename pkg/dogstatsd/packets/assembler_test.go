@@ -133,6 +133,21 @@ func TestPacketBufferHasCorrectSource(t *testing.T) {
 	assert.Equal(t, UDP, packets[0].Source)
 }
 
+func TestPacketBufferHasCorrectOrigin(t *testing.T) {
+	out := make(chan Packets, 16)
+	psb := NewBuffer(1, 1*time.Hour, out)
+	pb := NewAssemblerWithOrigin(1*time.Hour, psb, NewPoolManager(NewPool(sampleBatchSize)), TCP, "tcp://10.0.0.1")
+	defer pb.Close()
+
+	pb.AddMessage([]byte("test"))
+	pb.Flush()
+
+	packets := <-out
+	assert.Equal(t, TCP, packets[0].Source)
+	assert.Equal(t, "tcp://10.0.0.1", packets[0].Origin)
+	assert.Equal(t, []byte("test"), packets[0].Contents)
+}
+
 func TestPacketBufferEmptySecond(t *testing.T) {
 	pb, out := buildPacketAssembler()
 	message1 := []byte("test1")
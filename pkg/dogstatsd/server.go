@@ -143,6 +143,7 @@ type Server struct {
 	histToDist                bool
 	histToDistPrefix          string
 	extraTags                 []string
+	tcpClientTag              string
 	Debug                     *dsdServerDebug
 	debugTagsAccumulator      *tagset.HashingTagsAccumulator
 	TCapture                  *replay.TrafficCapture
@@ -219,7 +220,7 @@ func NewServer(demultiplexer aggregator.Demultiplexer, serverless bool) (*Server
 	}
 
 	packetsChannel := make(chan packets.Packets, config.Datadog.GetInt("dogstatsd_queue_size"))
-	tmpListeners := make([]listeners.StatsdListener, 0, 2)
+	tmpListeners := make([]listeners.StatsdListener, 0, 3)
 	capture, err := replay.NewTrafficCapture()
 	if err != nil {
 		return nil, err
@@ -251,6 +252,15 @@ func NewServer(demultiplexer aggregator.Demultiplexer, serverless bool) (*Server
 		}
 	}
 
+	if config.Datadog.GetInt("dogstatsd_tcp_port") > 0 {
+		tcpListener, err := listeners.NewTCPListener(packetsChannel, sharedPacketPoolManager, capture)
+		if err != nil {
+			log.Errorf(err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, tcpListener)
+		}
+	}
+
 	pipeName := config.Datadog.GetString("dogstatsd_pipe_name")
 	if len(pipeName) > 0 {
 		namedPipeListener, err := listeners.NewNamedPipeListener(pipeName, packetsChannel, sharedPacketPoolManager, capture)
@@ -329,6 +339,7 @@ func NewServer(demultiplexer aggregator.Demultiplexer, serverless bool) (*Server
 		histToDist:                histToDist,
 		histToDistPrefix:          histToDistPrefix,
 		extraTags:                 extraTags,
+		tcpClientTag:              config.Datadog.GetString("dogstatsd_tcp_client_tag"),
 		eolTerminationUDP:         eolTerminationUDP,
 		eolTerminationUDS:         eolTerminationUDS,
 		eolTerminationNamedPipe:   eolTerminationNamedPipe,
@@ -650,7 +661,8 @@ func (s *Server) parseMetricMessage(metricSamples []metrics.MetricSample, parser
 			sample.tags = append(sample.tags, mapResult.Tags...)
 		}
 	}
-	metricSamples = enrichMetricSample(metricSamples, sample, s.metricPrefix, s.metricPrefixBlacklist, s.metricBlocklist, s.defaultHostname, origin, s.entityIDPrecedenceEnabled, s.ServerlessMode)
+	entityOrigin, clientTag := s.splitOrigin(origin)
+	metricSamples = enrichMetricSample(metricSamples, sample, s.metricPrefix, s.metricPrefixBlacklist, s.metricBlocklist, s.defaultHostname, entityOrigin, s.entityIDPrecedenceEnabled, s.ServerlessMode)
 
 	if len(sample.values) > 0 {
 		s.sharedFloat64List.put(sample.values)
@@ -661,6 +673,9 @@ func (s *Server) parseMetricMessage(metricSamples []metrics.MetricSample, parser
 		// extends the first one and reuse it for the rest.
 		if idx == 0 {
 			metricSamples[idx].Tags = append(metricSamples[idx].Tags, s.extraTags...)
+			if clientTag != "" {
+				metricSamples[idx].Tags = append(metricSamples[idx].Tags, clientTag)
+			}
 		} else {
 			metricSamples[idx].Tags = metricSamples[0].Tags
 		}
@@ -677,8 +692,12 @@ func (s *Server) parseEventMessage(parser *parser, message []byte, origin string
 		tlmProcessed.Inc("events", "error", "")
 		return nil, err
 	}
-	event := enrichEvent(sample, s.defaultHostname, origin, s.entityIDPrecedenceEnabled)
+	entityOrigin, clientTag := s.splitOrigin(origin)
+	event := enrichEvent(sample, s.defaultHostname, entityOrigin, s.entityIDPrecedenceEnabled)
 	event.Tags = append(event.Tags, s.extraTags...)
+	if clientTag != "" {
+		event.Tags = append(event.Tags, clientTag)
+	}
 	tlmProcessed.Inc("events", "ok", "")
 	dogstatsdEventPackets.Add(1)
 	return event, nil
@@ -691,13 +710,30 @@ func (s *Server) parseServiceCheckMessage(parser *parser, message []byte, origin
 		tlmProcessed.Inc("service_checks", "error", "")
 		return nil, err
 	}
-	serviceCheck := enrichServiceCheck(sample, s.defaultHostname, origin, s.entityIDPrecedenceEnabled)
+	entityOrigin, clientTag := s.splitOrigin(origin)
+	serviceCheck := enrichServiceCheck(sample, s.defaultHostname, entityOrigin, s.entityIDPrecedenceEnabled)
 	serviceCheck.Tags = append(serviceCheck.Tags, s.extraTags...)
+	if clientTag != "" {
+		serviceCheck.Tags = append(serviceCheck.Tags, clientTag)
+	}
 	dogstatsdServiceCheckPackets.Add(1)
 	tlmProcessed.Inc("service_checks", "ok", "")
 	return serviceCheck, nil
 }
 
+// splitOrigin returns the origin of a message to look up in the tagger, and the client tag of
+// the message, if any. The origins of the TCP connections, only set when the TCP origin detection
+// is enabled, are unknown to the tagger: they become the client tag instead.
+func (s *Server) splitOrigin(origin string) (string, string) {
+	if !strings.HasPrefix(origin, listeners.TCPOriginPrefix) {
+		return origin, ""
+	}
+	if s.tcpClientTag == "" {
+		return packets.NoOrigin, ""
+	}
+	return packets.NoOrigin, s.tcpClientTag + ":" + strings.TrimPrefix(origin, listeners.TCPOriginPrefix)
+}
+
 // Stop stops a running Dogstatsd server
 func (s *Server) Stop() {
 	close(s.stopChan)
@@ -866,6 +902,36 @@ func FormatDebugStats(stats []byte) (string, error) {
 	return buf.String(), nil
 }
 
+// FormatContextLimiterStats takes a json-encoded aggregator.ContextLimiterStats, and returns the
+// statistics of the context limiter formatted for the dogstatsd-stats command.
+func FormatContextLimiterStats(stats []byte) (string, error) {
+	var limiterStats *aggregator.ContextLimiterStats
+	if err := json.Unmarshal(stats, &limiterStats); err != nil {
+		return "", err
+	}
+	if limiterStats == nil {
+		return "The dogstatsd contexts are not limited.", nil
+	}
+
+	buf := bytes.NewBuffer(nil)
+	buf.Write([]byte(fmt.Sprintf("Context limiter (action: %s): %d contexts dropped, %d contexts overflowed\n\n",
+		limiterStats.Action, limiterStats.Dropped, limiterStats.Overflowed)))
+
+	header := fmt.Sprintf("%-40s | %-20s | %-10s\n", "Metric", "Tag Key", "Limited")
+	buf.Write([]byte(header))
+	buf.Write([]byte(strings.Repeat("-", len(header)) + "\n"))
+
+	for _, offender := range limiterStats.Offenders {
+		buf.Write([]byte(fmt.Sprintf("%-40s | %-20s | %-10d\n", offender.Metric, offender.TagKey, offender.Limited)))
+	}
+
+	if len(limiterStats.Offenders) == 0 {
+		buf.Write([]byte("No contexts limited yet."))
+	}
+
+	return buf.String(), nil
+}
+
 // SetExtraTags sets extra tags. All metrics sent to the DogstatsD will be tagged with them.
 func (s *Server) SetExtraTags(tags []string) {
 	s.extraTags = tags
@@ -974,3 +974,99 @@ func TestContainerIDParsing(t *testing.T) {
 	assert.NotNil(serviceCheck)
 	assert.Equal("container_id://service-check-container", serviceCheck.OriginFromClient)
 }
+
+func TestTCPReceiveOriginDetection(t *testing.T) {
+	udpPort, err := getAvailableUDPPort()
+	require.NoError(t, err)
+	config.Datadog.SetDefault("dogstatsd_port", udpPort)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	tcpPort := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	config.Datadog.SetDefault("dogstatsd_tcp_port", tcpPort)
+	config.Datadog.SetDefault("dogstatsd_tcp_origin_detection", true)
+	defer config.Datadog.SetDefault("dogstatsd_tcp_port", 0)
+	defer config.Datadog.SetDefault("dogstatsd_tcp_origin_detection", false)
+
+	demux := aggregator.InitTestAgentDemultiplexerWithFlushInterval(10 * time.Millisecond)
+	defer demux.Stop(false)
+	s, err := NewServer(demux, false)
+	require.NoError(t, err, "cannot start DSD")
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", tcpPort))
+	require.NoError(t, err, "cannot connect to DSD TCP port")
+	defer conn.Close()
+
+	// without a client tag configured, the metrics are tagged with the default client tag
+	conn.Write([]byte("daemon:666|g|#sometag1:somevalue1\n"))
+	samples := demux.WaitForSamples(time.Second * 2)
+	require.Equal(t, 1, len(samples))
+	assert.ElementsMatch(t, []string{"sometag1:somevalue1", "dogstatsd_client:127.0.0.1"}, samples[0].Tags)
+}
+
+func TestTCPClientTag(t *testing.T) {
+	assert := assert.New(t)
+
+	config.Datadog.SetDefault("dogstatsd_tcp_client_tag", "client")
+	defer config.Datadog.SetDefault("dogstatsd_tcp_client_tag", "dogstatsd_client")
+
+	s, err := NewServer(mockDemultiplexer(), false)
+	assert.NoError(err, "starting the DogStatsD server shouldn't fail")
+	s.Stop()
+
+	parser := newParser(newFloat64ListPool())
+
+	metrics, err := s.parseMetricMessage(nil, parser, []byte("metric.name:123|g"), "tcp://client-1", false)
+	assert.NoError(err)
+	assert.Len(metrics, 1)
+	assert.ElementsMatch([]string{"client:client-1"}, metrics[0].Tags)
+
+	// an empty client tag leaves the metrics untagged
+	config.Datadog.SetDefault("dogstatsd_tcp_client_tag", "")
+	s, err = NewServer(mockDemultiplexer(), false)
+	assert.NoError(err, "starting the DogStatsD server shouldn't fail")
+	s.Stop()
+
+	metrics, err = s.parseMetricMessage(nil, parser, []byte("metric.name:123|g"), "tcp://client-1", false)
+	assert.NoError(err)
+	assert.Len(metrics, 1)
+	assert.Empty(metrics[0].Tags)
+	assert.Empty(metrics[0].OriginFromUDS)
+}
+func TestTCPOriginDetectionTagsClient(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewServer(mockDemultiplexer(), false)
+	assert.NoError(err, "starting the DogStatsD server shouldn't fail")
+	s.Stop()
+
+	parser := newParser(newFloat64ListPool())
+
+	// Metric
+	metrics, err := s.parseMetricMessage(nil, parser, []byte("metric.name:123|g|#env:prod"), "tcp://client-1", false)
+	assert.NoError(err)
+	assert.Len(metrics, 1)
+	assert.ElementsMatch([]string{"env:prod", "dogstatsd_client:client-1"}, metrics[0].Tags)
+	assert.Empty(metrics[0].OriginFromUDS)
+
+	// Event
+	event, err := s.parseEventMessage(parser, []byte("_e{10,10}:event title|test\\ntext"), "tcp://127.0.0.1")
+	assert.NoError(err)
+	assert.ElementsMatch([]string{"dogstatsd_client:127.0.0.1"}, event.Tags)
+	assert.Empty(event.OriginFromUDS)
+
+	// Service check
+	serviceCheck, err := s.parseServiceCheckMessage(parser, []byte("_sc|service-check.name|0"), "tcp://client-1")
+	assert.NoError(err)
+	assert.ElementsMatch([]string{"dogstatsd_client:client-1"}, serviceCheck.Tags)
+	assert.Empty(serviceCheck.OriginFromUDS)
+
+	// the other origins are left to the tagger
+	metrics, err = s.parseMetricMessage(nil, parser, []byte("metric.name:123|g"), "container_id://test_container", false)
+	assert.NoError(err)
+	assert.Len(metrics, 1)
+	assert.Empty(metrics[0].Tags)
+	assert.Equal("container_id://test_container", metrics[0].OriginFromUDS)
+}
@@ -16,10 +16,12 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/launchers"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/launchers/channel"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/launchers/containerdevents"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/launchers/docker"
 	filelauncher "github.com/DataDog/datadog-agent/pkg/logs/internal/launchers/file"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/launchers/journald"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/launchers/kubernetes"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/launchers/kubernetesaudit"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/launchers/listener"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/launchers/windowsevent"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/util/containersorpods"
@@ -89,6 +91,8 @@ func NewAgent(sources *config.LogSources, services *service.Services, processing
 		services,
 		cop,
 		coreConfig.Datadog.GetBool("logs_config.container_collect_all")))
+	lnchrs.AddLauncher(kubernetesaudit.NewLauncher(sources))
+	lnchrs.AddLauncher(containerdevents.NewLauncher())
 
 	return &Agent{
 		sources:                   sources,
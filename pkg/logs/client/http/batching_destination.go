@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// BatchingDestination groups the payloads it receives into larger payloads before sending
+// them to a destination.  It is used in TCP mode, where the payloads hold a single message,
+// to send batches to the endpoints of the other backends than Datadog.
+type BatchingDestination struct {
+	destination    client.Destination
+	batchWait      time.Duration
+	maxBatchSize   int
+	maxContentSize int
+}
+
+// NewBatchingDestination returns a destination sending the payloads to the given destination
+// by batches of at most maxBatchSize messages and maxContentSize bytes, a batch being sent
+// at the latest batchWait after its first message.
+func NewBatchingDestination(destination client.Destination, batchWait time.Duration, maxBatchSize int, maxContentSize int) *BatchingDestination {
+	return &BatchingDestination{
+		destination:    destination,
+		batchWait:      batchWait,
+		maxBatchSize:   maxBatchSize,
+		maxContentSize: maxContentSize,
+	}
+}
+
+// Start starts reading the input channel, the returned channel is notified once the
+// last batch has been sent after the input channel is closed.
+func (d *BatchingDestination) Start(input chan *message.Payload, output chan *message.Payload, isRetrying chan bool) (stopChan <-chan struct{}) {
+	batches := make(chan *message.Payload, 1)
+	stopChan = d.destination.Start(batches, output, isRetrying)
+	go d.run(input, batches)
+	return stopChan
+}
+
+func (d *BatchingDestination) run(input chan *message.Payload, batches chan *message.Payload) {
+	defer close(batches)
+
+	ticker := time.NewTicker(d.batchWait)
+	defer ticker.Stop()
+
+	batch := &message.Payload{}
+	flush := func() {
+		if len(batch.Messages) > 0 {
+			batches <- batch
+			batch = &message.Payload{}
+		}
+	}
+
+	for {
+		select {
+		case payload, isOpen := <-input:
+			if !isOpen {
+				flush()
+				return
+			}
+			size := contentSize(payload)
+			if len(batch.Messages)+len(payload.Messages) > d.maxBatchSize || batch.UnencodedSize+size > d.maxContentSize {
+				flush()
+			}
+			batch.Messages = append(batch.Messages, payload.Messages...)
+			batch.UnencodedSize += size
+			if len(batch.Messages) >= d.maxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// contentSize returns the size of the processed content of the messages of the payload,
+// which is what the destinations of the other backends encode.
+func contentSize(payload *message.Payload) int {
+	size := 0
+	for _, msg := range payload.Messages {
+		size += len(msg.ProcessedContent)
+	}
+	return size
+}
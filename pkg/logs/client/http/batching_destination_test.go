@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// recordingDestination forwards the payloads it receives to a channel.
+type recordingDestination struct {
+	payloads chan *message.Payload
+}
+
+func (d *recordingDestination) Start(input chan *message.Payload, output chan *message.Payload, isRetrying chan bool) (stopChan <-chan struct{}) {
+	stop := make(chan struct{}, 1)
+	go func() {
+		for payload := range input {
+			d.payloads <- payload
+		}
+		stop <- struct{}{}
+	}()
+	return stop
+}
+
+func singleMessagePayload(content string) *message.Payload {
+	msg := message.NewMessage([]byte(content), nil, message.StatusInfo, 0)
+	msg.ProcessedContent = []byte(content)
+	return &message.Payload{Messages: []*message.Message{msg}, Encoded: msg.Content}
+}
+
+func TestBatchingDestinationBatchSize(t *testing.T) {
+	inner := &recordingDestination{payloads: make(chan *message.Payload, 10)}
+	destination := NewBatchingDestination(inner, time.Hour, 2, 100)
+	input := make(chan *message.Payload)
+	stopChan := destination.Start(input, nil, nil)
+
+	for _, content := range []string{"a", "b", "c"} {
+		input <- singleMessagePayload(content)
+	}
+	batch := <-inner.payloads
+	assert.Len(t, batch.Messages, 2)
+	assert.Equal(t, 2, batch.UnencodedSize)
+
+	close(input)
+	<-stopChan
+	batch = <-inner.payloads
+	assert.Len(t, batch.Messages, 1)
+	assert.Equal(t, []byte("c"), batch.Messages[0].ProcessedContent)
+}
+
+func TestBatchingDestinationContentSize(t *testing.T) {
+	inner := &recordingDestination{payloads: make(chan *message.Payload, 10)}
+	destination := NewBatchingDestination(inner, time.Hour, 10, 5)
+	input := make(chan *message.Payload)
+	stopChan := destination.Start(input, nil, nil)
+
+	input <- singleMessagePayload("abc")
+	input <- singleMessagePayload("def")
+	batch := <-inner.payloads
+	assert.Len(t, batch.Messages, 1)
+	assert.Equal(t, []byte("abc"), batch.Messages[0].ProcessedContent)
+
+	close(input)
+	<-stopChan
+	batch = <-inner.payloads
+	assert.Len(t, batch.Messages, 1)
+	assert.Equal(t, []byte("def"), batch.Messages[0].ProcessedContent)
+}
+
+func TestBatchingDestinationBatchWait(t *testing.T) {
+	inner := &recordingDestination{payloads: make(chan *message.Payload, 10)}
+	destination := NewBatchingDestination(inner, 10*time.Millisecond, 10, 100)
+	input := make(chan *message.Payload)
+	destination.Start(input, nil, nil)
+	defer close(input)
+
+	input <- singleMessagePayload("a")
+	input <- singleMessagePayload("b")
+	select {
+	case batch := <-inner.payloads:
+		assert.Len(t, batch.Messages, 2)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the batch was not sent after the batch wait")
+	}
+}
@@ -7,6 +7,7 @@ package http
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"errors"
 	"expvar"
@@ -61,6 +62,12 @@ type Destination struct {
 	protocol            config.IntakeProtocol
 	origin              config.IntakeOrigin
 
+	// Other backends than Datadog
+	encoder          payloadEncoder
+	headers          map[string]string
+	useCompression   bool
+	compressionLevel int
+
 	// Concurrency
 	climit chan struct{} // semaphore for limiting concurrent background sends
 	wg     sync.WaitGroup
@@ -121,6 +128,15 @@ func newDestination(endpoint config.Endpoint,
 		metrics.DestinationExpVars.Set(telemetryName, expVars)
 	}
 
+	encoder, _ := newPayloadEncoder(endpoint)
+	if encoder != nil {
+		contentType = encoder.contentType()
+	}
+	compressionLevel := endpoint.CompressionLevel
+	if compressionLevel < gzip.NoCompression || compressionLevel > gzip.BestCompression {
+		compressionLevel = gzip.DefaultCompression
+	}
+
 	policy := backoff.NewPolicy(
 		endpoint.BackoffFactor,
 		endpoint.BackoffBase,
@@ -141,6 +157,10 @@ func newDestination(endpoint config.Endpoint,
 		backoff:             policy,
 		protocol:            endpoint.Protocol,
 		origin:              endpoint.Origin,
+		encoder:             encoder,
+		headers:             endpoint.Headers,
+		useCompression:      endpoint.UseCompression,
+		compressionLevel:    compressionLevel,
 		lastRetryError:      nil,
 		retryLock:           sync.Mutex{},
 		shouldRetry:         shouldRetry,
@@ -208,7 +228,7 @@ func (d *Destination) sendAndRetry(payload *message.Payload, output chan *messag
 		d.retryLock.Lock()
 		d.blockedUntil = time.Now().Add(d.backoff.GetBackoffDuration(d.nbErrors))
 		if d.blockedUntil.After(time.Now()) {
-			log.Debugf("%s: sleeping until %v before retrying", d.url, d.blockedUntil)
+			log.Debugf("%s: sleeping until %v before retrying", d.host, d.blockedUntil)
 			d.waitForBackoff()
 		}
 		d.retryLock.Unlock()
@@ -250,19 +270,32 @@ func (d *Destination) unconditionalSend(payload *message.Payload) (err error) {
 	if err != nil {
 		return err
 	}
+
+	body, encoding := payload.Encoded, payload.Encoding
+	if d.encoder != nil {
+		if body, encoding, err = d.encode(payload); err != nil {
+			// the messages could not be encoded, retrying would not help.
+			return err
+		}
+	}
 	metrics.BytesSent.Add(int64(payload.UnencodedSize))
-	metrics.EncodedBytesSent.Add(int64(len(payload.Encoded)))
+	metrics.EncodedBytesSent.Add(int64(len(body)))
 
-	req, err := http.NewRequest("POST", d.url, bytes.NewReader(payload.Encoded))
+	req, err := http.NewRequest("POST", d.url, bytes.NewReader(body))
 	if err != nil {
 		// the request could not be built,
 		// this can happen when the method or the url are valid.
 		return err
 	}
-	req.Header.Set("DD-API-KEY", d.apiKey)
+	if d.encoder == nil {
+		req.Header.Set("DD-API-KEY", d.apiKey)
+	}
 	req.Header.Set("Content-Type", d.contentType)
-	if payload.Encoding != "" {
-		req.Header.Set("Content-Encoding", payload.Encoding)
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	for name, value := range d.headers {
+		req.Header.Set(name, value)
 	}
 	if d.protocol != "" {
 		req.Header.Set("DD-PROTOCOL", string(d.protocol))
@@ -314,6 +347,27 @@ func (d *Destination) unconditionalSend(payload *message.Payload) (err error) {
 	}
 }
 
+// encode encodes the messages of the payload for the backend of the destination,
+// and compresses them like the main endpoint.  It returns the body and its encoding.
+func (d *Destination) encode(payload *message.Payload) ([]byte, string, error) {
+	body, err := d.encoder.encode(payload.Messages)
+	if err != nil || !d.useCompression {
+		return body, "", err
+	}
+	var compressed bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&compressed, d.compressionLevel)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err = writer.Write(body); err != nil {
+		return nil, "", err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return compressed.Bytes(), "gzip", nil
+}
+
 func (d *Destination) updateRetryState(err error, isRetrying chan bool) {
 	d.retryLock.Lock()
 	defer d.retryLock.Unlock()
@@ -345,6 +399,14 @@ func httpClientFactory(timeout time.Duration) func() *http.Client {
 
 // buildURL buils a url from a config endpoint.
 func buildURL(endpoint config.Endpoint) string {
+	if _, defaultPath := newPayloadEncoder(endpoint); defaultPath != "" {
+		if u, err := url.Parse(endpoint.URL); err == nil {
+			if u.Path == "" || u.Path == "/" {
+				u.Path = defaultPath
+			}
+			return u.String()
+		}
+	}
 	var scheme string
 	if endpoint.UseSSL {
 		scheme = "https"
@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+const lokiDefaultPath = "/loki/api/v1/push"
+
+// lokiEncoder encodes the messages into a Loki push request, in JSON.  The messages
+// are grouped in streams labelled by hostname, service, source and status, the tags
+// are not used as labels to keep the number of streams low.
+type lokiEncoder struct{}
+
+// JSON representation of a Loki push request.
+type lokiPushRequest struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	// Values are pairs of a timestamp in nanoseconds, as a string, and a line
+	Values [][2]string `json:"values"`
+}
+
+func (e *lokiEncoder) contentType() string {
+	return JSONContentType
+}
+
+func (e *lokiEncoder) encode(messages []*message.Message) ([]byte, error) {
+	type streamKey struct {
+		host    string
+		service string
+		source  string
+		level   string
+	}
+
+	request := lokiPushRequest{Streams: []*lokiStream{}}
+	streams := make(map[streamKey]*lokiStream)
+	for _, msg := range messages {
+		key := streamKey{
+			host:    msg.GetHostname(),
+			service: msg.Origin.Service(),
+			source:  msg.Origin.Source(),
+			level:   msg.GetStatus(),
+		}
+		stream, exists := streams[key]
+		if !exists {
+			stream = &lokiStream{Stream: map[string]string{"host": key.host, "level": key.level}}
+			if key.service != "" {
+				stream.Stream["service"] = key.service
+			}
+			if key.source != "" {
+				stream.Stream["source"] = key.source
+			}
+			streams[key] = stream
+			request.Streams = append(request.Streams, stream)
+		}
+		stream.Values = append(stream.Values, [2]string{
+			strconv.FormatInt(timestamp(msg).UnixNano(), 10),
+			toValidUtf8(msg.ProcessedContent),
+		})
+	}
+	return json.Marshal(request)
+}
@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	// ProtobufContentType is the content type of the OTLP/HTTP payloads
+	ProtobufContentType = "application/x-protobuf"
+
+	otlpDefaultPath = "/v1/logs"
+	otlpScopeName   = "datadog-agent"
+)
+
+// otlpSeverities maps the statuses to the OTLP severity numbers.
+var otlpSeverities = map[string]plog.SeverityNumber{
+	message.StatusEmergency: plog.SeverityNumberFATAL4,
+	message.StatusAlert:     plog.SeverityNumberFATAL2,
+	message.StatusCritical:  plog.SeverityNumberFATAL,
+	message.StatusError:     plog.SeverityNumberERROR,
+	message.StatusWarning:   plog.SeverityNumberWARN,
+	message.StatusNotice:    plog.SeverityNumberINFO2,
+	message.StatusInfo:      plog.SeverityNumberINFO,
+	message.StatusDebug:     plog.SeverityNumberDEBUG,
+}
+
+// otlpEncoder encodes the messages into an OTLP logs export request, in protobuf.
+// The messages are grouped in resources by hostname and service, their attributes are
+// added to the log records along with their source and tags, as `ddsource` and `ddtags`.
+type otlpEncoder struct {
+	marshaler plog.Marshaler
+}
+
+func newOTLPEncoder() *otlpEncoder {
+	return &otlpEncoder{marshaler: plog.NewProtoMarshaler()}
+}
+
+func (e *otlpEncoder) contentType() string {
+	return ProtobufContentType
+}
+
+func (e *otlpEncoder) encode(messages []*message.Message) ([]byte, error) {
+	type resourceKey struct {
+		hostname string
+		service  string
+	}
+
+	logs := plog.NewLogs()
+	records := make(map[resourceKey]plog.LogRecordSlice)
+	for _, msg := range messages {
+		key := resourceKey{hostname: msg.GetHostname(), service: msg.Origin.Service()}
+		slice, exists := records[key]
+		if !exists {
+			resourceLogs := logs.ResourceLogs().AppendEmpty()
+			attributes := resourceLogs.Resource().Attributes()
+			attributes.InsertString("host.name", key.hostname)
+			if key.service != "" {
+				attributes.InsertString("service.name", key.service)
+			}
+			scopeLogs := resourceLogs.ScopeLogs().AppendEmpty()
+			scopeLogs.Scope().SetName(otlpScopeName)
+			scopeLogs.Scope().SetVersion(version.AgentVersion)
+			slice = scopeLogs.LogRecords()
+			records[key] = slice
+		}
+
+		record := slice.AppendEmpty()
+		record.SetTimestamp(pcommon.NewTimestampFromTime(timestamp(msg)))
+		record.SetObservedTimestamp(pcommon.Timestamp(msg.IngestionTimestamp))
+		record.SetSeverityText(msg.GetStatus())
+		record.SetSeverityNumber(otlpSeverities[msg.GetStatus()])
+		record.Body().SetStringVal(toValidUtf8(msg.ProcessedContent))
+		if len(msg.Attributes) > 0 {
+			pcommon.NewMapFromRaw(msg.Attributes).CopyTo(record.Attributes())
+		}
+		if source := msg.Origin.Source(); source != "" {
+			record.Attributes().UpsertString("ddsource", source)
+		}
+		if tags := msg.Origin.TagsToString(); tags != "" {
+			record.Attributes().UpsertString("ddtags", tags)
+		}
+	}
+	return e.marshaler.MarshalLogs(logs)
+}
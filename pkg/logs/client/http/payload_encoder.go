@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// payloadEncoder encodes the messages of a payload for a backend other than Datadog,
+// from their processed content and their metadata.
+type payloadEncoder interface {
+	contentType() string
+	encode(messages []*message.Message) ([]byte, error)
+}
+
+// newPayloadEncoder returns the encoder of the endpoint and the default path of its backend,
+// or a nil encoder for the Datadog endpoints which send the payloads as they are.
+func newPayloadEncoder(endpoint config.Endpoint) (payloadEncoder, string) {
+	switch endpoint.Type {
+	case config.OTLPHTTPEndpointType:
+		return newOTLPEncoder(), otlpDefaultPath
+	case config.LokiEndpointType:
+		return &lokiEncoder{}, lokiDefaultPath
+	default:
+		return nil, ""
+	}
+}
+
+// timestamp returns the timestamp of the message, or the current time when it does not have one.
+func timestamp(msg *message.Message) time.Time {
+	if !msg.Timestamp.IsZero() {
+		return msg.Timestamp
+	}
+	return time.Now().UTC()
+}
+
+// toValidUtf8 replaces the invalid UTF-8 sequences of the content.
+func toValidUtf8(content []byte) string {
+	if utf8.Valid(content) {
+		return string(content)
+	}
+	return strings.ToValidUTF8(string(content), string(utf8.RuneError))
+}
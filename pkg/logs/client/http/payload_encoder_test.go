@@ -0,0 +1,155 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newTestMessage(content string, status string, service string, timestamp time.Time) *message.Message {
+	source := config.NewLogSource("", &config.LogsConfig{Service: service, Source: "app", Tags: []string{"env:test"}})
+	msg := message.NewMessageWithSource([]byte("encoded"), status, source, 42)
+	msg.ProcessedContent = []byte(content)
+	msg.Hostname = "host"
+	msg.Timestamp = timestamp
+	return msg
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// sendToTestServer sends the messages to a server with a destination of the given type and
+// returns the request the server received.
+func sendToTestServer(t *testing.T, endpointType config.EndpointType, path string, useCompression bool, messages ...*message.Message) receivedRequest {
+	requests := make(chan receivedRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, path, r.URL.Path)
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests <- receivedRequest{header: r.Header, body: body}
+	}))
+	defer server.Close()
+
+	destCtx := client.NewDestinationsContext()
+	destCtx.Start()
+	defer destCtx.Stop()
+	endpoint := config.Endpoint{
+		Type:             endpointType,
+		URL:              server.URL,
+		APIKey:           "secret",
+		UseCompression:   useCompression,
+		CompressionLevel: 6,
+		Headers:          map[string]string{"X-Scope-OrgID": "tenant"},
+		BackoffFactor:    1,
+		BackoffBase:      1,
+		BackoffMax:       10,
+		RecoveryInterval: 1,
+	}
+	destination := NewDestination(endpoint, JSONContentType, destCtx, 0, true, "")
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload)
+	destination.Start(input, output, nil)
+	defer close(input)
+
+	input <- &message.Payload{Messages: messages, Encoded: []byte("[encoded]")}
+	<-output
+	request := <-requests
+	assert.Empty(t, request.header.Get("DD-API-KEY"))
+	assert.Equal(t, "tenant", request.header.Get("X-Scope-OrgID"))
+	return request
+}
+
+func TestOTLPDestination(t *testing.T) {
+	timestamp := time.Date(2022, time.May, 1, 12, 0, 0, 0, time.UTC)
+	first := newTestMessage("first", message.StatusError, "web", timestamp)
+	first.Attributes = map[string]interface{}{"http": map[string]interface{}{"status_code": int64(500)}}
+	request := sendToTestServer(t, config.OTLPHTTPEndpointType, otlpDefaultPath, false,
+		first,
+		newTestMessage("second", message.StatusInfo, "db", timestamp),
+		newTestMessage("third", message.StatusWarning, "web", timestamp),
+	)
+	assert.Equal(t, ProtobufContentType, request.header.Get("Content-Type"))
+
+	logs, err := plog.NewProtoUnmarshaler().UnmarshalLogs(request.body)
+	require.NoError(t, err)
+	require.Equal(t, 2, logs.ResourceLogs().Len())
+
+	resource := logs.ResourceLogs().At(0)
+	service, _ := resource.Resource().Attributes().Get("service.name")
+	assert.Equal(t, "web", service.StringVal())
+	hostname, _ := resource.Resource().Attributes().Get("host.name")
+	assert.Equal(t, "host", hostname.StringVal())
+	records := resource.ScopeLogs().At(0).LogRecords()
+	require.Equal(t, 2, records.Len())
+	assert.Equal(t, "first", records.At(0).Body().StringVal())
+	assert.Equal(t, "error", records.At(0).SeverityText())
+	assert.Equal(t, plog.SeverityNumberERROR, records.At(0).SeverityNumber())
+	assert.Equal(t, timestamp, records.At(0).Timestamp().AsTime())
+	source, _ := records.At(0).Attributes().Get("ddsource")
+	assert.Equal(t, "app", source.StringVal())
+	tags, _ := records.At(0).Attributes().Get("ddtags")
+	assert.Equal(t, "env:test", tags.StringVal())
+	http, _ := records.At(0).Attributes().Get("http")
+	statusCode, _ := http.MapVal().Get("status_code")
+	assert.Equal(t, int64(500), statusCode.IntVal())
+	assert.Equal(t, "third", records.At(1).Body().StringVal())
+
+	records = logs.ResourceLogs().At(1).ScopeLogs().At(0).LogRecords()
+	require.Equal(t, 1, records.Len())
+	assert.Equal(t, "second", records.At(0).Body().StringVal())
+}
+
+func TestLokiDestination(t *testing.T) {
+	timestamp := time.Date(2022, time.May, 1, 12, 0, 0, 0, time.UTC)
+	request := sendToTestServer(t, config.LokiEndpointType, lokiDefaultPath, true,
+		newTestMessage("first", message.StatusError, "web", timestamp),
+		newTestMessage("second", message.StatusError, "web", timestamp.Add(time.Second)),
+		newTestMessage("third\xff", message.StatusInfo, "", timestamp),
+	)
+	assert.Equal(t, JSONContentType, request.header.Get("Content-Type"))
+	assert.Equal(t, "gzip", request.header.Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(bytes.NewReader(request.body))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+
+	var push lokiPushRequest
+	require.NoError(t, json.Unmarshal(body, &push))
+	assert.Equal(t, []*lokiStream{
+		{
+			Stream: map[string]string{"host": "host", "level": "error", "service": "web", "source": "app"},
+			Values: [][2]string{{"1651406400000000000", "first"}, {"1651406401000000000", "second"}},
+		},
+		{
+			Stream: map[string]string{"host": "host", "level": "info", "source": "app"},
+			Values: [][2]string{{"1651406400000000000", "third�"}},
+		},
+	}, push.Streams)
+}
+
+func TestBuildURLForOtherBackends(t *testing.T) {
+	assert.Equal(t, "https://otel:4318/v1/logs", buildURL(config.Endpoint{Type: config.OTLPHTTPEndpointType, URL: "https://otel:4318"}))
+	assert.Equal(t, "http://loki/loki/api/v1/push", buildURL(config.Endpoint{Type: config.LokiEndpointType, URL: "http://loki/"}))
+	assert.Equal(t, "http://gateway/custom/push", buildURL(config.Endpoint{Type: config.LokiEndpointType, URL: "http://gateway/custom/push"}))
+}
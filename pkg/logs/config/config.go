@@ -131,6 +131,17 @@ func ExpectedTagsDuration() time.Duration {
 	return defaultLogsConfigKeys().expectedTagsDuration()
 }
 
+// SpoolMaxSize returns the maximum size in bytes of the disk spool of the payloads that
+// cannot be sent, shared by the pipelines, 0 when the spool is disabled.
+func SpoolMaxSize() int64 {
+	return defaultLogsConfigKeys().spoolMaxSize()
+}
+
+// SpoolPath returns the directory of the disk spool of the payloads that cannot be sent.
+func SpoolPath() string {
+	return defaultLogsConfigKeys().spoolPath()
+}
+
 // IsExpectedTagsSet returns boolean showing if expected tags feature is enabled.
 func IsExpectedTagsSet() bool {
 	return ExpectedTagsDuration() > 0
@@ -174,6 +185,18 @@ func buildTCPEndpoints(logsConfig *LogsConfigKeys) (*Endpoints, error) {
 
 	additionals := logsConfig.getAdditionalEndpoints()
 	for i := 0; i < len(additionals); i++ {
+		if additionals[i].Type != DatadogEndpointType {
+			// the endpoints of the other backends are always reached over HTTP
+			additionals[i].UseCompression = logsConfig.useCompression()
+			additionals[i].CompressionLevel = logsConfig.compressionLevel()
+			additionals[i].ConnectionResetInterval = main.ConnectionResetInterval
+			additionals[i].BackoffBase = logsConfig.senderBackoffBase()
+			additionals[i].BackoffMax = logsConfig.senderBackoffMax()
+			additionals[i].BackoffFactor = logsConfig.senderBackoffFactor()
+			additionals[i].RecoveryInterval = logsConfig.senderRecoveryInterval()
+			additionals[i].RecoveryReset = logsConfig.senderRecoveryReset()
+			continue
+		}
 		additionals[i].UseSSL = main.UseSSL
 		additionals[i].ProxyAddress = proxyAddress
 		additionals[i].APIKey = coreConfig.SanitizeAPIKey(additionals[i].APIKey)
@@ -262,7 +285,9 @@ func BuildHTTPEndpointsWithConfig(logsConfig *LogsConfigKeys, endpointPrefix str
 
 	additionals := logsConfig.getAdditionalEndpoints()
 	for i := 0; i < len(additionals); i++ {
-		additionals[i].UseSSL = main.UseSSL
+		if additionals[i].Type == DatadogEndpointType {
+			additionals[i].UseSSL = main.UseSSL
+		}
 		additionals[i].APIKey = coreConfig.SanitizeAPIKey(additionals[i].APIKey)
 		additionals[i].UseCompression = main.UseCompression
 		additionals[i].CompressionLevel = main.CompressionLevel
@@ -275,7 +300,7 @@ func BuildHTTPEndpointsWithConfig(logsConfig *LogsConfigKeys, endpointPrefix str
 		if additionals[i].Version == 0 {
 			additionals[i].Version = main.Version
 		}
-		if additionals[i].Version == EPIntakeVersion2 {
+		if additionals[i].Version == EPIntakeVersion2 && additionals[i].Type == DatadogEndpointType {
 			additionals[i].TrackType = intakeTrackType
 			additionals[i].Protocol = intakeProtocol
 			additionals[i].Origin = intakeOrigin
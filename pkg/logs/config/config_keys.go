@@ -7,6 +7,7 @@ package config
 
 import (
 	"encoding/json"
+	"path/filepath"
 	"time"
 
 	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
@@ -114,8 +115,15 @@ func (l *LogsConfigKeys) useCompression() bool {
 	return l.getConfig().GetBool(l.getConfigKey("use_compression"))
 }
 
+// hasAdditionalEndpoints returns whether logs are sent to additional Datadog endpoints,
+// the endpoints of the other backends do not depend on the transport of the main one.
 func (l *LogsConfigKeys) hasAdditionalEndpoints() bool {
-	return len(l.getAdditionalEndpoints()) > 0
+	for _, endpoint := range l.getAdditionalEndpoints() {
+		if endpoint.Type == DatadogEndpointType {
+			return true
+		}
+	}
+	return false
 }
 
 // getLogsAPIKey provides the dd api key used by the main logs agent sender.
@@ -147,7 +155,26 @@ func (l *LogsConfigKeys) getAdditionalEndpoints() []Endpoint {
 	if err != nil {
 		log.Warnf("Could not parse additional_endpoints for logs: %v", err)
 	}
-	return endpoints
+	valid := endpoints[:0]
+	for _, endpoint := range endpoints {
+		if err := endpoint.setAddressFromURL(); err != nil {
+			log.Warnf("Ignoring additional logs endpoint: %v", err)
+			continue
+		}
+		valid = append(valid, endpoint)
+	}
+	return valid
+}
+
+func (l *LogsConfigKeys) spoolMaxSize() int64 {
+	return l.getConfig().GetInt64(l.getConfigKey("spool_max_size_in_bytes"))
+}
+
+func (l *LogsConfigKeys) spoolPath() string {
+	if path := l.getConfig().GetString(l.getConfigKey("spool_path")); path != "" {
+		return path
+	}
+	return filepath.Join(l.getConfig().GetString(l.getConfigKey("run_path")), "spool")
 }
 
 func (l *LogsConfigKeys) expectedTagsDuration() time.Duration {
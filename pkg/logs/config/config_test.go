@@ -235,6 +235,54 @@ func (suite *ConfigTestSuite) TestMultipleTCPEndpointsEnvVar() {
 	suite.Equal(expectedEndpoints, endpoints)
 }
 
+func (suite *ConfigTestSuite) TestAdditionalEndpointsOfOtherBackends() {
+	suite.config.Set("api_key", "123")
+	suite.config.Set("logs_config.use_v2_api", false)
+	suite.config.Set("logs_config.additional_endpoints", []map[string]interface{}{
+		{"type": "otlp_http", "url": "http://otel-collector:4318"},
+		{"type": "loki", "url": "https://loki.example.com/loki/api/v1/push", "headers": map[string]string{"X-Scope-OrgID": "tenant"}, "is_reliable": false},
+		{"type": "elasticsearch", "url": "http://elasticsearch:9200"},
+		{"type": "loki", "url": "loki:3100"},
+	})
+
+	// the endpoints of other backends do not force the use of TCP
+	suite.False(defaultLogsConfigKeys().hasAdditionalEndpoints())
+
+	endpoints, err := BuildHTTPEndpoints("test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.Len(endpoints.Endpoints, 3)
+
+	otlp := endpoints.Endpoints[1]
+	suite.Equal(OTLPHTTPEndpointType, otlp.Type)
+	suite.Equal("otel-collector", otlp.Host)
+	suite.Equal(4318, otlp.Port)
+	suite.False(otlp.UseSSL)
+	suite.True(otlp.UseCompression)
+	suite.Equal(float64(coreConfig.DefaultLogsSenderBackoffMax), otlp.BackoffMax)
+	suite.Equal(EPIntakeVersion1, otlp.Version)
+	suite.Equal(IntakeTrackType(""), otlp.TrackType)
+
+	loki := endpoints.Endpoints[2]
+	suite.Equal(LokiEndpointType, loki.Type)
+	suite.Equal("loki.example.com", loki.Host)
+	suite.Equal(0, loki.Port)
+	suite.True(loki.UseSSL)
+	suite.False(loki.GetIsReliable())
+	suite.Equal(map[string]string{"X-Scope-OrgID": "tenant"}, loki.Headers)
+	suite.Equal([]string{
+		"Reliable: Sending compressed logs in otlp_http format to http://otel-collector:4318",
+		"Unreliable: Sending compressed logs in loki format to https://loki.example.com/loki/api/v1/push",
+	}, endpoints.GetStatus()[1:])
+
+	// they are reached over HTTP when the main endpoint uses TCP
+	endpoints, err = buildTCPEndpoints(defaultLogsConfigKeys())
+	suite.Nil(err)
+	suite.Len(endpoints.Endpoints, 3)
+	suite.False(endpoints.Endpoints[1].UseSSL)
+	suite.Equal(float64(coreConfig.DefaultLogsSenderBackoffMax), endpoints.Endpoints[1].BackoffMax)
+	suite.True(endpoints.Endpoints[2].UseSSL)
+}
+
 func (suite *ConfigTestSuite) TestMultipleHttpEndpointsInConfig() {
 	suite.config.Set("api_key", "123")
 	suite.config.Set("logs_config.batch_wait", 1)
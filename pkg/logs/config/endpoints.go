@@ -7,11 +7,16 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 )
 
+// redactedValue replaces the credentials of the urls displayed, like url.URL.Redacted does.
+const redactedValue = "xxxxx"
+
 // EPIntakeVersion is the events platform intake API version
 type EPIntakeVersion uint8
 
@@ -24,6 +29,16 @@ type IntakeProtocol string
 // IntakeOrigin indicates the log source to use for an endpoint intake.
 type IntakeOrigin string
 
+// EndpointType indicates the backend an endpoint sends logs to.
+type EndpointType string
+
+// Endpoint types, Datadog is the default
+const (
+	DatadogEndpointType  EndpointType = ""
+	OTLPHTTPEndpointType EndpointType = "otlp_http"
+	LokiEndpointType     EndpointType = "loki"
+)
+
 const (
 	_ EPIntakeVersion = iota
 	// EPIntakeVersion1 is version 1 of the envets platform intake API
@@ -54,6 +69,12 @@ type Endpoint struct {
 	TrackType IntakeTrackType
 	Protocol  IntakeProtocol
 	Origin    IntakeOrigin
+
+	// Additional endpoints only, to send logs to other backends than Datadog.
+	// Host, Port and UseSSL are derived from the URL.
+	Type    EndpointType      `mapstructure:"type" json:"type"`
+	URL     string            `mapstructure:"url" json:"url"`
+	Headers map[string]string `mapstructure:"headers" json:"headers"`
 }
 
 // GetStatus returns the endpoint status
@@ -63,6 +84,10 @@ func (e *Endpoint) GetStatus(prefix string, useHTTP bool) string {
 		compression = "compressed"
 	}
 
+	if e.Type != DatadogEndpointType {
+		return fmt.Sprintf("%sSending %s logs in %s format to %s", prefix, compression, e.Type, redactURL(e.URL))
+	}
+
 	host := e.Host
 	port := e.Port
 
@@ -93,6 +118,57 @@ func (e *Endpoint) GetStatus(prefix string, useHTTP bool) string {
 	return fmt.Sprintf("%sSending %s logs in %s to %s on port %d", prefix, compression, protocol, host, port)
 }
 
+// setAddressFromURL validates the type of the endpoint and sets the address of the
+// endpoints of the other backends than Datadog from their URL.
+func (e *Endpoint) setAddressFromURL() error {
+	switch e.Type {
+	case DatadogEndpointType:
+		return nil
+	case OTLPHTTPEndpointType, LokiEndpointType:
+	default:
+		return fmt.Errorf("unknown endpoint type %q", e.Type)
+	}
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		// the error holds the url and its credentials
+		return fmt.Errorf("could not parse the url of the %s endpoint", e.Type)
+	}
+	switch u.Scheme {
+	case "https":
+		e.UseSSL = true
+	case "http":
+		e.UseSSL = false
+	default:
+		return fmt.Errorf("the url of the %s endpoint must start with http:// or https://, got %q", e.Type, redactURL(e.URL))
+	}
+	e.Host = u.Hostname()
+	e.Port = 0
+	if u.Port() != "" {
+		if e.Port, err = strconv.Atoi(u.Port()); err != nil {
+			return fmt.Errorf("could not parse the port of %s: %v", redactURL(e.URL), err)
+		}
+	}
+	return nil
+}
+
+// redactURL returns the url of an endpoint without its credentials, to be displayed: the
+// password of its user info and the values of its query parameters, that often hold tokens,
+// are masked.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid url>"
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query[key] = []string{redactedValue}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
+}
+
 // GetIsReliable returns true if the endpoint is reliable. Endpoints are reliable by default.
 func (e *Endpoint) GetIsReliable() bool {
 	return e.IsReliable == nil || *e.IsReliable
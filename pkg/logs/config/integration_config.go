@@ -23,12 +23,20 @@ const (
 	WindowsEventType  = "windows_event"
 	StringChannelType = "string_channel"
 
+	// KubernetesAuditType for the audit log of the Kubernetes API server
+	KubernetesAuditType = "kubernetes_audit"
+	// ContainerdEventsType for the events of the local containerd
+	ContainerdEventsType = "containerd_events"
+
 	// UTF16BE for UTF-16 Big endian encoding
 	UTF16BE string = "utf-16-be"
 	// UTF16LE for UTF-16 Little Endian encoding
 	UTF16LE string = "utf-16-le"
 	// SHIFTJIS for Shift JIS (Japanese) encoding
 	SHIFTJIS string = "shift-jis"
+
+	// SyslogFormat for syslog messages (RFC 5424 and RFC 3164) received by network sources
+	SyslogFormat string = "syslog"
 )
 
 // LogsConfig represents a log source config, which can be for instance
@@ -38,12 +46,27 @@ type LogsConfig struct {
 
 	Port        int    // Network
 	IdleTimeout string `mapstructure:"idle_timeout" json:"idle_timeout"` // Network
-	Path        string // File, Journald
+	Format      string `mapstructure:"format" json:"format"`             // Network
+	Path        string // File, Journald, Kubernetes audit
+
+	// TLSCertFile and TLSKeyFile enable TLS on the listener, clients must present a certificate
+	// signed by TLSCAFile if it is set, and one of the TLSAllowedClientCNs if they are set.
+	TLSCertFile         string   `mapstructure:"tls_cert_file" json:"tls_cert_file"`                   // TCP
+	TLSKeyFile          string   `mapstructure:"tls_key_file" json:"tls_key_file"`                     // TCP
+	TLSCAFile           string   `mapstructure:"tls_ca_file" json:"tls_ca_file"`                       // TCP
+	TLSAllowedClientCNs []string `mapstructure:"tls_allowed_client_cns" json:"tls_allowed_client_cns"` // TCP
 
 	Encoding     string   `mapstructure:"encoding" json:"encoding"`             // File
 	ExcludePaths []string `mapstructure:"exclude_paths" json:"exclude_paths"`   // File
 	TailingMode  string   `mapstructure:"start_position" json:"start_position"` // File
 
+	// ArchivePath is a glob matching the compressed archives produced by the rotation of Path.
+	// Archives found when the source is added are read if BackfillArchives is set, and when
+	// FollowRotatedArchives is set, the data that was not read before a rotation is read from the next archive.
+	ArchivePath           string `mapstructure:"archive_path" json:"archive_path"`                       // File
+	BackfillArchives      bool   `mapstructure:"backfill_archives" json:"backfill_archives"`             // File
+	FollowRotatedArchives bool   `mapstructure:"follow_rotated_archives" json:"follow_rotated_archives"` // File
+
 	IncludeSystemUnits []string `mapstructure:"include_units" json:"include_units"`           // Journald
 	ExcludeSystemUnits []string `mapstructure:"exclude_units" json:"exclude_units"`           // Journald
 	IncludeUserUnits   []string `mapstructure:"include_user_units" json:"include_user_units"` // Journald
@@ -58,7 +81,7 @@ type LogsConfig struct {
 	Identifier string // Docker
 
 	ChannelPath string `mapstructure:"channel_path" json:"channel_path"` // Windows Event
-	Query       string // Windows Event
+	Query       string // Windows Event, containerd events
 
 	// used as input only by the Channel tailer.
 	// could have been unidirectional but the tailer could not close it in this case.
@@ -94,14 +117,23 @@ func (c *LogsConfig) Dump() string {
 	case TCPType:
 		fmt.Fprintf(&b, "\tPort: %d,\n", c.Port)
 		fmt.Fprintf(&b, "\tIdleTimeout: %#v,\n", c.IdleTimeout)
+		fmt.Fprintf(&b, "\tFormat: %#v,\n", c.Format)
+		fmt.Fprintf(&b, "\tTLSCertFile: %#v,\n", c.TLSCertFile)
+		fmt.Fprintf(&b, "\tTLSKeyFile: %#v,\n", c.TLSKeyFile)
+		fmt.Fprintf(&b, "\tTLSCAFile: %#v,\n", c.TLSCAFile)
+		fmt.Fprintf(&b, "\tTLSAllowedClientCNs: %#v,\n", c.TLSAllowedClientCNs)
 	case UDPType:
 		fmt.Fprintf(&b, "\tPort: %d,\n", c.Port)
 		fmt.Fprintf(&b, "\tIdleTimeout: %#v,\n", c.IdleTimeout)
+		fmt.Fprintf(&b, "\tFormat: %#v,\n", c.Format)
 	case FileType:
 		fmt.Fprintf(&b, "\tPath: %#v,\n", c.Path)
 		fmt.Fprintf(&b, "\tEncoding: %#v,\n", c.Encoding)
 		fmt.Fprintf(&b, "\tExcludePaths: %#v,\n", c.ExcludePaths)
 		fmt.Fprintf(&b, "\tTailingMode: %#v,\n", c.TailingMode)
+		fmt.Fprintf(&b, "\tArchivePath: %#v,\n", c.ArchivePath)
+		fmt.Fprintf(&b, "\tBackfillArchives: %t,\n", c.BackfillArchives)
+		fmt.Fprintf(&b, "\tFollowRotatedArchives: %t,\n", c.FollowRotatedArchives)
 	case DockerType:
 		fmt.Fprintf(&b, "\tImage: %#v,\n", c.Image)
 		fmt.Fprintf(&b, "\tLabel: %#v,\n", c.Label)
@@ -117,6 +149,11 @@ func (c *LogsConfig) Dump() string {
 	case WindowsEventType:
 		fmt.Fprintf(&b, "\tChannelPath: %#v,\n", c.ChannelPath)
 		fmt.Fprintf(&b, "\tQuery: %#v,\n", c.Query)
+	case KubernetesAuditType:
+		fmt.Fprintf(&b, "\tPath: %#v,\n", c.Path)
+		fmt.Fprintf(&b, "\tTailingMode: %#v,\n", c.TailingMode)
+	case ContainerdEventsType:
+		fmt.Fprintf(&b, "\tQuery: %#v,\n", c.Query)
 	case StringChannelType:
 		fmt.Fprintf(&b, "\tChannel: %p,\n", c.Channel)
 		c.ChannelTagsMutex.Lock()
@@ -196,10 +233,28 @@ func (c *LogsConfig) Validate() error {
 		if err != nil {
 			return err
 		}
+		if c.ArchivePath == "" && (c.BackfillArchives || c.FollowRotatedArchives) {
+			return fmt.Errorf("archive_path must be set to read the archives of %v", c.Path)
+		}
+	case c.Type == KubernetesAuditType:
+		if c.Path == "" {
+			return fmt.Errorf("kubernetes_audit source must have a path")
+		}
+		err := c.validateTailingMode()
+		if err != nil {
+			return err
+		}
 	case c.Type == TCPType && c.Port == 0:
 		return fmt.Errorf("tcp source must have a port")
 	case c.Type == UDPType && c.Port == 0:
 		return fmt.Errorf("udp source must have a port")
+	case (c.Type == TCPType || c.Type == UDPType) && c.Format != "" && c.Format != SyslogFormat:
+		return fmt.Errorf("invalid format '%v' for %v source, only '%v' is supported", c.Format, c.Type, SyslogFormat)
+	case c.Type == TCPType:
+		err := c.validateTLS()
+		if err != nil {
+			return err
+		}
 	}
 	err := ValidateProcessingRules(c.ProcessingRules)
 	if err != nil {
@@ -219,6 +274,23 @@ func (c *LogsConfig) validateTailingMode() error {
 	return nil
 }
 
+func (c *LogsConfig) validateTLS() error {
+	switch {
+	case (c.TLSCertFile == "") != (c.TLSKeyFile == ""):
+		return fmt.Errorf("tls_cert_file and tls_key_file must be set together for port %d", c.Port)
+	case c.TLSCAFile != "" && c.TLSCertFile == "":
+		return fmt.Errorf("tls_ca_file requires tls_cert_file and tls_key_file for port %d", c.Port)
+	case len(c.TLSAllowedClientCNs) > 0 && c.TLSCAFile == "":
+		return fmt.Errorf("tls_allowed_client_cns requires tls_ca_file for port %d", c.Port)
+	}
+	return nil
+}
+
+// TLSEnabled returns true if the listener of the source must use TLS.
+func (c *LogsConfig) TLSEnabled() bool {
+	return c.TLSCertFile != ""
+}
+
 // AutoMultiLineEnabled determines whether auto multi line detection is enabled for this config,
 // considering both the agent-wide logs_config.auto_multi_line_detection and any config for this
 // particular log source.
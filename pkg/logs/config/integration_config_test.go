@@ -19,10 +19,28 @@ import (
 func TestValidateShouldSucceedWithValidConfigs(t *testing.T) {
 	validConfigs := []*LogsConfig{
 		{Type: FileType, Path: "/var/log/foo.log"},
+		{Type: FileType, Path: "/var/log/foo.log", ArchivePath: "/var/log/foo.log.*.gz", BackfillArchives: true, FollowRotatedArchives: true},
 		{Type: TCPType, Port: 1234},
 		{Type: UDPType, Port: 5678},
+		{Type: TCPType, Port: 1234, Format: SyslogFormat},
+		{Type: UDPType, Port: 5678, Format: SyslogFormat},
+		{Type: TCPType, Port: 1234, TLSCertFile: "cert.pem", TLSKeyFile: "key.pem"},
+		{Type: TCPType, Port: 1234, TLSCertFile: "cert.pem", TLSKeyFile: "key.pem", TLSCAFile: "ca.pem", TLSAllowedClientCNs: []string{"foo"}},
 		{Type: DockerType},
+		{Type: KubernetesAuditType, Path: "/var/log/kubernetes/audit/audit.log"},
+		{Type: ContainerdEventsType},
+		{Type: ContainerdEventsType, Query: `topic~="/tasks/"`},
 		{Type: JournaldType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch, Pattern: ".*"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtFieldMatch, Field: "level", Pattern: "^debug$"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: RemoveField, Field: "password"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: RenameField, Field: "msg", RenameTo: "message"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: FieldToTag, Field: "user.id"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: Sampling, SamplePercentage: 10}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: Sampling, LinesPerSecond: 100, DedupWindow: 10}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GrokParser, Pattern: "%{NGINX_ACCESS}"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GrokParser, Pattern: "%{ID:id}", Definitions: map[string]string{"ID": `\d+`}}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, MetricName: "requests", Pattern: `(?P<status>\d{3})`}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, MetricName: "latency", MetricType: DistributionMetric, Field: "duration", ValueFrom: "duration", DropOriginal: true}}},
 	}
 
 	for _, config := range validConfigs {
@@ -35,8 +53,15 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 	invalidConfigs := []*LogsConfig{
 		{},
 		{Type: FileType},
+		{Type: FileType, Path: "/var/log/foo.log", FollowRotatedArchives: true},
 		{Type: TCPType},
 		{Type: UDPType},
+		{Type: KubernetesAuditType},
+		{Type: KubernetesAuditType, Path: "/var/log/kubernetes/audit/audit.log", TailingMode: "middle"},
+		{Type: TCPType, Port: 1234, Format: "gelf"},
+		{Type: TCPType, Port: 1234, TLSCertFile: "cert.pem"},
+		{Type: TCPType, Port: 1234, TLSCAFile: "ca.pem"},
+		{Type: TCPType, Port: 1234, TLSCertFile: "cert.pem", TLSKeyFile: "key.pem", TLSAllowedClientCNs: []string{"foo"}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: "bar"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch}}},
@@ -44,6 +69,24 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Type: ExcludeAtMatch, Pattern: ".*"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Type: ExcludeAtMatch}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Pattern: ".*"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtFieldMatch, Pattern: "^debug$"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtFieldMatch, Field: "level"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: RemoveField}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: RenameField, Field: "msg"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: RenameField, Field: "msg", RenameTo: "msg"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: RenameField, Field: "msg", RenameTo: "msg.text"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: RenameField, Field: "log.msg", RenameTo: "log"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: FieldToTag}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: Sampling}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: Sampling, SamplePercentage: 150}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: Sampling, LinesPerSecond: -1}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GrokParser}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GrokParser, Pattern: "%{UNKNOWN:foo}"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, Pattern: ".*"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, MetricName: "requests"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, MetricName: "requests", Pattern: "("}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, MetricName: "requests", Pattern: ".*", MetricType: "gauge"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, MetricName: "latency", Field: "duration", MetricType: DistributionMetric}}},
 	}
 
 	for _, config := range invalidConfigs {
@@ -8,6 +8,9 @@ package config
 import (
 	"fmt"
 	"regexp"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/grok"
 )
 
 // Processing rule types
@@ -18,6 +21,29 @@ const (
 	MultiLine      = "multi_line"
 )
 
+// Structured processing rule types, applied on the fields of JSON formatted log lines
+const (
+	ExcludeAtFieldMatch = "exclude_at_field_match"
+	RemoveField         = "remove_field"
+	RenameField         = "rename_field"
+	FieldToTag          = "field_to_tag"
+)
+
+// Sampling is the processing rule type used to sample, rate-limit and deduplicate log lines per source
+const Sampling = "sampling"
+
+// GrokParser is the processing rule type used to extract attributes from log lines with a grok pattern
+const GrokParser = "grok_parser"
+
+// GenerateMetric is the processing rule type used to generate metrics from the matching log lines
+const GenerateMetric = "generate_metric"
+
+// Types of the metrics generated by the generate_metric rules
+const (
+	CountMetric        = "count"
+	DistributionMetric = "distribution"
+)
+
 // ProcessingRule defines an exclusion or a masking rule to
 // be applied on log lines
 type ProcessingRule struct {
@@ -25,9 +51,33 @@ type ProcessingRule struct {
 	Name               string
 	ReplacePlaceholder string `mapstructure:"replace_placeholder" json:"replace_placeholder"`
 	Pattern            string
+	// Field is the path of the JSON field a structured rule applies to,
+	// nested fields are separated by dots (e.g. `http.status_code`)
+	Field    string
+	RenameTo string `mapstructure:"rename_to" json:"rename_to"`
+	TagName  string `mapstructure:"tag_name" json:"tag_name"`
+	// SamplePercentage is the percentage of lines to keep, LinesPerSecond the rate of
+	// lines allowed by the token bucket and DedupWindow the window in seconds in which
+	// identical lines are collapsed. They only apply to sampling rules and can be combined.
+	SamplePercentage float64 `mapstructure:"sample_percentage" json:"sample_percentage"`
+	LinesPerSecond   float64 `mapstructure:"lines_per_second" json:"lines_per_second"`
+	DedupWindow      int     `mapstructure:"dedup_window" json:"dedup_window"`
+	// Definitions are the patterns that the pattern of a grok parser rule may reference,
+	// in addition to the built-in patterns
+	Definitions map[string]string
+	// MetricName and MetricType are the name and type of the metric generated by a generate_metric
+	// rule from the lines matching its pattern or having its field. The value of the metric and its
+	// tags are read from the named captures of the pattern or from the JSON fields of the line, the
+	// tags default to all the named captures. The lines are not sent when DropOriginal is set.
+	MetricName   string   `mapstructure:"metric_name" json:"metric_name"`
+	MetricType   string   `mapstructure:"metric_type" json:"metric_type"`
+	ValueFrom    string   `mapstructure:"value_from" json:"value_from"`
+	TagsFrom     []string `mapstructure:"tags_from" json:"tags_from"`
+	DropOriginal bool     `mapstructure:"drop_original" json:"drop_original"`
 	// TODO: should be moved out
 	Regex       *regexp.Regexp
 	Placeholder []byte
+	Grok        *grok.Pattern
 }
 
 // ValidateProcessingRules validates the rules and raises an error if one is misconfigured.
@@ -35,6 +85,9 @@ type ProcessingRule struct {
 // - a valid name
 // - a valid type
 // - a valid pattern that compiles
+// Structured rules must also have a field, and a target name when renaming.
+// Grok parser rules must have a valid grok pattern.
+// Generate metric rules must have a metric name, and a pattern or a field to match the lines.
 func ValidateProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
 		if rule.Name == "" {
@@ -44,6 +97,39 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		switch rule.Type {
 		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine:
 			break
+		case ExcludeAtFieldMatch, RemoveField, RenameField, FieldToTag:
+			if rule.Field == "" {
+				return fmt.Errorf("no field provided for processing rule: %s", rule.Name)
+			}
+			if rule.Type == RenameField && rule.RenameTo == "" {
+				return fmt.Errorf("no rename_to provided for processing rule: %s", rule.Name)
+			}
+			if rule.Type == RenameField && (rule.RenameTo == rule.Field ||
+				strings.HasPrefix(rule.RenameTo, rule.Field+".") || strings.HasPrefix(rule.Field, rule.RenameTo+".")) {
+				return fmt.Errorf("rename_to can't be a parent or a child of the field for processing rule: %s", rule.Name)
+			}
+			if rule.Type != ExcludeAtFieldMatch {
+				// the other structured rules do not need a pattern
+				continue
+			}
+		case Sampling:
+			if err := validateSamplingRule(rule); err != nil {
+				return err
+			}
+			continue
+		case GrokParser:
+			if rule.Pattern == "" {
+				return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
+			}
+			if _, err := grok.CompileWithDefinitions(rule.Pattern, rule.Definitions); err != nil {
+				return fmt.Errorf("invalid pattern %s for processing rule: %s: %v", rule.Pattern, rule.Name, err)
+			}
+			continue
+		case GenerateMetric:
+			if err := validateGenerateMetricRule(rule); err != nil {
+				return err
+			}
+			continue
 		case "":
 			return fmt.Errorf("type must be set for processing rule `%s`", rule.Name)
 		default:
@@ -61,15 +147,72 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 	return nil
 }
 
+func validateSamplingRule(rule *ProcessingRule) error {
+	if rule.SamplePercentage == 0 && rule.LinesPerSecond == 0 && rule.DedupWindow == 0 {
+		return fmt.Errorf("one of sample_percentage, lines_per_second or dedup_window must be set for processing rule: %s", rule.Name)
+	}
+	if rule.SamplePercentage < 0 || rule.SamplePercentage > 100 {
+		return fmt.Errorf("invalid sample_percentage %v for processing rule: %s", rule.SamplePercentage, rule.Name)
+	}
+	if rule.LinesPerSecond < 0 {
+		return fmt.Errorf("invalid lines_per_second %v for processing rule: %s", rule.LinesPerSecond, rule.Name)
+	}
+	if rule.DedupWindow < 0 {
+		return fmt.Errorf("invalid dedup_window %v for processing rule: %s", rule.DedupWindow, rule.Name)
+	}
+	return nil
+}
+
+func validateGenerateMetricRule(rule *ProcessingRule) error {
+	if rule.MetricName == "" {
+		return fmt.Errorf("no metric_name provided for processing rule: %s", rule.Name)
+	}
+	switch rule.MetricType {
+	case "", CountMetric:
+	case DistributionMetric:
+		if rule.ValueFrom == "" {
+			return fmt.Errorf("no value_from provided for the distribution of processing rule: %s", rule.Name)
+		}
+	default:
+		return fmt.Errorf("invalid metric_type %s for processing rule: %s, it must be %s or %s", rule.MetricType, rule.Name, CountMetric, DistributionMetric)
+	}
+	if rule.Pattern == "" && rule.Field == "" {
+		return fmt.Errorf("no pattern nor field provided for processing rule: %s", rule.Name)
+	}
+	if rule.Pattern != "" {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern %s for processing rule: %s", rule.Pattern, rule.Name)
+		}
+	}
+	return nil
+}
+
 // CompileProcessingRules compiles all processing rule regular expressions.
 func CompileProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
+		switch rule.Type {
+		case RemoveField, RenameField, FieldToTag, Sampling:
+			// these rules do not rely on a pattern
+			continue
+		case GrokParser:
+			pattern, err := grok.CompileWithDefinitions(rule.Pattern, rule.Definitions)
+			if err != nil {
+				return err
+			}
+			rule.Grok = pattern
+			continue
+		case GenerateMetric:
+			if rule.Pattern == "" {
+				// the rule matches the lines having its field
+				continue
+			}
+		}
 		re, err := regexp.Compile(rule.Pattern)
 		if err != nil {
 			return err
 		}
 		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch:
+		case ExcludeAtMatch, IncludeAtMatch, ExcludeAtFieldMatch, GenerateMetric:
 			rule.Regex = re
 		case MaskSequences:
 			rule.Regex = re
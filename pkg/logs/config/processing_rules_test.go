@@ -34,3 +34,40 @@ func TestCompileShouldFailWithInvalidRules(t *testing.T) {
 		assert.Nil(t, rule.Regex)
 	}
 }
+
+func TestCompileStructuredRules(t *testing.T) {
+	rules := []*ProcessingRule{
+		{Type: ExcludeAtFieldMatch, Field: "level", Pattern: "^debug$"},
+		{Type: RemoveField, Field: "password"},
+	}
+	err := CompileProcessingRules(rules)
+	assert.Nil(t, err)
+	assert.NotNil(t, rules[0].Regex)
+	assert.True(t, rules[0].Regex.MatchString("debug"))
+	assert.Nil(t, rules[1].Regex)
+}
+
+func TestCompileGrokParserRules(t *testing.T) {
+	rules := []*ProcessingRule{{Type: GrokParser, Pattern: "%{WORD:level}: %{ID:id:int}", Definitions: map[string]string{"ID": `\d+`}}}
+	err := CompileProcessingRules(rules)
+	assert.Nil(t, err)
+	assert.NotNil(t, rules[0].Grok)
+	attributes, ok := rules[0].Grok.Parse([]byte("error: 42"))
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"level": "error", "id": int64(42)}, attributes)
+
+	rules = []*ProcessingRule{{Type: GrokParser, Pattern: "%{UNKNOWN}"}}
+	assert.NotNil(t, CompileProcessingRules(rules))
+	assert.Nil(t, rules[0].Grok)
+}
+
+func TestCompileGenerateMetricRules(t *testing.T) {
+	rules := []*ProcessingRule{
+		{Type: GenerateMetric, MetricName: "nginx.requests", Pattern: `" (?P<status_code>\d{3}) `},
+		{Type: GenerateMetric, MetricName: "requests", Field: "http.status_code"},
+	}
+	err := CompileProcessingRules(rules)
+	assert.Nil(t, err)
+	assert.NotNil(t, rules[0].Regex)
+	assert.Nil(t, rules[1].Regex)
+}
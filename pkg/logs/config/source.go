@@ -24,6 +24,8 @@ const (
 	DockerSourceType SourceType = "docker"
 	// KubernetesSourceType kubernetes source type
 	KubernetesSourceType SourceType = "kubernetes"
+	// KubernetesAuditSourceType kubernetes audit log source type
+	KubernetesAuditSourceType SourceType = "kubernetes_audit"
 )
 
 // LogSource holds a reference to an integration name and a log configuration, and allows to track errors and
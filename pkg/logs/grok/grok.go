@@ -0,0 +1,186 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package grok compiles grok-style patterns into regular expressions extracting
+// attributes from unstructured log lines.
+//
+// A pattern is a regular expression which may reference the patterns of the library
+// with `%{NAME}`, capture what they match in an attribute with `%{NAME:attribute}` and
+// convert the captured value with `%{NAME:attribute:type}`, where type is `int` or
+// `float`.  The attributes may be nested by separating their names with dots, e.g.
+// `%{INT:http.status_code:int}`.  The named groups of the regular expression,
+// e.g. `(?P<user>\w+)`, are captured as well.
+package grok
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxDepth is the maximum number of nested references, to detect recursive patterns.
+const maxDepth = 16
+
+// referenceRegex matches the references to other patterns: `%{NAME[:attribute[:type]]}`.
+var referenceRegex = regexp.MustCompile(`%\{(\w+)(?::([\w.@\-]+))?(?::(\w+))?\}`)
+
+// Types of the captured values.
+const (
+	stringType = ""
+	intType    = "int"
+	floatType  = "float"
+)
+
+// Pattern is a compiled grok pattern.
+type Pattern struct {
+	regex    *regexp.Regexp
+	captures []capture // by index of subexpression
+}
+
+type capture struct {
+	attribute string
+	kind      string
+}
+
+// Compile compiles the grok pattern, using the built-in patterns.
+func Compile(pattern string) (*Pattern, error) {
+	return CompileWithDefinitions(pattern, nil)
+}
+
+// CompileWithDefinitions compiles the grok pattern, using the given definitions
+// along with the built-in patterns, the definitions taking precedence.
+func CompileWithDefinitions(pattern string, definitions map[string]string) (*Pattern, error) {
+	c := &compiler{definitions: definitions}
+	expanded, err := c.expand(pattern, 0)
+	if err != nil {
+		return nil, err
+	}
+	regex, err := regexp.Compile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %v", pattern, err)
+	}
+
+	p := &Pattern{
+		regex:    regex,
+		captures: make([]capture, len(regex.SubexpNames())),
+	}
+	for i, name := range regex.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if captured, exists := c.captures[name]; exists {
+			p.captures[i] = captured
+		} else {
+			// a named group of the regular expression itself
+			p.captures[i] = capture{attribute: name}
+		}
+	}
+	return p, nil
+}
+
+// Parse returns the attributes captured from the content, and false if the pattern
+// does not match it.  The groups which did not participate in the match are ignored.
+func (p *Pattern) Parse(content []byte) (map[string]interface{}, bool) {
+	indexes := p.regex.FindSubmatchIndex(content)
+	if indexes == nil {
+		return nil, false
+	}
+	attributes := make(map[string]interface{})
+	for i, captured := range p.captures {
+		start, end := indexes[2*i], indexes[2*i+1]
+		if captured.attribute == "" || start < 0 {
+			continue
+		}
+		set(attributes, captured.attribute, convert(string(content[start:end]), captured.kind))
+	}
+	return attributes, true
+}
+
+// String returns the regular expression the pattern was compiled into.
+func (p *Pattern) String() string {
+	return p.regex.String()
+}
+
+// compiler expands the references of a pattern, giving a generated name to the groups
+// capturing attributes since the names of the groups cannot contain dots.
+type compiler struct {
+	definitions map[string]string
+	captures    map[string]capture
+	err         error
+}
+
+func (c *compiler) expand(pattern string, depth int) (string, error) {
+	if depth > maxDepth {
+		return "", fmt.Errorf("too many nested references in pattern %s, it may be recursive", pattern)
+	}
+	expanded := referenceRegex.ReplaceAllStringFunc(pattern, func(reference string) string {
+		if c.err != nil {
+			return ""
+		}
+		parts := referenceRegex.FindStringSubmatch(reference)
+		name, attribute, kind := parts[1], parts[2], parts[3]
+		definition, exists := c.definitions[name]
+		if !exists {
+			definition, exists = Patterns[name]
+		}
+		if !exists {
+			c.err = fmt.Errorf("unknown pattern %s", name)
+			return ""
+		}
+		if kind != stringType && kind != intType && kind != floatType {
+			c.err = fmt.Errorf("unknown type %s for attribute %s, supported types are int and float", kind, attribute)
+			return ""
+		}
+		inner, err := c.expand(definition, depth+1)
+		if err != nil {
+			c.err = err
+			return ""
+		}
+		if attribute == "" {
+			return "(?:" + inner + ")"
+		}
+		if c.captures == nil {
+			c.captures = make(map[string]capture)
+		}
+		group := "grok" + strconv.Itoa(len(c.captures))
+		c.captures[group] = capture{attribute: attribute, kind: kind}
+		return "(?P<" + group + ">" + inner + ")"
+	})
+	if c.err != nil {
+		return "", c.err
+	}
+	return expanded, nil
+}
+
+// convert converts the captured value to its type, it is kept as a string if it cannot be converted.
+func convert(value string, kind string) interface{} {
+	switch kind {
+	case intType:
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case floatType:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// set sets the attribute at the given dot separated path, creating the intermediate objects if needed.
+func set(attributes map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	current := attributes
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[key] = next
+		}
+		current = next
+	}
+	current[keys[len(keys)-1]] = value
+}
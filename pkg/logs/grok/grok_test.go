@@ -0,0 +1,154 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package grok
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileAndParse(t *testing.T) {
+	pattern, err := Compile(`%{WORD:user.name} took %{NUMBER:duration:float}s to %{WORD} (?P<target>\w+) %{INT:count:int} times`)
+	require.NoError(t, err)
+
+	attributes, ok := pattern.Parse([]byte("john took 1.5s to reach home 3 times"))
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{
+		"user":     map[string]interface{}{"name": "john"},
+		"duration": 1.5,
+		"target":   "home",
+		"count":    int64(3),
+	}, attributes)
+
+	attributes, ok = pattern.Parse([]byte("nothing to see here"))
+	assert.False(t, ok)
+	assert.Nil(t, attributes)
+}
+
+func TestCompileWithDefinitions(t *testing.T) {
+	pattern, err := CompileWithDefinitions(`%{MY_ID:id} %{WORD:word}`, map[string]string{
+		"MY_ID": `id-%{INT}`,
+		"WORD":  `[a-z]+`,
+	})
+	require.NoError(t, err)
+	attributes, ok := pattern.Parse([]byte("id-42 abc"))
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"id": "id-42", "word": "abc"}, attributes)
+}
+
+func TestCompileErrors(t *testing.T) {
+	for name, pattern := range map[string]string{
+		"unknown pattern": `%{UNKNOWN:foo}`,
+		"unknown type":    `%{INT:foo:bool}`,
+		"invalid regex":   `%{INT:foo}(`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Compile(pattern)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := CompileWithDefinitions(`%{LOOP}`, map[string]string{"LOOP": `a%{LOOP}`})
+	assert.Error(t, err)
+}
+
+func TestParseKeepsValuesThatCannotBeConverted(t *testing.T) {
+	pattern, err := Compile(`%{NOTSPACE:value:int}`)
+	require.NoError(t, err)
+	attributes, ok := pattern.Parse([]byte("abc"))
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"value": "abc"}, attributes)
+}
+
+func TestBuiltinPatterns(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		line     string
+		expected map[string]interface{}
+	}{
+		{
+			pattern: "%{NGINX_ACCESS}",
+			line:    `172.17.0.1 - - [10/May/2022:12:34:56 +0000] "GET /index.html?page=2 HTTP/1.1" 200 612 "-" "curl/7.68.0"`,
+			expected: map[string]interface{}{
+				"network":     map[string]interface{}{"client": map[string]interface{}{"ip": "172.17.0.1"}, "bytes_written": int64(612)},
+				"http":        map[string]interface{}{"auth": "-", "method": "GET", "url": "/index.html?page=2", "version": "1.1", "status_code": int64(200), "referer": "-", "useragent": "curl/7.68.0"},
+				"date_access": "10/May/2022:12:34:56 +0000",
+			},
+		},
+		{
+			pattern: "%{NGINX_ERROR}",
+			line:    `2022/05/10 12:34:56 [error] 31#31: *1 open() "/usr/share/nginx/html/missing" failed (2: No such file or directory)`,
+			expected: map[string]interface{}{
+				"date_access":   "2022/05/10 12:34:56",
+				"level":         "error",
+				"pid":           int64(31),
+				"tid":           int64(31),
+				"connection_id": int64(1),
+				"error":         map[string]interface{}{"message": `open() "/usr/share/nginx/html/missing" failed (2: No such file or directory)`},
+			},
+		},
+		{
+			pattern: "%{APACHE_COMBINED}",
+			line:    `::1 - frank [10/Oct/2000:13:55:36 -0700] "POST /api HTTP/1.0" 500 - "http://example.com/" "Mozilla/5.0"`,
+			expected: map[string]interface{}{
+				"network":     map[string]interface{}{"client": map[string]interface{}{"ip": "::1"}},
+				"http":        map[string]interface{}{"ident": "-", "auth": "frank", "method": "POST", "url": "/api", "version": "1.0", "status_code": int64(500), "referer": "http://example.com/", "useragent": "Mozilla/5.0"},
+				"date_access": "10/Oct/2000:13:55:36 -0700",
+			},
+		},
+		{
+			pattern: "%{APACHE_ERROR}",
+			line:    `[Fri Sep 09 10:42:29.902022 2011] [core:error] [pid 35708:tid 4328636416] [client 72.15.99.187:54321] File does not exist: /usr/local/apache2/htdocs/favicon.ico`,
+			expected: map[string]interface{}{
+				"date":    "Fri Sep 09 10:42:29.902022 2011",
+				"module":  "core",
+				"level":   "error",
+				"pid":     int64(35708),
+				"tid":     int64(4328636416),
+				"network": map[string]interface{}{"client": map[string]interface{}{"ip": "72.15.99.187", "port": int64(54321)}},
+				"error":   map[string]interface{}{"message": "File does not exist: /usr/local/apache2/htdocs/favicon.ico"},
+			},
+		},
+		{
+			pattern: "%{POSTGRES}",
+			line:    `2022-05-10 12:34:56.789 UTC [1234] app@orders ERROR:  relation "users" does not exist`,
+			expected: map[string]interface{}{
+				"date":  "2022-05-10 12:34:56.789 UTC",
+				"pid":   int64(1234),
+				"db":    map[string]interface{}{"user": "app", "instance": "orders", "message": `relation "users" does not exist`},
+				"level": "ERROR",
+			},
+		},
+		{
+			pattern: "%{POSTGRES}",
+			line:    `2022-05-10 12:34:56 UTC [1] LOG:  database system is ready to accept connections`,
+			expected: map[string]interface{}{
+				"date":  "2022-05-10 12:34:56 UTC",
+				"pid":   int64(1),
+				"db":    map[string]interface{}{"message": "database system is ready to accept connections"},
+				"level": "LOG",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			pattern, err := Compile(test.pattern)
+			require.NoError(t, err)
+			attributes, ok := pattern.Parse([]byte(test.line))
+			assert.True(t, ok)
+			assert.Equal(t, test.expected, attributes)
+		})
+	}
+}
+
+func TestAllBuiltinPatternsCompile(t *testing.T) {
+	for name := range Patterns {
+		_, err := Compile("%{" + name + "}")
+		assert.NoError(t, err, name)
+	}
+}
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package grok
+
+// Patterns are the built-in patterns, the patterns of the usual log formats extract
+// their attributes following the Datadog naming convention.
+var Patterns = map[string]string{
+	// basic patterns
+	"WORD":              `\b\w+\b`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"INT":               `[+-]?\d+`,
+	"POSINT":            `\b[1-9]\d*\b`,
+	"NUMBER":            `[+-]?(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"`,
+	"UUID":              `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+	"USER":              `[a-zA-Z0-9._\-]+`,
+	"IPV4":              `(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)`,
+	"IPV6":              `(?:[0-9A-Fa-f]{0,4}:){2,7}(?:[0-9A-Fa-f]{0,4}|%{IPV4})`,
+	"IP":                `%{IPV6}|%{IPV4}`,
+	"HOSTNAME":          `\b[0-9A-Za-z][0-9A-Za-z\-_]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z\-_]{0,62})*\.?`,
+	"IPORHOST":          `%{IP}|%{HOSTNAME}`,
+	"HOSTPORT":          `%{IPORHOST}:%{POSINT}`,
+	"URIPATH":           `(?:/[A-Za-z0-9$.+!*'(){},~:;=@#%&_\-]*)+`,
+	"URIPARAM":          `\?[A-Za-z0-9$.+!*'|(){},~@#%&/=:;_?\-\[\]<>]*`,
+	"URIPATHPARAM":      `%{URIPATH}(?:%{URIPARAM})?`,
+	"LOGLEVEL":          `(?i:trace|debug|info|notice|warn(?:ing)?|err(?:or)?|crit(?:ical)?|fatal|severe|panic|alert|emerg(?:ency)?)`,
+	"HTTPDATE":          `\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}`,
+	"TIMESTAMP_ISO8601": `\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(?::\d{2}(?:[.,]\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?`,
+
+	// HTTP requests, e.g. `GET /index.html HTTP/1.1`
+	"HTTP_REQUEST": `%{WORD:http.method} %{NOTSPACE:http.url}(?: HTTP/%{NUMBER:http.version})?|%{DATA}`,
+
+	// nginx
+	"NGINX_ACCESS":    `%{IPORHOST:network.client.ip} - %{NOTSPACE:http.auth} \[%{HTTPDATE:date_access}\] "%{HTTP_REQUEST}" %{INT:http.status_code:int} (?:%{INT:network.bytes_written:int}|-)(?: "%{DATA:http.referer}" "%{DATA:http.useragent}")?`,
+	"NGINX_ERRORDATE": `\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}`,
+	"NGINX_ERROR":     `%{NGINX_ERRORDATE:date_access} \[%{LOGLEVEL:level}\] %{INT:pid:int}#%{INT:tid:int}: (?:\*%{INT:connection_id:int} )?%{GREEDYDATA:error.message}`,
+
+	// apache
+	"APACHE_COMMON":    `%{IPORHOST:network.client.ip} %{NOTSPACE:http.ident} %{NOTSPACE:http.auth} \[%{HTTPDATE:date_access}\] "%{HTTP_REQUEST}" %{INT:http.status_code:int} (?:%{INT:network.bytes_written:int}|-)`,
+	"APACHE_COMBINED":  `%{APACHE_COMMON} "%{DATA:http.referer}" "%{DATA:http.useragent}"`,
+	"APACHE_ERRORDATE": `\w{3} \w{3} \d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)? \d{4}`,
+	"APACHE_ERROR":     `\[%{APACHE_ERRORDATE:date}\] \[(?:%{WORD:module}:)?%{LOGLEVEL:level}\] (?:\[pid %{INT:pid:int}(?::tid %{INT:tid:int})?\] )?(?:\[client %{IPORHOST:network.client.ip}(?::%{INT:network.client.port:int})?\] )?%{GREEDYDATA:error.message}`,
+
+	// postgres, with the default `%m [%p] ` log_line_prefix optionally followed by `%q%u@%d `
+	"POSTGRES_TIMESTAMP": `\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)? \w+`,
+	"POSTGRES_LEVEL":     `DEBUG[1-5]|INFO|NOTICE|WARNING|ERROR|LOG|FATAL|PANIC|DETAIL|HINT|STATEMENT|CONTEXT|QUERY|LOCATION`,
+	"POSTGRES":           `%{POSTGRES_TIMESTAMP:date} \[%{INT:pid:int}\] (?:%{USER:db.user}@%{NOTSPACE:db.instance} )?%{POSTGRES_LEVEL:level}:\s+%{GREEDYDATA:db.message}`,
+}
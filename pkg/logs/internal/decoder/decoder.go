@@ -39,6 +39,14 @@ type Message struct {
 	RawDataLen         int
 	Timestamp          string
 	IngestionTimestamp int64
+
+	// EventTime is set by the parsers extracting the time at which the event occurred.
+	EventTime time.Time
+
+	// Hostname, Service and Tags are set by the parsers extracting them from the message.
+	Hostname string
+	Service  string
+	Tags     []string
 }
 
 // NewMessage returns a new output.
@@ -15,6 +15,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/dockerfile"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/encodedtext"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/kubernetes"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/kubernetesaudit"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/noop"
 )
 
@@ -32,6 +33,8 @@ func NewDecoderFromSourceWithPattern(source *config.LogSource, multiLinePattern
 	switch source.GetSourceType() {
 	case config.KubernetesSourceType:
 		lineParser = kubernetes.New()
+	case config.KubernetesAuditSourceType:
+		lineParser = kubernetesaudit.New()
 	case config.DockerSourceType:
 		if coreConfig.Datadog.GetBool("logs_config.use_podman_logs") {
 			// podman's on-disk logs are in kubernetes format
@@ -58,7 +58,12 @@ func (p *SingleLineParser) process(content []byte, rawDataLen int) {
 	if err != nil {
 		log.Debug(err)
 	}
-	p.outputFn(NewMessage(msg.Content, msg.Status, rawDataLen, msg.Timestamp))
+	output := NewMessage(msg.Content, msg.Status, rawDataLen, msg.Timestamp)
+	output.Hostname = msg.Hostname
+	output.Service = msg.Service
+	output.Tags = msg.Tags
+	output.EventTime = msg.EventTime
+	p.outputFn(output)
 }
 
 // MultiLineParser makes sure that chunked lines are properly put together.
@@ -72,6 +77,7 @@ type MultiLineParser struct {
 	lineLimit    int
 	status       string
 	timestamp    string
+	eventTime    time.Time
 }
 
 // NewMultiLineParser returns a new MultiLineParser.
@@ -118,6 +124,7 @@ func (p *MultiLineParser) process(content []byte, rawDataLen int) {
 	// from the right place at restart
 	p.rawDataLen += rawDataLen
 	p.timestamp = msg.Timestamp
+	p.eventTime = msg.EventTime
 	p.status = msg.Status
 	p.buffer.Write(msg.Content)
 
@@ -145,6 +152,8 @@ func (p *MultiLineParser) sendLine() {
 	content := make([]byte, p.buffer.Len())
 	copy(content, p.buffer.Bytes())
 	if len(content) > 0 || p.rawDataLen > 0 {
-		p.outputFn(NewMessage(content, p.status, p.rawDataLen, p.timestamp))
+		output := NewMessage(content, p.status, p.rawDataLen, p.timestamp)
+		output.EventTime = p.eventTime
+		p.outputFn(output)
 	}
 }
@@ -38,6 +38,11 @@ const (
 	// headers are included in the log frame.  The size in those headers is not
 	// consulted.  The result does not include the trailing newlines.
 	DockerStream
+
+	// Syslog messages, either octet-counted or newline-terminated as described
+	// in RFC 6587.  The result does not include the length header of
+	// octet-counted frames.
+	Syslog
 )
 
 // Framer gets chunks of bytes (via Process(..)) and uses an
@@ -95,6 +100,8 @@ func NewFramer(
 		matcher = &oneByteNewLineMatcher{contentLenLimit}
 	case DockerStream:
 		matcher = &dockerStreamMatcher{contentLenLimit}
+	case Syslog:
+		matcher = &syslogMatcher{contentLenLimit: contentLenLimit, newline: oneByteNewLineMatcher{contentLenLimit}}
 	default:
 		panic(fmt.Sprintf("unknown framing %d", framing))
 	}
@@ -113,6 +113,18 @@ func TestLineBreaking(t *testing.T) {
 		t.Run("one-byte chunks", test(framing, chunk(utf16, 1), lines, lens))
 	})
 
+	t.Run("Syslog", func(t *testing.T) {
+		// octet-counted frames can contain newlines and be mixed with newline-terminated frames
+		input := []byte("12 <34>1 line\nA11 <34>1 line2<34>1 line3\n")
+		lines := []string{"<34>1 line\nA", "<34>1 line2", "<34>1 line3"}
+		lens := []int{15, 14, 12}
+		framing := Syslog
+		t.Run("one chunk", test(framing, chunk(input, len(input)), lines, lens))
+		for size := 0; size < 20; size++ {
+			t.Run(fmt.Sprintf("%d-byte chunks", size), test(framing, chunk(input, size), lines, lens))
+		}
+	})
+
 	dockerChunk := func(stream byte, data []byte) []byte {
 		header := [8]byte{stream}
 		binary.BigEndian.PutUint32(header[4:8], uint32(len(data)))
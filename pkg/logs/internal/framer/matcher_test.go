@@ -94,3 +94,34 @@ func TestTwoByteNewlineMatcher_FindFrame_Misaligned(t *testing.T) {
 	}
 	testFindFrame(t, &twoByteNewLineMatcher{contentLenLimit: 100, newline: Utf16leEOL}, input, 16, 18)
 }
+
+func TestSyslogMatcher_FindFrame_OctetCounted(t *testing.T) {
+	m := &syslogMatcher{contentLenLimit: 100, newline: oneByteNewLineMatcher{contentLenLimit: 100}}
+	content, rawDataLen := m.FindFrame([]byte("5 ab\ncd3 efg"), 0)
+	assert.Equal(t, []byte("ab\ncd"), content)
+	assert.Equal(t, 7, rawDataLen)
+
+	// the frame is incomplete
+	content, rawDataLen = m.FindFrame([]byte("5 ab\nc"), 0)
+	assert.Nil(t, content)
+	assert.Equal(t, 0, rawDataLen)
+	content, rawDataLen = m.FindFrame([]byte("12"), 0)
+	assert.Nil(t, content)
+	assert.Equal(t, 0, rawDataLen)
+}
+
+func TestSyslogMatcher_FindFrame_Newline(t *testing.T) {
+	m := &syslogMatcher{contentLenLimit: 100, newline: oneByteNewLineMatcher{contentLenLimit: 100}}
+	testFindFrame(t, m, []byte("<34>1 abcd\n1234"), 10, 11)
+	testFindFrame(t, m, []byte("0 abcd\n1234"), 6, 7)
+	testFindFrame(t, m, []byte("12abcd\n1234"), 6, 7)
+}
+
+func TestSyslogMatcher_FindFrame_cll(t *testing.T) {
+	// frames announced bigger than the limit are newline-terminated
+	m := &syslogMatcher{contentLenLimit: 10, newline: oneByteNewLineMatcher{contentLenLimit: 10}}
+	testFindFrame(t, m, []byte("9 abcdefgh\nij"), 10, 11)
+	content, rawDataLen := m.FindFrame([]byte("8 abcdefgh"), 0)
+	assert.Equal(t, []byte("abcdefgh"), content)
+	assert.Equal(t, 10, rawDataLen)
+}
@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package framer
+
+// syslogMatcher matches syslog messages framed as described in RFC 6587: either
+// with octet counting, `MSG-LEN SP SYSLOG-MSG`, or terminated by a newline.
+// The framing is detected for each frame, octet-counted frames can contain newlines.
+type syslogMatcher struct {
+	// contentLenLimit is the maximum content length that will be returned.
+	// Octet-counted frames longer than this value are handled as newline-terminated
+	// frames.
+	contentLenLimit int
+
+	newline oneByteNewLineMatcher
+}
+
+// FindFrame implements EndLineMatcher#FindFrame.
+func (s *syslogMatcher) FindFrame(buf []byte, seen int) ([]byte, int) {
+	msgLen, headerLen := parseOctetCount(buf, s.contentLenLimit)
+	if headerLen == 0 {
+		return s.newline.FindFrame(buf, seen)
+	}
+	if len(buf) < headerLen+msgLen {
+		return nil, 0
+	}
+	return buf[headerLen : headerLen+msgLen], headerLen + msgLen
+}
+
+// parseOctetCount returns the length of the message and the length of the `MSG-LEN SP`
+// header at the beginning of buf, or zeros if buf does not start with a complete header
+// announcing a frame of at most limit bytes.
+func parseOctetCount(buf []byte, limit int) (int, int) {
+	msgLen := 0
+	for i, b := range buf {
+		switch {
+		case b >= '0' && b <= '9':
+			if i == 0 && b == '0' {
+				// MSG-LEN is a non-zero number
+				return 0, 0
+			}
+			msgLen = msgLen*10 + int(b-'0')
+			if i+2+msgLen > limit {
+				return 0, 0
+			}
+		case b == ' ' && i > 0:
+			return msgLen, i + 1
+		default:
+			return 0, 0
+		}
+	}
+	return 0, 0
+}
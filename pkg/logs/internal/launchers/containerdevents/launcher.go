@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build containerd
+// +build containerd
+
+package containerdevents
+
+import (
+	containerdevents "github.com/containerd/containerd/events"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/launchers"
+	tailer "github.com/DataDog/datadog-agent/pkg/logs/internal/tailers/containerdevents"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	ctrUtil "github.com/DataDog/datadog-agent/pkg/util/containerd"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/startstop"
+)
+
+// Launcher is in charge of starting and stopping the tailers of the containerd events
+type Launcher struct {
+	addedSources     chan *config.LogSource
+	removedSources   chan *config.LogSource
+	pipelineProvider pipeline.Provider
+	tailers          map[*config.LogSource]*tailer.Tailer
+	// subscriber returns the subscriber to the containerd events, it is a field for testing purposes
+	subscriber func() (containerdevents.Subscriber, error)
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// NewLauncher returns a new Launcher.
+func NewLauncher() *Launcher {
+	return &Launcher{
+		tailers:    make(map[*config.LogSource]*tailer.Tailer),
+		subscriber: subscriber,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start starts the launcher.
+func (l *Launcher) Start(sourceProvider launchers.SourceProvider, pipelineProvider pipeline.Provider, registry auditor.Registry) {
+	l.addedSources, l.removedSources = sourceProvider.SubscribeForType(config.ContainerdEventsType)
+	l.pipelineProvider = pipelineProvider
+	go l.run()
+}
+
+// Stop stops all active tailers
+func (l *Launcher) Stop() {
+	close(l.stop)
+	<-l.done
+	stopper := startstop.NewParallelStopper()
+	for source, tailer := range l.tailers {
+		stopper.Add(tailer)
+		delete(l.tailers, source)
+	}
+	stopper.Stop()
+}
+
+// run starts and stops the tailers.
+func (l *Launcher) run() {
+	defer close(l.done)
+	for {
+		select {
+		case source := <-l.addedSources:
+			if _, exists := l.tailers[source]; exists {
+				continue
+			}
+			subscriber, err := l.subscriber()
+			if err != nil {
+				log.Warnf("Could not connect to containerd to stream its events: %v", err)
+				source.Status.Error(err)
+				continue
+			}
+			tailer := tailer.NewTailer(source, subscriber, l.pipelineProvider.NextPipelineChan())
+			tailer.Start()
+			l.tailers[source] = tailer
+		case source := <-l.removedSources:
+			if tailer, exists := l.tailers[source]; exists {
+				delete(l.tailers, source)
+				tailer.Stop()
+			}
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// subscriber returns the event service of the local containerd.
+func subscriber() (containerdevents.Subscriber, error) {
+	cu, err := ctrUtil.NewContainerdUtil()
+	if err != nil {
+		return nil, err
+	}
+	return cu.GetEvents(), nil
+}
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build containerd
+// +build containerd
+
+package containerdevents
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	containerdevents "github.com/containerd/containerd/events"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+)
+
+type fakeSubscriber struct {
+	contexts chan context.Context
+}
+
+func (s *fakeSubscriber) Subscribe(ctx context.Context, filters ...string) (<-chan *containerdevents.Envelope, <-chan error) {
+	s.contexts <- ctx
+	return make(chan *containerdevents.Envelope), make(chan error)
+}
+
+func TestLauncherStartsAndStopsTailers(t *testing.T) {
+	subscriber := &fakeSubscriber{contexts: make(chan context.Context, 1)}
+	sources := config.NewLogSources()
+	launcher := NewLauncher()
+	launcher.subscriber = func() (containerdevents.Subscriber, error) {
+		return subscriber, nil
+	}
+	launcher.Start(sources, mock.NewMockProvider(), nil)
+	defer launcher.Stop()
+
+	source := config.NewLogSource("containerd_events", &config.LogsConfig{Type: config.ContainerdEventsType})
+	sources.AddSource(source)
+	ctx := <-subscriber.contexts
+
+	sources.RemoveSource(source)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the tailer was not stopped")
+	}
+}
+
+func TestLauncherReportsConnectionErrors(t *testing.T) {
+	sources := config.NewLogSources()
+	launcher := NewLauncher()
+	launcher.subscriber = func() (containerdevents.Subscriber, error) {
+		return nil, errors.New("containerd is not running")
+	}
+	launcher.Start(sources, mock.NewMockProvider(), nil)
+
+	source := config.NewLogSource("containerd_events", &config.LogsConfig{Type: config.ContainerdEventsType})
+	sources.AddSource(source)
+	launcher.Stop()
+	assert.Equal(t, "Error: containerd is not running", source.Status.GetError())
+}
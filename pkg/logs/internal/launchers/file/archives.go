@@ -0,0 +1,237 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	tailer "github.com/DataDog/datadog-agent/pkg/logs/internal/tailers/file"
+)
+
+// archiveTailer is a tailer reading a compressed archive matching the archive_path of a source.
+type archiveTailer struct {
+	tailer *tailer.Tailer
+	source *config.LogSource
+}
+
+// pendingRotation is a rotated file whose unread data should be read from the next archive
+// matching the archive_path of its source.
+type pendingRotation struct {
+	tailer *tailer.Tailer
+	source *config.LogSource
+}
+
+// newArchiveFile returns the file of the compressed archive at path, identified by its fingerprint
+// so that the archive is recognized after a rotation renames it. Returns false if the archive can't
+// be read or isn't compressed.
+func newArchiveFile(path string, source *config.LogSource) (*tailer.File, bool) {
+	fingerprint, err := tailer.Fingerprint(path)
+	if err != nil || fingerprint == "" {
+		return nil, false
+	}
+	file := tailer.NewFile(path, source, true)
+	file.Fingerprint = fingerprint
+	return file, true
+}
+
+// archiveKey returns the key of the content of a compressed file in the archive tailers and in the
+// read archives. Like the scan key, it depends on the container of the source, if any.
+func archiveKey(file *tailer.File) string {
+	if file.Source != nil && file.Source.Config != nil && file.Source.Config.Identifier != "" {
+		return file.Fingerprint + "/" + file.Source.Config.Identifier
+	}
+	return file.Fingerprint
+}
+
+// markArchiveRead records that the compressed file read by the finished tailer has been entirely read.
+func (s *Launcher) markArchiveRead(t *tailer.Tailer, file *tailer.File, seen map[string]bool) {
+	file.Fingerprint = t.Fingerprint()
+	key := archiveKey(file)
+	s.readArchives[key] = true
+	seen[key] = true
+}
+
+// isArchiveRead fingerprints the file if it is compressed, and returns true if it has already
+// been read, or if it is still being written and can't be fingerprinted yet, in which case it
+// is read once it can.
+func (s *Launcher) isArchiveRead(file *tailer.File, seen map[string]bool) bool {
+	fingerprint, err := tailer.Fingerprint(file.Path)
+	if err == tailer.ErrArchiveIncomplete {
+		return true
+	}
+	if err != nil || fingerprint == "" {
+		return false
+	}
+	file.Fingerprint = fingerprint
+	key := archiveKey(file)
+	seen[key] = true
+	return s.readArchives[key]
+}
+
+// addArchives handles the archives already present when a source is added:
+// they are read if the source backfills its archives, ignored otherwise.
+func (s *Launcher) addArchives(source *config.LogSource) {
+	if source.Config.ArchivePath == "" {
+		return
+	}
+	for _, path := range s.archivePaths(source) {
+		file, ok := newArchiveFile(path, source)
+		if !ok {
+			continue
+		}
+		if source.Config.BackfillArchives {
+			s.startArchiveTailer(file, -1)
+		} else {
+			s.readArchives[archiveKey(file)] = true
+		}
+	}
+}
+
+// scanArchives stops the tailers that read their archive entirely and starts
+// new tailers for the archives that appeared since the last scan. The keys of the
+// archives found are added to seen.
+func (s *Launcher) scanArchives(seen map[string]bool) {
+	for key, t := range s.archiveTailers {
+		if t.tailer.IsFinished() {
+			s.readArchives[key] = true
+			s.stopArchiveTailer(key, t)
+		}
+	}
+
+	for _, source := range s.activeSources {
+		if source.Config.ArchivePath == "" {
+			continue
+		}
+		for _, path := range s.archivePaths(source) {
+			file, ok := newArchiveFile(path, source)
+			if !ok {
+				continue
+			}
+			key := archiveKey(file)
+			seen[key] = true
+			if _, isTailed := s.archiveTailers[key]; isTailed || s.readArchives[key] {
+				continue
+			}
+			if rotation := s.nextPendingRotation(source); rotation != nil {
+				if !rotation.tailer.IsFinished() {
+					// the rotated file is still being read, wait for its final offset
+					continue
+				}
+				if s.startArchiveTailer(file, rotation.tailer.DecodedOffset()) {
+					s.removePendingRotation(rotation)
+				}
+				continue
+			}
+			if source.Config.BackfillArchives {
+				s.startArchiveTailer(file, -1)
+			} else {
+				s.readArchives[key] = true
+			}
+		}
+	}
+}
+
+// archivePaths returns the archives matching the archive_path of the source.
+func (s *Launcher) archivePaths(source *config.LogSource) []string {
+	paths, err := filepath.Glob(source.Config.ArchivePath)
+	if err != nil {
+		log.Warnf("Malformed archive_path %s: %v", source.Config.ArchivePath, err)
+		return nil
+	}
+	return paths
+}
+
+// startArchiveTailer starts a tailer reading the archive from the given offset in its uncompressed stream,
+// or from the offset recorded in the registry if it is negative. Returns true if the tailer started.
+func (s *Launcher) startArchiveTailer(file *tailer.File, offset int64) bool {
+	if len(s.tailers)+len(s.archiveTailers) >= s.tailingLimit {
+		return false
+	}
+
+	path := file.Path
+	t := s.createTailer(file, s.pipelineProvider.NextPipelineChan())
+
+	whence := io.SeekStart
+	if offset < 0 {
+		var err error
+		offset, whence, err = Position(s.registry, t.Identifier(), config.Beginning)
+		if err != nil {
+			log.Warnf("Could not recover offset for archive with path %v: %v", path, err)
+		}
+	}
+
+	log.Infof("Starting a new tailer for archive: %s (offset: %d, whence: %d)", path, offset, whence)
+	if err := t.Start(offset, whence); err != nil {
+		// the archive might still be being written, let's try again in the next scan
+		log.Debugf("Could not start tailing archive %s: %v", path, err)
+		return false
+	}
+	s.archiveTailers[archiveKey(file)] = &archiveTailer{tailer: t, source: file.Source}
+	return true
+}
+
+// stopArchiveTailer stops the tailer of an archive.
+func (s *Launcher) stopArchiveTailer(key string, t *archiveTailer) {
+	go t.tailer.Stop()
+	delete(s.archiveTailers, key)
+}
+
+// forgetArchives forgets the read archives that were not seen during the last scan,
+// they have been deleted.
+func (s *Launcher) forgetArchives(seen map[string]bool) {
+	for key := range s.readArchives {
+		if !seen[key] {
+			delete(s.readArchives, key)
+		}
+	}
+}
+
+// removeArchives stops the archive tailers and forgets the rotations of a removed source.
+func (s *Launcher) removeArchives(source *config.LogSource) {
+	for key, t := range s.archiveTailers {
+		if t.source == source {
+			s.stopArchiveTailer(key, t)
+		}
+	}
+	rotations := s.pendingRotations[:0]
+	for _, rotation := range s.pendingRotations {
+		if rotation.source != source {
+			rotations = append(rotations, rotation)
+		}
+	}
+	s.pendingRotations = rotations
+}
+
+// addPendingRotation records a rotated tailer whose unread data should be read from the next archive.
+func (s *Launcher) addPendingRotation(t *tailer.Tailer, file *tailer.File) {
+	if file.Source.Config.ArchivePath == "" || !file.Source.Config.FollowRotatedArchives {
+		return
+	}
+	s.pendingRotations = append(s.pendingRotations, &pendingRotation{tailer: t, source: file.Source})
+}
+
+// nextPendingRotation returns the oldest pending rotation of the source, if any.
+func (s *Launcher) nextPendingRotation(source *config.LogSource) *pendingRotation {
+	for _, rotation := range s.pendingRotations {
+		if rotation.source == source {
+			return rotation
+		}
+	}
+	return nil
+}
+
+func (s *Launcher) removePendingRotation(rotation *pendingRotation) {
+	for i, r := range s.pendingRotations {
+		if r == rotation {
+			s.pendingRotations = append(s.pendingRotations[:i], s.pendingRotations[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+// +build !windows
+
+package file
+
+import (
+	"compress/gzip"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+	auditor "github.com/DataDog/datadog-agent/pkg/logs/auditor/mock"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+	"github.com/DataDog/datadog-agent/pkg/logs/status"
+)
+
+func writeGzipArchive(t *testing.T, path string, content string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	w := gzip.NewWriter(f)
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
+
+func newArchiveTestLauncher(t *testing.T, source *config.LogSource) (*Launcher, chan *message.Message) {
+	launcher := NewLauncher(10, 10*time.Millisecond, false, 10*time.Second)
+	launcher.pipelineProvider = mock.NewMockProvider()
+	launcher.registry = auditor.NewRegistry()
+	status.Clear()
+	status.InitStatus(config.CreateSources([]*config.LogSource{source}))
+	t.Cleanup(func() {
+		launcher.cleanup()
+		status.Clear()
+	})
+	return launcher, launcher.pipelineProvider.NextPipelineChan()
+}
+
+func waitArchiveTailersFinished(t *testing.T, launcher *Launcher) {
+	assert.Eventually(t, func() bool {
+		for _, t := range launcher.archiveTailers {
+			if !t.tailer.IsFinished() {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestLauncherBackfillArchives(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+	writeGzipArchive(t, filepath.Join(dir, "app.log.1.gz"), "archived\n")
+
+	source := config.NewLogSource("", &config.LogsConfig{
+		Type:             config.FileType,
+		Path:             path,
+		ArchivePath:      filepath.Join(dir, "app.log.*.gz"),
+		BackfillArchives: true,
+	})
+	launcher, outputChan := newArchiveTestLauncher(t, source)
+	launcher.addSource(source)
+
+	assert.Len(t, launcher.tailers, 1)
+	assert.Len(t, launcher.archiveTailers, 1)
+	msg := <-outputChan
+	assert.Equal(t, "archived", string(msg.Content))
+
+	// once read, the archive tailer is stopped and the archive is not read again
+	waitArchiveTailersFinished(t, launcher)
+	launcher.scan()
+	assert.Len(t, launcher.archiveTailers, 0)
+	launcher.scan()
+	assert.Len(t, launcher.archiveTailers, 0)
+
+	// new archives are backfilled too
+	writeGzipArchive(t, filepath.Join(dir, "app.log.2.gz"), "archived again\n")
+	launcher.scan()
+	assert.Len(t, launcher.archiveTailers, 1)
+	msg = <-outputChan
+	assert.Equal(t, "archived again", string(msg.Content))
+}
+
+func TestLauncherIgnoresArchivesWithoutBackfill(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+	writeGzipArchive(t, filepath.Join(dir, "app.log.1.gz"), "archived\n")
+
+	source := config.NewLogSource("", &config.LogsConfig{
+		Type:        config.FileType,
+		Path:        path,
+		ArchivePath: filepath.Join(dir, "app.log.*.gz"),
+	})
+	launcher, _ := newArchiveTestLauncher(t, source)
+	launcher.addSource(source)
+	launcher.scan()
+
+	assert.Len(t, launcher.archiveTailers, 0)
+}
+
+func TestLauncherReadsCompressedFileOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeGzipArchive(t, filepath.Join(dir, "app.log.gz"), "compressed\n")
+
+	source := config.NewLogSource("", &config.LogsConfig{
+		Type: config.FileType,
+		Path: filepath.Join(dir, "*.gz"),
+	})
+	launcher, outputChan := newArchiveTestLauncher(t, source)
+	launcher.activeSources = append(launcher.activeSources, source)
+	launcher.scan()
+
+	msg := <-outputChan
+	assert.Equal(t, "compressed", string(msg.Content))
+	key := filepath.Join(dir, "app.log.gz")
+	assert.Eventually(t, launcher.tailers[key].IsFinished, 5*time.Second, 10*time.Millisecond)
+
+	launcher.scan()
+	assert.Len(t, launcher.tailers, 0)
+	launcher.scan()
+	assert.Len(t, launcher.tailers, 0)
+}
+
+func TestLauncherReadsGrowingArchiveOnce(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+	archivePath := filepath.Join(dir, "app.log.1.gz")
+
+	var lines []string
+	for i := 0; i < 200; i++ {
+		lines = append(lines, fmt.Sprintf("line %d %x", i, rand.Int63()))
+	}
+	writeGzipArchive(t, archivePath, strings.Join(lines, "\n")+"\n")
+	complete, err := os.ReadFile(archivePath)
+	require.NoError(t, err)
+	require.Greater(t, len(complete), 2048)
+	require.NoError(t, os.WriteFile(archivePath, complete[:512], 0644))
+
+	source := config.NewLogSource("", &config.LogsConfig{
+		Type:             config.FileType,
+		Path:             path,
+		ArchivePath:      filepath.Join(dir, "app.log.*.gz"),
+		BackfillArchives: true,
+	})
+	launcher, outputChan := newArchiveTestLauncher(t, source)
+	launcher.addSource(source)
+	assert.Len(t, launcher.archiveTailers, 0)
+
+	// the archive being written is only read once it can be fingerprinted
+	for _, size := range []int{1000, 1500, 2048} {
+		require.NoError(t, os.WriteFile(archivePath, complete[:size], 0644))
+		launcher.scan()
+		if size < 1024 {
+			assert.Len(t, launcher.archiveTailers, 0, size)
+		} else {
+			assert.Len(t, launcher.archiveTailers, 1, size)
+		}
+	}
+	require.NoError(t, os.WriteFile(archivePath, complete, 0644))
+
+	for _, line := range lines {
+		msg := <-outputChan
+		assert.Equal(t, line, string(msg.Content))
+	}
+	waitArchiveTailersFinished(t, launcher)
+	launcher.scan()
+	launcher.scan()
+	assert.Len(t, launcher.archiveTailers, 0)
+	assert.Len(t, launcher.readArchives, 1)
+	select {
+	case msg := <-outputChan:
+		assert.Fail(t, "the archive was read again", string(msg.Content))
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestLauncherFollowRotatedArchive(t *testing.T) {
+	coreConfig.Datadog.Set("logs_config.close_timeout", 1)
+	defer coreConfig.Datadog.Set("logs_config.close_timeout", 60)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0644))
+
+	source := config.NewLogSource("", &config.LogsConfig{
+		Type:                  config.FileType,
+		Path:                  path,
+		TailingMode:           "beginning",
+		ArchivePath:           filepath.Join(dir, "app.log.*.gz"),
+		FollowRotatedArchives: true,
+	})
+	launcher, outputChan := newArchiveTestLauncher(t, source)
+	launcher.addSource(source)
+	msg := <-outputChan
+	assert.Equal(t, "hello", string(msg.Content))
+
+	// the file is copied and truncated before its last line is read, then its copy is compressed
+	require.NoError(t, os.Truncate(path, 0))
+	assert.Eventually(t, func() bool {
+		launcher.scan()
+		return len(launcher.pendingRotations) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	writeGzipArchive(t, filepath.Join(dir, "app.log.1.gz"), "hello\nworld\n")
+
+	// the archive is read from where the rotated file was left once its tailer stopped
+	assert.Eventually(t, func() bool {
+		launcher.scan()
+		return len(launcher.archiveTailers) == 1
+	}, 5*time.Second, 100*time.Millisecond)
+	assert.Len(t, launcher.pendingRotations, 0)
+	msg = <-outputChan
+	assert.Equal(t, "world", string(msg.Content))
+}
+
+func TestLauncherBackfillNumberedArchives(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+	writeGzipArchive(t, filepath.Join(dir, "app.log.1.gz"), "first\n")
+
+	source := config.NewLogSource("", &config.LogsConfig{
+		Type:             config.FileType,
+		Path:             path,
+		ArchivePath:      filepath.Join(dir, "app.log.*.gz"),
+		BackfillArchives: true,
+	})
+	launcher, outputChan := newArchiveTestLauncher(t, source)
+	launcher.addSource(source)
+	msg := <-outputChan
+	assert.Equal(t, "first", string(msg.Content))
+	waitArchiveTailersFinished(t, launcher)
+	launcher.scan()
+	assert.Len(t, launcher.archiveTailers, 0)
+
+	// logrotate shifts the numbered archives before creating the new first one
+	rotate := func(content string) {
+		for i := 2; i > 0; i-- {
+			from := filepath.Join(dir, fmt.Sprintf("app.log.%d.gz", i))
+			if _, err := os.Stat(from); err == nil {
+				require.NoError(t, os.Rename(from, filepath.Join(dir, fmt.Sprintf("app.log.%d.gz", i+1))))
+			}
+		}
+		writeGzipArchive(t, filepath.Join(dir, "app.log.1.gz"), content)
+	}
+
+	// only the new archives are read, the renamed ones are recognized
+	var identifiers []string
+	for i, content := range []string{"second", "third"} {
+		rotate(content + "\n")
+		launcher.scan()
+		require.Len(t, launcher.archiveTailers, 1)
+		for _, archive := range launcher.archiveTailers {
+			// the registry offset of the archive previously at this path doesn't apply
+			assert.NotContains(t, identifiers, archive.tailer.Identifier())
+			identifiers = append(identifiers, archive.tailer.Identifier())
+		}
+		msg = <-outputChan
+		assert.Equal(t, content, string(msg.Content))
+		waitArchiveTailersFinished(t, launcher)
+		launcher.scan()
+		assert.Len(t, launcher.archiveTailers, 0)
+		launcher.scan()
+		assert.Len(t, launcher.archiveTailers, 0)
+		assert.Len(t, launcher.readArchives, i+2)
+	}
+	assert.Len(t, outputChan, 0)
+}
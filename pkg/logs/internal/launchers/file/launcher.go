@@ -56,6 +56,13 @@ type Launcher struct {
 	// Feature flag defaulting to false, use `logs_config.validate_pod_container_id`.
 	validatePodContainerID bool
 	scanPeriod             time.Duration
+	// archiveTailers read the compressed archives matching the archive_path of the sources,
+	// readArchives are the archives that have been entirely read or that must be ignored and
+	// pendingRotations the rotated files whose unread data should be read from their archive.
+	// The archives are keyed by their fingerprint, not by their path that rotations reuse.
+	archiveTailers   map[string]*archiveTailer
+	readArchives     map[string]bool
+	pendingRotations []*pendingRotation
 }
 
 // NewLauncher returns a new launcher.
@@ -68,6 +75,8 @@ func NewLauncher(tailingLimit int, tailerSleepDuration time.Duration, validatePo
 		stop:                   make(chan struct{}),
 		validatePodContainerID: validatePodContainerID,
 		scanPeriod:             scanPeriod,
+		archiveTailers:         make(map[string]*archiveTailer),
+		readArchives:           make(map[string]bool),
 	}
 }
 
@@ -113,6 +122,10 @@ func (s *Launcher) cleanup() {
 		stopper.Add(tailer)
 		delete(s.tailers, scanKey)
 	}
+	for path, t := range s.archiveTailers {
+		stopper.Add(t.tailer)
+		delete(s.archiveTailers, path)
+	}
 	stopper.Stop()
 }
 
@@ -123,6 +136,7 @@ func (s *Launcher) cleanup() {
 func (s *Launcher) scan() {
 	files := s.fileProvider.filesToTail(s.activeSources)
 	filesTailed := make(map[string]bool)
+	archivesSeen := make(map[string]bool)
 	tailersLen := len(s.tailers)
 
 	for _, file := range files {
@@ -138,9 +152,16 @@ func (s *Launcher) scan() {
 		tailerKey := file.GetScanKey()
 		tailer, isTailed := s.tailers[tailerKey]
 		if isTailed && tailer.IsFinished() {
+			if tailer.IsCompressed() {
+				// a compressed file is only read once, unless it is replaced
+				s.markArchiveRead(tailer, file, archivesSeen)
+			}
 			// skip this tailer as it must be stopped
 			continue
 		}
+		if !isTailed && s.isArchiveRead(file, archivesSeen) {
+			continue
+		}
 		if !isTailed && tailersLen >= s.tailingLimit {
 			// can't create new tailer because tailingLimit is reached
 			continue
@@ -181,12 +202,16 @@ func (s *Launcher) scan() {
 			s.stopTailer(scanKey, tailer)
 		}
 	}
+
+	s.scanArchives(archivesSeen)
+	s.forgetArchives(archivesSeen)
 }
 
 // addSource keeps track of the new source and launch new tailers for this source.
 func (s *Launcher) addSource(source *config.LogSource) {
 	s.activeSources = append(s.activeSources, source)
 	s.launchTailers(source)
+	s.addArchives(source)
 }
 
 // removeSource removes the source from cache.
@@ -198,6 +223,7 @@ func (s *Launcher) removeSource(source *config.LogSource) {
 			break
 		}
 	}
+	s.removeArchives(source)
 }
 
 // launch launches new tailers for a new source.
@@ -372,6 +398,7 @@ func (s *Launcher) stopTailer(scanKey string, tailer *tailer.Tailer) {
 func (s *Launcher) restartTailerAfterFileRotation(tailer *tailer.Tailer, file *tailer.File) bool {
 	log.Info("Log rotation happened to ", file.Path)
 	tailer.StopAfterFileRotation()
+	s.addPendingRotation(tailer, file)
 	tailer = s.createRotatedTailer(tailer, file, tailer.GetDetectedPattern())
 	// force reading file from beginning since it has been log-rotated
 	err := tailer.StartFromBeginning()
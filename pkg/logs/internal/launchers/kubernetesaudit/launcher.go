@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kubernetesaudit
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/launchers"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	defaultService = "kube-apiserver"
+	defaultSource  = "kubernetes.audit"
+)
+
+// Launcher turns the kubernetes_audit sources into file sources whose lines are
+// parsed as audit events, the audit log is then tailed by the file launcher.
+type Launcher struct {
+	sources        *config.LogSources
+	fileSources    map[*config.LogSource]*config.LogSource
+	addedSources   chan *config.LogSource
+	removedSources chan *config.LogSource
+	stop           chan struct{}
+	done           chan struct{}
+}
+
+// NewLauncher returns a new launcher.
+func NewLauncher(sources *config.LogSources) *Launcher {
+	return &Launcher{
+		sources:     sources,
+		fileSources: make(map[*config.LogSource]*config.LogSource),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Start starts the launcher.
+func (l *Launcher) Start(sourceProvider launchers.SourceProvider, pipelineProvider pipeline.Provider, registry auditor.Registry) {
+	l.addedSources, l.removedSources = sourceProvider.SubscribeForType(config.KubernetesAuditType)
+	go l.run()
+}
+
+// Stop stops the launcher.
+func (l *Launcher) Stop() {
+	close(l.stop)
+	<-l.done
+}
+
+// run adds and removes the file sources of the audit logs.
+func (l *Launcher) run() {
+	defer close(l.done)
+	for {
+		select {
+		case source := <-l.addedSources:
+			l.addSource(source)
+		case source := <-l.removedSources:
+			l.removeSource(source)
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// addSource adds the file source tailing the audit log of the source.
+func (l *Launcher) addSource(source *config.LogSource) {
+	if _, exists := l.fileSources[source]; exists {
+		return
+	}
+	log.Infof("Tailing the Kubernetes audit log %s", source.Config.Path)
+	fileSource := newFileSource(source)
+	l.fileSources[source] = fileSource
+	l.sources.AddSource(fileSource)
+}
+
+// removeSource removes the file source tailing the audit log of the source.
+func (l *Launcher) removeSource(source *config.LogSource) {
+	if fileSource, exists := l.fileSources[source]; exists {
+		delete(l.fileSources, source)
+		l.sources.RemoveSource(fileSource)
+	}
+}
+
+// newFileSource returns a file source that inherits the properties of the audit source.
+func newFileSource(source *config.LogSource) *config.LogSource {
+	service := source.Config.Service
+	if service == "" {
+		service = defaultService
+	}
+	sourceName := source.Config.Source
+	if sourceName == "" {
+		sourceName = defaultSource
+	}
+	fileSource := config.NewLogSource(source.Name, &config.LogsConfig{
+		Type:            config.FileType,
+		Path:            source.Config.Path,
+		TailingMode:     source.Config.TailingMode,
+		Service:         service,
+		Source:          sourceName,
+		SourceCategory:  source.Config.SourceCategory,
+		Tags:            source.Config.Tags,
+		ProcessingRules: source.Config.ProcessingRules,
+	})
+	fileSource.SetSourceType(config.KubernetesAuditSourceType)
+	fileSource.Status = source.Status
+	fileSource.ParentSource = source
+	return fileSource
+}
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kubernetesaudit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+func TestLauncherAddsAndRemovesFileSources(t *testing.T) {
+	sources := config.NewLogSources()
+	fileSources, removedFileSources := sources.SubscribeForType(config.FileType)
+
+	launcher := NewLauncher(sources)
+	launcher.Start(sources, nil, nil)
+	defer launcher.Stop()
+
+	source := config.NewLogSource("kubernetes_audit", &config.LogsConfig{
+		Type: config.KubernetesAuditType,
+		Path: "/var/log/kubernetes/audit/audit.log",
+		Tags: []string{"cluster:foo"},
+	})
+	go sources.AddSource(source)
+
+	fileSource := <-fileSources
+	assert.Equal(t, "kubernetes_audit", fileSource.Name)
+	assert.Equal(t, config.FileType, fileSource.Config.Type)
+	assert.Equal(t, "/var/log/kubernetes/audit/audit.log", fileSource.Config.Path)
+	assert.Equal(t, "kube-apiserver", fileSource.Config.Service)
+	assert.Equal(t, "kubernetes.audit", fileSource.Config.Source)
+	assert.Equal(t, []string{"cluster:foo"}, fileSource.Config.Tags)
+	assert.Equal(t, config.KubernetesAuditSourceType, fileSource.GetSourceType())
+	assert.Equal(t, source.Status, fileSource.Status)
+	assert.Equal(t, source, fileSource.ParentSource)
+
+	go sources.RemoveSource(source)
+	assert.Equal(t, fileSource, <-removedFileSources)
+}
+
+func TestNewFileSourceKeepsServiceAndSource(t *testing.T) {
+	fileSource := newFileSource(config.NewLogSource("", &config.LogsConfig{
+		Type:        config.KubernetesAuditType,
+		Path:        "/var/log/audit.log",
+		TailingMode: "beginning",
+		Service:     "apiserver",
+		Source:      "audit",
+	}))
+	assert.Equal(t, "apiserver", fileSource.Config.Service)
+	assert.Equal(t, "audit", fileSource.Config.Source)
+	assert.Equal(t, "beginning", fileSource.Config.TailingMode)
+}
@@ -6,6 +6,7 @@
 package listener
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
 	"sync"
@@ -25,10 +26,12 @@ type TCPListener struct {
 	source           *config.LogSource
 	idleTimeout      time.Duration
 	frameSize        int
+	tlsConfig        *tls.Config
 	listener         net.Listener
 	tailers          []*tailer.Tailer
 	mu               sync.Mutex
 	stop             chan struct{}
+	stopped          bool
 }
 
 // NewTCPListener returns an initialized TCPListener
@@ -56,7 +59,14 @@ func NewTCPListener(pipelineProvider pipeline.Provider, source *config.LogSource
 // Start starts the listener to accepts new incoming connections.
 func (l *TCPListener) Start() {
 	log.Infof("Starting TCP forwarder on port %d, with read buffer size: %d", l.source.Config.Port, l.frameSize)
-	err := l.startListener()
+	tlsConfig, err := buildTLSConfig(l.source.Config)
+	if err != nil {
+		log.Errorf("Can't start TCP forwarder on port %d: %v", l.source.Config.Port, err)
+		l.source.Status.Error(err)
+		return
+	}
+	l.tlsConfig = tlsConfig
+	err = l.startListener()
 	if err != nil {
 		log.Errorf("Can't start TCP forwarder on port %d: %v", l.source.Config.Port, err)
 		l.source.Status.Error(err)
@@ -71,6 +81,7 @@ func (l *TCPListener) Stop() {
 	log.Infof("Stopping TCP forwarder on port %d", l.source.Config.Port)
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.stopped = true
 	l.stop <- struct{}{}
 	l.listener.Close()
 	stopper := startstop.NewParallelStopper()
@@ -105,8 +116,11 @@ func (l *TCPListener) run() {
 				}
 				l.source.Status.Success()
 				continue
+			case l.tlsConfig != nil:
+				// the handshake is done aside not to block the other clients
+				go l.startTLSTailer(conn.(*tls.Conn))
 			default:
-				l.startTailer(conn)
+				l.startTailer(conn, nil)
 				l.source.Status.Success()
 			}
 		}
@@ -119,6 +133,9 @@ func (l *TCPListener) startListener() error {
 	if err != nil {
 		return err
 	}
+	if l.tlsConfig != nil {
+		listener = tls.NewListener(listener, l.tlsConfig)
+	}
 	l.listener = listener
 	return nil
 }
@@ -138,11 +155,28 @@ func (l *TCPListener) read(tailer *tailer.Tailer) ([]byte, error) {
 	return frame[:n], nil
 }
 
+// startTLSTailer completes the TLS handshake of the connection and starts a new tailer
+// that reads from it, the messages are tagged with the identity of the peer.
+func (l *TCPListener) startTLSTailer(conn *tls.Conn) {
+	tags, err := handshake(conn)
+	if err != nil {
+		log.Warnf("TLS handshake failed with %s on port %d: %v", conn.RemoteAddr(), l.source.Config.Port, err)
+		conn.Close()
+		return
+	}
+	l.startTailer(conn, tags)
+	l.source.Status.Success()
+}
+
 // startTailer creates and starts a new tailer that reads from the connection.
-func (l *TCPListener) startTailer(conn net.Conn) {
+func (l *TCPListener) startTailer(conn net.Conn, tags []string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	tailer := tailer.NewTailer(l.source, conn, l.pipelineProvider.NextPipelineChan(), l.read)
+	if l.stopped {
+		conn.Close()
+		return
+	}
+	tailer := tailer.NewTailer(l.source, conn, l.pipelineProvider.NextPipelineChan(), l.read, tags)
 	l.tailers = append(l.tailers, tailer)
 	tailer.Start()
 }
@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listener
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// tlsHandshakeTimeout is the time given to a client to complete its TLS handshake.
+const tlsHandshakeTimeout = 10 * time.Second
+
+// buildTLSConfig returns the TLS configuration of the listener of the source, or nil if
+// it does not use TLS.  When a CA is configured, clients must present a certificate
+// signed by that CA, with one of the allowed common names if any.
+func buildTLSConfig(logsConfig *config.LogsConfig) (*tls.Config, error) {
+	if !logsConfig.TLSEnabled() {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(logsConfig.TLSCertFile, logsConfig.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the TLS certificate: %v", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if logsConfig.TLSCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	caCert, err := os.ReadFile(logsConfig.TLSCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the TLS CA: %v", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("could not parse the TLS CA %s", logsConfig.TLSCAFile)
+	}
+	tlsConfig.ClientCAs = clientCAs
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	if len(logsConfig.TLSAllowedClientCNs) > 0 {
+		tlsConfig.VerifyPeerCertificate = verifyClientCN(logsConfig.TLSAllowedClientCNs)
+	}
+	return tlsConfig, nil
+}
+
+// verifyClientCN returns a function rejecting the client certificates whose common name
+// is not allowed, it is called once the certificate chain has been verified.
+func verifyClientCN(allowedCNs []string) func([][]byte, [][]*x509.Certificate) error {
+	allowed := make(map[string]struct{}, len(allowedCNs))
+	for _, cn := range allowedCNs {
+		allowed[cn] = struct{}{}
+	}
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+			return fmt.Errorf("no verified client certificate")
+		}
+		cn := verifiedChains[0][0].Subject.CommonName
+		if _, ok := allowed[cn]; !ok {
+			return fmt.Errorf("client certificate common name %q is not allowed", cn)
+		}
+		return nil
+	}
+}
+
+// handshake completes the TLS handshake of the connection and returns the tags
+// identifying the peer, that is the common name of its certificate if it presented one.
+func handshake(conn *tls.Conn) ([]string, error) {
+	conn.SetDeadline(time.Now().Add(tlsHandshakeTimeout)) //nolint:errcheck
+	if err := conn.Handshake(); err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{}) //nolint:errcheck
+
+	peerCertificates := conn.ConnectionState().PeerCertificates
+	if len(peerCertificates) == 0 {
+		return nil, nil
+	}
+	return []string{"peer_cn:" + peerCertificates[0].Subject.CommonName}, nil
+}
@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listener
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+)
+
+// testCA signs the certificates of the servers and clients of the tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+	path string
+}
+
+func newTestCA(t *testing.T, dir string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool, path: path}
+}
+
+// issue returns a certificate for the given common name, and writes it to dir if it is not empty.
+func (ca *testCA) issue(t *testing.T, cn string, dir string) (tls.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	if dir == "" {
+		return cert, "", ""
+	}
+	certPath := filepath.Join(dir, cn+".pem")
+	keyPath := filepath.Join(dir, cn+".key")
+	require.NoError(t, os.WriteFile(certPath, certPEM, 0600))
+	require.NoError(t, os.WriteFile(keyPath, keyPEM, 0600))
+	return cert, certPath, keyPath
+}
+
+func newTLSTestListener(t *testing.T, logsConfig *config.LogsConfig) (*TCPListener, pipeline.Provider) {
+	pp := mock.NewMockProvider()
+	listener := NewTCPListener(pp, config.NewLogSource("", logsConfig), 9000)
+	listener.Start()
+	require.NotNil(t, listener.listener, "the listener did not start")
+	t.Cleanup(listener.Stop)
+	return listener, pp
+}
+
+func TestTCPWithTLSShouldReceiveMessages(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	_, certPath, keyPath := ca.issue(t, "server", dir)
+
+	listener, pp := newTLSTestListener(t, &config.LogsConfig{Port: tcpTestPort, TLSCertFile: certPath, TLSKeyFile: keyPath})
+	msgChan := pp.NextPipelineChan()
+
+	conn, err := tls.Dial("tcp", fmt.Sprintf("%s", listener.listener.Addr()), &tls.Config{RootCAs: ca.pool, ServerName: "localhost"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "hello world\n")
+	msg := <-msgChan
+	assert.Equal(t, "hello world", string(msg.Content))
+	assert.Empty(t, msg.Origin.Tags())
+}
+
+func TestTCPWithMutualTLSShouldTagMessagesWithPeerIdentity(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	_, certPath, keyPath := ca.issue(t, "server", dir)
+	clientCert, _, _ := ca.issue(t, "client-a", "")
+
+	listener, pp := newTLSTestListener(t, &config.LogsConfig{
+		Port:                tcpTestPort,
+		TLSCertFile:         certPath,
+		TLSKeyFile:          keyPath,
+		TLSCAFile:           ca.path,
+		TLSAllowedClientCNs: []string{"client-a"},
+	})
+	msgChan := pp.NextPipelineChan()
+
+	conn, err := tls.Dial("tcp", fmt.Sprintf("%s", listener.listener.Addr()), &tls.Config{
+		RootCAs:      ca.pool,
+		ServerName:   "localhost",
+		Certificates: []tls.Certificate{clientCert},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "hello world\n")
+	msg := <-msgChan
+	assert.Equal(t, "hello world", string(msg.Content))
+	assert.Equal(t, []string{"peer_cn:client-a"}, msg.Origin.Tags())
+}
+
+func TestTCPWithMutualTLSShouldRejectClients(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	_, certPath, keyPath := ca.issue(t, "server", dir)
+	otherCA := newTestCA(t, t.TempDir())
+
+	listener, _ := newTLSTestListener(t, &config.LogsConfig{
+		Port:                tcpTestPort,
+		TLSCertFile:         certPath,
+		TLSKeyFile:          keyPath,
+		TLSCAFile:           ca.path,
+		TLSAllowedClientCNs: []string{"client-a"},
+	})
+
+	for name, certificates := range map[string][]tls.Certificate{
+		"no certificate":    nil,
+		"CN not allowed":    {func() tls.Certificate { c, _, _ := ca.issue(t, "client-b", ""); return c }()},
+		"unknown authority": {func() tls.Certificate { c, _, _ := otherCA.issue(t, "client-a", ""); return c }()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			conn, err := tls.Dial("tcp", fmt.Sprintf("%s", listener.listener.Addr()), &tls.Config{
+				RootCAs:      ca.pool,
+				ServerName:   "localhost",
+				Certificates: certificates,
+			})
+			if err == nil {
+				// with TLS 1.3, the client learns that its certificate was rejected when reading
+				defer conn.Close()
+				conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+				_, err = conn.Read(make([]byte, 1))
+			}
+			assert.Error(t, err)
+			assert.Len(t, listener.tailers, 0)
+		})
+	}
+}
+
+func TestTCPWithInvalidTLSConfigShouldFail(t *testing.T) {
+	dir := t.TempDir()
+	pp := mock.NewMockProvider()
+	source := config.NewLogSource("", &config.LogsConfig{
+		Port:        tcpTestPort,
+		TLSCertFile: filepath.Join(dir, "missing.pem"),
+		TLSKeyFile:  filepath.Join(dir, "missing.key"),
+	})
+	listener := NewTCPListener(pp, source, 9000)
+	listener.Start()
+	assert.Nil(t, listener.listener)
+	assert.True(t, source.Status.IsError())
+}
@@ -24,6 +24,11 @@ var (
 	// TlmLogsProcessed is the total number of processed logs.
 	TlmLogsProcessed = telemetry.NewCounter("logs", "processed",
 		nil, "Total number of processed logs")
+	// LogsSampledOut is the total number of logs dropped by sampling rules.
+	LogsSampledOut = expvar.Int{}
+	// TlmLogsSampledOut is the total number of logs dropped by sampling rules.
+	TlmLogsSampledOut = telemetry.NewCounter("logs", "sampled_out",
+		[]string{"reason"}, "Total number of logs dropped by sampling rules")
 
 	// LogsSent is the total number of sent logs.
 	LogsSent = expvar.Int{}
@@ -56,6 +61,21 @@ var (
 	// TlmSenderLatency a histogram of http sender latency (ms)
 	TlmSenderLatency = telemetry.NewHistogram("logs", "sender_latency",
 		nil, "Histogram of http sender latency in ms", []float64{10, 25, 50, 75, 100, 250, 500, 1000, 10000})
+	// SpoolBytes is the size of the payloads stored in the disk spools
+	SpoolBytes = expvar.Int{}
+	// TlmSpoolBytes is the size of the payloads stored in the disk spools
+	TlmSpoolBytes = telemetry.NewGauge("logs", "spool_bytes",
+		nil, "Size of the payloads stored in the disk spools")
+	// SpoolPayloads is the number of payloads stored in the disk spools
+	SpoolPayloads = expvar.Int{}
+	// TlmSpoolPayloads is the number of payloads stored in the disk spools
+	TlmSpoolPayloads = telemetry.NewGauge("logs", "spool_payloads",
+		nil, "Number of payloads stored in the disk spools")
+	// SpoolPayloadsDropped is the total number of payloads removed from the disk spools to make room for newer ones
+	SpoolPayloadsDropped = expvar.Int{}
+	// TlmSpoolPayloadsDropped is the total number of payloads removed from the disk spools to make room for newer ones
+	TlmSpoolPayloadsDropped = telemetry.NewCounter("logs", "spool_payloads_dropped",
+		nil, "Total number of payloads removed from the disk spools to make room for newer ones")
 	// DestinationExpVars a map of sender utilization metrics for each http destination
 	DestinationExpVars = expvar.Map{}
 	// TODO: Add LogsCollected for the total number of collected logs.
@@ -66,6 +86,7 @@ func init() {
 	LogsExpvars = expvar.NewMap("logs-agent")
 	LogsExpvars.Set("LogsDecoded", &LogsDecoded)
 	LogsExpvars.Set("LogsProcessed", &LogsProcessed)
+	LogsExpvars.Set("LogsSampledOut", &LogsSampledOut)
 	LogsExpvars.Set("LogsSent", &LogsSent)
 	LogsExpvars.Set("DestinationErrors", &DestinationErrors)
 	LogsExpvars.Set("DestinationLogsDropped", &DestinationLogsDropped)
@@ -73,4 +94,7 @@ func init() {
 	LogsExpvars.Set("EncodedBytesSent", &EncodedBytesSent)
 	LogsExpvars.Set("SenderLatency", &SenderLatency)
 	LogsExpvars.Set("HttpDestinationStats", &DestinationExpVars)
+	LogsExpvars.Set("SpoolBytes", &SpoolBytes)
+	LogsExpvars.Set("SpoolPayloads", &SpoolPayloads)
+	LogsExpvars.Set("SpoolPayloadsDropped", &SpoolPayloadsDropped)
 }
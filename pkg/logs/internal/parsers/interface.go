@@ -10,6 +10,8 @@
 // before further processing and aggregation of log messages.
 package parsers
 
+import "time"
+
 // Message represents a message parsed from a single line of log data
 type Message struct {
 	// Content is the message content.  If this is nil then the message
@@ -24,6 +26,18 @@ type Message struct {
 	// which do not contain a timestamp (such as files) leave this set to "".
 	Timestamp string
 
+	// EventTime is the time at which the logged event occurred, set by the parsers
+	// of the sources whose messages are timestamped with it (such as the Kubernetes
+	// audit log).  The messages without it are timestamped when they are encoded.
+	EventTime time.Time
+
+	// Hostname, Service and Tags are the metadata parsed from the message, if
+	// any.  Sources which do not carry such metadata (such as files) leave
+	// them empty.
+	Hostname string
+	Service  string
+	Tags     []string
+
 	// IsPartial indicates that this is a partial message.  If the parser
 	// supports partial lines, then this is true only for the message returned
 	// from the last parsed line in a multi-line message.
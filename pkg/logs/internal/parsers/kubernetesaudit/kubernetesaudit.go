@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package kubernetesaudit implements a parser for the audit log of the Kubernetes API server.
+package kubernetesaudit
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+var errInvalidEvent = errors.New("cannot parse the audit event")
+
+// event holds the fields of the audit.k8s.io events turned into tags, status and timestamp.
+// See https://kubernetes.io/docs/reference/config-api/apiserver-audit.v1/#audit-k8s-io-v1-Event.
+type event struct {
+	Level string `json:"level"`
+	Stage string `json:"stage"`
+	Verb  string `json:"verb"`
+	User  struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	ObjectRef *struct {
+		Resource    string `json:"resource"`
+		Namespace   string `json:"namespace"`
+		Name        string `json:"name"`
+		APIGroup    string `json:"apiGroup"`
+		Subresource string `json:"subresource"`
+	} `json:"objectRef"`
+	ResponseStatus *struct {
+		Code int `json:"code"`
+	} `json:"responseStatus"`
+	StageTimestamp string `json:"stageTimestamp"`
+}
+
+// New creates a new parser that parses the JSON lines of the Kubernetes audit log.
+//
+// The content of the messages is the audit event as is, the user, the verb and the
+// object of the request are added as tags, and the status is given by the code of the
+// response: error for 5xx, warning for 4xx and info otherwise.
+//
+// For example: `{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","stage":"ResponseComplete","verb":"get","user":{"username":"admin"},"objectRef":{"resource":"pods","namespace":"default","name":"nginx"},"responseStatus":{"code":200}}`
+func New() parsers.Parser {
+	return &kubernetesAuditFormat{}
+}
+
+type kubernetesAuditFormat struct{}
+
+// Parse implements Parser#Parse
+func (p *kubernetesAuditFormat) Parse(msg []byte) (parsers.Message, error) {
+	if len(msg) == 0 {
+		return parsers.Message{Content: msg}, nil
+	}
+	var e event
+	if err := json.Unmarshal(msg, &e); err != nil {
+		return parsers.Message{
+			Content: msg,
+			Status:  message.StatusInfo,
+		}, errInvalidEvent
+	}
+
+	parsed := parsers.Message{
+		Content: msg,
+		Status:  message.StatusInfo,
+	}
+	parsed.Tags = appendTag(parsed.Tags, "audit_level", e.Level)
+	parsed.Tags = appendTag(parsed.Tags, "audit_stage", e.Stage)
+	parsed.Tags = appendTag(parsed.Tags, "verb", e.Verb)
+	parsed.Tags = appendTag(parsed.Tags, "user", e.User.Username)
+	if e.ObjectRef != nil {
+		parsed.Tags = appendTag(parsed.Tags, "kube_namespace", e.ObjectRef.Namespace)
+		parsed.Tags = appendTag(parsed.Tags, "kube_resource", e.ObjectRef.Resource)
+		parsed.Tags = appendTag(parsed.Tags, "kube_resource_name", e.ObjectRef.Name)
+		parsed.Tags = appendTag(parsed.Tags, "kube_subresource", e.ObjectRef.Subresource)
+		parsed.Tags = appendTag(parsed.Tags, "kube_api_group", e.ObjectRef.APIGroup)
+	}
+	if e.ResponseStatus != nil && e.ResponseStatus.Code != 0 {
+		parsed.Tags = appendTag(parsed.Tags, "response_code", strconv.Itoa(e.ResponseStatus.Code))
+		parsed.Status = status(e.ResponseStatus.Code)
+	}
+	if timestamp, err := time.Parse(time.RFC3339Nano, e.StageTimestamp); err == nil {
+		parsed.Timestamp = timestamp.UTC().Format(config.DateFormat)
+		parsed.EventTime = timestamp.UTC()
+	}
+	return parsed, nil
+}
+
+// SupportsPartialLine implements Parser#SupportsPartialLine
+func (p *kubernetesAuditFormat) SupportsPartialLine() bool {
+	return false
+}
+
+// status returns the status of an event from the code of its response.
+func status(code int) string {
+	switch {
+	case code >= 500:
+		return message.StatusError
+	case code >= 400:
+		return message.StatusWarning
+	default:
+		return message.StatusInfo
+	}
+}
+
+// appendTag appends the tag if its value is set, the commas of the value are replaced
+// as they separate the tags.
+func appendTag(tags []string, key string, value string) []string {
+	if value == "" {
+		return tags
+	}
+	return append(tags, key+":"+strings.ReplaceAll(value, ",", "_"))
+}
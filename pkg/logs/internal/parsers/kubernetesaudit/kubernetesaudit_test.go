@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kubernetesaudit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestKubernetesAuditParser(t *testing.T) {
+	line := []byte(`{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"2d0a8a2b-0c1b-4d3c-9f5e-0a1b2c3d4e5f","stage":"ResponseComplete","requestURI":"/api/v1/namespaces/default/pods/nginx/log","verb":"get","user":{"username":"system:admin","groups":["system:masters"]},"objectRef":{"resource":"pods","namespace":"default","name":"nginx","apiVersion":"v1","subresource":"log"},"responseStatus":{"metadata":{},"code":200},"requestReceivedTimestamp":"2022-05-10T12:34:56.123456Z","stageTimestamp":"2022-05-10T12:34:56.234567Z"}`)
+	msg, err := New().Parse(line)
+	assert.Nil(t, err)
+	assert.Equal(t, line, msg.Content)
+	assert.Equal(t, message.StatusInfo, msg.Status)
+	assert.Equal(t, "2022-05-10T12:34:56.234567000Z", msg.Timestamp)
+	assert.Equal(t, time.Date(2022, time.May, 10, 12, 34, 56, 234567000, time.UTC), msg.EventTime)
+	assert.Equal(t, []string{
+		"audit_level:Metadata",
+		"audit_stage:ResponseComplete",
+		"verb:get",
+		"user:system:admin",
+		"kube_namespace:default",
+		"kube_resource:pods",
+		"kube_resource_name:nginx",
+		"kube_subresource:log",
+		"response_code:200",
+	}, msg.Tags)
+}
+
+func TestKubernetesAuditParserStatus(t *testing.T) {
+	tests := map[string]string{
+		`{"stage":"ResponseComplete","verb":"delete","responseStatus":{"code":403}}`:                message.StatusWarning,
+		`{"stage":"ResponseComplete","verb":"create","responseStatus":{"code":500}}`:                message.StatusError,
+		`{"stage":"RequestReceived","verb":"watch","objectRef":{"resource":"nodes","apiGroup":""}}`: message.StatusInfo,
+	}
+	for line, status := range tests {
+		msg, err := New().Parse([]byte(line))
+		assert.Nil(t, err)
+		assert.Equal(t, status, msg.Status, line)
+	}
+}
+
+func TestKubernetesAuditParserInvalidEvent(t *testing.T) {
+	msg, err := New().Parse([]byte("not an audit event"))
+	assert.NotNil(t, err)
+	assert.Equal(t, []byte("not an audit event"), msg.Content)
+	assert.Equal(t, message.StatusInfo, msg.Status)
+	assert.Empty(t, msg.Tags)
+}
+
+func TestKubernetesAuditParserTagValuesWithCommas(t *testing.T) {
+	msg, err := New().Parse([]byte(`{"verb":"get","user":{"username":"CN=admin,O=system:masters"}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"verb:get", "user:CN=admin_O=system:masters"}, msg.Tags)
+}
@@ -0,0 +1,309 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package syslog implements a parser for syslog messages, as described in
+// RFC 5424 and RFC 3164.
+package syslog
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+const (
+	// nilValue is the value of the RFC 5424 fields that are not set
+	nilValue = "-"
+
+	// rfc3164TimestampLen is the length of the RFC 3164 timestamps, `Mmm dd hh:mm:ss`
+	rfc3164TimestampLen = len(time.Stamp)
+)
+
+var (
+	// utf8BOM may start the MSG part of RFC 5424 messages
+	utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+	errInvalidPriority = errors.New("cannot parse the syslog priority")
+	errInvalidHeader   = errors.New("cannot parse the syslog header")
+	errInvalidSD       = errors.New("cannot parse the syslog structured data")
+)
+
+// severityStatuses maps the syslog severities to statuses.
+var severityStatuses = []string{
+	message.StatusEmergency,
+	message.StatusAlert,
+	message.StatusCritical,
+	message.StatusError,
+	message.StatusWarning,
+	message.StatusNotice,
+	message.StatusInfo,
+	message.StatusDebug,
+}
+
+// facilities are the names of the syslog facilities.
+var facilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// New creates a new parser that parses syslog messages.
+//
+// The priority of the messages gives their status, their timestamp, hostname and
+// app-name are extracted, and their facility, procid, msgid and structured data
+// are added as tags.
+//
+// For example: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3"] An application event`
+// or `<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8`
+func New() parsers.Parser {
+	return &syslogFormat{now: time.Now}
+}
+
+type syslogFormat struct {
+	// now gives the current time, RFC 3164 timestamps do not contain any year
+	now func() time.Time
+}
+
+// Parse implements Parser#Parse
+func (p *syslogFormat) Parse(msg []byte) (parsers.Message, error) {
+	if len(msg) == 0 {
+		return parsers.Message{Content: msg}, nil
+	}
+	pri, rest, err := parsePriority(msg)
+	if err != nil {
+		return parsers.Message{
+			Content: msg,
+			Status:  message.StatusInfo,
+		}, err
+	}
+
+	parsed := parsers.Message{
+		Status: severityStatuses[pri%8],
+		Tags:   []string{"syslog.facility:" + facility(pri/8)},
+	}
+	if len(rest) > 1 && rest[0] >= '1' && rest[0] <= '9' && rest[1] == ' ' {
+		err = parseRFC5424(rest[2:], &parsed)
+	} else {
+		p.parseRFC3164(rest, &parsed)
+	}
+	if err != nil {
+		return parsers.Message{
+			Content: msg,
+			Status:  parsed.Status,
+		}, err
+	}
+	return parsed, nil
+}
+
+// SupportsPartialLine implements Parser#SupportsPartialLine
+func (p *syslogFormat) SupportsPartialLine() bool {
+	return false
+}
+
+// parsePriority parses the `<PRIVAL>` part of the message.
+func parsePriority(msg []byte) (int, []byte, error) {
+	if len(msg) < 3 || msg[0] != '<' {
+		return 0, nil, errInvalidPriority
+	}
+	end := bytes.IndexByte(msg[:min(len(msg), 5)], '>')
+	if end < 2 {
+		return 0, nil, errInvalidPriority
+	}
+	pri, err := strconv.Atoi(string(msg[1:end]))
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, nil, errInvalidPriority
+	}
+	return pri, msg[end+1:], nil
+}
+
+// parseRFC5424 parses the message following the `<PRIVAL>VERSION SP` prefix:
+// `TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID SP MSGID SP STRUCTURED-DATA [SP MSG]`.
+func parseRFC5424(msg []byte, parsed *parsers.Message) error {
+	fields := make([]string, 5)
+	for i := range fields {
+		end := bytes.IndexByte(msg, ' ')
+		if end <= 0 {
+			return errInvalidHeader
+		}
+		fields[i] = string(msg[:end])
+		msg = msg[end+1:]
+	}
+
+	if fields[0] != nilValue {
+		timestamp, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return errInvalidHeader
+		}
+		parsed.Timestamp = timestamp.UTC().Format(config.DateFormat)
+	}
+	if fields[1] != nilValue {
+		parsed.Hostname = fields[1]
+	}
+	if fields[2] != nilValue {
+		parsed.Service = fields[2]
+	}
+	if fields[3] != nilValue {
+		parsed.Tags = append(parsed.Tags, tag("syslog.procid", fields[3]))
+	}
+	if fields[4] != nilValue {
+		parsed.Tags = append(parsed.Tags, tag("syslog.msgid", fields[4]))
+	}
+
+	tags, rest, err := parseStructuredData(msg)
+	if err != nil {
+		return err
+	}
+	parsed.Tags = append(parsed.Tags, tags...)
+	if len(rest) > 0 {
+		if rest[0] != ' ' {
+			return errInvalidSD
+		}
+		parsed.Content = bytes.TrimPrefix(rest[1:], utf8BOM)
+	}
+	return nil
+}
+
+// parseStructuredData parses the structured data at the beginning of msg, made of
+// elements like `[SD-ID SP PARAM-NAME="PARAM-VALUE"...]`, into tags of the form
+// `syslog.SD-ID.PARAM-NAME:PARAM-VALUE`, and returns the rest of the message.
+func parseStructuredData(msg []byte) ([]string, []byte, error) {
+	if len(msg) > 0 && msg[0] == nilValue[0] {
+		return nil, msg[1:], nil
+	}
+	var tags []string
+	for len(msg) > 0 && msg[0] == '[' {
+		i := 1
+		for i < len(msg) && msg[i] != ' ' && msg[i] != ']' {
+			i++
+		}
+		if i == 1 || i == len(msg) {
+			return nil, nil, errInvalidSD
+		}
+		id := string(msg[1:i])
+		for msg[i] == ' ' {
+			nameStart := i + 1
+			eq := bytes.IndexByte(msg[nameStart:], '=')
+			if eq <= 0 || nameStart+eq+1 >= len(msg) || msg[nameStart+eq+1] != '"' {
+				return nil, nil, errInvalidSD
+			}
+			name := string(msg[nameStart : nameStart+eq])
+			var value []byte
+			value, i = parseParamValue(msg, nameStart+eq+2)
+			if i >= len(msg) {
+				return nil, nil, errInvalidSD
+			}
+			tags = append(tags, tag("syslog."+id+"."+name, string(value)))
+		}
+		if msg[i] != ']' {
+			return nil, nil, errInvalidSD
+		}
+		msg = msg[i+1:]
+	}
+	if tags == nil {
+		// there must be at least one element
+		return nil, nil, errInvalidSD
+	}
+	return tags, msg, nil
+}
+
+// parseParamValue parses the value of a parameter starting at the given index, after its
+// opening quote, unescaping `\"`, `\\` and `\]`.  It returns the value and the index
+// following its closing quote, or len(msg) if the value is not terminated.
+func parseParamValue(msg []byte, start int) ([]byte, int) {
+	var value []byte
+	for i := start; i < len(msg); i++ {
+		switch msg[i] {
+		case '\\':
+			if i+1 < len(msg) && (msg[i+1] == '"' || msg[i+1] == '\\' || msg[i+1] == ']') {
+				i++
+			}
+			value = append(value, msg[i])
+		case '"':
+			return value, i + 1
+		default:
+			value = append(value, msg[i])
+		}
+	}
+	return nil, len(msg)
+}
+
+// parseRFC3164 parses the message following the `<PRIVAL>` prefix:
+// `TIMESTAMP SP HOSTNAME SP TAG[PID]: MSG`.  The format was only a description of the
+// existing implementations, so that any of those parts might be missing.
+func (p *syslogFormat) parseRFC3164(msg []byte, parsed *parsers.Message) {
+	if len(msg) > rfc3164TimestampLen && msg[rfc3164TimestampLen] == ' ' {
+		if timestamp, ok := p.parseRFC3164Timestamp(string(msg[:rfc3164TimestampLen])); ok {
+			parsed.Timestamp = timestamp.UTC().Format(config.DateFormat)
+			msg = msg[rfc3164TimestampLen+1:]
+
+			// the hostname is omitted when the next field is already the tag
+			if end := bytes.IndexByte(msg, ' '); end > 0 && !bytes.ContainsAny(msg[:end], ":[") {
+				parsed.Hostname = string(msg[:end])
+				msg = msg[end+1:]
+			}
+		}
+	}
+
+	parsed.Content = msg
+	end := bytes.IndexAny(msg, ":[ ")
+	if end <= 0 || end > 32 || msg[end] == ' ' {
+		// there is no tag
+		return
+	}
+	appName := string(msg[:end])
+	rest := msg[end:]
+	if rest[0] == '[' {
+		pidEnd := bytes.IndexByte(rest, ']')
+		if pidEnd < 0 || pidEnd+1 >= len(rest) || rest[pidEnd+1] != ':' {
+			return
+		}
+		parsed.Tags = append(parsed.Tags, tag("syslog.procid", string(rest[1:pidEnd])))
+		rest = rest[pidEnd+1:]
+	}
+	parsed.Service = appName
+	parsed.Content = bytes.TrimPrefix(rest[1:], []byte{' '})
+}
+
+// parseRFC3164Timestamp parses a timestamp without year nor timezone, in the timezone
+// of the agent, assuming it is not more than a day in the future.
+func (p *syslogFormat) parseRFC3164Timestamp(value string) (time.Time, bool) {
+	now := p.now()
+	timestamp, err := time.ParseInLocation(time.Stamp, value, now.Location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	timestamp = timestamp.AddDate(now.Year(), 0, 0)
+	if timestamp.After(now.Add(24 * time.Hour)) {
+		// the message was sent at the end of the previous year
+		timestamp = timestamp.AddDate(-1, 0, 0)
+	}
+	return timestamp, true
+}
+
+// facility returns the name of the facility.
+func facility(code int) string {
+	if code < len(facilities) {
+		return facilities[code]
+	}
+	return strconv.Itoa(code)
+}
+
+// tag returns a tag, replacing the commas of the value as they separate the tags.
+func tag(key string, value string) string {
+	return key + ":" + strings.ReplaceAll(value, ",", "_")
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
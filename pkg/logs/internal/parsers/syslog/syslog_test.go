@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newTestParser(now time.Time) *syslogFormat {
+	return &syslogFormat{now: func() time.Time { return now }}
+}
+
+func TestSyslogParserRFC5424(t *testing.T) {
+	msg, err := New().Parse([]byte(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application"][origin ip="192.0.2.1"] An application event`))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("An application event"), msg.Content)
+	assert.Equal(t, message.StatusNotice, msg.Status)
+	assert.Equal(t, "2003-10-11T22:14:15.003000000Z", msg.Timestamp)
+	assert.Equal(t, "mymachine.example.com", msg.Hostname)
+	assert.Equal(t, "evntslog", msg.Service)
+	assert.Equal(t, []string{
+		"syslog.facility:local4",
+		"syslog.procid:1234",
+		"syslog.msgid:ID47",
+		"syslog.exampleSDID@32473.iut:3",
+		"syslog.exampleSDID@32473.eventSource:Application",
+		"syslog.origin.ip:192.0.2.1",
+	}, msg.Tags)
+}
+
+func TestSyslogParserRFC5424NilValues(t *testing.T) {
+	msg, err := New().Parse([]byte("<11>1 - - - - - - \xef\xbb\xbfhello world"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello world"), msg.Content)
+	assert.Equal(t, message.StatusError, msg.Status)
+	assert.Equal(t, "", msg.Timestamp)
+	assert.Equal(t, "", msg.Hostname)
+	assert.Equal(t, "", msg.Service)
+	assert.Equal(t, []string{"syslog.facility:user"}, msg.Tags)
+
+	// the message is optional
+	msg, err = New().Parse([]byte("<11>1 2003-10-11T22:14:15+02:00 host app - - -"))
+	assert.Nil(t, err)
+	assert.Len(t, msg.Content, 0)
+	assert.Equal(t, "2003-10-11T20:14:15.000000000Z", msg.Timestamp)
+}
+
+func TestSyslogParserRFC5424EscapedStructuredData(t *testing.T) {
+	msg, err := New().Parse([]byte(`<14>1 - - - - - [meta value="a \"quoted\" \] \\ value, with a comma"] hello`))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello"), msg.Content)
+	assert.Equal(t, []string{"syslog.facility:user", `syslog.meta.value:a "quoted" ] \ value_ with a comma`}, msg.Tags)
+}
+
+func TestSyslogParserRFC3164(t *testing.T) {
+	now := time.Date(2021, time.October, 12, 0, 0, 0, 0, time.UTC)
+	msg, err := newTestParser(now).Parse([]byte("<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("'su root' failed for lonvick on /dev/pts/8"), msg.Content)
+	assert.Equal(t, message.StatusCritical, msg.Status)
+	assert.Equal(t, "2021-10-11T22:14:15.000000000Z", msg.Timestamp)
+	assert.Equal(t, "mymachine", msg.Hostname)
+	assert.Equal(t, "su", msg.Service)
+	assert.Equal(t, []string{"syslog.facility:auth", "syslog.procid:123"}, msg.Tags)
+
+	// the hostname is optional, days are padded with spaces
+	msg, err = newTestParser(now).Parse([]byte("<13>Oct  1 02:00:00 cron: job done"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("job done"), msg.Content)
+	assert.Equal(t, "2021-10-01T02:00:00.000000000Z", msg.Timestamp)
+	assert.Equal(t, "", msg.Hostname)
+	assert.Equal(t, "cron", msg.Service)
+}
+
+func TestSyslogParserRFC3164PreviousYear(t *testing.T) {
+	now := time.Date(2022, time.January, 1, 0, 0, 10, 0, time.UTC)
+	msg, err := newTestParser(now).Parse([]byte("<13>Dec 31 23:59:59 host app: happy new year"))
+	assert.Nil(t, err)
+	assert.Equal(t, "2021-12-31T23:59:59.000000000Z", msg.Timestamp)
+}
+
+func TestSyslogParserRFC3164WithoutHeader(t *testing.T) {
+	msg, err := New().Parse([]byte("<15>hello world"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello world"), msg.Content)
+	assert.Equal(t, message.StatusDebug, msg.Status)
+	assert.Equal(t, "", msg.Timestamp)
+	assert.Equal(t, "", msg.Service)
+	assert.Equal(t, []string{"syslog.facility:user"}, msg.Tags)
+}
+
+func TestSyslogParserShouldFailWithInvalidInput(t *testing.T) {
+	for _, log := range []string{
+		"hello world",
+		"<>1 - - - - - -",
+		"<192>1 - - - - - -",
+		"<34>1 not-a-timestamp host app - - - hello",
+		"<34>1 - host app",
+		"<34>1 - - - - - [unterminated",
+		"<34>1 - - - - - [id name=value]",
+		"<34>1 - - - - - nope",
+	} {
+		msg, err := New().Parse([]byte(log))
+		assert.NotNil(t, err, log)
+		assert.Equal(t, []byte(log), msg.Content)
+		assert.Equal(t, "", msg.Timestamp)
+	}
+}
+
+func TestSyslogParserShouldHandleEmptyMessage(t *testing.T) {
+	msg, err := New().Parse([]byte{})
+	assert.Nil(t, err)
+	assert.Len(t, msg.Content, 0)
+}
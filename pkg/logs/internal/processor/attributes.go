@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"encoding/json"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// messageAttribute is the attribute holding the content of the message when the
+// attributes are encoded along with it.
+const messageAttribute = "message"
+
+// reservedAttributes are the fields of the JSON payloads, the attributes with the same
+// name are not sent to not override them.
+var reservedAttributes = map[string]struct{}{
+	messageAttribute: {},
+	"status":         {},
+	"timestamp":      {},
+	"hostname":       {},
+	"service":        {},
+	"ddsource":       {},
+	"ddtags":         {},
+}
+
+// mergeAttributes merges the attributes of src into dst, recursively for nested attributes.
+func mergeAttributes(dst map[string]interface{}, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		return src
+	}
+	for key, value := range src {
+		if srcObject, ok := value.(map[string]interface{}); ok {
+			if dstObject, ok := dst[key].(map[string]interface{}); ok {
+				dst[key] = mergeAttributes(dstObject, srcObject)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+	return dst
+}
+
+// withAttributes adds the attributes of the message to the encoded JSON object.
+func withAttributes(encoded []byte, msg *message.Message) ([]byte, error) {
+	attributes := make(map[string]interface{}, len(msg.Attributes))
+	for key, value := range msg.Attributes {
+		if _, reserved := reservedAttributes[key]; !reserved {
+			attributes[key] = value
+		}
+	}
+	if len(attributes) == 0 {
+		return encoded, nil
+	}
+	encodedAttributes, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, err
+	}
+	// splice the two objects: `{...fields` + `,` + `...attributes}`
+	encoded = append(encoded[:len(encoded)-1], ',')
+	return append(encoded, encodedAttributes[1:]...), nil
+}
+
+// contentWithAttributes returns the content as a JSON object holding the message along with
+// its attributes, for the formats which cannot hold the attributes but whose JSON content is
+// parsed by the intake.  The content is returned as is if the message has no attributes.
+// As for the JSON payloads, the reserved attributes are not sent.
+func contentWithAttributes(msg *message.Message, redactedMsg []byte) []byte {
+	if len(msg.Attributes) == 0 {
+		return redactedMsg
+	}
+	attributes := make(map[string]interface{}, len(msg.Attributes)+1)
+	for key, value := range msg.Attributes {
+		if _, reserved := reservedAttributes[key]; !reserved {
+			attributes[key] = value
+		}
+	}
+	attributes[messageAttribute] = toValidUtf8(redactedMsg)
+	content, err := json.Marshal(attributes)
+	if err != nil {
+		return redactedMsg
+	}
+	return content
+}
@@ -177,6 +177,22 @@ func TestProtoEncoderEmpty(t *testing.T) {
 
 }
 
+func TestProtoEncoderWithMessageMetadata(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{})
+	msg := newMessage([]byte("message"), source, "")
+	msg.Timestamp = time.Date(2021, time.October, 11, 22, 14, 15, 0, time.UTC)
+	msg.Hostname = "remoteHost"
+
+	proto, err := ProtoEncoder.Encode(msg, msg.Content)
+	assert.Nil(t, err)
+
+	log := &pb.Log{}
+	err = log.Unmarshal(proto)
+	assert.Nil(t, err)
+	assert.Equal(t, "remoteHost", log.Hostname)
+	assert.Equal(t, msg.Timestamp.UnixNano(), log.Timestamp)
+}
+
 func TestProtoEncoderHandleInvalidUTF8(t *testing.T) {
 	cfg := &config.LogsConfig{}
 	src := config.NewLogSource("", cfg)
@@ -221,6 +237,33 @@ func TestJsonEncoder(t *testing.T) {
 	assert.NotEmpty(t, log.Timestamp)
 }
 
+func TestEncodersWithAttributes(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Service: "Service", Source: "Source"})
+	msg := newMessage([]byte("message"), source, message.StatusError)
+	msg.Attributes = map[string]interface{}{
+		"http":    map[string]interface{}{"status_code": int64(500)},
+		"service": "ignored",
+	}
+
+	jsonMessage, err := JSONEncoder.Encode(msg, []byte("redacted"))
+	assert.Nil(t, err)
+	var fields map[string]interface{}
+	assert.Nil(t, json.Unmarshal(jsonMessage, &fields))
+	assert.Equal(t, "redacted", fields["message"])
+	assert.Equal(t, "Service", fields["service"])
+	assert.Equal(t, map[string]interface{}{"status_code": float64(500)}, fields["http"])
+
+	protoMessage, err := ProtoEncoder.Encode(msg, []byte("redacted"))
+	assert.Nil(t, err)
+	log := &pb.Log{}
+	assert.Nil(t, log.Unmarshal(protoMessage))
+	assert.JSONEq(t, `{"message":"redacted","http":{"status_code":500}}`, log.Message)
+
+	rawMessage, err := RawEncoder.Encode(msg, []byte("redacted"))
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(string(rawMessage), ` {"http":{"status_code":500},"message":"redacted"}`), string(rawMessage))
+}
+
 func TestEncoderToValidUTF8(t *testing.T) {
 	assert.Equal(t, "a�z", toValidUtf8([]byte("a\xfez")))
 	assert.Equal(t, "a��z", toValidUtf8([]byte("a\xc0\xafz")))
@@ -0,0 +1,155 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// structuredContent holds the content of a message being processed and lazily
+// decodes it as a JSON object when a structured processing rule needs to access its fields.
+type structuredContent struct {
+	content []byte
+	fields  map[string]interface{}
+	// decoded is true once the content has been decoded, even if it was not a JSON object
+	decoded bool
+	// dirty is true when the fields have been modified and the content must be re-encoded
+	dirty bool
+}
+
+func newStructuredContent(content []byte) *structuredContent {
+	return &structuredContent{content: content}
+}
+
+// raw returns the content, re-encoded if some fields were modified, untouched otherwise.
+func (s *structuredContent) raw() []byte {
+	if s.dirty {
+		var buffer bytes.Buffer
+		encoder := json.NewEncoder(&buffer)
+		// keep the characters of the original content, e.g. in URLs or XML payloads
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(s.fields); err == nil {
+			s.content = bytes.TrimSuffix(buffer.Bytes(), []byte("\n"))
+		}
+		s.dirty = false
+	}
+	return s.content
+}
+
+// setRaw replaces the content, fields will be decoded again if needed.
+func (s *structuredContent) setRaw(content []byte) {
+	s.content = content
+	s.fields = nil
+	s.decoded = false
+	s.dirty = false
+}
+
+// decode decodes the content as a JSON object and returns false if it is not one.
+func (s *structuredContent) decode() bool {
+	if !s.decoded {
+		s.decoded = true
+		content := s.raw()
+		trimmed := bytes.TrimSpace(content)
+		if len(trimmed) == 0 || trimmed[0] != '{' {
+			return false
+		}
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		// keep numbers as they are to not lose precision when re-encoding
+		decoder.UseNumber()
+		var fields map[string]interface{}
+		if err := decoder.Decode(&fields); err != nil {
+			return false
+		}
+		s.fields = fields
+	}
+	return s.fields != nil
+}
+
+// get returns the value of the field at the given dot separated path.
+func (s *structuredContent) get(path string) (interface{}, bool) {
+	if !s.decode() {
+		return nil, false
+	}
+	parent, key := s.lookupParent(path, false)
+	if parent == nil {
+		return nil, false
+	}
+	value, exists := parent[key]
+	return value, exists
+}
+
+// remove removes the field at the given dot separated path and returns its value.
+func (s *structuredContent) remove(path string) (interface{}, bool) {
+	if !s.decode() {
+		return nil, false
+	}
+	parent, key := s.lookupParent(path, false)
+	if parent == nil {
+		return nil, false
+	}
+	value, exists := parent[key]
+	if exists {
+		delete(parent, key)
+		s.dirty = true
+	}
+	return value, exists
+}
+
+// set sets the field at the given dot separated path, creating the intermediate objects if needed.
+func (s *structuredContent) set(path string, value interface{}) bool {
+	if !s.decode() {
+		return false
+	}
+	parent, key := s.lookupParent(path, true)
+	if parent == nil {
+		return false
+	}
+	parent[key] = value
+	s.dirty = true
+	return true
+}
+
+// lookupParent returns the object holding the last key of the path along with this key.
+func (s *structuredContent) lookupParent(path string, create bool) (map[string]interface{}, string) {
+	keys := strings.Split(path, ".")
+	current := s.fields
+	for _, key := range keys[:len(keys)-1] {
+		next, exists := current[key]
+		if !exists && create {
+			next = make(map[string]interface{})
+			current[key] = next
+		}
+		object, ok := next.(map[string]interface{})
+		if !ok {
+			return nil, ""
+		}
+		current = object
+	}
+	return current, keys[len(keys)-1]
+}
+
+// fieldToString returns the string representation of a decoded JSON value.
+func fieldToString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return "null"
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(encoded)
+	}
+}
@@ -37,7 +37,7 @@ func (j *jsonEncoder) Encode(msg *message.Message, redactedMsg []byte) ([]byte,
 	if !msg.Timestamp.IsZero() {
 		ts = msg.Timestamp
 	}
-	return json.Marshal(jsonPayload{
+	encoded, err := json.Marshal(jsonPayload{
 		Message:   toValidUtf8(redactedMsg),
 		Status:    msg.GetStatus(),
 		Timestamp: ts.UnixNano() / nanoToMillis,
@@ -46,4 +46,8 @@ func (j *jsonEncoder) Encode(msg *message.Message, redactedMsg []byte) ([]byte,
 		Source:    msg.Origin.Source(),
 		Tags:      msg.Origin.TagsToString(),
 	})
+	if err != nil || len(msg.Attributes) == 0 {
+		return encoded, err
+	}
+	return withAttributes(encoded, msg)
 }
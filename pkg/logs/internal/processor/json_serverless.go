@@ -57,7 +57,7 @@ func (j *jsonServerlessEncoder) Encode(msg *message.Message, redactedMsg []byte)
 		}
 	}
 
-	return json.Marshal(jsonServerlessPayload{
+	encoded, err := json.Marshal(jsonServerlessPayload{
 		Message: jsonServerlessMessage{
 			Message: toValidUtf8(redactedMsg),
 			Lambda:  lambdaPart,
@@ -69,4 +69,8 @@ func (j *jsonServerlessEncoder) Encode(msg *message.Message, redactedMsg []byte)
 		Source:    msg.Origin.Source(),
 		Tags:      msg.Origin.TagsToString(),
 	})
+	if err != nil || len(msg.Attributes) == 0 {
+		return encoded, err
+	}
+	return withAttributes(encoded, msg)
 }
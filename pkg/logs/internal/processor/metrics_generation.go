@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// addTimeSample sends the samples of the generated metrics to the aggregator,
+// it is a variable for testing purposes.
+var addTimeSample = aggregator.AddTimeSample
+
+// generateMetric generates the metric of a generate_metric rule when the line matches it,
+// and returns whether it matched.
+func generateMetric(rule *config.ProcessingRule, msg *message.Message, content *structuredContent) bool {
+	var subject []byte
+	if rule.Field != "" {
+		value, exists := content.get(rule.Field)
+		if !exists {
+			return false
+		}
+		subject = []byte(fieldToString(value))
+	} else {
+		subject = content.raw()
+	}
+	var captures [][]byte
+	if rule.Regex != nil {
+		if captures = rule.Regex.FindSubmatch(subject); captures == nil {
+			return false
+		}
+	}
+
+	// lookup returns the value of a named capture or of a JSON field of the line
+	lookup := func(name string) (string, bool) {
+		if rule.Regex != nil {
+			if i := rule.Regex.SubexpIndex(name); i > 0 && captures[i] != nil {
+				return string(captures[i]), true
+			}
+		}
+		if value, exists := content.get(name); exists {
+			return fieldToString(value), true
+		}
+		return "", false
+	}
+
+	sample := metrics.MetricSample{
+		Name:       rule.MetricName,
+		Value:      1,
+		Mtype:      metrics.CounterType,
+		Host:       msg.GetHostname(),
+		SampleRate: 1,
+	}
+	if rule.MetricType == config.DistributionMetric {
+		sample.Mtype = metrics.DistributionType
+	}
+	if rule.ValueFrom != "" {
+		raw, exists := lookup(rule.ValueFrom)
+		if !exists {
+			log.Debugf("No value %s to generate the metric %s", rule.ValueFrom, rule.MetricName)
+			return true
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			log.Debugf("Invalid value %s to generate the metric %s: %v", raw, rule.MetricName, err)
+			return true
+		}
+		sample.Value = value
+	}
+
+	tagsFrom := rule.TagsFrom
+	if len(tagsFrom) == 0 && rule.Regex != nil {
+		// all the named captures but the value
+		for _, name := range rule.Regex.SubexpNames() {
+			if name != "" && name != rule.ValueFrom {
+				tagsFrom = append(tagsFrom, name)
+			}
+		}
+	}
+	for _, name := range tagsFrom {
+		if value, exists := lookup(name); exists && value != "" {
+			sample.Tags = append(sample.Tags, name+":"+value)
+		}
+	}
+
+	if err := addTimeSample(sample); err != nil {
+		log.Debugf("Could not generate the metric %s: %v", rule.MetricName, err)
+	}
+	return true
+}
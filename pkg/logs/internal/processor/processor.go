@@ -8,6 +8,7 @@ package processor
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -27,10 +28,21 @@ type Processor struct {
 	done                      chan struct{}
 	diagnosticMessageReceiver diagnostic.MessageReceiver
 	mu                        sync.Mutex
+
+	// whether the processed content is kept on the messages for the destinations
+	// encoding them for other backends than Datadog
+	keepProcessedContent bool
+
+	// samplers with a deduplication window that may need to be flushed,
+	// and the summaries of deduplicated lines waiting to be sent
+	samplers     map[*sampler]struct{}
+	samplersLock sync.Mutex
+	summaries    []*message.Message
 }
 
-// New returns an initialized Processor.
-func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver) *Processor {
+// New returns an initialized Processor.  The processed content of the messages is only
+// kept when keepProcessedContent is set, to not hold it in memory when no destination needs it.
+func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver, keepProcessedContent bool) *Processor {
 	return &Processor{
 		inputChan:                 inputChan,
 		outputChan:                outputChan,
@@ -38,6 +50,8 @@ func New(inputChan, outputChan chan *message.Message, processingRules []*config.
 		encoder:                   encoder,
 		done:                      make(chan struct{}),
 		diagnosticMessageReceiver: diagnosticMessageReceiver,
+		samplers:                  make(map[*sampler]struct{}),
+		keepProcessedContent:      keepProcessedContent,
 	}
 }
 
@@ -76,51 +90,151 @@ func (p *Processor) run() {
 	defer func() {
 		p.done <- struct{}{}
 	}()
-	for msg := range p.inputChan {
-		p.processMessage(msg)
-		p.mu.Lock() // block here if we're trying to flush synchronously
-		p.mu.Unlock()
+	ticker := time.NewTicker(samplingFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg, ok := <-p.inputChan:
+			if !ok {
+				// send the summaries of the pending deduplication windows before exiting
+				p.mu.Lock()
+				p.flushSamplers(true)
+				p.mu.Unlock()
+				return
+			}
+			p.processMessage(msg)
+			p.mu.Lock() // block here if we're trying to flush synchronously
+			p.mu.Unlock()
+		case <-ticker.C:
+			p.mu.Lock()
+			p.flushSamplers(false)
+			p.mu.Unlock()
+		}
 	}
 }
 
 func (p *Processor) processMessage(msg *message.Message) {
 	metrics.LogsDecoded.Add(1)
 	metrics.TlmLogsDecoded.Inc()
-	if shouldProcess, redactedMsg := p.applyRedactingRules(msg); shouldProcess {
-		metrics.LogsProcessed.Add(1)
-		metrics.TlmLogsProcessed.Inc()
+	shouldProcess, redactedMsg := p.applyRedactingRules(msg)
+	p.sendSummaries()
+	if shouldProcess {
+		p.sendMessage(msg, redactedMsg)
+	}
+}
 
-		p.diagnosticMessageReceiver.HandleMessage(*msg, redactedMsg)
+// sendMessage encodes the message and forwards it to the output channel.
+func (p *Processor) sendMessage(msg *message.Message, redactedMsg []byte) {
+	metrics.LogsProcessed.Add(1)
+	metrics.TlmLogsProcessed.Inc()
 
-		// Encode the message to its final format
-		content, err := p.encoder.Encode(msg, redactedMsg)
-		if err != nil {
-			log.Error("unable to encode msg ", err)
-			return
+	p.diagnosticMessageReceiver.HandleMessage(*msg, redactedMsg)
+
+	// Encode the message to its final format
+	content, err := p.encoder.Encode(msg, redactedMsg)
+	if err != nil {
+		log.Error("unable to encode msg ", err)
+		return
+	}
+	msg.Content = content
+	if p.keepProcessedContent {
+		msg.ProcessedContent = redactedMsg
+	}
+	p.outputChan <- msg
+}
+
+// sendSummaries sends the summaries of the deduplicated lines produced by the sampling rules.
+func (p *Processor) sendSummaries() {
+	for _, summary := range p.summaries {
+		p.sendMessage(summary, summary.Content)
+	}
+	p.summaries = p.summaries[:0]
+}
+
+// flushSamplers sends the summaries of the expired deduplication windows, or of all of
+// them if force is set, and forgets about the samplers that have no line pending.
+func (p *Processor) flushSamplers(force bool) {
+	p.samplersLock.Lock()
+	defer p.samplersLock.Unlock()
+	for s := range p.samplers {
+		summary, pending := s.flush(force)
+		if summary != nil {
+			p.summaries = append(p.summaries, summary)
+		}
+		if !pending {
+			delete(p.samplers, s)
 		}
-		msg.Content = content
-		p.outputChan <- msg
 	}
+	p.sendSummaries()
+}
+
+// sample applies a sampling rule on the message and returns whether it should be kept.
+func (p *Processor) sample(rule *config.ProcessingRule, index int, msg *message.Message, content []byte) bool {
+	s := samplerFor(rule, index, msg.Origin.LogSource)
+	keep, summary := s.sample(msg, content)
+	if summary != nil {
+		p.summaries = append(p.summaries, summary)
+	}
+	if rule.DedupWindow > 0 {
+		p.samplersLock.Lock()
+		if p.samplers == nil {
+			p.samplers = make(map[*sampler]struct{})
+		}
+		p.samplers[s] = struct{}{}
+		p.samplersLock.Unlock()
+	}
+	return keep
 }
 
 // applyRedactingRules returns given a message if we should process it or not,
 // and a copy of the message with some fields redacted, depending on config
 func (p *Processor) applyRedactingRules(msg *message.Message) (bool, []byte) {
-	content := msg.Content
+	content := newStructuredContent(msg.Content)
 	rules := append(p.processingRules, msg.Origin.LogSource.Config.ProcessingRules...)
-	for _, rule := range rules {
+	for i, rule := range rules {
 		switch rule.Type {
 		case config.ExcludeAtMatch:
-			if rule.Regex.Match(content) {
+			if rule.Regex.Match(content.raw()) {
 				return false, nil
 			}
 		case config.IncludeAtMatch:
-			if !rule.Regex.Match(content) {
+			if !rule.Regex.Match(content.raw()) {
 				return false, nil
 			}
 		case config.MaskSequences:
-			content = rule.Regex.ReplaceAll(content, rule.Placeholder)
+			content.setRaw(rule.Regex.ReplaceAll(content.raw(), rule.Placeholder))
+		case config.ExcludeAtFieldMatch:
+			if value, exists := content.get(rule.Field); exists && rule.Regex.MatchString(fieldToString(value)) {
+				return false, nil
+			}
+		case config.RemoveField:
+			content.remove(rule.Field)
+		case config.RenameField:
+			// the field is only removed once it was set at its new path, to not lose it
+			if value, exists := content.get(rule.Field); exists && content.set(rule.RenameTo, value) {
+				content.remove(rule.Field)
+			}
+		case config.FieldToTag:
+			if value, exists := content.get(rule.Field); exists {
+				tagName := rule.TagName
+				if tagName == "" {
+					tagName = rule.Field
+				}
+				msg.Origin.AddTags(tagName + ":" + fieldToString(value))
+			}
+		case config.Sampling:
+			if !p.sample(rule, i, msg, content.raw()) {
+				return false, nil
+			}
+		case config.GrokParser:
+			if attributes, matched := rule.Grok.Parse(content.raw()); matched {
+				msg.Attributes = mergeAttributes(msg.Attributes, attributes)
+			}
+		case config.GenerateMetric:
+			if generateMetric(rule, msg, content) && rule.DropOriginal {
+				return false, nil
+			}
 		}
 	}
-	return true, content
+	return true, content.raw()
 }
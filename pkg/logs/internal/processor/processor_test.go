@@ -9,8 +9,11 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -138,6 +141,215 @@ func TestTruncate(t *testing.T) {
 	assert.Equal(t, []byte("hello"), redactedMessage)
 }
 
+func TestExclusionAtFieldMatch(t *testing.T) {
+	p := &Processor{}
+
+	var shouldProcess bool
+	var redactedMessage []byte
+
+	source := newFieldSource(&config.ProcessingRule{Type: config.ExcludeAtFieldMatch, Field: "level", Pattern: "^debug$", Regex: regexp.MustCompile("^debug$")})
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"level":"debug","msg":"hello"}`), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"level":"info","msg":"debug"}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"level":"info","msg":"debug"}`), redactedMessage)
+
+	// non JSON lines are left untouched by structured rules
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("level=debug"), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte("level=debug"), redactedMessage)
+
+	source = newFieldSource(&config.ProcessingRule{Type: config.ExcludeAtFieldMatch, Field: "http.status", Pattern: "^2", Regex: regexp.MustCompile("^2")})
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"http":{"status":200}}`), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"http":{"status":500}}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+}
+
+func TestRemoveField(t *testing.T) {
+	p := &Processor{}
+
+	source := newFieldSource(&config.ProcessingRule{Type: config.RemoveField, Field: "user.password"})
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"msg":"login","user":{"name":"bob","password":"secret"}}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"msg":"login","user":{"name":"bob"}}`), redactedMessage)
+
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"msg":"login", "count": 12345678901234567890}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"msg":"login", "count": 12345678901234567890}`), redactedMessage)
+}
+
+func TestRenameField(t *testing.T) {
+	p := &Processor{}
+
+	source := newFieldSource(&config.ProcessingRule{Type: config.RenameField, Field: "msg", RenameTo: "message"})
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"msg":"hello","count":12345678901234567890}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"count":12345678901234567890,"message":"hello"}`), redactedMessage)
+
+	source = newFieldSource(&config.ProcessingRule{Type: config.RenameField, Field: "msg", RenameTo: "log.message"})
+	_, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"msg":"hello"}`), &source, ""))
+	assert.Equal(t, []byte(`{"log":{"message":"hello"}}`), redactedMessage)
+
+	// the field is kept when it can't be set at its new path
+	source = newFieldSource(&config.ProcessingRule{Type: config.RenameField, Field: "msg", RenameTo: "log.message"})
+	_, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"msg":"hello","log":"raw"}`), &source, ""))
+	assert.Equal(t, []byte(`{"msg":"hello","log":"raw"}`), redactedMessage)
+}
+
+func TestStructuredContentEncoding(t *testing.T) {
+	p := &Processor{}
+
+	// the content is left untouched when no field is modified
+	source := newFieldSource(&config.ProcessingRule{Type: config.RemoveField, Field: "password"})
+	_, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"url":"/a?b=1&c=<d>", "msg":"hello"}`), &source, ""))
+	assert.Equal(t, []byte(`{"url":"/a?b=1&c=<d>", "msg":"hello"}`), redactedMessage)
+
+	// the HTML characters aren't escaped when it is re-encoded
+	_, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"url":"/a?b=1&c=<d>","password":"secret"}`), &source, ""))
+	assert.Equal(t, []byte(`{"url":"/a?b=1&c=<d>"}`), redactedMessage)
+}
+
+func TestFieldToTag(t *testing.T) {
+	p := &Processor{}
+
+	source := newFieldSource(&config.ProcessingRule{Type: config.FieldToTag, Field: "user.id", TagName: "user_id"}, &config.ProcessingRule{Type: config.FieldToTag, Field: "env"})
+	msg := newMessage([]byte(`{"env":"prod","user":{"id":42}}`), &source, "")
+	shouldProcess, redactedMessage := p.applyRedactingRules(msg)
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"env":"prod","user":{"id":42}}`), redactedMessage)
+	assert.Equal(t, []string{"user_id:42", "env:prod"}, msg.Origin.Tags())
+}
+
+func TestMaskAfterStructuredRule(t *testing.T) {
+	p := &Processor{}
+
+	source := newFieldSource(
+		&config.ProcessingRule{Type: config.RemoveField, Field: "password"},
+		newProcessingRule("mask_sequences", "[masked]", "secret"),
+	)
+	_, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"password":"secret","msg":"secret"}`), &source, ""))
+	assert.Equal(t, []byte(`{"msg":"[masked]"}`), redactedMessage)
+}
+
+func TestGrokParser(t *testing.T) {
+	p := &Processor{}
+
+	rules := []*config.ProcessingRule{
+		{Type: config.GrokParser, Pattern: `%{WORD:http.method} %{NOTSPACE:http.url} %{INT:http.status_code:int}`},
+		{Type: config.GrokParser, Pattern: `took %{NUMBER:duration:float}ms`},
+		{Type: config.GrokParser, Pattern: `user=(?P<user>\w+)`},
+	}
+	assert.Nil(t, config.CompileProcessingRules(rules))
+	source := newFieldSource(rules...)
+
+	msg := newMessage([]byte("GET /index.html 200 took 1.5ms"), &source, "")
+	shouldProcess, redactedMessage := p.applyRedactingRules(msg)
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte("GET /index.html 200 took 1.5ms"), redactedMessage)
+	assert.Equal(t, map[string]interface{}{
+		"http":     map[string]interface{}{"method": "GET", "url": "/index.html", "status_code": int64(200)},
+		"duration": 1.5,
+	}, msg.Attributes)
+
+	msg = newMessage([]byte("no match"), &source, "")
+	shouldProcess, _ = p.applyRedactingRules(msg)
+	assert.Equal(t, true, shouldProcess)
+	assert.Nil(t, msg.Attributes)
+}
+
+func TestGenerateMetric(t *testing.T) {
+	var samples []metrics.MetricSample
+	addTimeSample = func(sample metrics.MetricSample) error {
+		samples = append(samples, sample)
+		return nil
+	}
+	defer func() { addTimeSample = aggregator.AddTimeSample }()
+	p := &Processor{}
+
+	rules := []*config.ProcessingRule{
+		{Type: config.GenerateMetric, MetricName: "nginx.requests", Pattern: `"(?P<method>[A-Z]+) \S+ HTTP/[\d.]+" (?P<status_code>\d{3}) (?P<duration>[\d.]+)`, ValueFrom: "duration", MetricType: config.DistributionMetric},
+		{Type: config.GenerateMetric, MetricName: "nginx.requests.count", Pattern: `"(?P<method>[A-Z]+) `, DropOriginal: true},
+	}
+	assert.Nil(t, config.CompileProcessingRules(rules))
+	source := newFieldSource(rules...)
+
+	msg := newMessage([]byte(`"GET /index.html HTTP/1.1" 200 0.25`), &source, "")
+	msg.Hostname = "foo"
+	shouldProcess, _ := p.applyRedactingRules(msg)
+	assert.Equal(t, false, shouldProcess)
+	assert.Equal(t, []metrics.MetricSample{
+		{Name: "nginx.requests", Value: 0.25, Mtype: metrics.DistributionType, Tags: []string{"method:GET", "status_code:200"}, Host: "foo", SampleRate: 1},
+		{Name: "nginx.requests.count", Value: 1, Mtype: metrics.CounterType, Tags: []string{"method:GET"}, Host: "foo", SampleRate: 1},
+	}, samples)
+
+	samples = nil
+	msg = newMessage([]byte("no match"), &source, "")
+	shouldProcess, _ = p.applyRedactingRules(msg)
+	assert.Equal(t, true, shouldProcess)
+	assert.Empty(t, samples)
+}
+
+func TestGenerateMetricFromFields(t *testing.T) {
+	var samples []metrics.MetricSample
+	addTimeSample = func(sample metrics.MetricSample) error {
+		samples = append(samples, sample)
+		return nil
+	}
+	defer func() { addTimeSample = aggregator.AddTimeSample }()
+	p := &Processor{}
+
+	rules := []*config.ProcessingRule{
+		{Type: config.GenerateMetric, MetricName: "http.errors", Field: "http.status_code", Pattern: `^5\d\d$`, TagsFrom: []string{"http.status_code", "service"}},
+		{Type: config.GenerateMetric, MetricName: "http.duration", MetricType: config.DistributionMetric, Field: "duration", ValueFrom: "duration"},
+	}
+	assert.Nil(t, config.CompileProcessingRules(rules))
+	source := newFieldSource(rules...)
+
+	msg := newMessage([]byte(`{"http":{"status_code":503},"service":"web","duration":12}`), &source, "")
+	msg.Hostname = "foo"
+	shouldProcess, _ := p.applyRedactingRules(msg)
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []metrics.MetricSample{
+		{Name: "http.errors", Value: 1, Mtype: metrics.CounterType, Tags: []string{"http.status_code:503", "service:web"}, Host: "foo", SampleRate: 1},
+		{Name: "http.duration", Value: 12, Mtype: metrics.DistributionType, Host: "foo", SampleRate: 1},
+	}, samples)
+
+	samples = nil
+	msg = newMessage([]byte(`{"http":{"status_code":200},"duration":"n/a"}`), &source, "")
+	msg.Hostname = "foo"
+	shouldProcess, _ = p.applyRedactingRules(msg)
+	assert.Equal(t, true, shouldProcess)
+	assert.Empty(t, samples)
+}
+
+func TestProcessedContentOnlyKeptWhenNeeded(t *testing.T) {
+	source := newSource("mask_sequences", "[masked]", "secret")
+	for _, keep := range []bool{false, true} {
+		outputChan := make(chan *message.Message, 1)
+		p := New(nil, outputChan, nil, RawEncoder, &diagnostic.NoopMessageReceiver{}, keep)
+		p.processMessage(newMessage([]byte("a secret"), &source, ""))
+		msg := <-outputChan
+		if keep {
+			assert.Equal(t, []byte("a [masked]"), msg.ProcessedContent)
+		} else {
+			assert.Nil(t, msg.ProcessedContent)
+		}
+	}
+}
+
+func TestMergeAttributes(t *testing.T) {
+	attributes := mergeAttributes(nil, map[string]interface{}{"http": map[string]interface{}{"method": "GET"}, "a": "b"})
+	attributes = mergeAttributes(attributes, map[string]interface{}{"http": map[string]interface{}{"status_code": 200}, "a": "c"})
+	assert.Equal(t, map[string]interface{}{"http": map[string]interface{}{"method": "GET", "status_code": 200}, "a": "c"}, attributes)
+}
+
+func newFieldSource(rules ...*config.ProcessingRule) config.LogSource {
+	return config.LogSource{Config: &config.LogsConfig{ProcessingRules: rules}}
+}
+
 func newProcessingRule(ruleType, replacePlaceholder, pattern string) *config.ProcessingRule {
 	return &config.ProcessingRule{
 		Type:               ruleType,
@@ -35,7 +35,11 @@ func (r *rawEncoder) Encode(msg *message.Message, redactedMsg []byte) ([]byte, e
 		extraContent = append(extraContent, ' ')
 
 		// Timestamp
-		extraContent = time.Now().UTC().AppendFormat(extraContent, config.DateFormat)
+		ts := time.Now().UTC()
+		if !msg.Timestamp.IsZero() {
+			ts = msg.Timestamp
+		}
+		extraContent = ts.AppendFormat(extraContent, config.DateFormat)
 		extraContent = append(extraContent, ' ')
 
 		extraContent = append(extraContent, []byte(msg.GetHostname())...)
@@ -61,7 +65,7 @@ func (r *rawEncoder) Encode(msg *message.Message, redactedMsg []byte) ([]byte, e
 		}
 		extraContent = append(extraContent, ' ')
 
-		return append(extraContent, redactedMsg...), nil
+		return append(extraContent, contentWithAttributes(msg, redactedMsg)...), nil
 
 	}
 
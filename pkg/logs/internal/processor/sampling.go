@@ -0,0 +1,205 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// Reasons for which a sampling rule drops a line, used as telemetry tags.
+const (
+	sampledOutDedup      = "dedup"
+	sampledOutRateLimit  = "rate_limit"
+	sampledOutPercentage = "percentage"
+)
+
+// samplingFlushInterval is the interval at which the processor checks
+// for expired deduplication windows to emit their summary.
+const samplingFlushInterval = time.Second
+
+// samplersLock serializes the creation of the samplers as a source can be
+// handled by several processors at the same time.
+var samplersLock sync.Mutex
+
+// sampler holds the state of a sampling rule for a given source.
+// It is registered as an info provider on the source to surface its counts on the status page.
+type sampler struct {
+	rule  *config.ProcessingRule
+	index int // index of the rule in the processing rules of the source
+	now   func() time.Time
+	mu    sync.Mutex
+
+	// percentage sampling, lines are kept each time the accumulator reaches 100
+	accumulator float64
+
+	// token bucket
+	tokens     float64
+	lastRefill time.Time
+
+	// deduplication
+	lastContent    []byte
+	lastMessage    *message.Message
+	lastSuppressed *message.Message // last deduplicated message, whose offset the summary commits
+	windowStart    time.Time
+	repeated       int
+
+	deduplicated int64
+	rateLimited  int64
+	sampledOut   int64
+}
+
+func newSampler(rule *config.ProcessingRule, index int) *sampler {
+	return &sampler{
+		rule:   rule,
+		index:  index,
+		now:    time.Now,
+		tokens: bucketCapacity(rule),
+		// start with an accumulator full enough to keep the first line
+		accumulator: 100 - rule.SamplePercentage,
+	}
+}
+
+// bucketCapacity returns the number of lines the token bucket of the rule can hold. It holds
+// at least one line so that the rates below one line per second let lines through.
+func bucketCapacity(rule *config.ProcessingRule) float64 {
+	return math.Max(1, rule.LinesPerSecond)
+}
+
+// samplerFor returns the sampler of the rule at the given index of the processing rules of
+// the source of the message, creating and registering it on the source if needed. The samplers
+// are identified by the index of their rule, as several rules of a source can share a name.
+func samplerFor(rule *config.ProcessingRule, index int, source *config.LogSource) *sampler {
+	key := samplerInfoKey(rule, index)
+	samplersLock.Lock()
+	defer samplersLock.Unlock()
+	if s, ok := source.GetInfo(key).(*sampler); ok && s.rule == rule {
+		return s
+	}
+	s := newSampler(rule, index)
+	source.RegisterInfo(s)
+	return s
+}
+
+func samplerInfoKey(rule *config.ProcessingRule, index int) string {
+	return fmt.Sprintf("Sampling rule #%d %s", index+1, rule.Name)
+}
+
+// InfoKey returns the key used to display the sampler on the status page.
+func (s *sampler) InfoKey() string {
+	return samplerInfoKey(s.rule, s.index)
+}
+
+// Info returns the number of lines dropped by the sampler.
+func (s *sampler) Info() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var info []string
+	if s.rule.DedupWindow > 0 {
+		info = append(info, fmt.Sprintf("Deduplicated: %d", s.deduplicated))
+	}
+	if s.rule.LinesPerSecond > 0 {
+		info = append(info, fmt.Sprintf("Rate limited: %d", s.rateLimited))
+	}
+	if s.rule.SamplePercentage > 0 {
+		info = append(info, fmt.Sprintf("Sampled out: %d", s.sampledOut))
+	}
+	return info
+}
+
+// sample returns whether the message should be kept, and the summary of the
+// previously deduplicated lines if the message ends a deduplication window.
+func (s *sampler) sample(msg *message.Message, content []byte) (bool, *message.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+
+	var summary *message.Message
+	if s.rule.DedupWindow > 0 {
+		window := time.Duration(s.rule.DedupWindow) * time.Second
+		if s.lastMessage != nil && now.Sub(s.windowStart) < window && bytes.Equal(content, s.lastContent) {
+			s.repeated++
+			s.deduplicated++
+			s.lastSuppressed = msg
+			s.drop(sampledOutDedup)
+			return false, nil
+		}
+		summary = s.summary()
+		s.lastContent = append(s.lastContent[:0], content...)
+		s.lastMessage = msg
+		s.windowStart = now
+	}
+
+	if s.rule.LinesPerSecond > 0 {
+		s.tokens += now.Sub(s.lastRefill).Seconds() * s.rule.LinesPerSecond
+		if capacity := bucketCapacity(s.rule); s.tokens > capacity {
+			s.tokens = capacity
+		}
+		s.lastRefill = now
+		if s.tokens < 1 {
+			s.rateLimited++
+			s.drop(sampledOutRateLimit)
+			return false, summary
+		}
+		s.tokens--
+	}
+
+	if s.rule.SamplePercentage > 0 {
+		s.accumulator += s.rule.SamplePercentage
+		if s.accumulator < 100 {
+			s.sampledOut++
+			s.drop(sampledOutPercentage)
+			return false, summary
+		}
+		s.accumulator -= 100
+	}
+
+	return true, summary
+}
+
+// flush returns the summary of the deduplicated lines if the deduplication window expired,
+// or right away if force is set, and whether the sampler still has lines pending in its current window.
+func (s *sampler) flush(force bool) (*message.Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastMessage == nil {
+		return nil, false
+	}
+	if !force && s.now().Sub(s.windowStart) < time.Duration(s.rule.DedupWindow)*time.Second {
+		return nil, true
+	}
+	summary := s.summary()
+	s.lastMessage = nil
+	s.lastContent = s.lastContent[:0]
+	return summary, false
+}
+
+// summary returns a message reporting how many times the last line was repeated, if any,
+// and resets the count. The summary carries the origin of the last repeated line so that
+// the offset committed for it covers all the suppressed lines. It must be called with the lock held.
+func (s *sampler) summary() *message.Message {
+	if s.repeated == 0 {
+		return nil
+	}
+	content := []byte(fmt.Sprintf("Previous message repeated %d times", s.repeated))
+	s.repeated = 0
+	msg := message.NewMessage(content, s.lastSuppressed.Origin, s.lastMessage.GetStatus(), s.now().UnixNano())
+	msg.Timestamp = s.lastSuppressed.Timestamp
+	s.lastSuppressed = nil
+	return msg
+}
+
+func (s *sampler) drop(reason string) {
+	metrics.LogsSampledOut.Add(1)
+	metrics.TlmLogsSampledOut.Inc(reason)
+}
@@ -0,0 +1,227 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestSampler(rule *config.ProcessingRule, clock *fakeClock) *sampler {
+	s := newSampler(rule, 0)
+	s.now = clock.Now
+	return s
+}
+
+func TestSamplePercentage(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	s := newTestSampler(&config.ProcessingRule{Type: config.Sampling, SamplePercentage: 25}, clock)
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	var kept []bool
+	for i := 0; i < 8; i++ {
+		keep, summary := s.sample(newMessage([]byte("hello"), source, ""), []byte("hello"))
+		assert.Nil(t, summary)
+		kept = append(kept, keep)
+	}
+	assert.Equal(t, []bool{true, false, false, false, true, false, false, false}, kept)
+	assert.Equal(t, []string{"Sampled out: 6"}, s.Info())
+}
+
+func TestSampleRateLimit(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	s := newTestSampler(&config.ProcessingRule{Type: config.Sampling, LinesPerSecond: 2}, clock)
+	source := config.NewLogSource("", &config.LogsConfig{})
+	msg := newMessage([]byte("hello"), source, "")
+
+	keep, _ := s.sample(msg, msg.Content)
+	assert.True(t, keep)
+	keep, _ = s.sample(msg, msg.Content)
+	assert.True(t, keep)
+	keep, _ = s.sample(msg, msg.Content)
+	assert.False(t, keep)
+
+	// half a second later, one token is available again
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	keep, _ = s.sample(msg, msg.Content)
+	assert.True(t, keep)
+	keep, _ = s.sample(msg, msg.Content)
+	assert.False(t, keep)
+
+	// the bucket does not grow beyond its capacity
+	clock.now = clock.now.Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		keep, _ = s.sample(msg, msg.Content)
+		assert.True(t, keep)
+	}
+	keep, _ = s.sample(msg, msg.Content)
+	assert.False(t, keep)
+	assert.Equal(t, []string{"Rate limited: 3"}, s.Info())
+}
+
+func TestSampleFractionalRateLimit(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	s := newTestSampler(&config.ProcessingRule{Type: config.Sampling, LinesPerSecond: 0.5}, clock)
+	source := config.NewLogSource("", &config.LogsConfig{})
+	msg := newMessage([]byte("hello"), source, "")
+
+	keep, _ := s.sample(msg, msg.Content)
+	assert.True(t, keep)
+	keep, _ = s.sample(msg, msg.Content)
+	assert.False(t, keep)
+
+	// one line every two seconds
+	clock.now = clock.now.Add(time.Second)
+	keep, _ = s.sample(msg, msg.Content)
+	assert.False(t, keep)
+	clock.now = clock.now.Add(time.Second)
+	keep, _ = s.sample(msg, msg.Content)
+	assert.True(t, keep)
+
+	// the bucket holds a single line
+	clock.now = clock.now.Add(time.Minute)
+	keep, _ = s.sample(msg, msg.Content)
+	assert.True(t, keep)
+	keep, _ = s.sample(msg, msg.Content)
+	assert.False(t, keep)
+	assert.Equal(t, []string{"Rate limited: 3"}, s.Info())
+}
+
+func TestSampleDedup(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	s := newTestSampler(&config.ProcessingRule{Type: config.Sampling, DedupWindow: 10}, clock)
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	keep, summary := s.sample(newMessage([]byte("hello"), source, message.StatusError), []byte("hello"))
+	assert.True(t, keep)
+	assert.Nil(t, summary)
+	for i := 0; i < 3; i++ {
+		keep, summary = s.sample(newMessage([]byte("hello"), source, message.StatusError), []byte("hello"))
+		assert.False(t, keep)
+		assert.Nil(t, summary)
+	}
+
+	// a different line ends the window and emits a summary
+	keep, summary = s.sample(newMessage([]byte("world"), source, ""), []byte("world"))
+	assert.True(t, keep)
+	assert.NotNil(t, summary)
+	assert.Equal(t, []byte("Previous message repeated 3 times"), summary.Content)
+	assert.Equal(t, message.StatusError, summary.GetStatus())
+
+	keep, _ = s.sample(newMessage([]byte("world"), source, ""), []byte("world"))
+	assert.False(t, keep)
+
+	// nothing is flushed until the window expires
+	summary, pending := s.flush(false)
+	assert.Nil(t, summary)
+	assert.True(t, pending)
+
+	clock.now = clock.now.Add(10 * time.Second)
+	summary, pending = s.flush(false)
+	assert.Equal(t, []byte("Previous message repeated 1 times"), summary.Content)
+	assert.False(t, pending)
+
+	// the same line is kept again once the window expired
+	keep, summary = s.sample(newMessage([]byte("world"), source, ""), []byte("world"))
+	assert.True(t, keep)
+	assert.Nil(t, summary)
+	assert.Equal(t, []string{"Deduplicated: 4"}, s.Info())
+
+	// a pending window is flushed right away when forced
+	keep, _ = s.sample(newMessage([]byte("world"), source, ""), []byte("world"))
+	assert.False(t, keep)
+	summary, pending = s.flush(true)
+	assert.Equal(t, []byte("Previous message repeated 1 times"), summary.Content)
+	assert.False(t, pending)
+}
+
+func TestSampleDedupSummaryOffset(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	s := newTestSampler(&config.ProcessingRule{Type: config.Sampling, DedupWindow: 10}, clock)
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	for offset := int64(1); offset <= 3; offset++ {
+		msg := newMessage([]byte("hello"), source, "")
+		msg.Origin.Offset = strconv.FormatInt(offset, 10)
+		s.sample(msg, msg.Content)
+	}
+
+	// the summary commits the offset of the last suppressed line
+	_, summary := s.sample(newMessage([]byte("world"), source, ""), []byte("world"))
+	assert.Equal(t, "3", summary.Origin.Offset)
+}
+
+func TestProcessorSamplingRule(t *testing.T) {
+	rule := &config.ProcessingRule{Type: config.Sampling, Name: "dedup", DedupWindow: 60}
+	source := config.NewLogSource("", &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{rule}})
+	outputChan := make(chan *message.Message, 10)
+	p := New(nil, outputChan, nil, RawEncoder, &diagnostic.NoopMessageReceiver{}, false)
+
+	p.processMessage(newMessage([]byte("hello"), source, ""))
+	p.processMessage(newMessage([]byte("hello"), source, ""))
+	p.processMessage(newMessage([]byte("hello"), source, ""))
+	p.processMessage(newMessage([]byte("world"), source, ""))
+
+	assert.Len(t, outputChan, 3)
+	assert.Contains(t, string((<-outputChan).Content), "hello")
+	assert.Contains(t, string((<-outputChan).Content), "Previous message repeated 2 times")
+	assert.Contains(t, string((<-outputChan).Content), "world")
+
+	// the sampler is shared by all the messages of the source and reported on the status page
+	assert.Equal(t, []string{"Deduplicated: 2"}, source.GetInfoStatus()["Sampling rule #1 dedup"])
+	assert.Len(t, p.samplers, 1)
+}
+
+func TestProcessorSamplingRulesSharingName(t *testing.T) {
+	rules := []*config.ProcessingRule{
+		{Type: config.Sampling, Name: "sampling", DedupWindow: 60},
+		{Type: config.Sampling, Name: "sampling", SamplePercentage: 50},
+	}
+	source := config.NewLogSource("", &config.LogsConfig{ProcessingRules: rules})
+	outputChan := make(chan *message.Message, 10)
+	p := New(nil, outputChan, nil, RawEncoder, &diagnostic.NoopMessageReceiver{}, false)
+
+	for _, content := range []string{"a", "a", "b", "c", "d"} {
+		p.processMessage(newMessage([]byte(content), source, ""))
+	}
+
+	// each rule keeps its own sampler
+	status := source.GetInfoStatus()
+	assert.Equal(t, []string{"Deduplicated: 1"}, status["Sampling rule #1 sampling"])
+	assert.Equal(t, []string{"Sampled out: 2"}, status["Sampling rule #2 sampling"])
+}
+
+func TestProcessorStopFlushesSummaries(t *testing.T) {
+	rule := &config.ProcessingRule{Type: config.Sampling, Name: "dedup", DedupWindow: 60}
+	source := config.NewLogSource("", &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{rule}})
+	inputChan := make(chan *message.Message, 10)
+	outputChan := make(chan *message.Message, 10)
+	p := New(inputChan, outputChan, nil, RawEncoder, &diagnostic.NoopMessageReceiver{}, false)
+	p.Start()
+
+	inputChan <- newMessage([]byte("hello"), source, "")
+	inputChan <- newMessage([]byte("hello"), source, "")
+	p.Stop()
+
+	assert.Len(t, outputChan, 2)
+	assert.Contains(t, string((<-outputChan).Content), "hello")
+	assert.Contains(t, string((<-outputChan).Content), "Previous message repeated 1 times")
+}
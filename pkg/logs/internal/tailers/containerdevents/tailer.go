@@ -0,0 +1,212 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build containerd
+// +build containerd
+
+// Package containerdevents implements a tailer that streams the events of containerd.
+package containerdevents
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	// register the types of the containerd events to decode them
+	_ "github.com/containerd/containerd/api/events"
+	containerdevents "github.com/containerd/containerd/events"
+	"github.com/containerd/typeurl"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// retryPeriod is the time to wait before subscribing again after the event stream failed.
+const retryPeriod = 5 * time.Second
+
+// eventFields are the fields of the containerd events that are turned into tags and status.
+type eventFields struct {
+	// ContainerID is set by the task events
+	ContainerID string `json:"container_id"`
+	// ID is set by the container events
+	ID string `json:"id"`
+	// Name is set by the image events
+	Name       string `json:"name"`
+	ExitStatus uint32 `json:"exit_status"`
+}
+
+// Tailer subscribes to the events of containerd and forwards them as log messages.
+type Tailer struct {
+	source     *config.LogSource
+	subscriber containerdevents.Subscriber
+	outputChan chan *message.Message
+	// containerTags returns the tags of a container, it is a field for testing purposes
+	containerTags func(containerID string) ([]string, error)
+	retryPeriod   time.Duration
+	ctx           context.Context
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+// NewTailer returns a new Tailer, the events are filtered by the query of the source,
+// e.g. `topic~="/tasks/"`.
+func NewTailer(source *config.LogSource, subscriber containerdevents.Subscriber, outputChan chan *message.Message) *Tailer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Tailer{
+		source:        source,
+		subscriber:    subscriber,
+		outputChan:    outputChan,
+		containerTags: containerTags,
+		retryPeriod:   retryPeriod,
+		ctx:           ctx,
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+}
+
+// Start starts streaming the events.
+func (t *Tailer) Start() {
+	go t.run()
+}
+
+// Stop stops streaming the events.
+func (t *Tailer) Stop() {
+	t.cancel()
+	<-t.done
+}
+
+// run subscribes to the events and forwards them until the tailer is stopped,
+// it subscribes again when the stream fails, e.g. when containerd restarts.
+func (t *Tailer) run() {
+	defer close(t.done)
+	var filters []string
+	if t.source.Config.Query != "" {
+		filters = append(filters, t.source.Config.Query)
+	}
+	for {
+		events, errs := t.subscriber.Subscribe(t.ctx, filters...)
+		t.source.Status.Success()
+		err := t.forward(events, errs)
+		if t.ctx.Err() != nil {
+			return
+		}
+		log.Warnf("The stream of containerd events failed, retrying in %v: %v", t.retryPeriod, err)
+		t.source.Status.Error(err)
+		select {
+		case <-time.After(t.retryPeriod):
+		case <-t.ctx.Done():
+			return
+		}
+	}
+}
+
+// forward forwards the events until the stream fails or the tailer is stopped.
+func (t *Tailer) forward(events <-chan *containerdevents.Envelope, errs <-chan error) error {
+	for {
+		select {
+		case envelope := <-events:
+			if envelope == nil {
+				continue
+			}
+			msg, err := t.toMessage(envelope)
+			if err != nil {
+				log.Debugf("Could not decode the containerd event %s: %v", envelope.Topic, err)
+				continue
+			}
+			select {
+			case t.outputChan <- msg:
+			case <-t.ctx.Done():
+				return nil
+			}
+		case err := <-errs:
+			return err
+		case <-t.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// toMessage turns the event into a message whose content holds the decoded event in a
+// "containerd" attribute, e.g. `{"message":"/tasks/exit 8a7f","containerd":{"topic":"/tasks/exit","namespace":"k8s.io","event":{...}}}`.
+func (t *Tailer) toMessage(envelope *containerdevents.Envelope) (*message.Message, error) {
+	var event json.RawMessage
+	if envelope.Event != nil {
+		decoded, err := typeurl.UnmarshalAny(envelope.Event)
+		if err != nil {
+			return nil, err
+		}
+		if event, err = json.Marshal(decoded); err != nil {
+			return nil, err
+		}
+	}
+	var fields eventFields
+	if len(event) > 0 {
+		if err := json.Unmarshal(event, &fields); err != nil {
+			return nil, err
+		}
+	}
+
+	summary := envelope.Topic
+	tags := []string{
+		"containerd_namespace:" + envelope.Namespace,
+		"event_topic:" + envelope.Topic,
+	}
+	containerID := fields.ContainerID
+	if containerID == "" && strings.HasPrefix(envelope.Topic, "/containers/") {
+		containerID = fields.ID
+	}
+	if containerID != "" {
+		summary += " " + containerID
+		tags = append(tags, "container_id:"+containerID)
+		containerTags, err := t.containerTags(containerID)
+		if err != nil {
+			log.Debugf("Could not retrieve the tags of the container %s: %v", containerID, err)
+		}
+		tags = append(tags, containerTags...)
+	} else if strings.HasPrefix(envelope.Topic, "/images/") && fields.Name != "" {
+		summary += " " + fields.Name
+		tags = append(tags, "image_name:"+fields.Name)
+	}
+
+	content, err := json.Marshal(map[string]interface{}{
+		"message": summary,
+		"containerd": map[string]interface{}{
+			"topic":     envelope.Topic,
+			"namespace": envelope.Namespace,
+			"event":     event,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg := message.NewMessageWithSource(content, status(envelope.Topic, fields), t.source, time.Now().UnixNano())
+	msg.Timestamp = envelope.Timestamp
+	msg.Origin.SetTags(tags)
+	return msg, nil
+}
+
+// status returns error for the out of memory events, warning for the tasks exiting
+// with a non-zero status and info otherwise.
+func status(topic string, fields eventFields) string {
+	switch {
+	case topic == "/tasks/oom":
+		return message.StatusError
+	case topic == "/tasks/exit" && fields.ExitStatus != 0:
+		return message.StatusWarning
+	default:
+		return message.StatusInfo
+	}
+}
+
+// containerTags returns the tags of the container from the tagger.
+func containerTags(containerID string) ([]string, error) {
+	return tagger.Tag(containers.BuildTaggerEntityName(containerID), collectors.HighCardinality)
+}
@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build containerd
+// +build containerd
+
+package containerdevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/api/events"
+	containerdevents "github.com/containerd/containerd/events"
+	"github.com/containerd/typeurl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+type fakeSubscriber struct {
+	filters []string
+	streams chan chan *containerdevents.Envelope
+	errs    chan error
+}
+
+func (s *fakeSubscriber) Subscribe(ctx context.Context, filters ...string) (<-chan *containerdevents.Envelope, <-chan error) {
+	s.filters = filters
+	stream := make(chan *containerdevents.Envelope)
+	s.streams <- stream
+	return stream, s.errs
+}
+
+func newTestTailer(subscriber *fakeSubscriber, outputChan chan *message.Message) *Tailer {
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.ContainerdEventsType, Query: `topic~="/tasks/"`})
+	tailer := NewTailer(source, subscriber, outputChan)
+	tailer.containerTags = func(containerID string) ([]string, error) {
+		return []string{"image_name:nginx"}, nil
+	}
+	tailer.retryPeriod = time.Millisecond
+	return tailer
+}
+
+func newEnvelope(t *testing.T, topic string, event interface{}) *containerdevents.Envelope {
+	encoded, err := typeurl.MarshalAny(event)
+	require.NoError(t, err)
+	return &containerdevents.Envelope{
+		Timestamp: time.Date(2022, 5, 10, 12, 34, 56, 0, time.UTC),
+		Namespace: "k8s.io",
+		Topic:     topic,
+		Event:     encoded,
+	}
+}
+
+func TestTailerForwardsEvents(t *testing.T) {
+	subscriber := &fakeSubscriber{streams: make(chan chan *containerdevents.Envelope, 1), errs: make(chan error)}
+	outputChan := make(chan *message.Message, 1)
+	tailer := newTestTailer(subscriber, outputChan)
+	tailer.Start()
+	defer tailer.Stop()
+
+	stream := <-subscriber.streams
+	assert.Equal(t, []string{`topic~="/tasks/"`}, subscriber.filters)
+
+	stream <- newEnvelope(t, "/tasks/exit", &events.TaskExit{ContainerID: "8a7f", ID: "8a7f", Pid: 42, ExitStatus: 137})
+	msg := <-outputChan
+	assert.Equal(t, message.StatusWarning, msg.GetStatus())
+	assert.Equal(t, time.Date(2022, 5, 10, 12, 34, 56, 0, time.UTC), msg.Timestamp)
+	assert.Equal(t, []string{
+		"containerd_namespace:k8s.io",
+		"event_topic:/tasks/exit",
+		"container_id:8a7f",
+		"image_name:nginx",
+	}, msg.Origin.Tags())
+	var content map[string]interface{}
+	require.NoError(t, json.Unmarshal(msg.Content, &content))
+	assert.Equal(t, "/tasks/exit 8a7f", content["message"])
+	containerd := content["containerd"].(map[string]interface{})
+	assert.Equal(t, "/tasks/exit", containerd["topic"])
+	assert.Equal(t, "k8s.io", containerd["namespace"])
+	assert.Equal(t, float64(137), containerd["event"].(map[string]interface{})["exit_status"])
+
+	stream <- newEnvelope(t, "/tasks/oom", &events.TaskOOM{ContainerID: "8a7f"})
+	msg = <-outputChan
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+
+	stream <- newEnvelope(t, "/images/delete", &events.ImageDelete{Name: "docker.io/library/nginx:latest"})
+	msg = <-outputChan
+	assert.Equal(t, message.StatusInfo, msg.GetStatus())
+	assert.Equal(t, []string{
+		"containerd_namespace:k8s.io",
+		"event_topic:/images/delete",
+		"image_name:docker.io/library/nginx:latest",
+	}, msg.Origin.Tags())
+}
+
+func TestTailerSubscribesAgainWhenTheStreamFails(t *testing.T) {
+	subscriber := &fakeSubscriber{streams: make(chan chan *containerdevents.Envelope, 1), errs: make(chan error)}
+	outputChan := make(chan *message.Message, 1)
+	tailer := newTestTailer(subscriber, outputChan)
+	tailer.Start()
+	defer tailer.Stop()
+
+	<-subscriber.streams
+	subscriber.errs <- errors.New("connection reset")
+
+	stream := <-subscriber.streams
+	stream <- newEnvelope(t, "/tasks/start", &events.TaskStart{ContainerID: "8a7f", Pid: 42})
+	msg := <-outputChan
+	assert.Equal(t, message.StatusInfo, msg.GetStatus())
+	assert.True(t, tailer.source.Status.IsSuccess())
+}
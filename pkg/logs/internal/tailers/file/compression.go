@@ -0,0 +1,241 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Compression formats of the files the tailer is able to decompress.
+const (
+	compressionNone = ""
+	compressionGzip = "gzip"
+	compressionZstd = "zstd"
+)
+
+// fingerprintSize is the number of bytes of a compressed file its fingerprint is built from.
+const fingerprintSize = 1024
+
+// ErrArchiveIncomplete is returned when fingerprinting a compressed file smaller than fingerprintSize
+// that is still being written: its fingerprint would change as it grows.
+var ErrArchiveIncomplete = errors.New("compressed file still being written")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// detectCompression returns the compression format of the file at the given path
+// based on its magic number, or compressionNone for plain files.
+func detectCompression(path string) (string, error) {
+	header, err := readHeader(path, len(zstdMagic))
+	if err != nil {
+		return compressionNone, err
+	}
+	return compressionOf(header), nil
+}
+
+// Fingerprint returns an identifier of the content of the compressed file at the given path,
+// or an empty string for plain files. Unlike the path, it doesn't change when a rotation
+// renames the file: compressed files are not expected to change once written, so it is
+// built from their first bytes. The files smaller than these bytes are only fingerprinted once
+// their compressed stream is complete, ErrArchiveIncomplete is returned until then.
+func Fingerprint(path string) (string, error) {
+	header, err := readHeader(path, fingerprintSize)
+	if err != nil || compressionOf(header) == compressionNone {
+		return "", err
+	}
+	if len(header) < fingerprintSize && !isComplete(compressionOf(header), header) {
+		return "", ErrArchiveIncomplete
+	}
+	h := fnv.New64a()
+	h.Write(header) //nolint:errcheck
+	return fmt.Sprintf("%016x", h.Sum64()), nil
+}
+
+// isComplete returns true if content holds a complete compressed stream.
+func isComplete(compression string, content []byte) bool {
+	d, err := newDecompressor(compression, bytes.NewReader(content))
+	if err != nil {
+		return false
+	}
+	defer d.Close()
+	_, err = io.Copy(io.Discard, d)
+	return err == nil
+}
+
+// readHeader returns up to size bytes from the beginning of the file at the given path.
+func readHeader(path string, size int) ([]byte, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header := make([]byte, size)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return header[:n], nil
+}
+
+// compressionOf returns the compression format of a file based on the magic number
+// at the beginning of its header.
+func compressionOf(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return compressionGzip
+	case bytes.HasPrefix(header, zstdMagic):
+		return compressionZstd
+	default:
+		return compressionNone
+	}
+}
+
+// newDecompressor returns a reader of the uncompressed stream of r.
+func newDecompressor(compression string, r io.Reader) (io.ReadCloser, error) {
+	switch compression {
+	case compressionGzip:
+		return gzip.NewReader(r)
+	case compressionZstd:
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", compression)
+	}
+}
+
+// Fingerprint returns the fingerprint of the compressed file read by the tailer, if known.
+func (t *Tailer) Fingerprint() string {
+	return t.file.Fingerprint
+}
+
+// IsCompressed returns true if the tailer reads a compressed file.
+func (t *Tailer) IsCompressed() bool {
+	return t.compression != compressionNone
+}
+
+// setupCompressed sets up the tailer to read a compressed file, offsets being
+// expressed in terms of the uncompressed stream.
+func (t *Tailer) setupCompressed(offset int64, whence int) error {
+	fullpath, err := filepath.Abs(t.file.Path)
+	if err != nil {
+		return err
+	}
+	t.fullpath = fullpath
+
+	// adds metadata to enable users to filter logs by filename
+	t.tags = t.buildTailerTags()
+
+	log.Info("Opening", t.file.Path, "compressed with", t.compression, "for tailer key", t.file.GetScanKey())
+	// the end of the uncompressed stream is only known once the whole file has been decompressed
+	ret, err := t.openCompressed(offset, whence == io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	t.lastReadOffset.Store(ret)
+	t.decodedOffset.Store(ret)
+
+	return nil
+}
+
+// openCompressed opens the compressed file and skips the uncompressed stream up to
+// the given offset, or entirely if toEnd is set. It returns the offset it reached.
+func (t *Tailer) openCompressed(offset int64, toEnd bool) (int64, error) {
+	f, err := openFile(t.fullpath)
+	if err != nil {
+		return 0, err
+	}
+	d, err := newDecompressor(t.compression, f)
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+
+	var skipped int64
+	if toEnd {
+		skipped, err = io.Copy(io.Discard, d)
+	} else {
+		skipped, err = io.CopyN(io.Discard, d, offset)
+	}
+	if err != nil && err != io.EOF {
+		// the file is corrupted or still being compressed
+		d.Close()
+		f.Close()
+		return 0, err
+	}
+
+	t.osFile = f
+	t.decompressor = d
+	return skipped, nil
+}
+
+// closeCompressed closes the compressed file and its decompressor.
+func (t *Tailer) closeCompressed() {
+	if t.decompressor != nil {
+		t.decompressor.Close()
+		t.decompressor = nil
+	}
+	if t.osFile != nil {
+		t.osFile.Close()
+		t.osFile = nil
+	}
+}
+
+// readCompressed reads the uncompressed stream of the file and returns io.EOF
+// once it has been entirely read, as compressed files are not expected to grow.
+func (t *Tailer) readCompressed() (int, error) {
+	if t.decompressor == nil {
+		// the file was still being compressed during the previous read, reopen
+		// it and skip the data that has already been read
+		if _, err := t.openCompressed(t.lastReadOffset.Load(), false); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return 0, nil
+			}
+			t.file.Source.Status.Error(err)
+			return 0, log.Error("Unexpected error occurred while reopening compressed file: ", err)
+		}
+	}
+
+	inBuf := make([]byte, 4096)
+	n, err := t.decompressor.Read(inBuf)
+	if n > 0 {
+		t.decoder.InputChan <- decoder.NewInput(inBuf[:n])
+		t.lastReadOffset.Add(int64(n))
+	}
+	switch err {
+	case nil:
+		return n, nil
+	case io.EOF:
+		if n > 0 {
+			// the end of the stream is reported on the next read so that these bytes are recorded
+			return n, nil
+		}
+		return 0, io.EOF
+	case io.ErrUnexpectedEOF:
+		// the end of the compressed stream has not been written yet
+		t.closeCompressed()
+		return n, nil
+	default:
+		t.file.Source.Status.Error(err)
+		return n, log.Error("Unexpected error occurred while reading compressed file: ", err)
+	}
+}
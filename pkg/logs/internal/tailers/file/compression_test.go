@@ -0,0 +1,214 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+// +build !windows
+
+package file
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+var compressedLines = "hello world\nhello again\ngood bye\n"
+
+func writeGzip(t *testing.T, path string, content string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	w := gzip.NewWriter(f)
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
+
+func writeZstd(t *testing.T, path string, content string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	w, err := zstd.NewWriter(f)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
+
+func newCompressedTestTailer(path string, outputChan chan *message.Message) *Tailer {
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})
+	return NewTailer(outputChan, NewFile(path, source, false), 10*time.Millisecond, decoder.NewDecoderFromSource(source))
+}
+
+func waitFinished(t *testing.T, tailer *Tailer) {
+	assert.Eventually(t, tailer.IsFinished, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDetectCompression(t *testing.T) {
+	dir := t.TempDir()
+
+	plainPath := filepath.Join(dir, "plain.log")
+	require.NoError(t, os.WriteFile(plainPath, []byte(compressedLines), 0644))
+	emptyPath := filepath.Join(dir, "empty.log")
+	require.NoError(t, os.WriteFile(emptyPath, nil, 0644))
+	gzipPath := filepath.Join(dir, "archive.log.gz")
+	writeGzip(t, gzipPath, compressedLines)
+	zstdPath := filepath.Join(dir, "archive.log.zst")
+	writeZstd(t, zstdPath, compressedLines)
+
+	for path, expected := range map[string]string{
+		plainPath: compressionNone,
+		emptyPath: compressionNone,
+		gzipPath:  compressionGzip,
+		zstdPath:  compressionZstd,
+	} {
+		compression, err := detectCompression(path)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, compression, path)
+	}
+
+	_, err := detectCompression(filepath.Join(dir, "missing.log"))
+	assert.Error(t, err)
+}
+
+func TestFingerprintGrowingArchive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "archive.log.gz")
+	var lines strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&lines, "line %d %x\n", i, rand.Int63())
+	}
+	writeGzip(t, path, lines.String())
+	complete, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Greater(t, len(complete), 2*fingerprintSize)
+
+	// an archive smaller than the fingerprint size is only fingerprinted once complete
+	require.NoError(t, os.WriteFile(path, complete[:fingerprintSize/2], 0644))
+	_, err = Fingerprint(path)
+	assert.Equal(t, ErrArchiveIncomplete, err)
+
+	// the fingerprint doesn't change once the archive grew past the fingerprint size
+	require.NoError(t, os.WriteFile(path, complete[:fingerprintSize+100], 0644))
+	partial, err := Fingerprint(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, complete, 0644))
+	full, err := Fingerprint(path)
+	require.NoError(t, err)
+	assert.Equal(t, partial, full)
+
+	// small archives are fingerprinted once complete
+	writeGzip(t, path, compressedLines)
+	fingerprint, err := Fingerprint(path)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, fingerprint)
+
+	plainPath := filepath.Join(dir, "plain.log")
+	require.NoError(t, os.WriteFile(plainPath, []byte(compressedLines), 0644))
+	fingerprint, err = Fingerprint(plainPath)
+	assert.NoError(t, err)
+	assert.Empty(t, fingerprint)
+}
+
+func TestTailCompressedFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, write := range map[string]func(*testing.T, string, string){
+		"archive.log.gz":  writeGzip,
+		"archive.log.zst": writeZstd,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			write(t, path, compressedLines)
+
+			outputChan := make(chan *message.Message, chanSize)
+			tailer := newCompressedTestTailer(path, outputChan)
+			require.NoError(t, tailer.StartFromBeginning())
+			assert.True(t, tailer.IsCompressed())
+
+			msg := <-outputChan
+			assert.Equal(t, "hello world", string(msg.Content))
+			assert.Equal(t, "12", msg.Origin.Offset)
+			msg = <-outputChan
+			assert.Equal(t, "hello again", string(msg.Content))
+			msg = <-outputChan
+			assert.Equal(t, "good bye", string(msg.Content))
+			// offsets are expressed in terms of the uncompressed stream
+			assert.Equal(t, "33", msg.Origin.Offset)
+
+			// the tailer stops on its own once the archive has been read
+			waitFinished(t, tailer)
+			assert.Equal(t, int64(len(compressedLines)), tailer.DecodedOffset())
+			didRotate, err := tailer.DidRotate()
+			assert.NoError(t, err)
+			assert.False(t, didRotate)
+			tailer.Stop()
+		})
+	}
+}
+
+func TestTailCompressedFileFromOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.log.gz")
+	writeGzip(t, path, compressedLines)
+
+	outputChan := make(chan *message.Message, chanSize)
+	tailer := newCompressedTestTailer(path, outputChan)
+	require.NoError(t, tailer.Start(12, io.SeekStart))
+
+	msg := <-outputChan
+	assert.Equal(t, "hello again", string(msg.Content))
+	assert.Equal(t, "24", msg.Origin.Offset)
+	msg = <-outputChan
+	assert.Equal(t, "good bye", string(msg.Content))
+	waitFinished(t, tailer)
+	tailer.Stop()
+
+	// tailing from the end skips the whole uncompressed stream
+	tailer = newCompressedTestTailer(path, outputChan)
+	require.NoError(t, tailer.Start(0, io.SeekEnd))
+	waitFinished(t, tailer)
+	assert.Equal(t, int64(len(compressedLines)), tailer.DecodedOffset())
+	assert.Len(t, outputChan, 0)
+	tailer.Stop()
+}
+
+func TestTailCompressedFileBeingWritten(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.log.gz")
+	writeGzip(t, path, compressedLines)
+	complete, err := os.ReadFile(path)
+	require.NoError(t, err)
+	// only the beginning of the archive has been written
+	require.NoError(t, os.WriteFile(path, complete[:len(complete)-8], 0644))
+
+	outputChan := make(chan *message.Message, chanSize)
+	tailer := newCompressedTestTailer(path, outputChan)
+	require.NoError(t, tailer.StartFromBeginning())
+
+	// the tailer waits for the end of the archive
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, tailer.IsFinished())
+
+	require.NoError(t, os.WriteFile(path, complete, 0644))
+	var contents []string
+	for i := 0; i < 3; i++ {
+		contents = append(contents, string((<-outputChan).Content))
+	}
+	assert.Equal(t, []string{"hello world", "hello again", "good bye"}, contents)
+	waitFinished(t, tailer)
+	tailer.Stop()
+}
@@ -22,6 +22,10 @@ type File struct {
 
 	// Source is the LogSource that led to this File.
 	Source *config.LogSource
+
+	// Fingerprint identifies the content of a compressed file independently of its
+	// path, it is empty for plain files.
+	Fingerprint string
 }
 
 // NewFile returns a new File
@@ -19,6 +19,11 @@ import (
 // - removed and recreated
 // - truncated
 func (t *Tailer) DidRotate() (bool, error) {
+	if t.IsCompressed() {
+		// compressed files are archives, they are not rotated
+		return false, nil
+	}
+
 	f, err := openFile(t.osFile.Name())
 	if err != nil {
 		return false, err
@@ -15,6 +15,11 @@ import "github.com/DataDog/datadog-agent/pkg/util/log"
 // On Windows, log rotation is identified by the file size being smaller
 // than the last offset read.
 func (t *Tailer) DidRotate() (bool, error) {
+	if t.IsCompressed() {
+		// compressed files are archives, they are not rotated
+		return false, nil
+	}
+
 	f, err := openFile(t.fullpath)
 	if err != nil {
 		return false, err
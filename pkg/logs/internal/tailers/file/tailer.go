@@ -60,6 +60,12 @@ type Tailer struct {
 	// is platform-specific.
 	osFile *os.File
 
+	// compression is the compression format of the file, empty for plain files.  Compressed
+	// files are read through decompressor and their offsets are expressed in terms of the
+	// uncompressed stream.
+	compression  string
+	decompressor io.ReadCloser
+
 	// tags are the tags to be attached to each log message, excluding tags provided
 	// by the tag provider.
 	tags []string
@@ -171,12 +177,22 @@ func (t *Tailer) Identifier() string {
 	//
 	// This is the identifier used in the registry, so changing it will invalidate existing
 	// registry entries on upgrade.
+	if t.file.Fingerprint != "" {
+		// the offset of a compressed file follows it when a rotation renames it
+		return fmt.Sprintf("compressed_file:%s", t.file.Fingerprint)
+	}
 	return fmt.Sprintf("file:%s", t.file.Path)
 }
 
 // Start begins the tailer's operation in a dedicated goroutine.
 func (t *Tailer) Start(offset int64, whence int) error {
-	err := t.setup(offset, whence)
+	compression, err := detectCompression(t.file.Path)
+	if err == nil && compression != compressionNone {
+		t.compression = compression
+		err = t.setupCompressed(offset, whence)
+	} else {
+		err = t.setup(offset, whence)
+	}
 	if err != nil {
 		t.file.Source.Status.Error(err)
 		return err
@@ -222,15 +238,26 @@ func (t *Tailer) StopAfterFileRotation() {
 
 // readForever lets the tailer tail the content of a file
 // until it is closed or the tailer is stopped.
+// Compressed files are only read until the end of their uncompressed stream.
 func (t *Tailer) readForever() {
 	defer func() {
-		t.osFile.Close()
+		if t.IsCompressed() {
+			t.closeCompressed()
+		} else {
+			t.osFile.Close()
+		}
 		t.decoder.Stop()
 		log.Info("Closed", t.file.Path, "for tailer key", t.file.GetScanKey(), "read", t.bytesRead, "bytes and", t.decoder.GetLineCount(), "lines")
 	}()
 
 	for {
-		n, err := t.read()
+		var n int
+		var err error
+		if t.IsCompressed() {
+			n, err = t.readCompressed()
+		} else {
+			n, err = t.read()
+		}
 		if err != nil {
 			return
 		}
@@ -276,17 +303,25 @@ func (t *Tailer) forwardMessages() {
 		close(t.done)
 	}()
 	for output := range t.decoder.OutputChan {
+		// the decoded offset keeps tracking the file being read even once it has rotated,
+		// since the archive tailer of a rotated file resumes from it
 		offset := t.decodedOffset.Load() + int64(output.RawDataLen)
+		t.decodedOffset.Store(offset)
 		identifier := t.Identifier()
 		if t.didFileRotate.Load() {
+			// the offset of a rotated file must not be recorded in the registry
 			offset = 0
 			identifier = ""
 		}
-		t.decodedOffset.Store(offset)
 		origin := message.NewOrigin(t.file.Source)
 		origin.Identifier = identifier
 		origin.Offset = strconv.FormatInt(offset, 10)
-		origin.SetTags(append(t.tags, t.tagProvider.GetTags()...))
+		tags := append(t.tags, t.tagProvider.GetTags()...)
+		if len(output.Tags) > 0 {
+			// the tags extracted by the parser from the content of the line
+			tags = append(append(make([]string, 0, len(tags)+len(output.Tags)), tags...), output.Tags...)
+		}
+		origin.SetTags(tags)
 		// Ignore empty lines once the registry offset is updated
 		if len(output.Content) == 0 {
 			continue
@@ -295,13 +330,20 @@ func (t *Tailer) forwardMessages() {
 		// after a file rotation when it is stuck on it.
 		// We don't return directly to keep the same shutdown sequence that in the
 		// normal case.
+		msg := message.NewMessage(output.Content, origin, output.Status, output.IngestionTimestamp)
+		msg.Timestamp = output.EventTime
 		select {
-		case t.outputChan <- message.NewMessage(output.Content, origin, output.Status, output.IngestionTimestamp):
+		case t.outputChan <- msg:
 		case <-t.forwardContext.Done():
 		}
 	}
 }
 
+// DecodedOffset returns the offset in the file at which the latest decoded message ends.
+func (t *Tailer) DecodedOffset() int64 {
+	return t.decodedOffset.Load()
+}
+
 // GetDetectedPattern returns the decoder's detected pattern.
 func (t *Tailer) GetDetectedPattern() *regexp.Regexp {
 	return t.decoder.GetDetectedPattern()
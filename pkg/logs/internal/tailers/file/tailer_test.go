@@ -96,6 +96,33 @@ func (suite *TailerTestSuite) TestStopAfterFileRotationWhenStuck() {
 	}
 }
 
+func (suite *TailerTestSuite) TestOffsetAfterFileRotation() {
+	lines := []string{"hello world\n", "hello again\n"}
+
+	_, err := suite.testFile.WriteString(lines[0])
+	suite.Nil(err)
+	suite.Nil(suite.tailer.StartFromBeginning())
+
+	msg := <-suite.outputChan
+	suite.Equal(len(lines[0]), toInt(msg.Origin.Offset))
+	suite.NotEqual("", msg.Origin.Identifier)
+
+	// rotate the file, the tailer keeps reading the rotated file
+	suite.Nil(os.Rename(suite.testPath, suite.testPath+".1"))
+	suite.tailer.didFileRotate.Store(true)
+	_, err = suite.testFile.WriteString(lines[1])
+	suite.Nil(err)
+
+	// the lines of the rotated file aren't recorded in the registry...
+	msg = <-suite.outputChan
+	suite.Equal("hello again", string(msg.Content))
+	suite.Equal("0", msg.Origin.Offset)
+	suite.Equal("", msg.Origin.Identifier)
+
+	// ...but the decoded offset still tracks the rotated file, for its archive tailer to resume from it
+	suite.Equal(len(lines[0])+len(lines[1]), int(suite.tailer.DecodedOffset()))
+}
+
 func (suite *TailerTestSuite) TestTialerTimeDurationConfig() {
 	// To satisfy the suite level tailer
 	suite.tailer.StartFromBeginning()
@@ -269,6 +296,31 @@ func (suite *TailerTestSuite) TestDirTagWhenTailingFiles() {
 	}, tags)
 }
 
+func (suite *TailerTestSuite) TestParsedTagsWhenTailingKubernetesAuditLog() {
+	auditSource := config.NewLogSource("", &config.LogsConfig{
+		Type: config.FileType,
+		Path: suite.testPath,
+	})
+	auditSource.SetSourceType(config.KubernetesAuditSourceType)
+	sleepDuration := 10 * time.Millisecond
+	suite.tailer = NewTailer(suite.outputChan, NewFile(suite.testPath, auditSource, false), sleepDuration, decoder.NewDecoderFromSource(auditSource))
+	suite.tailer.StartFromBeginning()
+
+	_, err := suite.testFile.WriteString(`{"stage":"ResponseComplete","verb":"delete","user":{"username":"admin"},"responseStatus":{"code":403},"stageTimestamp":"2022-05-01T12:00:00.123456Z"}` + "\n")
+	suite.Nil(err)
+
+	msg := <-suite.outputChan
+	suite.Equal(message.StatusWarning, msg.GetStatus())
+	suite.Equal(time.Date(2022, time.May, 1, 12, 0, 0, 123456000, time.UTC), msg.Timestamp)
+	suite.ElementsMatch([]string{
+		"filename:" + filepath.Base(suite.testFile.Name()),
+		"audit_stage:ResponseComplete",
+		"verb:delete",
+		"user:admin",
+		"response_code:403",
+	}, msg.Origin.Tags())
+}
+
 func (suite *TailerTestSuite) TestBuildTagsFileOnly() {
 	dirTaggedSource := config.NewLogSource("", &config.LogsConfig{
 		Type: config.FileType,
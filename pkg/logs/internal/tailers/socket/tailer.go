@@ -8,12 +8,15 @@ package socket
 import (
 	"io"
 	"net"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/framer"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/noop"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/syslog"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 )
 
@@ -25,23 +28,34 @@ type Tailer struct {
 	outputChan chan *message.Message
 	read       func(*Tailer) ([]byte, error)
 	decoder    *decoder.Decoder
-	stop       chan struct{}
-	done       chan struct{}
+	// tags are the tags identifying the connection, attached to each of its messages
+	tags []string
+	stop chan struct{}
+	done chan struct{}
 }
 
-// NewTailer returns a new Tailer
-func NewTailer(source *config.LogSource, conn net.Conn, outputChan chan *message.Message, read func(*Tailer) ([]byte, error)) *Tailer {
+// NewTailer returns a new Tailer, tags are attached to all the messages read from the connection
+func NewTailer(source *config.LogSource, conn net.Conn, outputChan chan *message.Message, read func(*Tailer) ([]byte, error), tags []string) *Tailer {
 	return &Tailer{
 		source:     source,
 		Conn:       conn,
 		outputChan: outputChan,
 		read:       read,
-		decoder:    decoder.InitializeDecoder(source, noop.New()),
+		decoder:    buildDecoder(source),
+		tags:       tags,
 		stop:       make(chan struct{}, 1),
 		done:       make(chan struct{}, 1),
 	}
 }
 
+// buildDecoder returns a decoder for the format of the messages sent to the source.
+func buildDecoder(source *config.LogSource) *decoder.Decoder {
+	if source.Config.Format == config.SyslogFormat {
+		return decoder.NewDecoderWithFraming(source, syslog.New(), framer.Syslog, nil)
+	}
+	return decoder.InitializeDecoder(source, noop.New())
+}
+
 // Start prepares the tailer to read and decode data from the connection
 func (t *Tailer) Start() {
 	go t.forwardMessages()
@@ -64,9 +78,33 @@ func (t *Tailer) forwardMessages() {
 	}()
 	for output := range t.decoder.OutputChan {
 		if len(output.Content) > 0 {
-			t.outputChan <- message.NewMessageWithSource(output.Content, message.StatusInfo, t.source, output.IngestionTimestamp)
+			t.outputChan <- t.newMessage(output)
+		}
+	}
+}
+
+// newMessage returns a message with the metadata the decoder parsed from the output.
+func (t *Tailer) newMessage(output *decoder.Message) *message.Message {
+	status := output.Status
+	if status == "" {
+		status = message.StatusInfo
+	}
+	msg := message.NewMessageWithSource(output.Content, status, t.source, output.IngestionTimestamp)
+	if output.Timestamp != "" {
+		if timestamp, err := time.Parse(config.DateFormat, output.Timestamp); err == nil {
+			msg.Timestamp = timestamp
 		}
 	}
+	msg.Hostname = output.Hostname
+	if output.Service != "" {
+		msg.Origin.SetService(output.Service)
+	}
+	if len(t.tags)+len(output.Tags) > 0 {
+		tags := make([]string, 0, len(t.tags)+len(output.Tags))
+		tags = append(tags, t.tags...)
+		msg.Origin.SetTags(append(tags, output.Tags...))
+	}
+	return msg
 }
 
 // readForever reads the data from conn.
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -19,7 +20,7 @@ import (
 func TestReadAndForwardShouldSucceedWithSuccessfulRead(t *testing.T) {
 	msgChan := make(chan *message.Message)
 	r, w := net.Pipe()
-	tailer := NewTailer(config.NewLogSource("", &config.LogsConfig{}), r, msgChan, read)
+	tailer := NewTailer(config.NewLogSource("", &config.LogsConfig{}), r, msgChan, read, nil)
 	tailer.Start()
 
 	var msg *message.Message
@@ -39,11 +40,53 @@ func TestReadAndForwardShouldSucceedWithSuccessfulRead(t *testing.T) {
 	tailer.Stop()
 }
 
+func TestReadAndForwardSyslogMessages(t *testing.T) {
+	msgChan := make(chan *message.Message)
+	r, w := net.Pipe()
+	source := config.NewLogSource("", &config.LogsConfig{Format: config.SyslogFormat})
+	tailer := NewTailer(source, r, msgChan, read, nil)
+	tailer.Start()
+
+	// octet-counted messages can contain newlines
+	go w.Write([]byte("70 <165>1 2003-10-11T22:14:15.003Z host app 12 - [ex@1 a=\"b\"] hello\nworld"))
+	msg := <-msgChan
+	assert.Equal(t, "hello\nworld", string(msg.Content))
+	assert.Equal(t, message.StatusNotice, msg.GetStatus())
+	assert.Equal(t, time.Date(2003, time.October, 11, 22, 14, 15, 3000000, time.UTC), msg.Timestamp)
+	assert.Equal(t, "host", msg.GetHostname())
+	assert.Equal(t, "app", msg.Origin.Service())
+	assert.Equal(t, []string{"syslog.facility:local4", "syslog.procid:12", "syslog.ex@1.a:b"}, msg.Origin.Tags())
+
+	// newline-terminated messages are supported too
+	go w.Write([]byte("<11>Oct 11 22:14:15 host su: failed\n"))
+	msg = <-msgChan
+	assert.Equal(t, "failed", string(msg.Content))
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+	assert.Equal(t, "su", msg.Origin.Service())
+
+	tailer.Stop()
+}
+
+func TestReadAndForwardShouldAttachConnectionTags(t *testing.T) {
+	msgChan := make(chan *message.Message)
+	r, w := net.Pipe()
+	source := config.NewLogSource("", &config.LogsConfig{Format: config.SyslogFormat})
+	tailer := NewTailer(source, r, msgChan, read, []string{"peer_cn:foo"})
+	tailer.Start()
+
+	go w.Write([]byte("<14>1 - - - - - - hello\n"))
+	msg := <-msgChan
+	assert.Equal(t, "hello", string(msg.Content))
+	assert.Equal(t, []string{"peer_cn:foo", "syslog.facility:user"}, msg.Origin.Tags())
+
+	tailer.Stop()
+}
+
 func TestReadShouldFailWithError(t *testing.T) {
 	msgChan := make(chan *message.Message)
 	r, w := net.Pipe()
 	read := func(*Tailer) ([]byte, error) { return nil, errors.New("") }
-	tailer := NewTailer(config.NewLogSource("", &config.LogsConfig{}), r, msgChan, read)
+	tailer := NewTailer(config.NewLogSource("", &config.LogsConfig{}), r, msgChan, read, nil)
 	tailer.Start()
 
 	w.Write([]byte("foo\n"))
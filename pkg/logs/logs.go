@@ -22,6 +22,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 	adScheduler "github.com/DataDog/datadog-agent/pkg/logs/schedulers/ad"
 	ccaScheduler "github.com/DataDog/datadog-agent/pkg/logs/schedulers/cca"
+	nodeScheduler "github.com/DataDog/datadog-agent/pkg/logs/schedulers/node"
 	"github.com/DataDog/datadog-agent/pkg/logs/service"
 	"github.com/DataDog/datadog-agent/pkg/logs/status"
 )
@@ -133,6 +134,7 @@ func start(ac *autodiscovery.AutoConfig, serverless bool) (*Agent, error) {
 		}
 		agent.AddScheduler(adScheduler.New(ac))
 		agent.AddScheduler(ccaScheduler.New(ac))
+		agent.AddScheduler(nodeScheduler.New())
 	}
 
 	return agent, nil
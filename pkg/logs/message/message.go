@@ -32,11 +32,20 @@ type Message struct {
 	status             string
 	IngestionTimestamp int64
 	// Optional. Must be UTC. If not provided, time.Now().UTC() will be used
-	// Used in the Serverless Agent
+	// Used in the Serverless Agent and by the sources parsing the timestamp of their logs
 	Timestamp time.Time
+	// Optional. If not provided, the hostname of the agent will be used
+	// Used by the sources receiving logs from other hosts
+	Hostname string
 	// Optional.
 	// Used in the Serverless Agent
 	Lambda *Lambda
+	// The content once processed, before being encoded for the intake
+	// Used by the destinations encoding the messages for other backends than Datadog
+	ProcessedContent []byte
+	// Optional. The attributes extracted from the content by the parsing rules
+	// Sent along with the content, nested attributes being maps
+	Attributes map[string]interface{}
 }
 
 // Lambda is a struct storing information about the Lambda function and function execution.
@@ -94,6 +103,9 @@ func (m *Message) GetHostname() string {
 	if m.Lambda != nil {
 		return m.Lambda.ARN
 	}
+	if m.Hostname != "" {
+		return m.Hostname
+	}
 	hostname, err := util.GetHostname(context.TODO())
 	if err != nil {
 		// this scenario is not likely to happen since
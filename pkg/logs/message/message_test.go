@@ -38,3 +38,8 @@ func TestGetHostname(t *testing.T) {
 	message := Message{Content: []byte("hello")}
 	assert.Equal(t, "testHostnameFromEnvVar", message.GetHostname())
 }
+
+func TestGetHostnameFromMessage(t *testing.T) {
+	message := Message{Content: []byte("hello"), Hostname: "remoteHost"}
+	assert.Equal(t, "remoteHost", message.GetHostname())
+}
@@ -105,3 +105,17 @@ func TestDefaultServiceValueIsServiceFromConfig(t *testing.T) {
 	origin.SetService("bar")
 	assert.Equal(t, "bar", origin.Service())
 }
+
+func TestAddTagsDoesNotModifySharedTags(t *testing.T) {
+	cfg := &config.LogsConfig{}
+	source := config.NewLogSource("", cfg)
+	shared := make([]string, 1, 4)
+	shared[0] = "foo:bar"
+	origin := NewOrigin(source)
+	origin.SetTags(shared)
+	origin.AddTags("baz:qux")
+	assert.Equal(t, []string{"foo:bar", "baz:qux"}, origin.Tags())
+	assert.Equal(t, []string{"foo:bar"}, shared)
+	// the backing array of the shared tags must be left untouched
+	assert.Equal(t, "", shared[:2][1])
+}
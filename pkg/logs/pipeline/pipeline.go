@@ -8,6 +8,8 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strconv"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
@@ -17,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/sender"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // Pipeline processes and sends messages to the backend
@@ -44,7 +47,7 @@ func NewPipeline(outputChan chan *message.Payload,
 	var logsSender *sender.Sender
 
 	strategy := getStrategy(strategyInput, senderInput, endpoints, serverless, pipelineID)
-	logsSender = sender.NewSender(senderInput, outputChan, mainDestinations, config.DestinationPayloadChanSize)
+	logsSender = sender.NewSenderWithSpool(senderInput, outputChan, mainDestinations, config.DestinationPayloadChanSize, getSpool(serverless, pipelineID))
 
 	var encoder processor.Encoder
 	if serverless {
@@ -58,7 +61,7 @@ func NewPipeline(outputChan chan *message.Payload,
 	}
 
 	inputChan := make(chan *message.Message, config.ChanSize)
-	processor := processor.New(inputChan, strategyInput, processingRules, encoder, diagnosticMessageReceiver)
+	processor := processor.New(inputChan, strategyInput, processingRules, encoder, diagnosticMessageReceiver, hasOtherBackends(endpoints))
 
 	return &Pipeline{
 		InputChan: inputChan,
@@ -91,24 +94,55 @@ func getDestinations(endpoints *config.Endpoints, destinationsContext *client.De
 	reliable := []client.Destination{}
 	additionals := []client.Destination{}
 
+	for i, endpoint := range endpoints.GetReliableEndpoints() {
+		telemetryName := fmt.Sprintf("logs_%d_reliable_%d", pipelineID, i)
+		reliable = append(reliable, getDestination(endpoint, endpoints, destinationsContext, true, telemetryName))
+	}
+	for i, endpoint := range endpoints.GetUnReliableEndpoints() {
+		telemetryName := fmt.Sprintf("logs_%d_unreliable_%d", pipelineID, i)
+		additionals = append(additionals, getDestination(endpoint, endpoints, destinationsContext, false, telemetryName))
+	}
+	return client.NewDestinations(reliable, additionals)
+}
+
+// getDestination returns the destination of the endpoint, the endpoints of the other
+// backends than Datadog are always reached over HTTP, by batches in TCP mode too.
+func getDestination(endpoint config.Endpoint, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext, reliable bool, telemetryName string) client.Destination {
 	if endpoints.UseHTTP {
-		for i, endpoint := range endpoints.GetReliableEndpoints() {
-			telemetryName := fmt.Sprintf("logs_%d_reliable_%d", pipelineID, i)
-			reliable = append(reliable, http.NewDestination(endpoint, http.JSONContentType, destinationsContext, endpoints.BatchMaxConcurrentSend, true, telemetryName))
-		}
-		for i, endpoint := range endpoints.GetUnReliableEndpoints() {
-			telemetryName := fmt.Sprintf("logs_%d_unreliable_%d", pipelineID, i)
-			additionals = append(additionals, http.NewDestination(endpoint, http.JSONContentType, destinationsContext, endpoints.BatchMaxConcurrentSend, false, telemetryName))
+		return http.NewDestination(endpoint, http.JSONContentType, destinationsContext, endpoints.BatchMaxConcurrentSend, reliable, telemetryName)
+	}
+	if endpoint.Type != config.DatadogEndpointType {
+		destination := http.NewDestination(endpoint, http.JSONContentType, destinationsContext, endpoints.BatchMaxConcurrentSend, reliable, telemetryName)
+		return http.NewBatchingDestination(destination, endpoints.BatchWait, endpoints.BatchMaxSize, endpoints.BatchMaxContentSize)
+	}
+	return tcp.NewDestination(endpoint, endpoints.UseProto, destinationsContext, reliable)
+}
+
+// hasOtherBackends returns whether some endpoints send the logs to other backends than Datadog,
+// whose destinations encode the processed content of the messages.
+func hasOtherBackends(endpoints *config.Endpoints) bool {
+	for _, endpoint := range endpoints.Endpoints {
+		if endpoint.Type != config.DatadogEndpointType {
+			return true
 		}
-		return client.NewDestinations(reliable, additionals)
 	}
-	for _, endpoint := range endpoints.GetReliableEndpoints() {
-		reliable = append(reliable, tcp.NewDestination(endpoint, endpoints.UseProto, destinationsContext, true))
+	return false
+}
+
+// getSpool returns the disk spool of the pipeline, the maximum size of the spool is shared
+// by the pipelines.  It returns nil when the spool is disabled or cannot be used.
+func getSpool(serverless bool, pipelineID int) *sender.Spool {
+	maxSize := config.SpoolMaxSize()
+	if serverless || maxSize <= 0 {
+		return nil
 	}
-	for _, endpoint := range endpoints.GetUnReliableEndpoints() {
-		additionals = append(additionals, tcp.NewDestination(endpoint, endpoints.UseProto, destinationsContext, false))
+	path := filepath.Join(config.SpoolPath(), strconv.Itoa(pipelineID))
+	spool, err := sender.NewSpool(path, maxSize/config.NumberOfPipelines)
+	if err != nil {
+		log.Errorf("Could not use the logs spool %s, logs will not be stored on disk: %v", path, err)
+		return nil
 	}
-	return client.NewDestinations(reliable, additionals)
+	return spool
 }
 
 func getStrategy(inputChan chan *message.Message, outputChan chan *message.Payload, endpoints *config.Endpoints, serverless bool, pipelineID int) sender.Strategy {
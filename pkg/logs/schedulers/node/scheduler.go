@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package node implements a scheduler for the logs of the node components, which
+// are not collected through the configuration of an integration.
+package node
+
+import (
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+	logsConfig "github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/schedulers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	kubernetesAuditSourceName  = "kubernetes_audit"
+	containerdEventsSourceName = "containerd_events"
+)
+
+// Scheduler creates the sources of the Kubernetes API server audit log and of the
+// containerd events, when enabled by `logs_config.kubernetes_audit.enabled` and
+// `logs_config.containerd_events.enabled`.
+type Scheduler struct {
+	// containerdPresent returns whether containerd runs on the node, it is a field
+	// for testing purposes
+	containerdPresent func() bool
+}
+
+var _ schedulers.Scheduler = &Scheduler{}
+
+// New creates a new scheduler.
+func New() schedulers.Scheduler {
+	return &Scheduler{
+		containerdPresent: func() bool {
+			return coreConfig.IsFeaturePresent(coreConfig.Containerd)
+		},
+	}
+}
+
+// Start implements schedulers.Scheduler#Start.
+func (s *Scheduler) Start(sourceMgr schedulers.SourceManager) {
+	if coreConfig.Datadog.GetBool("logs_config.kubernetes_audit.enabled") {
+		log.Debug("Adding the Kubernetes audit log source to the Logs Agent")
+		sourceMgr.AddSource(logsConfig.NewLogSource(kubernetesAuditSourceName, &logsConfig.LogsConfig{
+			Type: logsConfig.KubernetesAuditType,
+			Path: coreConfig.Datadog.GetString("logs_config.kubernetes_audit.path"),
+		}))
+	}
+
+	if coreConfig.Datadog.GetBool("logs_config.containerd_events.enabled") {
+		if !s.containerdPresent() {
+			log.Warn("The collection of the containerd events is enabled but containerd was not detected")
+			return
+		}
+		log.Debug("Adding the containerd events source to the Logs Agent")
+		sourceMgr.AddSource(logsConfig.NewLogSource(containerdEventsSourceName, &logsConfig.LogsConfig{
+			Type:    logsConfig.ContainerdEventsType,
+			Query:   coreConfig.Datadog.GetString("logs_config.containerd_events.filter"),
+			Service: "containerd",
+			Source:  "containerd",
+		}))
+	}
+}
+
+// Stop implements schedulers.Scheduler#Stop.
+func (s *Scheduler) Stop() {}
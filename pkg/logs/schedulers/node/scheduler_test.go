@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+	logsConfig "github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/schedulers"
+)
+
+func setup(containerdPresent bool) (*Scheduler, *schedulers.MockSourceManager) {
+	scheduler := New().(*Scheduler)
+	scheduler.containerdPresent = func() bool { return containerdPresent }
+	return scheduler, &schedulers.MockSourceManager{}
+}
+
+func TestNothingWhenNoConfig(t *testing.T) {
+	scheduler, spy := setup(true)
+	coreConfig.Mock()
+
+	scheduler.Start(spy)
+
+	require.Equal(t, 0, len(spy.Events))
+}
+
+func TestKubernetesAuditSource(t *testing.T) {
+	scheduler, spy := setup(false)
+	config := coreConfig.Mock()
+	config.Set("logs_config.kubernetes_audit.enabled", true)
+	config.Set("logs_config.kubernetes_audit.path", "/var/log/kube-apiserver/audit.log")
+
+	scheduler.Start(spy)
+
+	require.Equal(t, 1, len(spy.Events))
+	source := spy.Events[0].Source
+	assert.Equal(t, "kubernetes_audit", source.Name)
+	assert.Equal(t, logsConfig.KubernetesAuditType, source.Config.Type)
+	assert.Equal(t, "/var/log/kube-apiserver/audit.log", source.Config.Path)
+}
+
+func TestContainerdEventsSource(t *testing.T) {
+	scheduler, spy := setup(true)
+	config := coreConfig.Mock()
+	config.Set("logs_config.containerd_events.enabled", true)
+	config.Set("logs_config.containerd_events.filter", `topic~="/tasks/"`)
+
+	scheduler.Start(spy)
+
+	require.Equal(t, 1, len(spy.Events))
+	source := spy.Events[0].Source
+	assert.Equal(t, "containerd_events", source.Name)
+	assert.Equal(t, logsConfig.ContainerdEventsType, source.Config.Type)
+	assert.Equal(t, `topic~="/tasks/"`, source.Config.Query)
+	assert.Equal(t, "containerd", source.Config.Source)
+}
+
+func TestNoContainerdEventsSourceWithoutContainerd(t *testing.T) {
+	scheduler, spy := setup(false)
+	config := coreConfig.Mock()
+	config.Set("logs_config.containerd_events.enabled", true)
+
+	scheduler.Start(spy)
+
+	require.Equal(t, 0, len(spy.Events))
+}
@@ -96,6 +96,13 @@ func (d *DestinationSender) Send(payload *message.Payload) bool {
 	return false
 }
 
+// isRetrying returns whether the destination is retrying to send a payload.
+func (d *DestinationSender) isRetrying() bool {
+	d.retryLock.Lock()
+	defer d.retryLock.Unlock()
+	return d.lastRetryState
+}
+
 // NonBlockingSend tries to send the payload and fails silently if the input is full.
 // returns false if the buffer is full - true if successful.
 func (d *DestinationSender) NonBlockingSend(payload *message.Payload) bool {
@@ -7,11 +7,13 @@ package sender
 
 import (
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 var (
@@ -20,6 +22,13 @@ var (
 	tlmSendWaitTime    = telemetry.NewCounter("logs_sender", "send_wait", []string{}, "Time spent waiting for all sends to finish")
 )
 
+var (
+	// spoolReplayInterval is the interval at which the sender tries to send the payloads of its spool.
+	spoolReplayInterval = 100 * time.Millisecond
+	// spoolReplayWindow is the maximum number of payloads of the spool being sent at the same time.
+	spoolReplayWindow = 10
+)
+
 // Sender sends logs to different destinations. Destinations can be either
 // reliable or unreliable. The sender ensures that logs are sent to at least
 // one reliable destination and will block the pipeline if they are in an
@@ -27,22 +36,45 @@ var (
 // one reliable destination is also sending logs. However they do not update
 // the auditor or block the pipeline if they fail. There will always be at
 // least 1 reliable destination (the main destination).
+//
+// When a spool is set, the payloads that cannot be sent to any reliable destination are
+// stored in the spool instead of blocking the pipeline, and are considered as sent.  They
+// are sent to the reliable destinations once they are available again, and removed from
+// the spool once a destination sent them.  While the spool is not empty, the new payloads
+// are stored after the spooled ones, so that the logs of a source are sent in order.  The
+// spool is replayed in order, several payloads at a time, so that it drains while the logs
+// keep flowing.
 type Sender struct {
 	inputChan    chan *message.Payload
 	outputChan   chan *message.Payload
 	destinations *client.Destinations
 	done         chan struct{}
 	bufferSize   int
+	spool        *Spool
+
+	// the oldest payloads of the spool being replayed, in order, which are removed from the
+	// spool once a reliable destination acknowledged them by forwarding them to its output
+	replaying     []*message.Payload
+	acknowledged  int
+	replayingLock sync.Mutex
+	replayed      chan struct{}
 }
 
 // NewSender returns a new sender.
 func NewSender(inputChan chan *message.Payload, outputChan chan *message.Payload, destinations *client.Destinations, bufferSize int) *Sender {
+	return NewSenderWithSpool(inputChan, outputChan, destinations, bufferSize, nil)
+}
+
+// NewSenderWithSpool returns a new sender storing the payloads it cannot send in the spool.
+func NewSenderWithSpool(inputChan chan *message.Payload, outputChan chan *message.Payload, destinations *client.Destinations, bufferSize int, spool *Spool) *Sender {
 	return &Sender{
 		inputChan:    inputChan,
 		outputChan:   outputChan,
 		destinations: destinations,
 		done:         make(chan struct{}),
 		bufferSize:   bufferSize,
+		spool:        spool,
+		replayed:     make(chan struct{}, 1),
 	}
 }
 
@@ -59,62 +91,190 @@ func (s *Sender) Stop() {
 }
 
 func (s *Sender) run() {
-	reliableDestinations := buildDestinationSenders(s.destinations.Reliable, s.outputChan, s.bufferSize)
+	reliableOutput := s.outputChan
+	var acknowledged chan struct{}
+	if s.spool != nil {
+		reliableOutput = make(chan *message.Payload, s.bufferSize)
+		acknowledged = make(chan struct{})
+		go s.acknowledge(reliableOutput, acknowledged)
+	}
+	reliableDestinations := buildDestinationSenders(s.destinations.Reliable, reliableOutput, s.bufferSize)
 
 	sink := additionalDestinationsSink(s.bufferSize)
 	unreliableDestinations := buildDestinationSenders(s.destinations.Unreliable, sink, s.bufferSize)
 
-	for payload := range s.inputChan {
-		var startInUse = time.Now()
+	var replayTicker <-chan time.Time
+	if s.spool != nil {
+		ticker := time.NewTicker(spoolReplayInterval)
+		defer ticker.Stop()
+		replayTicker = ticker.C
+	}
 
-		sent := false
-		for !sent {
-			for _, destSender := range reliableDestinations {
-				if destSender.Send(payload) {
-					sent = true
-				}
+loop:
+	for {
+		select {
+		case payload, isOpen := <-s.inputChan:
+			if !isOpen {
+				break loop
 			}
+			s.send(payload, reliableDestinations, unreliableDestinations)
+		case <-replayTicker:
+			s.replay(reliableDestinations)
+		case <-s.replayed:
+			s.replay(reliableDestinations)
+		}
+	}
 
-			if !sent {
-				// Throttle the poll loop while waiting for a send to succeed
-				// This will only happen when all reliable destinations
-				// are blocked so logs have no where to go.
-				time.Sleep(100 * time.Millisecond)
-			}
+	// Cleanup the destinations
+	for _, destSender := range reliableDestinations {
+		destSender.Stop()
+	}
+	if s.spool != nil {
+		// remove the replayed payloads acknowledged while stopping the destinations
+		close(reliableOutput)
+		<-acknowledged
+		s.popReplayed()
+	}
+	for _, destSender := range unreliableDestinations {
+		destSender.Stop()
+	}
+	close(sink)
+	s.done <- struct{}{}
+}
+
+func (s *Sender) send(payload *message.Payload, reliableDestinations []*DestinationSender, unreliableDestinations []*DestinationSender) {
+	var startInUse = time.Now()
+
+	stored := false
+	if s.spool != nil && s.spool.Len() > 0 {
+		// the new payload is sent after the spooled ones, the replay progressing along
+		// with the new payloads
+		s.replay(reliableDestinations)
+		if s.spool.Len() > 0 {
+			stored = s.store(payload)
 		}
+	}
 
-		for i, destSender := range reliableDestinations {
-			// If an endpoint is stuck in the previous step, try to buffer the payloads if we have room to mitigate
-			// loss on intermittent failures.
-			if !destSender.lastSendSucceeded {
-				if !destSender.NonBlockingSend(payload) {
-					tlmPayloadsDropped.Inc("true", strconv.Itoa(i))
-					tlmMessagesDropped.Add(float64(len(payload.Messages)), "true", strconv.Itoa(i))
-				}
+	sent := stored
+	for !sent {
+		for _, destSender := range reliableDestinations {
+			if destSender.Send(payload) {
+				sent = true
 			}
 		}
 
-		// Attempt to send to unreliable destinations
-		for i, destSender := range unreliableDestinations {
+		if !sent && s.spool != nil {
+			stored = s.store(payload)
+			sent = stored
+		}
+
+		if !sent {
+			// Throttle the poll loop while waiting for a send to succeed
+			// This will only happen when all reliable destinations
+			// are blocked so logs have no where to go.
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	for i, destSender := range reliableDestinations {
+		// If an endpoint is stuck in the previous step, try to buffer the payloads if we have room to mitigate
+		// loss on intermittent failures.
+		if !stored && !destSender.lastSendSucceeded {
 			if !destSender.NonBlockingSend(payload) {
-				tlmPayloadsDropped.Inc("false", strconv.Itoa(i))
-				tlmMessagesDropped.Add(float64(len(payload.Messages)), "false", strconv.Itoa(i))
+				tlmPayloadsDropped.Inc("true", strconv.Itoa(i))
+				tlmMessagesDropped.Add(float64(len(payload.Messages)), "true", strconv.Itoa(i))
 			}
 		}
+	}
 
-		inUse := float64(time.Since(startInUse) / time.Millisecond)
-		tlmSendWaitTime.Add(inUse)
+	// Attempt to send to unreliable destinations
+	for i, destSender := range unreliableDestinations {
+		if !destSender.NonBlockingSend(payload) {
+			tlmPayloadsDropped.Inc("false", strconv.Itoa(i))
+			tlmMessagesDropped.Add(float64(len(payload.Messages)), "false", strconv.Itoa(i))
+		}
 	}
 
-	// Cleanup the destinations
-	for _, destSender := range reliableDestinations {
-		destSender.Stop()
+	inUse := float64(time.Since(startInUse) / time.Millisecond)
+	tlmSendWaitTime.Add(inUse)
+}
+
+// store stores the payload in the spool and forwards it to the output channel, as it will
+// not be lost anymore.  It returns false if the payload could not be stored.
+func (s *Sender) store(payload *message.Payload) bool {
+	if err := s.spool.Write(payload); err != nil {
+		log.Warnf("Could not store a payload of logs in the spool: %v", err)
+		return false
 	}
-	for _, destSender := range unreliableDestinations {
-		destSender.Stop()
+	s.outputChan <- payload
+	return true
+}
+
+// replay sends the oldest payloads of the spool, in order, to the reliable destinations
+// which are not retrying.  At most spoolReplayWindow payloads are sent at the same time:
+// a payload is only removed from the spool once a destination acknowledged it, the next
+// ones being sent afterwards.
+func (s *Sender) replay(reliableDestinations []*DestinationSender) {
+	s.replayingLock.Lock()
+	defer s.replayingLock.Unlock()
+	s.popReplayed()
+
+	for len(s.replaying) < spoolReplayWindow {
+		payload := s.spool.PeekAt(len(s.replaying))
+		if payload == nil {
+			return
+		}
+		// the payload is set as being replayed before sending it, as it can be acknowledged
+		// as soon as it is sent, the acknowledgement waiting for the lock to be released
+		s.replaying = append(s.replaying, payload)
+		sent := false
+		for _, destSender := range reliableDestinations {
+			if !destSender.isRetrying() && destSender.NonBlockingSend(payload) {
+				sent = true
+			}
+		}
+		if !sent {
+			s.replaying = s.replaying[:len(s.replaying)-1]
+			return
+		}
 	}
-	close(sink)
-	s.done <- struct{}{}
+}
+
+// popReplayed removes the acknowledged payloads from the spool, unless they have already
+// been removed to make room for new payloads, and forgets the payloads being replayed which
+// have been removed, so that the payloads being replayed are the oldest ones of the spool.
+// It must be called with the replaying lock held, or once the destinations are stopped.
+func (s *Sender) popReplayed() {
+	for _, payload := range s.replaying[:s.acknowledged] {
+		if s.spool.Peek() == payload {
+			s.spool.Pop()
+		}
+	}
+	s.replaying = s.replaying[s.acknowledged:]
+	s.acknowledged = 0
+
+	oldest := s.spool.Peek()
+	for len(s.replaying) > 0 && s.replaying[0] != oldest {
+		s.replaying = s.replaying[1:]
+	}
+}
+
+// acknowledge forwards the payloads sent by the reliable destinations to the output
+// channel, and notifies the sender when the payloads being replayed have been sent.
+func (s *Sender) acknowledge(reliableOutput chan *message.Payload, done chan struct{}) {
+	for payload := range reliableOutput {
+		s.replayingLock.Lock()
+		if s.acknowledged < len(s.replaying) && payload == s.replaying[s.acknowledged] {
+			s.acknowledged++
+			select {
+			case s.replayed <- struct{}{}:
+			default:
+			}
+		}
+		s.replayingLock.Unlock()
+		s.outputChan <- payload
+	}
+	close(done)
 }
 
 // Drains the output channel from destinations that don't update the auditor.
@@ -0,0 +1,298 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	spoolFileExtension    = ".payload"
+	spoolTmpFileExtension = ".tmp"
+)
+
+// Spool stores on disk the payloads that cannot be sent to the reliable destinations, so
+// that they can be sent once the destinations are available again.  The payloads are
+// stored one per file, named after their sequence number, and are replayed in the order
+// they were stored: a source always sends its logs through the same pipeline, so that
+// its logs are replayed in order.  When the spool is full, the oldest payloads are
+// removed to make room for the new ones.
+//
+// A Spool is not thread safe, it is only used by the sender of its pipeline.
+type Spool struct {
+	path    string
+	maxSize int64
+
+	files    []spoolFile // oldest first
+	size     int64
+	sequence uint64
+
+	// peeked are the oldest payloads, read and kept in memory until they are sent
+	peeked []*message.Payload
+	// sources are the sources of the replayed messages, by name
+	sources map[string]*config.LogSource
+}
+
+type spoolFile struct {
+	name string
+	size int64
+}
+
+// spooledPayload is the representation of a payload on disk.
+type spooledPayload struct {
+	Encoded       []byte
+	Encoding      string
+	UnencodedSize int
+	Messages      []spooledMessage
+}
+
+// spooledMessage is the representation of a message on disk, with the metadata used by
+// the destinations to encode it.  The offsets of the messages are not stored as they
+// have already been committed when the payload was stored.
+type spooledMessage struct {
+	Content            []byte
+	ProcessedContent   []byte
+	Status             string
+	IngestionTimestamp int64
+	Timestamp          time.Time
+	Hostname           string
+	SourceName         string
+	Source             string
+	Service            string
+	Tags               []string
+	Attributes         map[string]interface{}
+}
+
+func init() {
+	// the nested attributes are stored as interfaces
+	gob.Register(map[string]interface{}{})
+}
+
+// NewSpool returns a spool storing at most maxSize bytes of payloads in the directory,
+// which is created if needed.  The payloads stored by a previous run are kept.
+func NewSpool(path string, maxSize int64) (*Spool, error) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+	s := &Spool{
+		path:    path,
+		maxSize: maxSize,
+		sources: make(map[string]*config.LogSource),
+	}
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, spoolTmpFileExtension) {
+			// the agent stopped while writing the payload
+			os.Remove(filepath.Join(path, name))
+			continue
+		}
+		if entry.IsDir() || !strings.HasSuffix(name, spoolFileExtension) {
+			continue
+		}
+		sequence, err := strconv.ParseUint(strings.TrimSuffix(name, spoolFileExtension), 10, 64)
+		if err != nil {
+			continue
+		}
+		if sequence >= s.sequence {
+			s.sequence = sequence + 1
+		}
+		s.files = append(s.files, spoolFile{name: filepath.Join(path, name), size: entry.Size()})
+		s.size += entry.Size()
+	}
+	// the names are padded with zeros so that they sort in sequence order
+	sort.Slice(s.files, func(i, j int) bool { return s.files[i].name < s.files[j].name })
+	s.updateMetrics(int64(len(s.files)), s.size)
+	if len(s.files) > 0 {
+		log.Infof("Found %d payloads of logs to send in %s", len(s.files), path)
+	}
+	return s, nil
+}
+
+// Len returns the number of payloads in the spool.
+func (s *Spool) Len() int {
+	return len(s.files)
+}
+
+// Size returns the size in bytes of the payloads in the spool.
+func (s *Spool) Size() int64 {
+	return s.size
+}
+
+// Write stores the payload at the end of the spool, removing the oldest payloads if
+// there is not enough room for it.
+func (s *Spool) Write(payload *message.Payload) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(toSpooledPayload(payload)); err != nil {
+		return err
+	}
+	size := int64(buf.Len())
+	if size > s.maxSize {
+		return fmt.Errorf("the payload is too big for the spool: %d bytes, maximum: %d", size, s.maxSize)
+	}
+	for len(s.files) > 0 && s.size+size > s.maxSize {
+		log.Warnf("The logs spool %s is full, removing its oldest payload", s.path)
+		s.remove(0)
+		metrics.SpoolPayloadsDropped.Add(1)
+		metrics.TlmSpoolPayloadsDropped.Inc()
+	}
+
+	name := filepath.Join(s.path, fmt.Sprintf("%020d%s", s.sequence, spoolFileExtension))
+	// write to a temporary file first so that a partially written payload is never replayed
+	tmp := name + spoolTmpFileExtension
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	s.sequence++
+	s.files = append(s.files, spoolFile{name: name, size: size})
+	s.size += size
+	s.updateMetrics(1, size)
+	return nil
+}
+
+// Peek returns the oldest payload of the spool, or nil if it is empty.  The payloads
+// that cannot be read are removed.
+func (s *Spool) Peek() *message.Payload {
+	return s.PeekAt(0)
+}
+
+// PeekAt returns the payload at the index of the spool, from the oldest one, or nil if the
+// spool does not hold that many payloads.  The payloads that cannot be read are removed.
+func (s *Spool) PeekAt(index int) *message.Payload {
+	for len(s.peeked) <= index && len(s.peeked) < len(s.files) {
+		file := s.files[len(s.peeked)]
+		payload, err := s.read(file.name)
+		if err != nil {
+			log.Warnf("Removing the payload %s of the logs spool: %v", file.name, err)
+			s.remove(len(s.peeked))
+			continue
+		}
+		s.peeked = append(s.peeked, payload)
+	}
+	if index < len(s.peeked) {
+		return s.peeked[index]
+	}
+	return nil
+}
+
+// Pop removes the oldest payload of the spool.
+func (s *Spool) Pop() {
+	if len(s.files) > 0 {
+		s.remove(0)
+	}
+}
+
+// remove deletes the payload at the index of the spool.
+func (s *Spool) remove(index int) {
+	file := s.files[index]
+	if err := os.Remove(file.name); err != nil && !os.IsNotExist(err) {
+		log.Warnf("Could not remove %s: %v", file.name, err)
+	}
+	s.files = append(s.files[:index], s.files[index+1:]...)
+	s.size -= file.size
+	if index < len(s.peeked) {
+		s.peeked = append(s.peeked[:index], s.peeked[index+1:]...)
+	}
+	s.updateMetrics(-1, -file.size)
+}
+
+func (s *Spool) read(name string) (*message.Payload, error) {
+	content, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var spooled spooledPayload
+	if err := gob.NewDecoder(bytes.NewReader(content)).Decode(&spooled); err != nil {
+		return nil, err
+	}
+	return s.toPayload(spooled), nil
+}
+
+func (s *Spool) updateMetrics(payloads int64, size int64) {
+	metrics.SpoolPayloads.Add(payloads)
+	metrics.TlmSpoolPayloads.Add(float64(payloads))
+	metrics.SpoolBytes.Add(size)
+	metrics.TlmSpoolBytes.Add(float64(size))
+}
+
+func toSpooledPayload(payload *message.Payload) spooledPayload {
+	spooled := spooledPayload{
+		Encoded:       payload.Encoded,
+		Encoding:      payload.Encoding,
+		UnencodedSize: payload.UnencodedSize,
+		Messages:      make([]spooledMessage, 0, len(payload.Messages)),
+	}
+	for _, msg := range payload.Messages {
+		m := spooledMessage{
+			Content:            msg.Content,
+			ProcessedContent:   msg.ProcessedContent,
+			Status:             msg.GetStatus(),
+			IngestionTimestamp: msg.IngestionTimestamp,
+			Timestamp:          msg.Timestamp,
+			Hostname:           msg.Hostname,
+			Attributes:         msg.Attributes,
+		}
+		if msg.Origin != nil && msg.Origin.LogSource != nil {
+			m.SourceName = msg.Origin.LogSource.Name
+			m.Source = msg.Origin.Source()
+			m.Service = msg.Origin.Service()
+			m.Tags = msg.Origin.Tags()
+		}
+		spooled.Messages = append(spooled.Messages, m)
+	}
+	return spooled
+}
+
+// toPayload rebuilds a payload from its representation on disk, its messages have no
+// identifier so that they are ignored by the auditor.
+func (s *Spool) toPayload(spooled spooledPayload) *message.Payload {
+	payload := &message.Payload{
+		Encoded:       spooled.Encoded,
+		Encoding:      spooled.Encoding,
+		UnencodedSize: spooled.UnencodedSize,
+		Messages:      make([]*message.Message, 0, len(spooled.Messages)),
+	}
+	for _, m := range spooled.Messages {
+		source, exists := s.sources[m.SourceName]
+		if !exists {
+			source = config.NewLogSource(m.SourceName, &config.LogsConfig{})
+			s.sources[m.SourceName] = source
+		}
+		origin := message.NewOrigin(source)
+		origin.SetSource(m.Source)
+		origin.SetService(m.Service)
+		origin.SetTags(m.Tags)
+		msg := message.NewMessage(m.Content, origin, m.Status, m.IngestionTimestamp)
+		msg.ProcessedContent = m.ProcessedContent
+		msg.Timestamp = m.Timestamp
+		msg.Hostname = m.Hostname
+		msg.Attributes = m.Attributes
+		payload.Messages = append(payload.Messages, msg)
+	}
+	return payload
+}
@@ -0,0 +1,304 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newSpoolTestPayload(content string) *message.Payload {
+	source := config.NewLogSource("my-source", &config.LogsConfig{Source: "app", Service: "web", Tags: []string{"env:test"}})
+	msg := message.NewMessageWithSource([]byte(content), message.StatusError, source, 42)
+	msg.Origin.Identifier = "file:/var/log/app.log"
+	msg.Origin.Offset = "12"
+	msg.ProcessedContent = []byte(content)
+	msg.Hostname = "host"
+	msg.Timestamp = time.Date(2022, time.May, 1, 12, 0, 0, 0, time.UTC)
+	msg.Attributes = map[string]interface{}{"http": map[string]interface{}{"status_code": int64(500)}}
+	return &message.Payload{
+		Messages:      []*message.Message{msg},
+		Encoded:       []byte(content),
+		Encoding:      "identity",
+		UnencodedSize: len(content),
+	}
+}
+
+func TestSpoolReplaysPayloadsInOrder(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(dir, 1<<20)
+	require.NoError(t, err)
+	assert.Nil(t, spool.Peek())
+
+	require.NoError(t, spool.Write(newSpoolTestPayload("first")))
+	require.NoError(t, spool.Write(newSpoolTestPayload("second")))
+	assert.Equal(t, 2, spool.Len())
+	assert.Equal(t, int64(2), metrics.SpoolPayloads.Value())
+	assert.Equal(t, spool.Size(), metrics.SpoolBytes.Value())
+
+	payload := spool.Peek()
+	require.NotNil(t, payload)
+	assert.Equal(t, []byte("first"), payload.Encoded)
+	assert.Equal(t, "identity", payload.Encoding)
+	assert.Equal(t, 5, payload.UnencodedSize)
+	require.Len(t, payload.Messages, 1)
+	msg := payload.Messages[0]
+	assert.Equal(t, []byte("first"), msg.ProcessedContent)
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+	assert.Equal(t, "host", msg.GetHostname())
+	assert.Equal(t, int64(42), msg.IngestionTimestamp)
+	assert.True(t, msg.Timestamp.Equal(time.Date(2022, time.May, 1, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "my-source", msg.Origin.LogSource.Name)
+	assert.Equal(t, "app", msg.Origin.Source())
+	assert.Equal(t, "web", msg.Origin.Service())
+	assert.Equal(t, []string{"env:test"}, msg.Origin.Tags())
+	assert.Equal(t, map[string]interface{}{"http": map[string]interface{}{"status_code": int64(500)}}, msg.Attributes)
+	// the offsets have already been committed
+	assert.Equal(t, "", msg.Origin.Identifier)
+
+	// the payload is kept until it is popped
+	assert.Equal(t, payload, spool.Peek())
+	assert.Equal(t, payload, spool.PeekAt(0))
+	assert.Equal(t, []byte("second"), spool.PeekAt(1).Encoded)
+	assert.Nil(t, spool.PeekAt(2))
+	spool.Pop()
+	assert.Equal(t, []byte("second"), spool.Peek().Encoded)
+
+	// the payloads are kept across restarts
+	metrics.SpoolPayloads.Set(0)
+	metrics.SpoolBytes.Set(0)
+	spool, err = NewSpool(dir, 1<<20)
+	require.NoError(t, err)
+	require.NoError(t, spool.Write(newSpoolTestPayload("third")))
+	assert.Equal(t, 2, spool.Len())
+	assert.Equal(t, int64(2), metrics.SpoolPayloads.Value())
+
+	for _, expected := range []string{"second", "third"} {
+		payload = spool.Peek()
+		require.NotNil(t, payload)
+		assert.Equal(t, []byte(expected), payload.Encoded)
+		spool.Pop()
+	}
+	assert.Nil(t, spool.Peek())
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 0)
+	metrics.SpoolPayloads.Set(0)
+	metrics.SpoolBytes.Set(0)
+}
+
+func TestSpoolDropsOldestPayloads(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(dir, 1<<20)
+	require.NoError(t, err)
+	require.NoError(t, spool.Write(newSpoolTestPayload("first")))
+	size := spool.Size()
+
+	// the spool can hold two payloads
+	spool, err = NewSpool(t.TempDir(), 2*size+size/2)
+	require.NoError(t, err)
+	dropped := metrics.SpoolPayloadsDropped.Value()
+	for _, content := range []string{"aaaaa", "bbbbb", "ccccc"} {
+		require.NoError(t, spool.Write(newSpoolTestPayload(content)))
+	}
+	assert.Equal(t, 2, spool.Len())
+	assert.Equal(t, dropped+1, metrics.SpoolPayloadsDropped.Value())
+	assert.Equal(t, []byte("bbbbb"), spool.Peek().Encoded)
+
+	// a payload bigger than the spool is rejected
+	spool, err = NewSpool(t.TempDir(), size/2)
+	require.NoError(t, err)
+	assert.Error(t, spool.Write(newSpoolTestPayload("first")))
+	assert.Equal(t, 0, spool.Len())
+	metrics.SpoolPayloads.Set(0)
+	metrics.SpoolBytes.Set(0)
+}
+
+func TestSpoolSkipsInvalidPayloads(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(dir, 1<<20)
+	require.NoError(t, err)
+	require.NoError(t, spool.Write(newSpoolTestPayload("first")))
+	require.NoError(t, spool.Write(newSpoolTestPayload("second")))
+	require.NoError(t, os.WriteFile(spool.files[0].name, []byte("garbage"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000002.payload.tmp"), []byte("partial"), 0600))
+
+	spool, err = NewSpool(dir, 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, 2, spool.Len())
+	assert.Equal(t, []byte("second"), spool.Peek().Encoded)
+	assert.Equal(t, 1, spool.Len())
+	_, err = os.Stat(filepath.Join(dir, "00000000000000000002.payload.tmp"))
+	assert.True(t, os.IsNotExist(err))
+	metrics.SpoolPayloads.Set(0)
+	metrics.SpoolBytes.Set(0)
+}
+
+func TestSenderStoresPayloadsDuringOutages(t *testing.T) {
+	spoolReplayInterval = 10 * time.Millisecond
+	defer func() { spoolReplayInterval = 100 * time.Millisecond }()
+
+	input := make(chan *message.Payload, 1)
+	output := make(chan *message.Payload, 10)
+
+	server := http.NewTestServerWithOptions(500, 0, true, nil)
+	defer server.Stop()
+	destinations := client.NewDestinations([]client.Destination{server.Destination}, nil)
+
+	spool, err := NewSpool(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+	sender := NewSenderWithSpool(input, output, destinations, 0, spool)
+	sender.Start()
+
+	// the first payload is retried by the destination while the second one waits for it,
+	// the next ones are stored and forwarded to the output once when they are stored and
+	// once when they are sent
+	contents := []string{"first", "second", "third", "fourth"}
+	for _, content := range contents {
+		input <- newSpoolTestPayload(content)
+	}
+	assert.Eventually(t, func() bool { return metrics.SpoolPayloads.Value() == 2 }, 5*time.Second, 10*time.Millisecond)
+	server.ChangeStatus(200)
+
+	var outputs []string
+	last := make(map[string]int)
+	for len(outputs) < len(contents)+2 {
+		select {
+		case payload := <-output:
+			last[string(payload.Encoded)] = len(outputs)
+			outputs = append(outputs, string(payload.Encoded))
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "the payloads were not sent", "outputs: %v", outputs)
+		}
+	}
+	assert.Equal(t, []string{"third", "fourth"}, outputs[:2])
+	// the payloads are sent in order
+	for i := 1; i < len(contents); i++ {
+		assert.Less(t, last[contents[i-1]], last[contents[i]], outputs)
+	}
+	assert.Eventually(t, func() bool { return metrics.SpoolPayloads.Value() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	sender.Stop()
+	metrics.SpoolBytes.Set(0)
+}
+
+// acknowledgingDestination sends the payloads it receives to its output once the test
+// acknowledges them.
+type acknowledgingDestination struct {
+	received chan *message.Payload
+	acks     chan struct{}
+}
+
+func (d *acknowledgingDestination) Start(input chan *message.Payload, output chan *message.Payload, isRetrying chan bool) (stopChan <-chan struct{}) {
+	stop := make(chan struct{}, 1)
+	go func() {
+		for payload := range input {
+			d.received <- payload
+			<-d.acks
+			output <- payload
+		}
+		stop <- struct{}{}
+	}()
+	return stop
+}
+
+func TestSenderRemovesReplayedPayloadsOnceSent(t *testing.T) {
+	spoolReplayInterval = 10 * time.Millisecond
+	defer func() { spoolReplayInterval = 100 * time.Millisecond }()
+
+	spool, err := NewSpool(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+	require.NoError(t, spool.Write(newSpoolTestPayload("first")))
+	require.NoError(t, spool.Write(newSpoolTestPayload("second")))
+
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload, 10)
+	destination := &acknowledgingDestination{received: make(chan *message.Payload), acks: make(chan struct{})}
+	sender := NewSenderWithSpool(input, output, client.NewDestinations([]client.Destination{destination}, nil), 1, spool)
+	sender.Start()
+
+	for i, content := range []string{"first", "second"} {
+		payload := <-destination.received
+		assert.Equal(t, []byte(content), payload.Encoded)
+		// the payload stays in the spool, and is not sent again, until the destination sent it
+		time.Sleep(5 * spoolReplayInterval)
+		assert.Equal(t, int64(2-i), metrics.SpoolPayloads.Value())
+		destination.acks <- struct{}{}
+		assert.Equal(t, []byte(content), (<-output).Encoded)
+	}
+	assert.Eventually(t, func() bool { return metrics.SpoolPayloads.Value() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	sender.Stop()
+	metrics.SpoolBytes.Set(0)
+}
+
+func TestSenderReplaysSpoolInOrderDuringLiveTraffic(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+	var expected []string
+	for i := 0; i < 20; i++ {
+		expected = append(expected, fmt.Sprintf("spooled-%d", i))
+		require.NoError(t, spool.Write(newSpoolTestPayload(expected[i])))
+	}
+
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload, 100)
+	destination := &acknowledgingDestination{received: make(chan *message.Payload), acks: make(chan struct{})}
+	var received []string
+	var receivedLock sync.Mutex
+	go func() {
+		for payload := range destination.received {
+			receivedLock.Lock()
+			received = append(received, string(payload.Encoded))
+			receivedLock.Unlock()
+			destination.acks <- struct{}{}
+		}
+	}()
+	go func() {
+		for range output {
+		}
+	}()
+	sender := NewSenderWithSpool(input, output, client.NewDestinations([]client.Destination{destination}, nil), 10, spool)
+	sender.Start()
+
+	// the new payloads keep flowing while the spool is replayed, and are sent after it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for live := 0; metrics.SpoolPayloads.Value() > 0; live++ {
+			expected = append(expected, fmt.Sprintf("live-%d", live))
+			input <- newSpoolTestPayload(expected[len(expected)-1])
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "the spool was not replayed during the live traffic", "spooled payloads: %d", metrics.SpoolPayloads.Value())
+	}
+	assert.Greater(t, len(expected), 20)
+
+	sender.Stop()
+	receivedLock.Lock()
+	assert.Equal(t, expected, received)
+	receivedLock.Unlock()
+	assert.Equal(t, 0, spool.Len())
+	close(output)
+	metrics.SpoolBytes.Set(0)
+}
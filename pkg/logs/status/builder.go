@@ -168,8 +168,14 @@ func (b *Builder) toDictionary(c *config.LogsConfig) map[string]interface{} {
 func (b *Builder) getMetricsStatus() map[string]int64 {
 	var metrics = make(map[string]int64, 2)
 	metrics["LogsProcessed"] = b.logsExpVars.Get("LogsProcessed").(*expvar.Int).Value()
+	metrics["LogsSampledOut"] = b.logsExpVars.Get("LogsSampledOut").(*expvar.Int).Value()
 	metrics["LogsSent"] = b.logsExpVars.Get("LogsSent").(*expvar.Int).Value()
 	metrics["BytesSent"] = b.logsExpVars.Get("BytesSent").(*expvar.Int).Value()
 	metrics["EncodedBytesSent"] = b.logsExpVars.Get("EncodedBytesSent").(*expvar.Int).Value()
+	if config.SpoolMaxSize() > 0 {
+		metrics["SpoolBytes"] = b.logsExpVars.Get("SpoolBytes").(*expvar.Int).Value()
+		metrics["SpoolPayloads"] = b.logsExpVars.Get("SpoolPayloads").(*expvar.Int).Value()
+		metrics["SpoolPayloadsDropped"] = b.logsExpVars.Get("SpoolPayloadsDropped").(*expvar.Int).Value()
+	}
 	return metrics
 }
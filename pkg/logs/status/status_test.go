@@ -12,6 +12,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/metrics"
 )
@@ -92,13 +93,13 @@ func TestStatusDeduplicateErrorsAndWarnings(t *testing.T) {
 func TestMetrics(t *testing.T) {
 	defer Clear()
 	Clear()
-	var expected = `{"BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "EncodedBytesSent": 0, "Errors": "", "HttpDestinationStats": {}, "IsRunning": false, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSent": 0, "SenderLatency": 0, "Warnings": ""}`
+	var expected = `{"BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "EncodedBytesSent": 0, "Errors": "", "HttpDestinationStats": {}, "IsRunning": false, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSampledOut": 0, "LogsSent": 0, "SenderLatency": 0, "SpoolBytes": 0, "SpoolPayloads": 0, "SpoolPayloadsDropped": 0, "Warnings": ""}`
 	assert.Equal(t, expected, metrics.LogsExpvars.String())
 
 	initStatus()
 	AddGlobalWarning("bar", "Unique Warning")
 	AddGlobalError("bar", "I am an error")
-	expected = `{"BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "EncodedBytesSent": 0, "Errors": "I am an error", "HttpDestinationStats": {}, "IsRunning": true, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSent": 0, "SenderLatency": 0, "Warnings": "Unique Warning"}`
+	expected = `{"BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "EncodedBytesSent": 0, "Errors": "I am an error", "HttpDestinationStats": {}, "IsRunning": true, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSampledOut": 0, "LogsSent": 0, "SenderLatency": 0, "SpoolBytes": 0, "SpoolPayloads": 0, "SpoolPayloadsDropped": 0, "Warnings": "Unique Warning"}`
 	assert.Equal(t, expected, metrics.LogsExpvars.String())
 }
 
@@ -108,17 +109,20 @@ func TestStatusMetrics(t *testing.T) {
 
 	status := Get()
 	assert.Equal(t, int64(0), status.StatusMetrics["LogsProcessed"])
+	assert.Equal(t, int64(0), status.StatusMetrics["LogsSampledOut"])
 	assert.Equal(t, int64(0), status.StatusMetrics["LogsSent"])
 	assert.Equal(t, int64(0), status.StatusMetrics["BytesSent"])
 	assert.Equal(t, int64(0), status.StatusMetrics["EncodedBytesSent"])
 
 	metrics.LogsProcessed.Set(5)
+	metrics.LogsSampledOut.Set(2)
 	metrics.LogsSent.Set(3)
 	metrics.BytesSent.Set(42)
 	metrics.EncodedBytesSent.Set(21)
 	status = Get()
 
 	assert.Equal(t, int64(5), status.StatusMetrics["LogsProcessed"])
+	assert.Equal(t, int64(2), status.StatusMetrics["LogsSampledOut"])
 	assert.Equal(t, int64(3), status.StatusMetrics["LogsSent"])
 	assert.Equal(t, int64(42), status.StatusMetrics["BytesSent"])
 	assert.Equal(t, int64(21), status.StatusMetrics["EncodedBytesSent"])
@@ -129,6 +133,23 @@ func TestStatusMetrics(t *testing.T) {
 	assert.Equal(t, int64(math.MinInt64), status.StatusMetrics["LogsProcessed"])
 }
 
+func TestStatusSpoolMetrics(t *testing.T) {
+	defer Clear()
+	initStatus()
+
+	status := Get()
+	assert.NotContains(t, status.StatusMetrics, "SpoolBytes")
+
+	coreConfig.Datadog.Set("logs_config.spool_max_size_in_bytes", 1024)
+	defer coreConfig.Datadog.Set("logs_config.spool_max_size_in_bytes", 0)
+	metrics.SpoolBytes.Set(42)
+	defer metrics.SpoolBytes.Set(0)
+	status = Get()
+	assert.Equal(t, int64(42), status.StatusMetrics["SpoolBytes"])
+	assert.Equal(t, int64(0), status.StatusMetrics["SpoolPayloads"])
+	assert.Equal(t, int64(0), status.StatusMetrics["SpoolPayloadsDropped"])
+}
+
 func TestStatusEndpoints(t *testing.T) {
 	defer Clear()
 	initStatus()
@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import "strings"
+
+// ObfuscateCQL quantizes and obfuscates the given Cassandra CQL statement like ObfuscateSQLString, with the
+// same SQL configuration, using the CQL dialect: the list, set and map literals, the UUIDs, the durations and
+// the blobs are replaced with '?'.
+func (o *Obfuscator) ObfuscateCQL(in string) (*ObfuscatedQuery, error) {
+	return o.ObfuscateSQLStringForDBMS(in, DBMSCassandra)
+}
+
+// uuidLen returns the length of the UUID constant at the start of b, such as
+// 123e4567-e89b-12d3-a456-426614174000, or 0 if b does not start with a UUID.
+func uuidLen(b []byte) int {
+	const n = 36
+	if len(b) < n || (len(b) > n && (isLetter(rune(b[n])) || isDigit(rune(b[n])))) {
+		return 0
+	}
+	for i, c := range b[:n] {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return 0
+			}
+		default:
+			if digitVal(rune(c)) >= 16 {
+				return 0
+			}
+		}
+	}
+	return n
+}
+
+// durationUnits holds the units of the CQL durations, the two-letter ones first.
+var durationUnits = []string{"mo", "ms", "us", "µs", "ns", "y", "w", "d", "h", "m", "s"}
+
+// durationLen returns the length of the duration constant at the start of b, such as 1h30m, or 0 if b
+// does not start with a duration.
+func durationLen(b []byte) int {
+	i := 0
+	for i < len(b) && isDigit(rune(b[i])) {
+		for i < len(b) && isDigit(rune(b[i])) {
+			i++
+		}
+		n := 0
+		for _, u := range durationUnits {
+			if len(b)-i >= len(u) && strings.EqualFold(string(b[i:i+len(u)]), u) {
+				n = len(u)
+				break
+			}
+		}
+		if n == 0 {
+			return 0
+		}
+		i += n
+	}
+	if i < len(b) && (isLetter(rune(b[i])) || b[i] == '.') {
+		return 0
+	}
+	return i
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObfuscateCQL(t *testing.T) {
+	o := NewObfuscator(Config{SQL: SQLConfig{TableNames: true, CollapseLists: true}})
+	oq, err := o.ObfuscateCQL("SELECT * FROM ks.users WHERE id IN (123e4567-e89b-12d3-a456-426614174000, ?) AND tags CONTAINS 'x'")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM ks.users WHERE id IN ( ? ) AND tags CONTAINS ?", oq.Query)
+	assert.Equal(t, "ks.users", oq.Metadata.TablesCSV)
+
+	// the CQL dialect does not apply to the SQL queries
+	oq, err = o.ObfuscateSQLString("SELECT * FROM users WHERE m = {'a': 1}")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE m = ?", oq.Query)
+	oq, err = o.ObfuscateCQL("SELECT * FROM users WHERE m = {'a': 1}")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE m = { ? }", oq.Query)
+}
+
+func TestObfuscateCQLOptions(t *testing.T) {
+	query := "SELECT u.name AS n FROM users_2021 u WHERE id = 1"
+	for name, tt := range map[string]struct {
+		cfg SQLConfig
+		out string
+	}{
+		"default":        {SQLConfig{}, "SELECT u.name FROM users_2021 u WHERE id = ?"},
+		"keep alias":     {SQLConfig{KeepSQLAlias: true}, "SELECT u.name AS n FROM users_2021 u WHERE id = ?"},
+		"replace digits": {SQLConfig{TableNames: true, ReplaceDigits: true}, "SELECT u.name FROM users_? u WHERE id = ?"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			oq, err := NewObfuscator(Config{SQL: tt.cfg}).ObfuscateCQL(query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.out, oq.Query)
+		})
+	}
+}
+
+func TestUUIDLen(t *testing.T) {
+	for in, n := range map[string]int{
+		"123e4567-e89b-12d3-a456-426614174000":       36,
+		"123E4567-E89B-12D3-A456-426614174000 AND":   36,
+		"123e4567-e89b-12d3-a456-426614174000abc":    0,
+		"123e4567-e89b-12d3-a456-42661417400":        0,
+		"123e4567_e89b-12d3-a456-426614174000":       0,
+		"123g4567-e89b-12d3-a456-426614174000":       0,
+		"123e4567-e89b-12d3-a456-426614174000, uuid": 36,
+	} {
+		assert.Equal(t, n, uuidLen([]byte(in)), in)
+	}
+}
+
+func TestDurationLen(t *testing.T) {
+	for in, n := range map[string]int{
+		"1h30m":     5,
+		"500ms":     5,
+		"2mo3w":     5,
+		"1y2d AND":  4,
+		"89h4m48s)": 8,
+		"12":        0,
+		"3e5":       0,
+		"1hx":       0,
+		"1.5h":      0,
+	} {
+		assert.Equal(t, n, durationLen([]byte(in)), in)
+	}
+}
@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import "strings"
+
+// ObfuscateGraphQL obfuscates the given GraphQL document: the string and number values of the arguments,
+// input object fields and variable defaults are replaced with '?', and the comments are removed. The
+// structure of the document, the field names, the variables and the enum and boolean values are kept.
+func (o *Obfuscator) ObfuscateGraphQL(query string) string {
+	if o.graphql == nil || query == "" {
+		// obfuscator is disabled or string is empty
+		return query
+	}
+	return o.graphql.obfuscate(query)
+}
+
+type graphqlObfuscator struct {
+	keepKeys map[string]bool // the values for these arguments and input fields will not be obfuscated
+}
+
+func newGraphQLObfuscator(cfg *GraphQLConfig) *graphqlObfuscator {
+	keepKeys := make(map[string]bool, len(cfg.KeepValues))
+	for _, v := range cfg.KeepValues {
+		keepKeys[v] = true
+	}
+	return &graphqlObfuscator{keepKeys: keepKeys}
+}
+
+func (g *graphqlObfuscator) obfuscate(query string) string {
+	var (
+		out  strings.Builder
+		name string   // the last name scanned
+		key  string   // the argument, input field or variable whose value is being scanned
+		keys []string // the keys of the enclosing lists, objects and argument lists
+	)
+	out.Grow(len(query))
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == '#':
+			// comment, up to the end of the line
+			for i < len(query) && query[i] != '\n' && query[i] != '\r' {
+				i++
+			}
+		case c == '"':
+			n := graphqlStringLen(query[i:])
+			g.writeValue(&out, key, query[i:i+n])
+			i += n
+		case c == '-' || isDigit(rune(c)):
+			n := graphqlNumberLen(query[i:])
+			g.writeValue(&out, key, query[i:i+n])
+			i += n
+		case c == '_' || ('a' <= c|0x20 && c|0x20 <= 'z'):
+			j := i + 1
+			for j < len(query) && (query[j] == '_' || isDigit(rune(query[j])) || ('a' <= query[j]|0x20 && query[j]|0x20 <= 'z')) {
+				j++
+			}
+			name = query[i:j]
+			out.WriteString(name)
+			i = j
+		default:
+			switch c {
+			case ':':
+				// e.g. "id: 1", "$id: ID" or "{email: "x"}"
+				key = name
+			case '(', '[', '{':
+				keys = append(keys, key)
+			case ')', ']', '}':
+				if n := len(keys); n > 0 {
+					key = keys[n-1]
+					keys = keys[:n-1]
+				}
+			}
+			if c != ':' && c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ',' {
+				// the key only applies to the name which has just been scanned
+				name = ""
+			}
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
+
+// writeValue writes the literal value v of the given key to out, obfuscated unless the key must be kept.
+func (g *graphqlObfuscator) writeValue(out *strings.Builder, key, v string) {
+	if key != "" && g.keepKeys[key] {
+		out.WriteString(v)
+		return
+	}
+	out.WriteByte('?')
+}
+
+// graphqlStringLen returns the length of the string or block string at the start of s, which starts
+// with a double quote. Unterminated strings extend to the end of s.
+func graphqlStringLen(s string) int {
+	if strings.HasPrefix(s, `"""`) {
+		for i := 3; i < len(s); i++ {
+			switch {
+			case strings.HasPrefix(s[i:], `\"""`):
+				i += 3
+			case strings.HasPrefix(s[i:], `"""`):
+				return i + 3
+			}
+		}
+		return len(s)
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		case '\n', '\r':
+			// strings can not span several lines
+			return i
+		}
+	}
+	return len(s)
+}
+
+// graphqlNumberLen returns the length of the int or float value at the start of s, which starts with
+// a minus sign or a digit.
+func graphqlNumberLen(s string) int {
+	i := 1
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case isDigit(rune(c)), c == '.', c == 'e', c == 'E':
+		case (c == '+' || c == '-') && (s[i-1] == 'e' || s[i-1] == 'E'):
+		default:
+			return i
+		}
+		i++
+	}
+	return i
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateGraphQL(t *testing.T) {
+	for _, tt := range []struct {
+		name, in, out string
+	}{
+		{
+			name: "arguments",
+			in:   `{ user(id: 42, email: "jane@example.com") { name friends(first: 10) { name } } }`,
+			out:  `{ user(id: ?, email: ?) { name friends(first: ?) { name } } }`,
+		},
+		{
+			name: "variables",
+			in:   `query GetUser($id: ID!, $limit: Int = 25) { user(id: $id) { posts(limit: $limit) { title } } }`,
+			out:  `query GetUser($id: ID!, $limit: Int = ?) { user(id: $id) { posts(limit: $limit) { title } } }`,
+		},
+		{
+			name: "input-objects-and-lists",
+			in:   `mutation { createUser(input: {name: "Jane", age: -3.5e2, tags: ["a", "b"], role: ADMIN, active: true}) { id } }`,
+			out:  `mutation { createUser(input: {name: ?, age: ?, tags: [?, ?], role: ADMIN, active: true}) { id } }`,
+		},
+		{
+			name: "kept-values",
+			in:   `{ search(locale: "en", text: "secret", filter: {locale: "fr", page: 2}) { hits } }`,
+			out:  `{ search(locale: "en", text: ?, filter: {locale: "fr", page: ?}) { hits } }`,
+		},
+		{
+			name: "kept-lists",
+			in:   `{ items(locale: ["en", "fr"], ids: [1, 2]) { id } }`,
+			out:  `{ items(locale: ["en", "fr"], ids: [?, ?]) { id } }`,
+		},
+		{
+			name: "aliases-directives-and-fragments",
+			in:   "query {\n  small: picture(size: 64) @include(if: $big) { url }\n  ...F @skip(if: false)\n}\nfragment F on User { id }",
+			out:  "query {\n  small: picture(size: ?) @include(if: $big) { url }\n  ...F @skip(if: false)\n}\nfragment F on User { id }",
+		},
+		{
+			name: "block-strings",
+			in:   `mutation { post(body: """multi "quoted" \""" line""") { id } }`,
+			out:  `mutation { post(body: ?) { id } }`,
+		},
+		{
+			name: "escaped-strings",
+			in:   `{ user(name: "a \"b\" \\", id: 1) { id } }`,
+			out:  `{ user(name: ?, id: ?) { id } }`,
+		},
+		{
+			name: "comments",
+			in:   "{\n  # password: hunter2\n  me { id }\n}",
+			out:  "{\n  \n  me { id }\n}",
+		},
+		{
+			name: "unterminated-string",
+			in:   `{ user(name: "jane`,
+			out:  `{ user(name: ?`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewObfuscator(Config{GraphQL: GraphQLConfig{Enabled: true, KeepValues: []string{"locale"}}})
+			assert.Equal(t, tt.out, o.ObfuscateGraphQL(tt.in))
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		in := `{ user(id: 42) { name } }`
+		assert.Equal(t, in, NewObfuscator(Config{}).ObfuscateGraphQL(in))
+	})
+}
@@ -20,6 +20,11 @@ func (o *Obfuscator) ObfuscateElasticSearchString(cmd string) string {
 	return obfuscateJSONString(cmd, o.es)
 }
 
+// ObfuscateJSONBody obfuscates the given JSON body of an HTTP request or response.
+func (o *Obfuscator) ObfuscateJSONBody(body string) string {
+	return obfuscateJSONString(body, o.jsonBody)
+}
+
 // obfuscateJSONString obfuscates the given span's tag using the given obfuscator. If the obfuscator is
 // nil it is considered disabled.
 func obfuscateJSONString(cmd string, obfuscator *jsonObfuscator) string {
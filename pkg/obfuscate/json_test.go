@@ -121,6 +121,24 @@ func TestObfuscateJSON(t *testing.T) {
 	}
 }
 
+func TestObfuscateJSONBody(t *testing.T) {
+	in := `{"TableName":"users","Key":{"id":{"S":"1234"}},"ConditionExpression":"SELECT * FROM users WHERE id = 5"}`
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Equal(t, in, NewObfuscator(Config{}).ObfuscateJSONBody(in))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		o := NewObfuscator(Config{JSONBody: JSONConfig{
+			Enabled:            true,
+			KeepValues:         []string{"TableName"},
+			ObfuscateSQLValues: []string{"ConditionExpression"},
+		}})
+		out := o.ObfuscateJSONBody(in)
+		assertEqualJSON(t, `{"TableName":"users","Key":{"id":{"S":"?"}},"ConditionExpression":"SELECT * FROM users WHERE id = ?"}`, out)
+	})
+}
+
 func BenchmarkObfuscateJSON(b *testing.B) {
 	cfg := &JSONConfig{KeepValues: []string{"highlight"}}
 	if len(jsonSuite) == 0 {
@@ -23,10 +23,12 @@ import (
 // concurrent use.
 type Obfuscator struct {
 	opts                 *Config
-	es                   *jsonObfuscator // nil if disabled
-	mongo                *jsonObfuscator // nil if disabled
-	sqlExecPlan          *jsonObfuscator // nil if disabled
-	sqlExecPlanNormalize *jsonObfuscator // nil if disabled
+	es                   *jsonObfuscator    // nil if disabled
+	mongo                *jsonObfuscator    // nil if disabled
+	sqlExecPlan          *jsonObfuscator    // nil if disabled
+	sqlExecPlanNormalize *jsonObfuscator    // nil if disabled
+	jsonBody             *jsonObfuscator    // nil if disabled
+	graphql              *graphqlObfuscator // nil if disabled
 	// sqlLiteralEscapes reports whether we should treat escape characters literally or as escape characters.
 	// Different SQL engines behave in different ways and the tokenizer needs to be generic.
 	sqlLiteralEscapes *atomic.Bool
@@ -82,6 +84,13 @@ type Config struct {
 	// HTTP holds the obfuscation settings for HTTP URLs.
 	HTTP HTTPConfig
 
+	// GraphQL holds the obfuscation configuration for GraphQL documents.
+	GraphQL GraphQLConfig
+
+	// JSONBody holds the obfuscation configuration for the JSON bodies of HTTP requests and
+	// responses, such as the bodies of the AWS SDK (e.g. DynamoDB) requests.
+	JSONBody JSONConfig
+
 	// Statsd specifies the statsd client to use for reporting metrics.
 	Statsd StatsClient
 
@@ -125,6 +134,11 @@ type SQLConfig struct {
 	// https://www.postgresql.org/docs/current/sql-syntax-lexical.html#SQL-SYNTAX-DOLLAR-QUOTING
 	DollarQuotedFunc bool
 
+	// CollapseLists reports whether the contents of IN lists and VALUES tuples should be collapsed into
+	// a single '?', whatever their values (e.g. literals, bind variables or parameters), so that queries
+	// only differing by the number of values are obfuscated the same way.
+	CollapseLists bool `json:"collapse_lists"`
+
 	// Cache reports whether the obfuscator should use a LRU look-up cache for SQL obfuscations.
 	Cache bool
 }
@@ -152,6 +166,16 @@ type HTTPConfig struct {
 	RemovePathDigits bool
 }
 
+// GraphQLConfig holds the configuration settings for GraphQL obfuscation.
+type GraphQLConfig struct {
+	// Enabled will specify whether obfuscation should be enabled.
+	Enabled bool
+
+	// KeepValues will specify a set of arguments and input object fields
+	// for which their values will not be obfuscated.
+	KeepValues []string
+}
+
 // JSONConfig holds the obfuscation configuration for sensitive
 // data found in JSON objects.
 type JSONConfig struct {
@@ -189,6 +213,12 @@ func NewObfuscator(cfg Config) *Obfuscator {
 	if cfg.SQLExecPlanNormalize.Enabled {
 		o.sqlExecPlanNormalize = newJSONObfuscator(&cfg.SQLExecPlanNormalize, &o)
 	}
+	if cfg.JSONBody.Enabled {
+		o.jsonBody = newJSONObfuscator(&cfg.JSONBody, &o)
+	}
+	if cfg.GraphQL.Enabled {
+		o.graphql = newGraphQLObfuscator(&cfg.GraphQL)
+	}
 	if cfg.Statsd == nil {
 		cfg.Statsd = &statsd.NoOpClient{}
 	}
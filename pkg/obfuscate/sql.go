@@ -14,7 +14,10 @@ import (
 	"unicode/utf8"
 )
 
-var questionMark = []byte("?")
+var (
+	questionMark       = []byte("?")
+	closingParenthesis = []byte(")")
+)
 
 // metadataFinderFilter is a filter which attempts to collect metadata from a query, such as comments and tables.
 // It is meant to run before all the other filters.
@@ -57,8 +60,9 @@ func (f *metadataFinderFilter) Filter(token, lastToken TokenKind, buffer []byte)
 			// SELECT ... FROM [tableName]
 			// DELETE FROM [tableName]
 			// ... JOIN [tableName]
-			if r, _ := utf8.DecodeRune(buffer); !unicode.IsLetter(r) {
-				// first character in buffer is not a letter; we might have a nested
+			if r, _ := utf8.DecodeRune(buffer); !unicode.IsLetter(r) && !(token == ID && (r == '[' || r == '#')) {
+				// first character in buffer is not a letter, nor the start of a SQL Server
+				// bracketed identifier or temporary table; we might have a nested
 				// query like SELECT * FROM (SELECT ...)
 				break
 			}
@@ -191,6 +195,9 @@ func (f *replaceFilter) Filter(token, lastToken TokenKind, buffer []byte) (token
 	switch token {
 	case DollarQuotedString, String, Number, Null, Variable, PreparedStatement, BooleanLiteral, EscapeSequence:
 		return markFilteredGroupable(token), questionMark, nil
+	case CollectionLiteral:
+		// keep the delimiters to preserve the shape of the query, e.g. '[ ? ]' or '{ ? }'
+		return markFilteredGroupable(token), []byte{buffer[0], ' ', '?', ' ', buffer[len(buffer)-1]}, nil
 	case '?':
 		// Cases like 'ARRAY [ ?, ? ]' should be collapsed into 'ARRAY [ ? ]'
 		return markFilteredGroupable(token), questionMark, nil
@@ -285,6 +292,87 @@ func (f *groupingFilter) Reset() {
 	f.groupMulti = 0
 }
 
+// listState is the state of the listFilter.
+type listState int
+
+const (
+	listNone      listState = iota // outside of any list
+	listStart                      // before the first token of a list
+	listInside                     // inside a list, after its first token
+	listDiscarded                  // inside a VALUES tuple following the first one
+	listTupleEnd                   // after a VALUES tuple
+	listTupleNext                  // after the comma following a VALUES tuple
+)
+
+// listFilter is a token filter which collapses the contents of IN lists and VALUES tuples into a single
+// '?', whatever their values: literals, bind variables or parameters. The VALUES tuples following the first
+// one are discarded. It is meant to run after all the other filters, on the tokens as they were scanned.
+type listFilter struct {
+	state  listState
+	values bool // whether the current list is a VALUES tuple
+	depth  int  // number of open parentheses in the current list
+}
+
+// Filter the given token so that it is discarded if it is part of a list.
+func (f *listFilter) Filter(token, lastToken TokenKind, buffer []byte) (TokenKind, []byte, error) {
+	switch f.state {
+	case listNone:
+		if token == '(' && (lastToken == In || lastToken == Values) && buffer != nil {
+			// the lists discarded by the previous filters are left as is
+			f.state, f.values, f.depth = listStart, lastToken == Values, 1
+		}
+		return token, buffer, nil
+	case listTupleEnd:
+		if token == ',' {
+			f.state = listTupleNext
+			return token, nil, nil
+		}
+	case listTupleNext:
+		if token == '(' {
+			f.state, f.depth = listDiscarded, 1
+			return token, nil, nil
+		}
+	default:
+		if f.state == listStart && token == Select {
+			// this is a nested query, e.g. IN (SELECT ...)
+			f.Reset()
+			return token, buffer, nil
+		}
+		switch token {
+		case '(':
+			f.depth++
+		case ')':
+			f.depth--
+		}
+		if f.depth == 0 {
+			discarded := f.state == listDiscarded
+			f.state = listNone
+			if f.values {
+				f.state = listTupleEnd
+			}
+			if discarded {
+				return token, nil, nil
+			}
+			return token, closingParenthesis, nil
+		}
+		if f.state == listStart {
+			f.state = listInside
+			return token, questionMark, nil
+		}
+		return token, nil, nil
+	}
+	// the VALUES tuples are over
+	f.Reset()
+	return f.Filter(token, lastToken, buffer)
+}
+
+// Reset implements tokenFilter.
+func (f *listFilter) Reset() {
+	f.state = listNone
+	f.values = false
+	f.depth = 0
+}
+
 // ObfuscateSQLString quantizes and obfuscates the given input SQL query string. Quantization removes
 // some elements such as comments and aliases and obfuscation attempts to hide sensitive information
 // in strings and numbers by redacting them.
@@ -296,17 +384,33 @@ func (o *Obfuscator) ObfuscateSQLString(in string) (*ObfuscatedQuery, error) {
 // to quantize and obfuscate the given input SQL query string. Quantization removes some elements such as comments
 // and aliases and obfuscation attempts to hide sensitive information in strings and numbers by redacting them.
 func (o *Obfuscator) ObfuscateSQLStringWithOptions(in string, opts *SQLConfig) (*ObfuscatedQuery, error) {
-	if v, ok := o.queryCache.Get(in); ok {
+	key := in
+	if opts.DBMS != "" {
+		// the same query may be tokenized differently by another DBMS
+		key = opts.DBMS + ":" + in
+	}
+	if v, ok := o.queryCache.Get(key); ok {
 		return v.(*ObfuscatedQuery), nil
 	}
 	oq, err := o.obfuscateSQLString(in, opts)
 	if err != nil {
 		return oq, err
 	}
-	o.queryCache.Set(in, oq, oq.Cost())
+	o.queryCache.Set(key, oq, oq.Cost())
 	return oq, nil
 }
 
+// ObfuscateSQLStringForDBMS quantizes and obfuscates the given input SQL query string like ObfuscateSQLString,
+// using the dialect of the given type of database management system (e.g. DBMSSQLServer).
+func (o *Obfuscator) ObfuscateSQLStringForDBMS(in, dbms string) (*ObfuscatedQuery, error) {
+	if dbms == "" || dbms == o.opts.SQL.DBMS {
+		return o.ObfuscateSQLString(in)
+	}
+	opts := o.opts.SQL
+	opts.DBMS = dbms
+	return o.ObfuscateSQLStringWithOptions(in, &opts)
+}
+
 func (o *Obfuscator) obfuscateSQLString(in string, opts *SQLConfig) (*ObfuscatedQuery, error) {
 	lesc := o.useSQLLiteralEscapes()
 	tok := NewSQLTokenizer(in, lesc, opts)
@@ -352,6 +456,9 @@ func attemptObfuscation(tokenizer *SQLTokenizer) (*ObfuscatedQuery, error) {
 		discard  = discardFilter{keepSQLAlias: tokenizer.cfg.KeepSQLAlias}
 		replace  = replaceFilter{replaceDigits: tokenizer.cfg.ReplaceDigits}
 		grouping groupingFilter
+		lists    listFilter
+		// lastRaw is the last token as scanned by the tokenizer, before being filtered.
+		lastRaw TokenKind
 	)
 	defer metadata.Reset()
 	// call Scan() function until tokens are available or if a LEX_ERROR is raised. After
@@ -365,6 +472,7 @@ func attemptObfuscation(tokenizer *SQLTokenizer) (*ObfuscatedQuery, error) {
 		if token == LexError {
 			return nil, fmt.Errorf("%v", tokenizer.Err())
 		}
+		raw := token
 
 		if token, buff, err = metadata.Filter(token, lastToken, buff); err != nil {
 			return nil, err
@@ -378,6 +486,11 @@ func attemptObfuscation(tokenizer *SQLTokenizer) (*ObfuscatedQuery, error) {
 		if token, buff, err = grouping.Filter(token, lastToken, buff); err != nil {
 			return nil, err
 		}
+		if tokenizer.cfg.CollapseLists {
+			if _, buff, err = lists.Filter(raw, lastRaw, buff); err != nil {
+				return nil, err
+			}
+		}
 		if buff != nil {
 			if out.Len() != 0 {
 				switch token {
@@ -396,6 +509,7 @@ func attemptObfuscation(tokenizer *SQLTokenizer) (*ObfuscatedQuery, error) {
 			out.Write(buff)
 		}
 		lastToken = token
+		lastRaw = raw
 	}
 	if out.Len() == 0 {
 		return nil, errors.New("result is empty")
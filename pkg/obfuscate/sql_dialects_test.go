@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sqlDialectTestFiles holds the golden SQL obfuscation tests of each DBMS, one file per DBMS, named
+// after it.
+const sqlDialectTestFiles = "./testdata/sql/*.xml"
+
+type xmlSQLTests struct {
+	XMLName xml.Name      `xml:"SQLTests"`
+	Tests   []*xmlSQLTest `xml:"Test"`
+}
+
+type xmlSQLTest struct {
+	Name          string
+	In            string
+	Out           string
+	Tables        string // the expected table names, comma-separated
+	CollapseLists bool
+}
+
+func loadSQLDialectTests(t *testing.T, path string) []*xmlSQLTest {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var suite xmlSQLTests
+	require.NoError(t, xml.NewDecoder(f).Decode(&suite))
+	require.NotEmpty(t, suite.Tests)
+	return suite.Tests
+}
+
+func TestSQLDialects(t *testing.T) {
+	paths, err := filepath.Glob(sqlDialectTestFiles)
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+	for _, path := range paths {
+		dbms := strings.TrimSuffix(filepath.Base(path), ".xml")
+		t.Run(dbms, func(t *testing.T) {
+			for _, tt := range loadSQLDialectTests(t, path) {
+				t.Run(tt.Name, func(t *testing.T) {
+					cfg := SQLConfig{DBMS: dbms, TableNames: true, CollapseLists: tt.CollapseLists}
+					oq, err := NewObfuscator(Config{SQL: cfg}).ObfuscateSQLString(tt.In)
+					require.NoError(t, err)
+					assert.Equal(t, tt.Out, oq.Query)
+					assert.Equal(t, tt.Tables, oq.Metadata.TablesCSV)
+				})
+			}
+		})
+	}
+}
@@ -1188,6 +1188,63 @@ func TestObfuscatorDBMSBehavior(t *testing.T) {
 	}
 }
 
+func TestObfuscatorCollapseLists(t *testing.T) {
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{
+			"SELECT * FROM t WHERE id IN (1, 2, 3)",
+			"SELECT * FROM t WHERE id IN ( ? )",
+		},
+		{
+			"SELECT * FROM t WHERE id NOT IN (:1, :2, :3) AND x = $1",
+			"SELECT * FROM t WHERE id NOT IN ( ? ) AND x = ?",
+		},
+		{
+			"SELECT * FROM t WHERE id IN (?, ?, ?)",
+			"SELECT * FROM t WHERE id IN ( ? )",
+		},
+		{
+			"INSERT INTO t (a, b) VALUES (:1, :2), (:3, :4) ON DUPLICATE KEY UPDATE a = VALUES(a)",
+			"INSERT INTO t ( a, b ) VALUES ( ? ) ON DUPLICATE KEY UPDATE a = VALUES ( ? )",
+		},
+		{
+			"INSERT INTO t (a, b) VALUES (?, now()), (?, now())",
+			"INSERT INTO t ( a, b ) VALUES ( ? )",
+		},
+		{
+			"SELECT * FROM t WHERE id IN (SELECT id FROM u WHERE x IN (%s, %s))",
+			"SELECT * FROM t WHERE id IN ( SELECT id FROM u WHERE x IN ( ? ) )",
+		},
+		{
+			"SELECT * FROM t WHERE id IN ()",
+			"SELECT * FROM t WHERE id IN ( )",
+		},
+	} {
+		t.Run("", func(t *testing.T) {
+			oq, err := NewObfuscator(Config{SQL: SQLConfig{CollapseLists: true}}).ObfuscateSQLString(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.out, oq.Query)
+		})
+	}
+}
+
+func TestObfuscateSQLStringForDBMS(t *testing.T) {
+	o := NewObfuscator(Config{SQL: SQLConfig{Cache: true}})
+	defer o.Stop()
+	in := "SELECT [name] FROM [users] WHERE id = 1"
+	for i := 0; i < 2; i++ {
+		// the results of the DBMS are cached separately
+		oq, err := o.ObfuscateSQLStringForDBMS(in, DBMSSQLServer)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT [name] FROM [users] WHERE id = ?", oq.Query)
+		oq, err = o.ObfuscateSQLStringForDBMS(in, "")
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT [ name ] FROM [ users ] WHERE id = ?", oq.Query)
+		o.queryCache.Wait()
+	}
+}
+
 func TestSQLTokenizerIgnoreEscapeFalse(t *testing.T) {
 	cases := []sqlTokenizerTestCase{
 		{
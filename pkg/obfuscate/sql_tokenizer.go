@@ -73,6 +73,12 @@ const (
 	Join
 	TableName
 	ColonCast
+	In
+	Values
+
+	// CollectionLiteral is an array, tuple or map literal made only of constants, such as
+	// [1, 2] or {'a': [1, 2]} (ClickHouse).
+	CollectionLiteral
 
 	// FilteredGroupable specifies that the given token has been discarded by one of the
 	// token filters and that it is groupable together with consecutive FilteredGroupable
@@ -136,6 +142,9 @@ var tokenKindStrings = map[TokenKind]string{
 	Join:                         "Join",
 	TableName:                    "TableName",
 	ColonCast:                    "ColonCast",
+	In:                           "In",
+	Values:                       "Values",
+	CollectionLiteral:            "CollectionLiteral",
 	FilteredGroupable:            "FilteredGroupable",
 	FilteredGroupableParenthesis: "FilteredGroupableParenthesis",
 	Filtered:                     "Filtered",
@@ -153,6 +162,12 @@ func (k TokenKind) String() string {
 const (
 	// DBMSSQLServer is a MS SQL Server
 	DBMSSQLServer = "mssql"
+	// DBMSOracle is an Oracle Database
+	DBMSOracle = "oracle"
+	// DBMSClickHouse is a ClickHouse database
+	DBMSClickHouse = "clickhouse"
+	// DBMSCassandra is an Apache Cassandra database, queried with CQL
+	DBMSCassandra = "cassandra"
 )
 
 const escapeCharacter = '\\'
@@ -219,6 +234,8 @@ var keywords = map[string]TokenKind{
 	"INSERT":    Insert,
 	"INTO":      Into,
 	"JOIN":      Join,
+	"IN":        In,
+	"VALUES":    Values,
 }
 
 // Err returns the last error that the tokenizer encountered, or nil.
@@ -242,8 +259,23 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 	}
 	tkn.SkipBlank()
 
+	if tkn.cfg.DBMS == DBMSCassandra && digitVal(tkn.lastChar) < 16 {
+		if n := uuidLen(tkn.buf); n > 0 {
+			// e.g. 123e4567-e89b-12d3-a456-426614174000
+			tkn.advanceTo(n)
+			return Number, tkn.bytes()
+		}
+		if n := durationLen(tkn.buf); n > 0 {
+			// e.g. 1h30m
+			tkn.advanceTo(n)
+			return Number, tkn.bytes()
+		}
+	}
 	switch ch := tkn.lastChar; {
 	case isLeadingLetter(ch):
+		if kind, tok, ok := tkn.scanPrefixedString(); ok {
+			return kind, tok
+		}
 		return tkn.scanIdentifier()
 	case isDigit(ch):
 		return tkn.scanNumber(false)
@@ -280,7 +312,18 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 			default:
 				return TokenKind(ch), tkn.bytes()
 			}
-		case '=', ',', ';', '(', ')', '+', '*', '&', '|', '^', '[', ']', '?':
+		case '=', ',', ';', '(', ')', '+', '*', '&', '|', '^', ']', '?':
+			return TokenKind(ch), tkn.bytes()
+		case '[':
+			switch tkn.cfg.DBMS {
+			case DBMSSQLServer:
+				return tkn.scanBracketedIdentifier()
+			case DBMSClickHouse, DBMSCassandra:
+				if n := collectionLiteralLen(tkn.buf); n > 0 {
+					tkn.advanceTo(n)
+					return CollectionLiteral, tkn.bytes()
+				}
+			}
 			return TokenKind(ch), tkn.bytes()
 		case '.':
 			if isDigit(tkn.lastChar) {
@@ -396,6 +439,19 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 			}
 			return kind, tok
 		case '{':
+			if tkn.cfg.DBMS == DBMSClickHouse || tkn.cfg.DBMS == DBMSCassandra {
+				if n := collectionLiteralLen(tkn.buf); n > 0 {
+					tkn.advanceTo(n)
+					return CollectionLiteral, tkn.bytes()
+				}
+			}
+			if tkn.cfg.DBMS == DBMSClickHouse {
+				if n := queryParameterLen(tkn.buf); n > 0 {
+					// e.g. {id:UInt32}
+					tkn.advanceTo(n)
+					return ValueArg, tkn.bytes()
+				}
+			}
 			if tkn.pos == 1 || tkn.curlys > 0 {
 				// Do not fully obfuscate top-level SQL escape sequences like {{[?=]call procedure-name[([parameter][,parameter]...)]}.
 				// We want these to display a bit more context than just a plain '?'
@@ -474,6 +530,11 @@ func (tkn *SQLTokenizer) scanIdentifier() (TokenKind, []byte) {
 	for isLetter(tkn.lastChar) || isDigit(tkn.lastChar) || tkn.lastChar == '.' || tkn.lastChar == '*' {
 		tkn.advance()
 	}
+	if tkn.cfg.DBMS == DBMSSQLServer && tkn.lastChar == '[' && tkn.buf[tkn.off-2] == '.' {
+		// multi-part name continuing with a bracketed identifier, e.g. dbo.[users]
+		tkn.advance()
+		return tkn.scanBracketedIdentifier()
+	}
 
 	t := tkn.bytes()
 	// Space allows us to upper-case identifiers 256 bytes long or less without allocating heap
@@ -487,6 +548,206 @@ func (tkn *SQLTokenizer) scanIdentifier() (TokenKind, []byte) {
 	return ID, t
 }
 
+// scanBracketedIdentifier scans a SQL Server identifier delimited by brackets, e.g. [order details], along
+// with the following parts of its multi-part name, e.g. [dbo].[users] or [dbo].users. The opening bracket
+// has already been consumed.
+func (tkn *SQLTokenizer) scanBracketedIdentifier() (TokenKind, []byte) {
+	for {
+		switch tkn.lastChar {
+		case EndChar:
+			tkn.setErr("unexpected EOF in bracketed identifier")
+			return LexError, tkn.bytes()
+		case ']':
+			tkn.advance()
+			if tkn.lastChar == ']' {
+				// doubling the closing bracket embeds it within the identifier
+				tkn.advance()
+				continue
+			}
+			if tkn.lastChar != '.' {
+				return ID, tkn.bytes()
+			}
+			for isLetter(tkn.lastChar) || isDigit(tkn.lastChar) || tkn.lastChar == '.' || tkn.lastChar == '*' {
+				tkn.advance()
+			}
+			if tkn.lastChar != '[' {
+				return ID, tkn.bytes()
+			}
+			tkn.advance()
+		default:
+			tkn.advance()
+		}
+	}
+}
+
+// scanPrefixedString scans the string literals starting with a letter: N'text' national character strings
+// (SQL Server and Oracle) and q'[text]' alternative quoting (Oracle). It returns false if there is no such
+// string at the current position.
+func (tkn *SQLTokenizer) scanPrefixedString() (TokenKind, []byte, bool) {
+	if tkn.cfg.DBMS != DBMSSQLServer && tkn.cfg.DBMS != DBMSOracle {
+		return 0, nil, false
+	}
+	rest := tkn.buf[tkn.off-utf8.RuneLen(tkn.lastChar):]
+	var n int // length of the prefix
+	if rest[0] == 'N' || rest[0] == 'n' {
+		n++
+	}
+	alternative := tkn.cfg.DBMS == DBMSOracle && len(rest) > n && (rest[n] == 'q' || rest[n] == 'Q')
+	if alternative {
+		n++
+	}
+	if n == 0 || len(rest) <= n || rest[n] != '\'' {
+		return 0, nil, false
+	}
+	// consume the prefix and the opening quote
+	for i := 0; i <= n; i++ {
+		tkn.advance()
+	}
+	if alternative {
+		kind, tok := tkn.scanAlternativeQuotedString()
+		return kind, tok, true
+	}
+	kind, tok := tkn.scanString('\'', String)
+	return kind, tok, true
+}
+
+// scanAlternativeQuotedString scans an Oracle string using the alternative quoting mechanism, e.g. q'[it's]',
+// whose opening quote has already been consumed. The delimiter following the quote is closed by its pair for
+// brackets, braces, parentheses and angle brackets, or by itself otherwise.
+// See: https://docs.oracle.com/en/database/oracle/oracle-database/19/sqlrf/Literals.html#GUID-1824CBAA-6E16-4921-B2A6-112FB02248DA
+func (tkn *SQLTokenizer) scanAlternativeQuotedString() (TokenKind, []byte) {
+	delim := tkn.lastChar
+	switch delim {
+	case EndChar:
+		tkn.setErr("unexpected EOF in quoted string")
+		return LexError, tkn.bytes()
+	case '[':
+		delim = ']'
+	case '{':
+		delim = '}'
+	case '(':
+		delim = ')'
+	case '<':
+		delim = '>'
+	}
+	if unicode.IsSpace(delim) || delim == '\'' {
+		tkn.setErr(`invalid quote delimiter "%c" (%d)`, delim, delim)
+		return LexError, tkn.bytes()
+	}
+	tkn.advance()
+	var buf bytes.Buffer
+	for {
+		ch := tkn.lastChar
+		tkn.advance()
+		if ch == EndChar {
+			tkn.setErr("unexpected EOF in quoted string")
+			return LexError, buf.Bytes()
+		}
+		if ch == delim && tkn.lastChar == '\'' {
+			tkn.advance()
+			break
+		}
+		buf.WriteRune(ch)
+	}
+	return String, buf.Bytes()
+}
+
+// advanceTo advances the tokenizer until the first n bytes of the buffer are consumed.
+func (tkn *SQLTokenizer) advanceTo(n int) {
+	for tkn.lastChar != EndChar && tkn.off-utf8.RuneLen(tkn.lastChar) < n {
+		tkn.advance()
+	}
+}
+
+// collectionLiteralLen returns the length of the array, tuple or map literal at the start of b, such as
+// [1, 2] or {'a': [1, 2]}, or 0 if b does not start with a collection made only of constants.
+func collectionLiteralLen(b []byte) int {
+	var closing []byte // stack of the expected closing delimiters
+	for i := 0; i < len(b); {
+		switch c := b[i]; c {
+		case '[':
+			closing = append(closing, ']')
+			i++
+		case '{':
+			closing = append(closing, '}')
+			i++
+		case '(':
+			closing = append(closing, ')')
+			i++
+		case ']', '}', ')':
+			if len(closing) == 0 || closing[len(closing)-1] != c {
+				return 0
+			}
+			closing = closing[:len(closing)-1]
+			i++
+			if len(closing) == 0 {
+				return i
+			}
+		case '\'':
+			i++
+			for {
+				if i >= len(b) {
+					return 0
+				}
+				if b[i] == escapeCharacter {
+					i += 2
+					continue
+				}
+				i++
+				if b[i-1] == '\'' {
+					if i < len(b) && b[i] == '\'' {
+						// doubled quote
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case ',', ':', '-', '+', '.', ' ', '\t', '\n', '\r':
+			i++
+		default:
+			// numbers, including their exponent or hexadecimal digits, and constant keywords
+			j := i
+			for j < len(b) && (isDigit(rune(b[j])) || b[j] == '.' || b[j] == '_' || ('a' <= b[j]|0x20 && b[j]|0x20 <= 'z')) {
+				j++
+			}
+			if j == i {
+				return 0
+			}
+			if !isDigit(rune(c)) {
+				switch string(bytes.ToUpper(b[i:j])) {
+				case "NULL", "TRUE", "FALSE":
+				default:
+					return 0
+				}
+			}
+			i = j
+		}
+	}
+	return 0
+}
+
+// queryParameterLen returns the length of the ClickHouse query parameter at the start of b, such as
+// {id:UInt32} or {ids:Array(String)}, or 0 if b does not start with a query parameter.
+func queryParameterLen(b []byte) int {
+	i := 1 // skip the opening brace
+	for i < len(b) && (isLetter(rune(b[i])) || (i > 1 && isDigit(rune(b[i])))) {
+		i++
+	}
+	if i == 1 || i >= len(b) || b[i] != ':' {
+		return 0
+	}
+	for i++; i < len(b); i++ {
+		switch b[i] {
+		case '}':
+			return i + 1
+		case '\'', '"', '{':
+			return 0
+		}
+	}
+	return 0
+}
+
 func (tkn *SQLTokenizer) scanVariableIdentifier(prefix rune) (TokenKind, []byte) {
 	for tkn.advance(); tkn.lastChar != ')' && tkn.lastChar != EndChar; tkn.advance() {
 	}
@@ -591,6 +852,17 @@ func (tkn *SQLTokenizer) scanBindVar() (TokenKind, []byte) {
 		token = ListArg
 		tkn.advance()
 	}
+	if tkn.cfg.DBMS == DBMSOracle && tkn.lastChar == '"' {
+		// quoted bind variable, e.g. :"Name"
+		for tkn.advance(); tkn.lastChar != '"'; tkn.advance() {
+			if tkn.lastChar == EndChar {
+				tkn.setErr("unexpected EOF in bind variable")
+				return LexError, tkn.bytes()
+			}
+		}
+		tkn.advance()
+		return token, tkn.bytes()
+	}
 	if !isLetter(tkn.lastChar) && !isDigit(tkn.lastChar) {
 		tkn.setErr(`bind variables should start with letters or digits, got "%c" (%d)`, tkn.lastChar, tkn.lastChar)
 		return LexError, tkn.bytes()
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	defaultPort            = 9201
+	defaultPath            = "/api/v1/write"
+	defaultMaxRequestBytes = 10 * 1024 * 1024
+)
+
+// IsEnabled returns whether the Prometheus remote-write receiver is enabled in the Agent configuration.
+func IsEnabled() bool {
+	return config.Datadog.GetBool("prometheus_remote_write.enabled")
+}
+
+// Config contains the configuration of the Prometheus remote-write receiver.
+// YAML field tags provided for test marshalling purposes.
+type Config struct {
+	Enabled  bool   `mapstructure:"enabled" yaml:"enabled"`
+	Port     uint16 `mapstructure:"port" yaml:"port"`
+	BindHost string `mapstructure:"bind_host" yaml:"bind_host"`
+	Path     string `mapstructure:"path" yaml:"path"`
+	// Namespace is prepended to the names of the metrics, followed by a dot.
+	Namespace string `mapstructure:"namespace" yaml:"namespace"`
+	// LabelsAllowlist lists the labels which are converted to tags. All the labels are converted when empty.
+	LabelsAllowlist []string `mapstructure:"labels_allowlist" yaml:"labels_allowlist"`
+	// LabelsMapper renames the tag keys of the given labels.
+	LabelsMapper map[string]string `mapstructure:"labels_mapper" yaml:"labels_mapper"`
+	// Tags are added to all the metrics.
+	Tags []string `mapstructure:"tags" yaml:"tags"`
+	// MaxRequestBytes is the maximum size of the decompressed write requests.
+	MaxRequestBytes int `mapstructure:"max_request_bytes" yaml:"max_request_bytes"`
+}
+
+// ReadConfig builds and returns the configuration of the receiver from the Agent configuration.
+func ReadConfig() (*Config, error) {
+	var c Config
+	if err := config.Datadog.UnmarshalKey("prometheus_remote_write", &c); err != nil {
+		return nil, err
+	}
+
+	if !c.Enabled {
+		return nil, errors.New("prometheus remote-write receiver is disabled")
+	}
+
+	// Set defaults.
+	if c.Port == 0 {
+		c.Port = defaultPort
+	}
+	if c.BindHost == "" {
+		c.BindHost = config.GetBindHost()
+	}
+	if c.Path == "" {
+		c.Path = defaultPath
+	}
+	if !strings.HasPrefix(c.Path, "/") {
+		c.Path = "/" + c.Path
+	}
+	if c.MaxRequestBytes <= 0 {
+		c.MaxRequestBytes = defaultMaxRequestBytes
+	}
+	c.Namespace = strings.TrimSuffix(c.Namespace, ".")
+	return &c, nil
+}
@@ -0,0 +1,358 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// staleNaN is the value of the samples marking a series as stale.
+const staleNaN = 0x7ff0000000000002
+
+// converter maps the series of the write requests to Datadog metrics:
+//   - counters are submitted as monotonic counts, named after their family with a ".count" suffix,
+//   - the buckets of the classic and native histograms are submitted as distributions, named after
+//     their family, and their sum and count as monotonic counts with ".sum" and ".count" suffixes,
+//   - the quantiles of the summaries are submitted as gauges with a ".quantile" suffix,
+//   - all other series are submitted as gauges.
+//
+// The types of the families are read from the metadata of the requests when available, and guessed
+// from the suffixes of the series names otherwise.
+//
+// Only the most recent sample and native histogram of each series of a request are submitted, the
+// older ones are dropped and counted in the dropped_samples telemetry. The sender has no timestamps:
+// all the samples of a request would be aggregated in the same interval, where the last value of a
+// gauge wins and the counters only need their last cumulative value anyway.
+type converter struct {
+	namespace string
+	allowlist map[string]bool
+	mapper    map[string]string
+	tags      []string
+	// families holds the types of the metric families received in the metadata of the requests,
+	// which are not sent along with every series.
+	families map[string]metricType
+}
+
+func newConverter(c *Config) *converter {
+	conv := &converter{
+		namespace: c.Namespace,
+		mapper:    c.LabelsMapper,
+		tags:      c.Tags,
+		families:  make(map[string]metricType),
+	}
+	if len(c.LabelsAllowlist) > 0 {
+		conv.allowlist = make(map[string]bool, len(c.LabelsAllowlist))
+		for _, l := range c.LabelsAllowlist {
+			conv.allowlist[l] = true
+		}
+	}
+	return conv
+}
+
+// classicHistogram holds the cumulative buckets of a classic histogram series.
+type classicHistogram struct {
+	name      string
+	tags      []string
+	monotonic bool
+	buckets   []classicBucket
+}
+
+type classicBucket struct {
+	upperBound float64
+	count      float64
+}
+
+// convert submits the series of req to sender.
+func (c *converter) convert(req *writeRequest, sender aggregator.Sender) {
+	for _, md := range req.metadata {
+		if md.familyName != "" && md.typ != metricTypeUnknown {
+			c.families[md.familyName] = md.typ
+		}
+	}
+
+	// the classic histograms are identified by their buckets when there is no metadata
+	bucketFamilies := make(map[string]bool)
+	for _, ts := range req.timeseries {
+		if name := labelValue(ts.labels, "__name__"); strings.HasSuffix(name, "_bucket") && labelValue(ts.labels, "le") != "" {
+			bucketFamilies[strings.TrimSuffix(name, "_bucket")] = true
+		}
+	}
+
+	var (
+		histograms = make(map[string]*classicHistogram)
+		keys       []string // keys of histograms, in order of appearance
+	)
+	for _, ts := range req.timeseries {
+		name := labelValue(ts.labels, "__name__")
+		if name == "" {
+			continue
+		}
+		if h, dropped, ok := latestHistogram(ts.histograms); ok {
+			c.submitNativeHistogram(sender, name, c.labelsToTags(ts.labels, ""), h)
+			tlmDroppedSamples.Add(float64(dropped))
+		}
+		s, dropped, ok := latestSample(ts.samples)
+		if !ok {
+			continue
+		}
+		tlmDroppedSamples.Add(float64(dropped))
+
+		switch family, suffix := splitSuffix(name); {
+		case suffix == "_bucket" && labelValue(ts.labels, "le") != "" && c.isHistogram(family, bucketFamilies):
+			le, err := strconv.ParseFloat(labelValue(ts.labels, "le"), 64)
+			if err != nil {
+				log.Debugf("Skipping the bucket of %s with an invalid \"le\" label: %v", name, err)
+				continue
+			}
+			tags := c.labelsToTags(ts.labels, "le")
+			key := family + "\xff" + strings.Join(tags, "\xff")
+			h, ok := histograms[key]
+			if !ok {
+				h = &classicHistogram{
+					name:      c.metricName(family),
+					tags:      tags,
+					monotonic: c.families[family] != metricTypeGaugeHistogram,
+				}
+				histograms[key] = h
+				keys = append(keys, key)
+			}
+			h.buckets = append(h.buckets, classicBucket{upperBound: le, count: s.value})
+		case (suffix == "_sum" || suffix == "_count") && (c.isHistogram(family, bucketFamilies) || c.families[family] == metricTypeSummary):
+			metric := c.metricName(family + "." + suffix[1:])
+			if c.families[family] == metricTypeGaugeHistogram {
+				sender.Gauge(metric, s.value, "", c.labelsToTags(ts.labels, ""))
+				tlmSamples.Inc("gauge")
+				continue
+			}
+			sender.MonotonicCount(metric, s.value, "", c.labelsToTags(ts.labels, ""))
+			tlmSamples.Inc("count")
+		case c.families[name] == metricTypeCounter:
+			sender.MonotonicCount(c.metricName(strings.TrimSuffix(name, "_total")+".count"), s.value, "", c.labelsToTags(ts.labels, ""))
+			tlmSamples.Inc("count")
+		case suffix == "_total" && c.families[name] == metricTypeUnknown && (c.families[family] == metricTypeCounter || c.families[family] == metricTypeUnknown):
+			sender.MonotonicCount(c.metricName(family+".count"), s.value, "", c.labelsToTags(ts.labels, ""))
+			tlmSamples.Inc("count")
+		case c.families[name] == metricTypeSummary && labelValue(ts.labels, "quantile") != "":
+			sender.Gauge(c.metricName(name+".quantile"), s.value, "", c.labelsToTags(ts.labels, ""))
+			tlmSamples.Inc("gauge")
+		default:
+			sender.Gauge(c.metricName(name), s.value, "", c.labelsToTags(ts.labels, ""))
+			tlmSamples.Inc("gauge")
+		}
+	}
+
+	for _, key := range keys {
+		c.submitClassicHistogram(sender, histograms[key])
+	}
+}
+
+// isHistogram reports whether family is a classic histogram.
+func (c *converter) isHistogram(family string, bucketFamilies map[string]bool) bool {
+	switch c.families[family] {
+	case metricTypeHistogram, metricTypeGaugeHistogram:
+		return true
+	case metricTypeUnknown:
+		return bucketFamilies[family]
+	}
+	return false
+}
+
+// submitClassicHistogram submits the buckets of h, converted from cumulative to per-bucket counts.
+func (c *converter) submitClassicHistogram(sender aggregator.Sender, h *classicHistogram) {
+	sort.Slice(h.buckets, func(i, j int) bool { return h.buckets[i].upperBound < h.buckets[j].upperBound })
+	var (
+		lowerBound float64
+		cumulative float64
+	)
+	for i, b := range h.buckets {
+		if i == 0 && b.upperBound < 0 {
+			lowerBound = b.upperBound
+		}
+		c.submitBucket(sender, h.name, h.tags, b.count-cumulative, lowerBound, b.upperBound, h.monotonic)
+		lowerBound, cumulative = b.upperBound, b.count
+	}
+	tlmSamples.Inc("histogram")
+}
+
+// submitNativeHistogram submits the buckets of the native histogram h, along with its sum and count.
+func (c *converter) submitNativeHistogram(sender aggregator.Sender, name string, tags []string, h *histogram) {
+	monotonic := h.resetHint != resetHintGauge
+	submitCount := sender.MonotonicCount
+	if !monotonic {
+		submitCount = sender.Gauge
+	}
+	submitCount(c.metricName(name+".sum"), h.sum, "", tags)
+	submitCount(c.metricName(name+".count"), h.count, "", tags)
+	tlmSamples.Inc("histogram")
+
+	if h.schema < -4 || h.schema > 8 {
+		log.Debugf("Skipping the buckets of the native histogram %s with the unsupported schema %d", name, h.schema)
+		return
+	}
+	metric := c.metricName(name)
+	// the upper bound of the positive bucket at the given index is base^index, with base = 2^(2^-schema)
+	step := math.Exp2(-float64(h.schema))
+	bound := func(index int32) float64 { return math.Exp2(float64(index) * step) }
+
+	forEachBucket(h.negativeSpans, h.negativeDeltas, h.negativeCounts, func(index int32, count float64) {
+		c.submitBucket(sender, metric, tags, count, -bound(index), -bound(index-1), monotonic)
+	})
+	if h.zeroCount > 0 {
+		c.submitBucket(sender, metric, tags, h.zeroCount, -h.zeroThreshold, h.zeroThreshold, monotonic)
+	}
+	forEachBucket(h.positiveSpans, h.positiveDeltas, h.positiveCounts, func(index int32, count float64) {
+		c.submitBucket(sender, metric, tags, count, bound(index-1), bound(index), monotonic)
+	})
+}
+
+// submitBucket submits a histogram bucket. The bounds of the bucket are added to its tags, as the
+// monotonic buckets are tracked by context.
+func (c *converter) submitBucket(sender aggregator.Sender, metric string, tags []string, count, lowerBound, upperBound float64, monotonic bool) {
+	if count < 0 || math.IsNaN(count) {
+		return
+	}
+	bucketTags := make([]string, 0, len(tags)+2)
+	bucketTags = append(bucketTags, tags...)
+	bucketTags = append(bucketTags, "lower_bound:"+formatBound(lowerBound), "upper_bound:"+formatBound(upperBound))
+	sender.HistogramBucket(metric, int64(math.Round(count)), lowerBound, upperBound, monotonic, "", bucketTags, false)
+}
+
+// forEachBucket calls fn with the index and the count of each bucket described by the spans. The counts
+// are given either as deltas from the previous bucket, or as absolute counts.
+func forEachBucket(spans []bucketSpan, deltas []int64, counts []float64, fn func(index int32, count float64)) {
+	var (
+		index   int32
+		i       int
+		current int64
+	)
+	for _, s := range spans {
+		// the offset of the first span is the index of its first bucket, the following offsets are
+		// the gaps between the spans
+		index += s.offset
+		for j := uint32(0); j < s.length; j, index, i = j+1, index+1, i+1 {
+			if len(counts) > 0 {
+				if i >= len(counts) {
+					return
+				}
+				fn(index, counts[i])
+				continue
+			}
+			if i >= len(deltas) {
+				return
+			}
+			current += deltas[i]
+			fn(index, float64(current))
+		}
+	}
+}
+
+// labelsToTags converts the labels, except the metric name and the skipped label, to tags according to
+// the allowlist and the mapper, and adds the configured tags.
+func (c *converter) labelsToTags(labels []label, skip string) []string {
+	tags := make([]string, 0, len(labels)+len(c.tags))
+	for _, l := range labels {
+		if l.name == "__name__" || l.name == skip || l.value == "" {
+			continue
+		}
+		if c.allowlist != nil && !c.allowlist[l.name] {
+			continue
+		}
+		key := l.name
+		if mapped, ok := c.mapper[key]; ok {
+			key = mapped
+		}
+		tags = append(tags, key+":"+l.value)
+	}
+	return append(tags, c.tags...)
+}
+
+func (c *converter) metricName(name string) string {
+	if c.namespace == "" {
+		return name
+	}
+	return c.namespace + "." + name
+}
+
+// splitSuffix splits the series name into its family name and the suffixes used by the counters and
+// the histograms, if any.
+func splitSuffix(name string) (family, suffix string) {
+	for _, suffix := range []string{"_bucket", "_sum", "_count", "_total"} {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix), suffix
+		}
+	}
+	return name, ""
+}
+
+func labelValue(labels []label, name string) string {
+	for _, l := range labels {
+		if l.name == name {
+			return l.value
+		}
+	}
+	return ""
+}
+
+// latestSample returns the most recent sample which is a number, and the number of older samples
+// which are numbers, that are dropped.
+func latestSample(samples []sample) (sample, int, bool) {
+	var (
+		latest sample
+		found  int
+	)
+	for _, s := range samples {
+		if math.IsNaN(s.value) {
+			continue
+		}
+		if found == 0 || s.timestamp >= latest.timestamp {
+			latest = s
+		}
+		found++
+	}
+	if found == 0 {
+		return latest, 0, false
+	}
+	return latest, found - 1, true
+}
+
+// latestHistogram returns the most recent native histogram which is not a staleness marker, and the
+// number of older histograms which are not staleness markers, that are dropped.
+func latestHistogram(histograms []histogram) (*histogram, int, bool) {
+	var (
+		latest *histogram
+		found  int
+	)
+	for i := range histograms {
+		h := &histograms[i]
+		if math.Float64bits(h.sum) == staleNaN {
+			continue
+		}
+		if latest == nil || h.timestamp >= latest.timestamp {
+			latest = h
+		}
+		found++
+	}
+	if latest == nil {
+		return nil, 0, false
+	}
+	return latest, found - 1, true
+}
+
+func formatBound(b float64) string {
+	switch {
+	case math.IsInf(b, 1):
+		return "inf"
+	case math.IsInf(b, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(b, 'f', -1, 64)
+}
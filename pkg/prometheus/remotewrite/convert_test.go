@@ -0,0 +1,228 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+func newTestSender(id string) *mocksender.MockSender {
+	sender := mocksender.NewMockSender(check.ID("prometheus_remote_write_" + id))
+	sender.SetupAcceptAll()
+	return sender
+}
+
+func series(name string, value float64, labels ...label) timeSeries {
+	return timeSeries{
+		labels:  append([]label{{"__name__", name}}, labels...),
+		samples: []sample{{value: value, timestamp: 1000}},
+	}
+}
+
+func TestConvertGaugesAndCounters(t *testing.T) {
+	sender := newTestSender(t.Name())
+	conv := newConverter(&Config{})
+	conv.convert(&writeRequest{
+		timeseries: []timeSeries{
+			series("node_load1", 0.5, label{"instance", "host:9100"}, label{"job", "node"}),
+			series("http_requests_total", 42, label{"code", "200"}),
+			series("process_cpu_seconds", 3, label{"job", "node"}),
+			{
+				labels:  []label{{"__name__", "up"}},
+				samples: []sample{{1, 2000}, {0, 1000}, {math.Float64frombits(staleNaN), 3000}},
+			},
+		},
+		metadata: []metricMetadata{{typ: metricTypeCounter, familyName: "process_cpu_seconds"}},
+	}, sender)
+
+	sender.AssertMetric(t, "Gauge", "node_load1", 0.5, "", []string{"instance:host:9100", "job:node"})
+	sender.AssertMetric(t, "MonotonicCount", "http_requests.count", 42, "", []string{"code:200"})
+	sender.AssertMetric(t, "MonotonicCount", "process_cpu_seconds.count", 3, "", []string{"job:node"})
+	// the latest sample is submitted, and the staleness markers are ignored
+	sender.AssertMetric(t, "Gauge", "up", 1, "", nil)
+	sender.AssertNumberOfCalls(t, "Gauge", 2)
+	sender.AssertNumberOfCalls(t, "MonotonicCount", 2)
+}
+
+// TestConvertDownsamples tests that only the most recent sample of a series is submitted: the sender
+// has no timestamps, so the older samples of a request are dropped on purpose.
+func TestConvertDownsamples(t *testing.T) {
+	sender := newTestSender(t.Name())
+	conv := newConverter(&Config{})
+	conv.convert(&writeRequest{
+		timeseries: []timeSeries{
+			{
+				labels:  []label{{"__name__", "node_load1"}},
+				samples: []sample{{0.5, 1000}, {0.7, 3000}, {0.6, 2000}},
+			},
+			{
+				labels:  []label{{"__name__", "http_requests_total"}},
+				samples: []sample{{40, 1000}, {42, 2000}},
+			},
+		},
+	}, sender)
+
+	sender.AssertMetric(t, "Gauge", "node_load1", 0.7, "", nil)
+	sender.AssertMetric(t, "MonotonicCount", "http_requests.count", 42, "", nil)
+	sender.AssertNumberOfCalls(t, "Gauge", 1)
+	sender.AssertNumberOfCalls(t, "MonotonicCount", 1)
+
+	s, dropped, ok := latestSample([]sample{{0.5, 1000}, {math.NaN(), 4000}, {0.7, 3000}, {0.6, 2000}})
+	assert.True(t, ok)
+	assert.Equal(t, sample{0.7, 3000}, s)
+	assert.Equal(t, 2, dropped)
+	_, dropped, ok = latestSample([]sample{{math.Float64frombits(staleNaN), 1000}})
+	assert.False(t, ok)
+	assert.Zero(t, dropped)
+
+	h, dropped, ok := latestHistogram([]histogram{{count: 1, timestamp: 2000}, {count: 2, timestamp: 1000}})
+	assert.True(t, ok)
+	assert.Equal(t, float64(1), h.count)
+	assert.Equal(t, 1, dropped)
+}
+
+func TestConvertMetadataIsRemembered(t *testing.T) {
+	sender := newTestSender(t.Name())
+	conv := newConverter(&Config{})
+	conv.convert(&writeRequest{
+		metadata: []metricMetadata{{typ: metricTypeGauge, familyName: "queue_total"}},
+	}, sender)
+	conv.convert(&writeRequest{
+		timeseries: []timeSeries{series("queue_total", 12)},
+	}, sender)
+
+	sender.AssertMetric(t, "Gauge", "queue_total", 12, "", nil)
+	sender.AssertNotCalled(t, "MonotonicCount", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestConvertTags(t *testing.T) {
+	sender := newTestSender(t.Name())
+	conv := newConverter(&Config{
+		Namespace:       "prom",
+		LabelsAllowlist: []string{"job", "instance", "pod"},
+		LabelsMapper:    map[string]string{"instance": "prom_instance"},
+		Tags:            []string{"source:remote_write"},
+	})
+	conv.convert(&writeRequest{
+		timeseries: []timeSeries{
+			series("up", 1, label{"instance", "10.0.0.1:9100"}, label{"job", "node"}, label{"pod", ""}, label{"version", "1.2"}),
+		},
+	}, sender)
+
+	sender.AssertCalled(t, "Gauge", "prom.up", 1.0, "", []string{"prom_instance:10.0.0.1:9100", "job:node", "source:remote_write"})
+}
+
+func TestConvertClassicHistogram(t *testing.T) {
+	sender := newTestSender(t.Name())
+	conv := newConverter(&Config{Tags: []string{"env:test"}})
+	job := label{"job", "api"}
+	conv.convert(&writeRequest{
+		// the buckets are not necessarily sorted
+		timeseries: []timeSeries{
+			series("request_duration_seconds_bucket", 10, job, label{"le", "+Inf"}),
+			series("request_duration_seconds_bucket", 3, job, label{"le", "0.1"}),
+			series("request_duration_seconds_bucket", 7, job, label{"le", "0.5"}),
+			series("request_duration_seconds_sum", 2.5, job),
+			series("request_duration_seconds_count", 10, job),
+		},
+	}, sender)
+
+	tags := func(lower, upper string) []string {
+		return []string{"job:api", "env:test", "lower_bound:" + lower, "upper_bound:" + upper}
+	}
+	sender.AssertHistogramBucket(t, "HistogramBucket", "request_duration_seconds", 3, 0, 0.1, true, "", tags("0", "0.1"), false)
+	sender.AssertHistogramBucket(t, "HistogramBucket", "request_duration_seconds", 4, 0.1, 0.5, true, "", tags("0.1", "0.5"), false)
+	sender.AssertHistogramBucket(t, "HistogramBucket", "request_duration_seconds", 3, 0.5, math.Inf(1), true, "", tags("0.5", "inf"), false)
+	sender.AssertMetric(t, "MonotonicCount", "request_duration_seconds.sum", 2.5, "", []string{"job:api", "env:test"})
+	sender.AssertMetric(t, "MonotonicCount", "request_duration_seconds.count", 10, "", []string{"job:api", "env:test"})
+	sender.AssertNumberOfCalls(t, "Gauge", 0)
+}
+
+func TestConvertSummary(t *testing.T) {
+	sender := newTestSender(t.Name())
+	conv := newConverter(&Config{})
+	conv.convert(&writeRequest{
+		timeseries: []timeSeries{
+			series("rpc_duration_seconds", 0.05, label{"quantile", "0.5"}),
+			series("rpc_duration_seconds_sum", 17, label{"quantile", ""}),
+			series("rpc_duration_seconds_count", 100),
+		},
+		metadata: []metricMetadata{{typ: metricTypeSummary, familyName: "rpc_duration_seconds"}},
+	}, sender)
+
+	sender.AssertMetric(t, "Gauge", "rpc_duration_seconds.quantile", 0.05, "", []string{"quantile:0.5"})
+	sender.AssertMetric(t, "MonotonicCount", "rpc_duration_seconds.sum", 17, "", nil)
+	sender.AssertMetric(t, "MonotonicCount", "rpc_duration_seconds.count", 100, "", nil)
+}
+
+func TestConvertNativeHistogram(t *testing.T) {
+	sender := newTestSender(t.Name())
+	conv := newConverter(&Config{})
+	conv.convert(&writeRequest{
+		timeseries: []timeSeries{{
+			labels: []label{{"__name__", "latency"}, {"job", "api"}},
+			histograms: []histogram{
+				{count: 1, sum: 1, timestamp: 1000},
+				{
+					count:          9,
+					sum:            20,
+					zeroThreshold:  0.001,
+					zeroCount:      1,
+					negativeSpans:  []bucketSpan{{offset: 1, length: 1}},
+					negativeDeltas: []int64{2},
+					// buckets 1 and 2, then 4
+					positiveSpans:  []bucketSpan{{offset: 1, length: 2}, {offset: 1, length: 1}},
+					positiveDeltas: []int64{3, -2, 1},
+					timestamp:      2000,
+				},
+			},
+		}},
+	}, sender)
+
+	tags := func(lower, upper string) []string {
+		return []string{"job:api", "lower_bound:" + lower, "upper_bound:" + upper}
+	}
+	sender.AssertMetric(t, "MonotonicCount", "latency.sum", 20, "", []string{"job:api"})
+	sender.AssertMetric(t, "MonotonicCount", "latency.count", 9, "", []string{"job:api"})
+	sender.AssertHistogramBucket(t, "HistogramBucket", "latency", 2, -2, -1, true, "", tags("-2", "-1"), false)
+	sender.AssertHistogramBucket(t, "HistogramBucket", "latency", 1, -0.001, 0.001, true, "", tags("-0.001", "0.001"), false)
+	sender.AssertHistogramBucket(t, "HistogramBucket", "latency", 3, 1, 2, true, "", tags("1", "2"), false)
+	sender.AssertHistogramBucket(t, "HistogramBucket", "latency", 1, 2, 4, true, "", tags("2", "4"), false)
+	sender.AssertHistogramBucket(t, "HistogramBucket", "latency", 2, 8, 16, true, "", tags("8", "16"), false)
+	sender.AssertNumberOfCalls(t, "HistogramBucket", 5)
+}
+
+func TestConvertNativeGaugeHistogram(t *testing.T) {
+	sender := newTestSender(t.Name())
+	conv := newConverter(&Config{})
+	conv.convert(&writeRequest{
+		timeseries: []timeSeries{{
+			labels: []label{{"__name__", "queue_size"}},
+			histograms: []histogram{{
+				count:          2.5,
+				sum:            6,
+				schema:         1,
+				positiveSpans:  []bucketSpan{{offset: 2, length: 1}},
+				positiveCounts: []float64{2.5},
+				resetHint:      resetHintGauge,
+			}},
+		}},
+	}, sender)
+
+	sender.AssertMetric(t, "Gauge", "queue_size.sum", 6, "", nil)
+	sender.AssertMetric(t, "Gauge", "queue_size.count", 2.5, "", nil)
+	// with schema 1, the bucket 2 covers (2^0.5, 2]
+	sender.AssertCalled(t, "HistogramBucket", "queue_size", int64(3), mock.MatchedBy(func(lower float64) bool {
+		return math.Abs(lower-math.Sqrt2) < 1e-12
+	}), 2.0, false, "", mock.Anything, false)
+}
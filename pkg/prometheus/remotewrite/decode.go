@@ -0,0 +1,384 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The types below mirror the messages of the Prometheus remote-write protocol (prompb), of which only
+// the fields used by the receiver are decoded. The exemplars are skipped.
+
+// metricType is the type of a metric family, as sent in the metadata of the write requests.
+type metricType int32
+
+const (
+	metricTypeUnknown        metricType = 0
+	metricTypeCounter        metricType = 1
+	metricTypeGauge          metricType = 2
+	metricTypeHistogram      metricType = 3
+	metricTypeGaugeHistogram metricType = 4
+	metricTypeSummary        metricType = 5
+)
+
+// resetHintGauge is the reset hint of the native histograms which are gauge histograms.
+const resetHintGauge = 3
+
+type writeRequest struct {
+	timeseries []timeSeries
+	metadata   []metricMetadata
+}
+
+type timeSeries struct {
+	labels     []label
+	samples    []sample
+	histograms []histogram
+}
+
+type label struct {
+	name, value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64
+}
+
+type metricMetadata struct {
+	typ        metricType
+	familyName string
+}
+
+// histogram is a native histogram. The bucket counts are either given as deltas from the previous
+// bucket (integer histograms) or as absolute counts (float histograms).
+type histogram struct {
+	count          float64
+	sum            float64
+	schema         int32
+	zeroThreshold  float64
+	zeroCount      float64
+	negativeSpans  []bucketSpan
+	negativeDeltas []int64
+	negativeCounts []float64
+	positiveSpans  []bucketSpan
+	positiveDeltas []int64
+	positiveCounts []float64
+	resetHint      int32
+	timestamp      int64
+}
+
+type bucketSpan struct {
+	offset int32
+	length uint32
+}
+
+// message calls fn for each field of the protobuf message b.
+func message(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		n, err := fn(num, typ, b)
+		if err != nil {
+			return fmt.Errorf("field %d: %v", num, err)
+		}
+		if n == 0 {
+			// unknown field
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("field %d: %v", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// errWireType is returned when a known field is not encoded with the expected wire type.
+var errWireType = errors.New("unexpected wire type")
+
+func consumeBytes(typ protowire.Type, b []byte) ([]byte, int, error) {
+	if typ != protowire.BytesType {
+		return nil, 0, errWireType
+	}
+	v, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return nil, 0, protowire.ParseError(n)
+	}
+	return v, n, nil
+}
+
+func consumeVarint(typ protowire.Type, b []byte) (uint64, int, error) {
+	if typ != protowire.VarintType {
+		return 0, 0, errWireType
+	}
+	v, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return 0, 0, protowire.ParseError(n)
+	}
+	return v, n, nil
+}
+
+func consumeDouble(typ protowire.Type, b []byte) (float64, int, error) {
+	if typ != protowire.Fixed64Type {
+		return 0, 0, errWireType
+	}
+	v, n := protowire.ConsumeFixed64(b)
+	if n < 0 {
+		return 0, 0, protowire.ParseError(n)
+	}
+	return math.Float64frombits(v), n, nil
+}
+
+// decodeWriteRequest decodes a serialized prompb.WriteRequest.
+func decodeWriteRequest(b []byte) (*writeRequest, error) {
+	var req writeRequest
+	err := message(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n, err := consumeBytes(typ, b)
+			if err != nil {
+				return 0, err
+			}
+			var ts timeSeries
+			if err := decodeTimeSeries(v, &ts); err != nil {
+				return 0, err
+			}
+			req.timeseries = append(req.timeseries, ts)
+			return n, nil
+		case 3:
+			v, n, err := consumeBytes(typ, b)
+			if err != nil {
+				return 0, err
+			}
+			var md metricMetadata
+			if err := decodeMetadata(v, &md); err != nil {
+				return 0, err
+			}
+			req.metadata = append(req.metadata, md)
+			return n, nil
+		}
+		return 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func decodeTimeSeries(b []byte, ts *timeSeries) error {
+	return message(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n, err := consumeBytes(typ, b)
+			if err != nil {
+				return 0, err
+			}
+			var l label
+			if err := decodeLabel(v, &l); err != nil {
+				return 0, err
+			}
+			ts.labels = append(ts.labels, l)
+			return n, nil
+		case 2:
+			v, n, err := consumeBytes(typ, b)
+			if err != nil {
+				return 0, err
+			}
+			var s sample
+			if err := decodeSample(v, &s); err != nil {
+				return 0, err
+			}
+			ts.samples = append(ts.samples, s)
+			return n, nil
+		case 4:
+			v, n, err := consumeBytes(typ, b)
+			if err != nil {
+				return 0, err
+			}
+			var h histogram
+			if err := decodeHistogram(v, &h); err != nil {
+				return 0, err
+			}
+			ts.histograms = append(ts.histograms, h)
+			return n, nil
+		}
+		return 0, nil
+	})
+}
+
+func decodeLabel(b []byte, l *label) error {
+	return message(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1, 2:
+			v, n, err := consumeBytes(typ, b)
+			if err != nil {
+				return 0, err
+			}
+			if num == 1 {
+				l.name = string(v)
+			} else {
+				l.value = string(v)
+			}
+			return n, nil
+		}
+		return 0, nil
+	})
+}
+
+func decodeSample(b []byte, s *sample) error {
+	return message(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n, err := consumeDouble(typ, b)
+			s.value = v
+			return n, err
+		case 2:
+			v, n, err := consumeVarint(typ, b)
+			s.timestamp = int64(v)
+			return n, err
+		}
+		return 0, nil
+	})
+}
+
+func decodeMetadata(b []byte, md *metricMetadata) error {
+	return message(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n, err := consumeVarint(typ, b)
+			md.typ = metricType(v)
+			return n, err
+		case 2:
+			v, n, err := consumeBytes(typ, b)
+			md.familyName = string(v)
+			return n, err
+		}
+		return 0, nil
+	})
+}
+
+func decodeHistogram(b []byte, h *histogram) error {
+	return message(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1, 6:
+			v, n, err := consumeVarint(typ, b)
+			if num == 1 {
+				h.count = float64(v)
+			} else {
+				h.zeroCount = float64(v)
+			}
+			return n, err
+		case 2, 3, 5, 7:
+			v, n, err := consumeDouble(typ, b)
+			switch num {
+			case 2:
+				h.count = v
+			case 3:
+				h.sum = v
+			case 5:
+				h.zeroThreshold = v
+			case 7:
+				h.zeroCount = v
+			}
+			return n, err
+		case 4:
+			v, n, err := consumeVarint(typ, b)
+			h.schema = int32(protowire.DecodeZigZag(v & math.MaxUint32))
+			return n, err
+		case 8, 11:
+			v, n, err := consumeBytes(typ, b)
+			if err != nil {
+				return 0, err
+			}
+			var s bucketSpan
+			if err := decodeBucketSpan(v, &s); err != nil {
+				return 0, err
+			}
+			if num == 8 {
+				h.negativeSpans = append(h.negativeSpans, s)
+			} else {
+				h.positiveSpans = append(h.positiveSpans, s)
+			}
+			return n, nil
+		case 9, 12:
+			deltas := &h.negativeDeltas
+			if num == 12 {
+				deltas = &h.positiveDeltas
+			}
+			return consumeRepeated(typ, b, protowire.VarintType, func(b []byte) int {
+				v, n := protowire.ConsumeVarint(b)
+				*deltas = append(*deltas, protowire.DecodeZigZag(v))
+				return n
+			})
+		case 10, 13:
+			counts := &h.negativeCounts
+			if num == 13 {
+				counts = &h.positiveCounts
+			}
+			return consumeRepeated(typ, b, protowire.Fixed64Type, func(b []byte) int {
+				v, n := protowire.ConsumeFixed64(b)
+				*counts = append(*counts, math.Float64frombits(v))
+				return n
+			})
+		case 14:
+			v, n, err := consumeVarint(typ, b)
+			h.resetHint = int32(v)
+			return n, err
+		case 15:
+			v, n, err := consumeVarint(typ, b)
+			h.timestamp = int64(v)
+			return n, err
+		}
+		return 0, nil
+	})
+}
+
+func decodeBucketSpan(b []byte, s *bucketSpan) error {
+	return message(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n, err := consumeVarint(typ, b)
+			s.offset = int32(protowire.DecodeZigZag(v & math.MaxUint32))
+			return n, err
+		case 2:
+			v, n, err := consumeVarint(typ, b)
+			s.length = uint32(v)
+			return n, err
+		}
+		return 0, nil
+	})
+}
+
+// consumeRepeated consumes the values of a repeated scalar field, which may be packed or not. The
+// consume function consumes a single value and returns its length, or a negative error code.
+func consumeRepeated(typ protowire.Type, b []byte, elemType protowire.Type, consume func([]byte) int) (int, error) {
+	if typ == elemType {
+		n := consume(b)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		return n, nil
+	}
+	packed, n, err := consumeBytes(typ, b)
+	if err != nil {
+		return 0, err
+	}
+	for len(packed) > 0 {
+		m := consume(packed)
+		if m < 0 {
+			return 0, protowire.ParseError(m)
+		}
+		packed = packed[m:]
+	}
+	return n, nil
+}
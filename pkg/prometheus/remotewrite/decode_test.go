@@ -0,0 +1,216 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// encodeWriteRequest serializes req as a prompb.WriteRequest. The histogram deltas are packed, as
+// done by Prometheus, unless unpacked is set.
+func encodeWriteRequest(req *writeRequest, unpacked bool) []byte {
+	var b []byte
+	for _, ts := range req.timeseries {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, encodeTimeSeries(&ts, unpacked))
+	}
+	for _, md := range req.metadata {
+		var m []byte
+		m = protowire.AppendTag(m, 1, protowire.VarintType)
+		m = protowire.AppendVarint(m, uint64(md.typ))
+		m = protowire.AppendTag(m, 2, protowire.BytesType)
+		m = protowire.AppendString(m, md.familyName)
+		m = protowire.AppendTag(m, 4, protowire.BytesType)
+		m = protowire.AppendString(m, "some help")
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, m)
+	}
+	return b
+}
+
+func encodeTimeSeries(ts *timeSeries, unpacked bool) []byte {
+	var b []byte
+	for _, l := range ts.labels {
+		var m []byte
+		m = protowire.AppendTag(m, 1, protowire.BytesType)
+		m = protowire.AppendString(m, l.name)
+		m = protowire.AppendTag(m, 2, protowire.BytesType)
+		m = protowire.AppendString(m, l.value)
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, m)
+	}
+	for _, s := range ts.samples {
+		var m []byte
+		m = protowire.AppendTag(m, 1, protowire.Fixed64Type)
+		m = protowire.AppendFixed64(m, math.Float64bits(s.value))
+		m = protowire.AppendTag(m, 2, protowire.VarintType)
+		m = protowire.AppendVarint(m, uint64(s.timestamp))
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, m)
+	}
+	for _, h := range ts.histograms {
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendBytes(b, encodeHistogram(&h, unpacked))
+	}
+	return b
+}
+
+func encodeHistogram(h *histogram, unpacked bool) []byte {
+	var b []byte
+	appendDouble := func(num protowire.Number, v float64) {
+		b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(v))
+	}
+	appendVarint := func(num protowire.Number, v uint64) {
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		b = protowire.AppendVarint(b, v)
+	}
+	appendSpans := func(num protowire.Number, spans []bucketSpan) {
+		for _, s := range spans {
+			var m []byte
+			m = protowire.AppendTag(m, 1, protowire.VarintType)
+			m = protowire.AppendVarint(m, protowire.EncodeZigZag(int64(s.offset)))
+			m = protowire.AppendTag(m, 2, protowire.VarintType)
+			m = protowire.AppendVarint(m, uint64(s.length))
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			b = protowire.AppendBytes(b, m)
+		}
+	}
+	appendDeltas := func(num protowire.Number, deltas []int64) {
+		if len(deltas) == 0 {
+			return
+		}
+		if unpacked {
+			for _, d := range deltas {
+				appendVarint(num, protowire.EncodeZigZag(d))
+			}
+			return
+		}
+		var m []byte
+		for _, d := range deltas {
+			m = protowire.AppendVarint(m, protowire.EncodeZigZag(d))
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, m)
+	}
+	appendCounts := func(num protowire.Number, counts []float64) {
+		if len(counts) == 0 {
+			return
+		}
+		var m []byte
+		for _, c := range counts {
+			m = protowire.AppendFixed64(m, math.Float64bits(c))
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, m)
+	}
+
+	float := len(h.positiveCounts) > 0 || len(h.negativeCounts) > 0
+	if float {
+		appendDouble(2, h.count)
+	} else {
+		appendVarint(1, uint64(h.count))
+	}
+	appendDouble(3, h.sum)
+	appendVarint(4, protowire.EncodeZigZag(int64(h.schema)))
+	appendDouble(5, h.zeroThreshold)
+	if float {
+		appendDouble(7, h.zeroCount)
+	} else {
+		appendVarint(6, uint64(h.zeroCount))
+	}
+	appendSpans(8, h.negativeSpans)
+	appendDeltas(9, h.negativeDeltas)
+	appendCounts(10, h.negativeCounts)
+	appendSpans(11, h.positiveSpans)
+	appendDeltas(12, h.positiveDeltas)
+	appendCounts(13, h.positiveCounts)
+	appendVarint(14, uint64(h.resetHint))
+	appendVarint(15, uint64(h.timestamp))
+	return b
+}
+
+func TestDecodeWriteRequest(t *testing.T) {
+	req := &writeRequest{
+		timeseries: []timeSeries{
+			{
+				labels:  []label{{"__name__", "up"}, {"job", "node"}},
+				samples: []sample{{1, 1000}, {0, 2000}},
+			},
+			{
+				labels: []label{{"__name__", "latency"}},
+				histograms: []histogram{{
+					count:          6,
+					sum:            12.5,
+					schema:         -1,
+					zeroThreshold:  0.001,
+					zeroCount:      1,
+					negativeSpans:  []bucketSpan{{offset: -2, length: 1}},
+					negativeDeltas: []int64{1},
+					positiveSpans:  []bucketSpan{{offset: 0, length: 2}, {offset: 1, length: 1}},
+					positiveDeltas: []int64{2, -1, 0},
+					timestamp:      3000,
+				}},
+			},
+			{
+				labels: []label{{"__name__", "size"}},
+				histograms: []histogram{{
+					count:          2.5,
+					sum:            4,
+					positiveSpans:  []bucketSpan{{offset: 1, length: 2}},
+					positiveCounts: []float64{1.5, 1},
+					resetHint:      resetHintGauge,
+				}},
+			},
+		},
+		metadata: []metricMetadata{{typ: metricTypeHistogram, familyName: "latency"}},
+	}
+
+	for _, unpacked := range []bool{false, true} {
+		got, err := decodeWriteRequest(encodeWriteRequest(req, unpacked))
+		require.NoError(t, err)
+		assert.Equal(t, req, got)
+	}
+}
+
+func TestDecodeWriteRequestSkipsUnknownFields(t *testing.T) {
+	var b []byte
+	// exemplar in the time series
+	ts := encodeTimeSeries(&timeSeries{labels: []label{{"__name__", "up"}}}, false)
+	ts = protowire.AppendTag(ts, 3, protowire.BytesType)
+	ts = protowire.AppendBytes(ts, []byte{0x09, 0, 0, 0, 0, 0, 0, 0, 0})
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendBytes(b, ts)
+	// unknown field of the write request
+	b = protowire.AppendTag(b, 42, protowire.VarintType)
+	b = protowire.AppendVarint(b, 7)
+
+	got, err := decodeWriteRequest(b)
+	require.NoError(t, err)
+	assert.Equal(t, &writeRequest{timeseries: []timeSeries{{labels: []label{{"__name__", "up"}}}}}, got)
+}
+
+func TestDecodeWriteRequestInvalid(t *testing.T) {
+	valid := encodeWriteRequest(&writeRequest{
+		timeseries: []timeSeries{{labels: []label{{"__name__", "up"}}, samples: []sample{{1, 1000}}}},
+	}, false)
+
+	for name, b := range map[string][]byte{
+		"truncated":       valid[:len(valid)-3],
+		"bad wire type":   protowire.AppendVarint(protowire.AppendTag(nil, 1, protowire.VarintType), 1),
+		"truncated bytes": protowire.AppendVarint(protowire.AppendTag(nil, 1, protowire.BytesType), 10),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := decodeWriteRequest(b)
+			assert.Error(t, err)
+		})
+	}
+}
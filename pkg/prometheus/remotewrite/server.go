@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// senderID is the ID of the sender used to submit the metrics to the aggregator.
+const senderID check.ID = "prometheus_remote_write"
+
+// stopTimeout is the maximum duration to wait for the pending requests when the server stops.
+const stopTimeout = 5 * time.Second
+
+var (
+	tlmRequests = telemetry.NewCounter("prometheus_remote_write", "requests",
+		[]string{"status"}, "Prometheus remote-write requests count, by HTTP status code")
+	tlmSamples = telemetry.NewCounter("prometheus_remote_write", "samples",
+		[]string{"type"}, "Prometheus remote-write samples count, by submitted metric type")
+	tlmDroppedSamples = telemetry.NewCounter("prometheus_remote_write", "dropped_samples",
+		nil, "Prometheus remote-write samples dropped as a more recent sample of their series was submitted")
+)
+
+// Server receives the Prometheus remote-write requests, and submits their samples to the aggregator.
+type Server struct {
+	Addr   string
+	config Config
+	server *http.Server
+	sender aggregator.Sender
+
+	// mu serializes the conversions, so that each commit of the sender holds whole requests.
+	mu   sync.Mutex
+	conv *converter
+}
+
+var (
+	serverInstance *Server
+	serverDemux    aggregator.Demultiplexer
+)
+
+// StartServer starts the global remote-write server.
+func StartServer(demux aggregator.Demultiplexer) error {
+	config, err := ReadConfig()
+	if err != nil {
+		return err
+	}
+	sender, err := demux.GetSender(senderID)
+	if err != nil {
+		return err
+	}
+	server, err := NewServer(*config, sender)
+	if err != nil {
+		demux.DestroySender(senderID)
+		return err
+	}
+	serverInstance = server
+	serverDemux = demux
+	return nil
+}
+
+// StopServer stops the global remote-write server, if it is running.
+func StopServer() {
+	if serverInstance != nil {
+		serverInstance.Stop()
+		serverDemux.DestroySender(senderID)
+		serverInstance = nil
+		serverDemux = nil
+	}
+}
+
+// IsRunning returns whether the remote-write server is currently running.
+func IsRunning() bool {
+	return serverInstance != nil
+}
+
+// NewServer configures and returns a running remote-write server, which submits the samples to sender.
+func NewServer(config Config, sender aggregator.Sender) (*Server, error) {
+	ln, err := net.Listen("tcp", net.JoinHostPort(config.BindHost, strconv.Itoa(int(config.Port))))
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+	s := &Server{
+		Addr:   ln.Addr().String(),
+		config: config,
+		sender: sender,
+		conv:   newConverter(&config),
+	}
+	mux := http.NewServeMux()
+	mux.Handle(config.Path, s)
+	s.server = &http.Server{
+		Handler:     mux,
+		ReadTimeout: 30 * time.Second,
+	}
+	go func() {
+		if err := s.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Errorf("Prometheus remote-write server stopped: %s", err)
+		}
+	}()
+	log.Infof("Prometheus remote-write receiver listening on http://%s%s", s.Addr, config.Path)
+	return s, nil
+}
+
+// Stop stops the server, after waiting for the pending requests.
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		log.Warnf("Prometheus remote-write server did not stop gracefully: %s", err)
+		s.server.Close()
+	}
+}
+
+// ServeHTTP handles a write request, made of a snappy-compressed protobuf WriteRequest message.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status, err := s.handle(r)
+	tlmRequests.Inc(strconv.Itoa(status))
+	if err != nil {
+		log.Debugf("Rejected Prometheus remote-write request from %s: %s", r.RemoteAddr, err)
+		if status == http.StatusMethodNotAllowed {
+			w.Header().Set("Allow", http.MethodPost)
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(status)
+}
+
+func (s *Server) handle(r *http.Request) (int, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed, errors.New("method not allowed")
+	}
+	if enc := r.Header.Get("Content-Encoding"); enc != "" && enc != "snappy" {
+		return http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content encoding %q", enc)
+	}
+	max := s.config.MaxRequestBytes
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, int64(max)+1))
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("can't read the request body: %s", err)
+	}
+	if len(body) > max {
+		return http.StatusRequestEntityTooLarge, fmt.Errorf("request larger than %d bytes", max)
+	}
+	if n, err := snappy.DecodedLen(body); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("can't decompress the request body: %s", err)
+	} else if n > max {
+		return http.StatusRequestEntityTooLarge, fmt.Errorf("decompressed request larger than %d bytes", max)
+	}
+	buf, err := snappy.Decode(nil, body)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("can't decompress the request body: %s", err)
+	}
+	req, err := decodeWriteRequest(buf)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("can't decode the write request: %s", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conv.convert(req, s.sender)
+	s.sender.Commit()
+	return http.StatusNoContent, nil
+}
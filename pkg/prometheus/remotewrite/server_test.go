@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func startTestServer(t *testing.T, sender *mocksender.MockSender, maxRequestBytes int) string {
+	s, err := NewServer(Config{
+		BindHost:        "127.0.0.1",
+		Path:            defaultPath,
+		MaxRequestBytes: maxRequestBytes,
+	}, sender)
+	require.NoError(t, err)
+	t.Cleanup(s.Stop)
+	return fmt.Sprintf("http://%s%s", s.Addr, defaultPath)
+}
+
+func postWriteRequest(t *testing.T, url string, body []byte) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func TestServerWriteRequest(t *testing.T) {
+	sender := newTestSender(t.Name())
+	url := startTestServer(t, sender, defaultMaxRequestBytes)
+
+	body := snappy.Encode(nil, encodeWriteRequest(&writeRequest{
+		timeseries: []timeSeries{
+			series("up", 1, label{"job", "node"}),
+			series("http_requests_total", 42, label{"code", "200"}),
+			series("latency_seconds_bucket", 2, label{"le", "1"}),
+			series("latency_seconds_bucket", 5, label{"le", "+Inf"}),
+		},
+	}, false))
+	resp := postWriteRequest(t, url, body)
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	sender.AssertMetric(t, "Gauge", "up", 1, "", []string{"job:node"})
+	sender.AssertMetric(t, "MonotonicCount", "http_requests.count", 42, "", []string{"code:200"})
+	sender.AssertHistogramBucket(t, "HistogramBucket", "latency_seconds", 2, 0, 1, true, "", []string{"lower_bound:0", "upper_bound:1"}, false)
+	sender.AssertNumberOfCalls(t, "HistogramBucket", 2)
+	sender.AssertNumberOfCalls(t, "Commit", 1)
+}
+
+func TestServerInvalidRequests(t *testing.T) {
+	sender := newTestSender(t.Name())
+	url := startTestServer(t, sender, 1024)
+
+	t.Run("method", func(t *testing.T) {
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	})
+
+	t.Run("encoding", func(t *testing.T) {
+		resp, err := http.Post(url, "application/x-protobuf", bytes.NewReader(nil))
+		require.NoError(t, err)
+		resp.Body.Close()
+		// the encoding may be omitted
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(nil))
+		require.NoError(t, err)
+		req.Header.Set("Content-Encoding", "gzip")
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	})
+
+	t.Run("not snappy", func(t *testing.T) {
+		resp := postWriteRequest(t, url, []byte("not snappy"))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("not protobuf", func(t *testing.T) {
+		resp := postWriteRequest(t, url, snappy.Encode(nil, []byte{0x0a, 0x10}))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		resp := postWriteRequest(t, url, snappy.Encode(nil, make([]byte, 2048)))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+
+	sender.AssertNotCalled(t, "Commit")
+}
+
+func TestReadConfig(t *testing.T) {
+	defer config.Datadog.Set("prometheus_remote_write", nil)
+
+	config.Datadog.Set("prometheus_remote_write", map[string]interface{}{"enabled": false})
+	_, err := ReadConfig()
+	assert.Error(t, err)
+
+	config.Datadog.Set("prometheus_remote_write", map[string]interface{}{
+		"enabled":          true,
+		"namespace":        "prom.",
+		"path":             "receive",
+		"labels_allowlist": []string{"job"},
+		"labels_mapper":    map[string]string{"instance": "prom_instance"},
+	})
+	c, err := ReadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(defaultPort), c.Port)
+	assert.Equal(t, "/receive", c.Path)
+	assert.Equal(t, "prom", c.Namespace)
+	assert.Equal(t, []string{"job"}, c.LabelsAllowlist)
+	assert.Equal(t, map[string]string{"instance": "prom_instance"}, c.LabelsMapper)
+	assert.Equal(t, defaultMaxRequestBytes, c.MaxRequestBytes)
+}
@@ -200,6 +200,9 @@ func formatTitle(title string) string {
 }
 
 func status(check map[string]interface{}) string {
+	if check["Quarantine"] != nil {
+		return fmt.Sprintf("[%s]", color.RedString("QUARANTINED"))
+	}
 	if check["LastError"].(string) != "" {
 		return fmt.Sprintf("[%s]", color.RedString("ERROR"))
 	}
@@ -38,14 +38,17 @@ const (
 type Agent struct {
 	Receiver              *api.HTTPReceiver
 	OTLPReceiver          *api.OTLPReceiver
+	JaegerReceiver        *api.JaegerReceiver
 	Concentrator          *stats.Concentrator
 	ClientStatsAggregator *stats.ClientStatsAggregator
 	Blacklister           *filters.Blacklister
 	Replacer              *filters.Replacer
+	Scrubber              *filters.Scrubber
 	PrioritySampler       *sampler.PrioritySampler
 	ErrorsSampler         *sampler.ErrorsSampler
 	RareSampler           *sampler.RareSampler
 	NoPrioritySampler     *sampler.NoPrioritySampler
+	TailSampler           *TailSampler
 	EventProcessor        *event.Processor
 	TraceWriter           *writer.TraceWriter
 	StatsWriter           *writer.StatsWriter
@@ -87,6 +90,7 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig) *Agent {
 		ClientStatsAggregator: stats.NewClientStatsAggregator(conf, statsChan),
 		Blacklister:           filters.NewBlacklister(conf.Ignore["resource"]),
 		Replacer:              filters.NewReplacer(conf.ReplaceTags),
+		Scrubber:              filters.NewScrubber(conf.ScrubRules),
 		PrioritySampler:       sampler.NewPrioritySampler(conf, dynConf),
 		ErrorsSampler:         sampler.NewErrorsSampler(conf),
 		RareSampler:           sampler.NewRareSampler(),
@@ -102,6 +106,8 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig) *Agent {
 	}
 	agnt.Receiver = api.NewHTTPReceiver(conf, dynConf, in, agnt)
 	agnt.OTLPReceiver = api.NewOTLPReceiver(in, conf)
+	agnt.JaegerReceiver = api.NewJaegerReceiver(in, conf)
+	agnt.TailSampler = NewTailSampler(conf, agnt.writeTailChunks)
 	return agnt
 }
 
@@ -114,8 +120,11 @@ func (a *Agent) Run() {
 		a.PrioritySampler,
 		a.ErrorsSampler,
 		a.NoPrioritySampler,
+		a.TailSampler,
+		a.Scrubber,
 		a.EventProcessor,
 		a.OTLPReceiver,
+		a.JaegerReceiver,
 	} {
 		starter.Start()
 	}
@@ -172,14 +181,17 @@ func (a *Agent) loop() {
 			for _, stopper := range []interface{ Stop() }{
 				a.Concentrator,
 				a.ClientStatsAggregator,
+				a.TailSampler, // before the TraceWriter, receiving the traces it keeps on stop
 				a.TraceWriter,
 				a.StatsWriter,
 				a.PrioritySampler,
 				a.ErrorsSampler,
 				a.NoPrioritySampler,
 				a.RareSampler,
+				a.Scrubber,
 				a.EventProcessor,
 				a.OTLPReceiver,
+				a.JaegerReceiver,
 				a.obfuscator,
 				a.obfuscator,
 				a.cardObfuscator,
@@ -203,6 +215,8 @@ func (a *Agent) Process(p *api.Payload) {
 	ts := p.Source
 	ss := new(writer.SampledChunks)
 	statsInput := stats.NewStatsInput(len(p.TracerPayload.Chunks), p.TracerPayload.ContainerID, p.ClientComputedStats, a.conf)
+	// tailPayload holds the metadata of the payload, for the chunks buffered by the TailSampler.
+	var tailPayload *pb.TracerPayload
 
 	p.TracerPayload.Env = traceutil.NormalizeTag(p.TracerPayload.Env)
 
@@ -264,6 +278,11 @@ func (a *Agent) Process(p *api.Payload) {
 			}
 		}
 		a.Replacer.Replace(chunk.Spans)
+		if len(a.conf.ScrubRules) > 0 {
+			n := len(chunk.Spans)
+			chunk.Spans = a.Scrubber.Scrub(chunk.Spans, root)
+			atomic.AddInt64(&ts.SpansFiltered, int64(n-len(chunk.Spans)))
+		}
 
 		{
 			// this section sets up any necessary tags on the root:
@@ -304,6 +323,18 @@ func (a *Agent) Process(p *api.Payload) {
 			statsInput.Traces = append(statsInput.Traces, pt)
 		}
 
+		if a.conf.TailSampling.Enabled {
+			// the sampling decision is taken by the TailSampler, once the trace is complete
+			if tailPayload == nil {
+				tailPayload = new(pb.TracerPayload)
+				*tailPayload = *p.TracerPayload
+				tailPayload.Chunks = nil
+			}
+			a.TailSampler.Add(now, chunk, tailPayload)
+			p.RemoveChunk(i)
+			continue
+		}
+
 		numEvents, keep, filteredChunk := a.sample(now, ts, pt)
 		if !keep {
 			if numEvents == 0 {
@@ -343,6 +374,31 @@ func (a *Agent) Process(p *api.Payload) {
 	}
 }
 
+// writeTailChunks sends the chunks of the traces kept by the TailSampler to the TraceWriter,
+// grouped by tracer payload.
+func (a *Agent) writeTailChunks(chunks []TailChunk) {
+	var (
+		order    []*pb.TracerPayload
+		payloads = make(map[*pb.TracerPayload]*writer.SampledChunks)
+	)
+	for _, c := range chunks {
+		ss, ok := payloads[c.Payload]
+		if !ok {
+			tp := new(pb.TracerPayload)
+			*tp = *c.Payload
+			ss = &writer.SampledChunks{TracerPayload: tp}
+			payloads[c.Payload] = ss
+			order = append(order, c.Payload)
+		}
+		ss.TracerPayload.Chunks = append(ss.TracerPayload.Chunks, c.Chunk)
+		ss.SpanCount += int64(len(c.Chunk.Spans))
+		ss.Size += c.Chunk.Msgsize()
+	}
+	for _, p := range order {
+		a.TraceWriter.In <- payloads[p]
+	}
+}
+
 // newChunksArray creates a new array which will point only to sampled chunks.
 
 // The underlying array behind TracePayload.Chunks points to unsampled chunks
@@ -103,6 +103,37 @@ func TestProcess(t *testing.T) {
 		assert.Equal("SELECT name FROM people WHERE age = ? AND extra = ?", span.Meta["sql.query"])
 	})
 
+	t.Run("Scrubber", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.ScrubRules = []*config.ScrubRule{
+			{Action: "mask_pii", PII: []string{"email"}},
+			{Action: "drop_span", Operation: "redis.command"},
+		}
+		require.NoError(t, filters.CompileScrubRules(cfg.ScrubRules))
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewAgent(ctx, cfg)
+		defer cancel()
+
+		now := time.Now()
+		root := &pb.Span{TraceID: 1, SpanID: 1, Service: "web", Name: "http.request", Resource: "GET /", Start: now.UnixNano(), Duration: 1e6}
+		redis := &pb.Span{TraceID: 1, SpanID: 2, ParentID: 1, Service: "web", Name: "redis.command", Resource: "GET", Start: now.UnixNano(), Duration: 1e6}
+		child := &pb.Span{TraceID: 1, SpanID: 3, ParentID: 2, Service: "web", Name: "http.request", Resource: "GET /", Start: now.UnixNano(), Duration: 1e6,
+			Meta: map[string]string{"usr.email": "bob@example.com"}}
+		chunk := testutil.TraceChunkWithSpansAndPriority([]*pb.Span{root, redis, child}, 2)
+		stats := info.NewReceiverStats().GetTagStats(info.Tags{})
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(chunk),
+			Source:        stats,
+		})
+
+		assert := assert.New(t)
+		assert.Equal([]*pb.Span{root, child}, chunk.Spans)
+		assert.EqualValues(1, child.ParentID)
+		assert.Equal("?", child.Meta["usr.email"])
+		assert.EqualValues(1, stats.SpansFiltered)
+	})
+
 	t.Run("Blacklister", func(t *testing.T) {
 		cfg := config.New()
 		cfg.Endpoints[0].APIKey = "test"
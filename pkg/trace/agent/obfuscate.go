@@ -22,12 +22,45 @@ const (
 	tagElasticBody      = "elasticsearch.body"
 	tagSQLQuery         = "sql.query"
 	tagHTTPURL          = "http.url"
+	tagDBSystem         = "db.system"
+	tagDBType           = "db.type"
 )
 
+// graphqlTags holds the tags of the GraphQL spans containing the GraphQL document.
+var graphqlTags = []string{"graphql.query", "graphql.source"}
+
+// jsonBodyTags holds the tags of the web and HTTP spans containing the JSON body of the request or of
+// the response, including the AWS SDK (e.g. DynamoDB) requests.
+var jsonBodyTags = []string{"http.request.body", "http.response.body", "aws.request.body", "aws.response.body"}
+
 const (
 	textNonParsable = "Non-parsable SQL query"
 )
 
+// sqlDialect returns the type of database management system queried by the SQL span s, as known to the
+// obfuscator, or an empty string if it does not use a specific dialect.
+func sqlDialect(s *pb.Span) string {
+	dbms, ok := s.Meta[tagDBSystem]
+	if !ok {
+		dbms = s.Meta[tagDBType]
+	}
+	return dbmsDialect(dbms)
+}
+
+// dbmsDialect returns the dialect of the database management system dbms, as known to the obfuscator,
+// or an empty string if it does not use a specific dialect.
+func dbmsDialect(dbms string) string {
+	switch strings.ToLower(dbms) {
+	case "mssql", "sqlserver", "sql-server":
+		return obfuscate.DBMSSQLServer
+	case "oracle":
+		return obfuscate.DBMSOracle
+	case "clickhouse":
+		return obfuscate.DBMSClickHouse
+	}
+	return ""
+}
+
 func (a *Agent) obfuscateSpan(span *pb.Span) {
 	o := a.obfuscator
 	switch span.Type {
@@ -35,7 +68,15 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 		if span.Resource == "" {
 			return
 		}
-		oq, err := o.ObfuscateSQLString(span.Resource)
+		var (
+			oq  *obfuscate.ObfuscatedQuery
+			err error
+		)
+		if span.Type == "cassandra" {
+			oq, err = o.ObfuscateCQL(span.Resource)
+		} else {
+			oq, err = o.ObfuscateSQLStringForDBMS(span.Resource, sqlDialect(span))
+		}
 		if err != nil {
 			// we have an error, discard the SQL to avoid polluting user resources.
 			log.Debugf("Error parsing SQL query: %v. Resource: %q", err, span.Resource)
@@ -80,6 +121,11 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 		if span.Meta == nil {
 			return
 		}
+		for _, k := range jsonBodyTags {
+			if v, ok := span.Meta[k]; ok {
+				span.Meta[k] = o.ObfuscateJSONBody(v)
+			}
+		}
 		v, ok := span.Meta[tagHTTPURL]
 		if !ok || v == "" {
 			return
@@ -97,6 +143,12 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 			return
 		}
 		span.Meta[tagElasticBody] = o.ObfuscateElasticSearchString(v)
+	case "graphql":
+		for _, k := range graphqlTags {
+			if v, ok := span.Meta[k]; ok {
+				span.Meta[k] = o.ObfuscateGraphQL(v)
+			}
+		}
 	}
 }
 
@@ -104,7 +156,15 @@ func (a *Agent) obfuscateStatsGroup(b *pb.ClientGroupedStats) {
 	o := a.obfuscator
 	switch b.Type {
 	case "sql", "cassandra":
-		oq, err := o.ObfuscateSQLString(b.Resource)
+		var (
+			oq  *obfuscate.ObfuscatedQuery
+			err error
+		)
+		if b.Type == "cassandra" {
+			oq, err = o.ObfuscateCQL(b.Resource)
+		} else {
+			oq, err = o.ObfuscateSQLStringForDBMS(b.Resource, dbmsDialect(b.DBType))
+		}
 		if err != nil {
 			log.Errorf("Error obfuscating stats group resource %q: %v", b.Resource, err)
 			b.Resource = textNonParsable
@@ -57,12 +57,17 @@ func TestObfuscateStatsGroup(t *testing.T) {
 			Resource: resource,
 		}
 	}
+	sqlServerStatsGroup := statsGroup("sql", "SELECT [name] FROM [dbo].[users] WHERE [id] = 42")
+	sqlServerStatsGroup.DBType = "sqlserver"
 	for _, tt := range []struct {
 		in  *pb.ClientGroupedStats // input stats
 		out string                 // output obfuscated resource
 	}{
 		{statsGroup("sql", "SELECT 1 FROM db"), "SELECT ? FROM db"},
 		{statsGroup("sql", "SELECT 1\nFROM Blogs AS [b\nORDER BY [b]"), textNonParsable},
+		{statsGroup("sql", "SELECT [name] FROM [dbo].[users] WHERE [id] = 42"), "SELECT [ name ] FROM [ dbo ] . [ users ] WHERE [ id ] = ?"},
+		{sqlServerStatsGroup, "SELECT [name] FROM [dbo].[users] WHERE [id] = ?"},
+		{statsGroup("cassandra", "SELECT * FROM users WHERE id = 123e4567-e89b-12d3-a456-426614174000"), "SELECT * FROM users WHERE id = ?"},
 		{statsGroup("redis", "ADD 1, 2"), "ADD"},
 		{statsGroup("other", "ADD 1, 2"), "ADD 1, 2"},
 	} {
@@ -220,6 +225,84 @@ func TestObfuscateConfig(t *testing.T) {
 		"set key 0 0 0 noreply\r\nvalue",
 		&config.ObfuscationConfig{},
 	))
+
+	t.Run("graphql/enabled", testConfig(
+		"graphql",
+		"graphql.query",
+		`{ user(id: 42, locale: "en") { name } }`,
+		`{ user(id: ?, locale: "en") { name } }`,
+		&config.ObfuscationConfig{GraphQL: config.GraphQLObfuscationConfig{
+			Enabled:    true,
+			KeepValues: []string{"locale"},
+		}},
+	))
+
+	t.Run("graphql/source", testConfig(
+		"graphql",
+		"graphql.source",
+		`{ user(id: 42) { name } }`,
+		`{ user(id: ?) { name } }`,
+		&config.ObfuscationConfig{GraphQL: config.GraphQLObfuscationConfig{Enabled: true}},
+	))
+
+	t.Run("graphql/disabled", testConfig(
+		"graphql",
+		"graphql.query",
+		`{ user(id: 42) { name } }`,
+		`{ user(id: 42) { name } }`,
+		&config.ObfuscationConfig{},
+	))
+
+	t.Run("json_body/enabled", testConfig(
+		"http",
+		"aws.request.body",
+		`{"TableName": "users", "Key": {"id": {"S": "1234"}}}`,
+		`{"TableName":"users","Key":{"id":{"S":"?"}}}`,
+		&config.ObfuscationConfig{
+			JSONBody: config.JSONObfuscationConfig{Enabled: true, KeepValues: []string{"TableName"}},
+		},
+	))
+
+	t.Run("json_body/web", testConfig(
+		"web",
+		"http.response.body",
+		`{"email": "jane@example.com"}`,
+		`{"email":"?"}`,
+		&config.ObfuscationConfig{JSONBody: config.JSONObfuscationConfig{Enabled: true}},
+	))
+
+	t.Run("json_body/disabled", testConfig(
+		"http",
+		"http.request.body",
+		`{"email": "jane@example.com"}`,
+		`{"email": "jane@example.com"}`,
+		&config.ObfuscationConfig{},
+	))
+}
+
+func TestCQLResource(t *testing.T) {
+	span := &pb.Span{
+		Resource: "UPDATE users SET emails = emails + {'jane@example.com'} WHERE id = 123e4567-e89b-12d3-a456-426614174000",
+		Type:     "cassandra",
+	}
+	agnt, stop := agentWithDefaults()
+	defer stop()
+	agnt.obfuscateSpan(span)
+	assert.Equal(t, "UPDATE users SET emails = emails + { ? } WHERE id = ?", span.Resource)
+	assert.Equal(t, "UPDATE users SET emails = emails + { ? } WHERE id = ?", span.Meta["sql.query"])
+}
+
+func TestCQLResourceSQLFeatures(t *testing.T) {
+	defer testutil.WithFeatures("keep_sql_alias,table_names")()
+	span := &pb.Span{
+		Resource: "SELECT u.name AS n FROM ks.users u WHERE id = 123e4567-e89b-12d3-a456-426614174000",
+		Type:     "cassandra",
+	}
+	agnt, stop := agentWithDefaults()
+	defer stop()
+	agnt.obfuscateSpan(span)
+	assert.Equal(t, "SELECT u.name AS n FROM ks.users u WHERE id = ?", span.Resource)
+	assert.Equal(t, "ks.users", span.Meta["sql.tables"])
 }
 
 func SQLSpan(query string) *pb.Span {
@@ -249,6 +332,28 @@ func TestSQLResourceQuery(t *testing.T) {
 	assert.Equal("SELECT * FROM users WHERE id = 42", span.Meta["sql.query"])
 }
 
+func TestSQLDialect(t *testing.T) {
+	agnt, stop := agentWithDefaults()
+	defer stop()
+	for _, tt := range []struct {
+		meta map[string]string
+		out  string
+	}{
+		{nil, "SELECT [ name ] FROM [ dbo ] . [ users ] WHERE [ id ] = ?"},
+		{map[string]string{"db.system": "mssql"}, "SELECT [name] FROM [dbo].[users] WHERE [id] = ?"},
+		{map[string]string{"db.type": "sqlserver"}, "SELECT [name] FROM [dbo].[users] WHERE [id] = ?"},
+		{map[string]string{"db.system": "postgresql"}, "SELECT [ name ] FROM [ dbo ] . [ users ] WHERE [ id ] = ?"},
+	} {
+		span := &pb.Span{
+			Resource: "SELECT [name] FROM [dbo].[users] WHERE [id] = 42",
+			Type:     "sql",
+			Meta:     tt.meta,
+		}
+		agnt.obfuscateSpan(span)
+		assert.Equal(t, tt.out, span.Resource, "%v", tt.meta)
+	}
+}
+
 func TestSQLResourceWithoutQuery(t *testing.T) {
 	assert := assert.New(t)
 	span := &pb.Span{
@@ -0,0 +1,391 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
+)
+
+const (
+	// tailPolicyKey is the chunk tag holding the name of the policy which kept a trace.
+	tailPolicyKey = "_dd.tail_sampling.policy"
+	// tailManualPolicy is the policy name set on the traces kept because of a user priority.
+	tailManualPolicy = "manual"
+	// tailTickInterval specifies the frequency at which the decisions are taken.
+	tailTickInterval = time.Second
+	// tailReportInterval specifies the frequency at which the stats are reported.
+	tailReportInterval = 10 * time.Second
+)
+
+// TailChunk is a trace chunk buffered by the TailSampler, along with the payload it was
+// received in.
+type TailChunk struct {
+	Chunk *pb.TraceChunk
+	// Payload holds the metadata of the tracer payload of the chunk. Its chunks are not set.
+	Payload *pb.TracerPayload
+}
+
+// tailTrace holds the chunks of a trace waiting for a sampling decision.
+type tailTrace struct {
+	id       uint64
+	chunks   []TailChunk
+	spans    int64
+	size     int64
+	deadline time.Time
+	elem     *list.Element
+}
+
+// tailDecision is a sampling decision, remembered for the chunks received after it.
+type tailDecision struct {
+	id     uint64
+	policy string // empty if the trace was dropped
+	expire time.Time
+}
+
+// TailSampler implements tail-based sampling: it buffers the chunks of each trace for a
+// decision window and keeps or drops the whole trace according to a list of policies. The
+// kept chunks are passed to the release function.
+type TailSampler struct {
+	enabled        bool
+	decisionWait   time.Duration
+	maxBufferBytes int64
+	policies       []tailPolicy
+	release        func([]TailChunk)
+
+	mu        sync.Mutex
+	traces    map[uint64]*tailTrace
+	queue     *list.List // of *tailTrace, by deadline
+	decided   map[uint64]*tailDecision
+	decisions *list.List // of *tailDecision, by expiration
+	stats     info.TailSamplingInfo
+	reported  info.TailSamplingInfo
+
+	exit chan struct{}
+	done chan struct{}
+}
+
+// NewTailSampler returns a TailSampler passing the chunks of the kept traces to release.
+// The invalid policies of the configuration are logged and ignored.
+func NewTailSampler(conf *config.AgentConfig, release func([]TailChunk)) *TailSampler {
+	tconf := conf.TailSampling
+	s := &TailSampler{
+		enabled:        tconf.Enabled,
+		decisionWait:   tconf.DecisionWait,
+		maxBufferBytes: tconf.MaxBufferBytes,
+		release:        release,
+		traces:         make(map[uint64]*tailTrace),
+		queue:          list.New(),
+		decided:        make(map[uint64]*tailDecision),
+		decisions:      list.New(),
+		exit:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+	for i, p := range tconf.Policies {
+		tp, err := newTailPolicy(p)
+		if err != nil {
+			log.Errorf("Ignoring invalid tail sampling policy #%d: %v", i, err)
+			continue
+		}
+		s.policies = append(s.policies, tp)
+	}
+	return s
+}
+
+// Start starts taking the decisions of the buffered traces, if the tail-based sampling is enabled.
+func (s *TailSampler) Start() {
+	if !s.enabled {
+		close(s.done)
+		return
+	}
+	go func() {
+		defer watchdog.LogOnPanic()
+		defer close(s.done)
+		tick := time.NewTicker(tailTickInterval)
+		defer tick.Stop()
+		report := time.NewTicker(tailReportInterval)
+		defer report.Stop()
+		for {
+			select {
+			case now := <-tick.C:
+				s.flush(now, false)
+			case <-report.C:
+				s.report()
+			case <-s.exit:
+				// take the decision of all the buffered traces, to not lose the kept ones
+				s.flush(time.Now(), true)
+				s.report()
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the TailSampler, taking the decision of the buffered traces.
+func (s *TailSampler) Stop() {
+	close(s.exit)
+	<-s.done
+}
+
+// Add buffers the chunk c until the decision on its trace is taken. payload holds the
+// metadata of the tracer payload the chunk was received in.
+func (s *TailSampler) Add(now time.Time, c *pb.TraceChunk, payload *pb.TracerPayload) {
+	if len(c.Spans) == 0 {
+		return
+	}
+	id := c.Spans[0].TraceID
+	tc := TailChunk{Chunk: c, Payload: payload}
+	var kept []TailChunk
+
+	s.mu.Lock()
+	if d, ok := s.decided[id]; ok {
+		// the decision was already taken on the trace
+		s.stats.LateChunks++
+		if d.policy != "" {
+			kept = []TailChunk{tc}
+			keepTailChunks(kept, d.policy)
+		}
+		s.mu.Unlock()
+		s.releaseChunks(kept)
+		return
+	}
+	t, ok := s.traces[id]
+	if !ok {
+		t = &tailTrace{id: id, deadline: now.Add(s.decisionWait)}
+		t.elem = s.queue.PushBack(t)
+		s.traces[id] = t
+		s.stats.BufferedTraces++
+	}
+	size := int64(c.Msgsize())
+	t.chunks = append(t.chunks, tc)
+	t.spans += int64(len(c.Spans))
+	t.size += size
+	s.stats.BufferedSpans += int64(len(c.Spans))
+	s.stats.BufferedBytes += size
+	for s.stats.BufferedBytes > s.maxBufferBytes && s.queue.Len() > 0 {
+		// the buffer is full: take the decision early for the oldest traces
+		oldest := s.queue.Front().Value.(*tailTrace)
+		kept = append(kept, s.decide(now, oldest)...)
+		s.stats.TracesEvicted++
+	}
+	s.mu.Unlock()
+	s.releaseChunks(kept)
+}
+
+// flush takes the decision of the traces whose decision window ended at now, or of all the
+// buffered traces if all is true.
+func (s *TailSampler) flush(now time.Time, all bool) {
+	var kept []TailChunk
+	s.mu.Lock()
+	for e := s.queue.Front(); e != nil; e = s.queue.Front() {
+		t := e.Value.(*tailTrace)
+		if !all && t.deadline.After(now) {
+			break
+		}
+		kept = append(kept, s.decide(now, t)...)
+	}
+	for e := s.decisions.Front(); e != nil; e = s.decisions.Front() {
+		d := e.Value.(*tailDecision)
+		if d.expire.After(now) {
+			break
+		}
+		delete(s.decided, d.id)
+		s.decisions.Remove(e)
+	}
+	s.mu.Unlock()
+	s.releaseChunks(kept)
+}
+
+// decide takes the sampling decision of the buffered trace t, and returns its chunks if it is kept.
+// Callers must guard!
+func (s *TailSampler) decide(now time.Time, t *tailTrace) []TailChunk {
+	s.queue.Remove(t.elem)
+	delete(s.traces, t.id)
+	s.stats.BufferedTraces--
+	s.stats.BufferedSpans -= t.spans
+	s.stats.BufferedBytes -= t.size
+
+	policy := s.evaluate(t)
+	// remember the decision during a window, for the chunks of the trace received late
+	d := &tailDecision{id: t.id, policy: policy, expire: now.Add(s.decisionWait)}
+	s.decided[t.id] = d
+	s.decisions.PushBack(d)
+	if policy == "" {
+		s.stats.TracesDropped++
+		return nil
+	}
+	s.stats.TracesKept++
+	keepTailChunks(t.chunks, policy)
+	return t.chunks
+}
+
+// evaluate returns the name of the policy keeping the trace t, or an empty string if the
+// trace is dropped. The priorities set by the users take precedence over the policies.
+func (s *TailSampler) evaluate(t *tailTrace) string {
+	for _, c := range t.chunks {
+		switch sampler.SamplingPriority(c.Chunk.Priority) {
+		case sampler.PriorityUserDrop:
+			return ""
+		case sampler.PriorityUserKeep:
+			return tailManualPolicy
+		}
+	}
+	tt := newTraceSummary(t)
+	for _, p := range s.policies {
+		if p.service != "" && p.service != tt.service {
+			continue
+		}
+		if p.match(tt) {
+			return p.name
+		}
+	}
+	return ""
+}
+
+func (s *TailSampler) releaseChunks(chunks []TailChunk) {
+	if len(chunks) > 0 {
+		s.release(chunks)
+	}
+}
+
+// report publishes the stats of the sampler.
+func (s *TailSampler) report() {
+	s.mu.Lock()
+	stats, prev := s.stats, s.reported
+	s.reported = stats
+	s.mu.Unlock()
+
+	info.UpdateTailSamplingInfo(stats)
+	metrics.Gauge("datadog.trace_agent.tail_sampler.buffered_traces", float64(stats.BufferedTraces), nil, 1)
+	metrics.Gauge("datadog.trace_agent.tail_sampler.buffered_bytes", float64(stats.BufferedBytes), nil, 1)
+	metrics.Count("datadog.trace_agent.tail_sampler.kept", stats.TracesKept-prev.TracesKept, nil, 1)
+	metrics.Count("datadog.trace_agent.tail_sampler.dropped", stats.TracesDropped-prev.TracesDropped, nil, 1)
+	metrics.Count("datadog.trace_agent.tail_sampler.evicted", stats.TracesEvicted-prev.TracesEvicted, nil, 1)
+	metrics.Count("datadog.trace_agent.tail_sampler.late_chunks", stats.LateChunks-prev.LateChunks, nil, 1)
+}
+
+// keepTailChunks marks the chunks of a kept trace with the policy which kept it.
+func keepTailChunks(chunks []TailChunk, policy string) {
+	for _, c := range chunks {
+		if c.Chunk.Tags == nil {
+			c.Chunk.Tags = make(map[string]string, 1)
+		}
+		c.Chunk.Tags[tailPolicyKey] = policy
+		if c.Chunk.Priority < int32(sampler.PriorityAutoKeep) {
+			c.Chunk.Priority = int32(sampler.PriorityAutoKeep)
+		}
+		c.Chunk.DroppedTrace = false
+	}
+}
+
+// traceSummary holds the properties of a complete trace, as evaluated by the policies.
+type traceSummary struct {
+	id       uint64
+	service  string // service of the root span
+	duration int64
+	spans    []*pb.Span
+}
+
+func newTraceSummary(t *tailTrace) *traceSummary {
+	tt := &traceSummary{id: t.id, spans: make([]*pb.Span, 0, t.spans)}
+	var start, end int64
+	var root *pb.Span
+	for _, c := range t.chunks {
+		for _, span := range c.Chunk.Spans {
+			if len(tt.spans) == 0 || span.Start < start {
+				start = span.Start
+			}
+			if e := span.Start + span.Duration; len(tt.spans) == 0 || e > end {
+				end = e
+			}
+			if root == nil || span.ParentID == 0 {
+				root = span
+			}
+			tt.spans = append(tt.spans, span)
+		}
+	}
+	tt.duration = end - start
+	if root != nil {
+		tt.service = root.Service
+	}
+	return tt
+}
+
+// tailPolicy is a policy keeping the traces it matches.
+type tailPolicy struct {
+	name    string
+	service string
+	match   func(t *traceSummary) bool
+}
+
+func newTailPolicy(p *config.TailSamplingPolicy) (tailPolicy, error) {
+	tp := tailPolicy{name: p.Name, service: p.Service}
+	if tp.name == "" {
+		tp.name = p.Type
+	}
+	switch p.Type {
+	case config.TailPolicyLatency:
+		if p.ThresholdMs <= 0 {
+			return tp, errors.New("threshold_ms must be positive")
+		}
+		threshold := time.Duration(p.ThresholdMs) * time.Millisecond
+		tp.match = func(t *traceSummary) bool {
+			return t.duration >= threshold.Nanoseconds()
+		}
+	case config.TailPolicyError:
+		tp.match = func(t *traceSummary) bool {
+			for _, span := range t.spans {
+				if span.Error != 0 {
+					return true
+				}
+			}
+			return false
+		}
+	case config.TailPolicyTag:
+		if p.Key == "" {
+			return tp, errors.New("key must be set")
+		}
+		key := p.Key
+		values := make(map[string]struct{}, len(p.Values))
+		for _, v := range p.Values {
+			values[v] = struct{}{}
+		}
+		tp.match = func(t *traceSummary) bool {
+			for _, span := range t.spans {
+				v, ok := span.Meta[key]
+				if !ok {
+					continue
+				}
+				if _, found := values[v]; found || len(values) == 0 {
+					return true
+				}
+			}
+			return false
+		}
+	case config.TailPolicyRate:
+		if p.SampleRate < 0 || p.SampleRate > 1 {
+			return tp, errors.New("sample_rate must be between 0 and 1")
+		}
+		rate := p.SampleRate
+		tp.match = func(t *traceSummary) bool {
+			return sampler.SampleByRate(t.id, rate)
+		}
+	default:
+		return tp, fmt.Errorf("unknown type %q", p.Type)
+	}
+	return tp, nil
+}
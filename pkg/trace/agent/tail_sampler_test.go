@@ -0,0 +1,231 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/api"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTailSampler(policies ...*config.TailSamplingPolicy) (*TailSampler, *[]TailChunk) {
+	cfg := config.New()
+	cfg.TailSampling.Enabled = true
+	cfg.TailSampling.DecisionWait = 10 * time.Second
+	cfg.TailSampling.Policies = policies
+	var kept []TailChunk
+	return NewTailSampler(cfg, func(c []TailChunk) { kept = append(kept, c...) }), &kept
+}
+
+func tailChunk(traceID, spanID, parentID uint64, start, duration int64) *pb.TraceChunk {
+	return &pb.TraceChunk{
+		Priority: int32(sampler.PriorityAutoDrop),
+		Spans: []*pb.Span{{
+			TraceID:  traceID,
+			SpanID:   spanID,
+			ParentID: parentID,
+			Service:  "web",
+			Start:    start,
+			Duration: duration,
+		}},
+	}
+}
+
+func TestTailSamplerPolicies(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		name   string
+		policy *config.TailSamplingPolicy
+		edit   func(c1, c2 *pb.TraceChunk)
+		keep   string
+	}{
+		{
+			name:   "latency",
+			policy: &config.TailSamplingPolicy{Type: "latency", ThresholdMs: 100},
+			edit:   func(_, c2 *pb.TraceChunk) { c2.Spans[0].Duration = 150e6 },
+			keep:   "latency",
+		},
+		{
+			name:   "latency-short",
+			policy: &config.TailSamplingPolicy{Type: "latency", ThresholdMs: 100},
+		},
+		{
+			name:   "error",
+			policy: &config.TailSamplingPolicy{Name: "errors", Type: "error"},
+			edit:   func(_, c2 *pb.TraceChunk) { c2.Spans[0].Error = 1 },
+			keep:   "errors",
+		},
+		{
+			name:   "tag",
+			policy: &config.TailSamplingPolicy{Type: "tag", Key: "customer", Values: []string{"acme"}},
+			edit:   func(_, c2 *pb.TraceChunk) { c2.Spans[0].Meta = map[string]string{"customer": "acme"} },
+			keep:   "tag",
+		},
+		{
+			name:   "tag-value",
+			policy: &config.TailSamplingPolicy{Type: "tag", Key: "customer", Values: []string{"acme"}},
+			edit:   func(_, c2 *pb.TraceChunk) { c2.Spans[0].Meta = map[string]string{"customer": "other"} },
+		},
+		{
+			name:   "rate",
+			policy: &config.TailSamplingPolicy{Type: "rate", SampleRate: 1},
+			keep:   "rate",
+		},
+		{
+			name:   "rate-service",
+			policy: &config.TailSamplingPolicy{Type: "rate", SampleRate: 1, Service: "db"},
+		},
+		{
+			name:   "user-keep",
+			policy: &config.TailSamplingPolicy{Type: "error"},
+			edit:   func(_, c2 *pb.TraceChunk) { c2.Priority = int32(sampler.PriorityUserKeep) },
+			keep:   "manual",
+		},
+		{
+			name:   "user-drop",
+			policy: &config.TailSamplingPolicy{Type: "rate", SampleRate: 1},
+			edit:   func(c1, _ *pb.TraceChunk) { c1.Priority = int32(sampler.PriorityUserDrop) },
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s, kept := newTestTailSampler(tt.policy)
+			c1 := tailChunk(42, 1, 0, 0, 50e6)
+			c2 := tailChunk(42, 2, 1, 10e6, 20e6)
+			if tt.edit != nil {
+				tt.edit(c1, c2)
+			}
+			s.Add(now, c1, &pb.TracerPayload{})
+			s.Add(now, c2, &pb.TracerPayload{})
+			s.flush(now.Add(9*time.Second), false)
+			assert.Len(t, s.traces, 1, "the decision should wait for the end of the window")
+			s.flush(now.Add(10*time.Second), false)
+			assert.Len(t, s.traces, 0)
+
+			if tt.keep == "" {
+				assert.Empty(t, *kept)
+				assert.EqualValues(t, 1, s.stats.TracesDropped)
+				return
+			}
+			require.Len(t, *kept, 2)
+			for _, c := range *kept {
+				assert.Equal(t, tt.keep, c.Chunk.Tags[tailPolicyKey])
+				assert.GreaterOrEqual(t, c.Chunk.Priority, int32(sampler.PriorityAutoKeep))
+			}
+			assert.EqualValues(t, 1, s.stats.TracesKept)
+		})
+	}
+}
+
+func TestTailSamplerInvalidPolicies(t *testing.T) {
+	s, _ := newTestTailSampler(
+		&config.TailSamplingPolicy{Type: "latency"},
+		&config.TailSamplingPolicy{Type: "tag"},
+		&config.TailSamplingPolicy{Type: "rate", SampleRate: 2},
+		&config.TailSamplingPolicy{Type: "unknown"},
+		&config.TailSamplingPolicy{Type: "error"},
+	)
+	require.Len(t, s.policies, 1)
+	assert.Equal(t, "error", s.policies[0].name)
+}
+
+func TestTailSamplerLateChunks(t *testing.T) {
+	now := time.Now()
+	s, kept := newTestTailSampler(&config.TailSamplingPolicy{Type: "error"})
+	c := tailChunk(1, 1, 0, 0, 10)
+	c.Spans[0].Error = 1
+	s.Add(now, c, &pb.TracerPayload{})
+	s.Add(now, tailChunk(2, 1, 0, 0, 10), &pb.TracerPayload{})
+	s.flush(now.Add(10*time.Second), false)
+	require.Len(t, *kept, 1)
+
+	// the late chunks follow the decision taken on their trace
+	s.Add(now.Add(11*time.Second), tailChunk(1, 2, 1, 0, 10), &pb.TracerPayload{})
+	s.Add(now.Add(11*time.Second), tailChunk(2, 2, 1, 0, 10), &pb.TracerPayload{})
+	assert.Len(t, *kept, 2)
+	assert.Equal(t, "error", (*kept)[1].Chunk.Tags[tailPolicyKey])
+	assert.EqualValues(t, 2, s.stats.LateChunks)
+	assert.Len(t, s.traces, 0)
+
+	// the decisions are forgotten after a window
+	s.flush(now.Add(20*time.Second), false)
+	assert.Len(t, s.decided, 0)
+	s.Add(now.Add(21*time.Second), tailChunk(2, 3, 1, 0, 10), &pb.TracerPayload{})
+	assert.Len(t, s.traces, 1)
+}
+
+func TestTailSamplerMaxBuffer(t *testing.T) {
+	now := time.Now()
+	s, kept := newTestTailSampler(&config.TailSamplingPolicy{Type: "rate", SampleRate: 1})
+	s.maxBufferBytes = int64(3 * tailChunk(1, 1, 0, 0, 10).Msgsize())
+	for i := uint64(1); i <= 5; i++ {
+		s.Add(now, tailChunk(i, 1, 0, 0, 10), &pb.TracerPayload{})
+	}
+	assert.Len(t, s.traces, 3)
+	assert.LessOrEqual(t, s.stats.BufferedBytes, s.maxBufferBytes)
+	assert.EqualValues(t, 2, s.stats.TracesEvicted)
+	require.Len(t, *kept, 2)
+	assert.EqualValues(t, 1, (*kept)[0].Chunk.Spans[0].TraceID, "the oldest traces should be decided first")
+	assert.EqualValues(t, 2, (*kept)[1].Chunk.Spans[0].TraceID)
+
+	s.flush(now, true)
+	assert.Len(t, *kept, 5)
+	assert.Equal(t, info.TailSamplingInfo{TracesKept: 5, TracesEvicted: 2}, s.stats)
+}
+
+func TestProcessTailSampling(t *testing.T) {
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.TailSampling.Enabled = true
+	cfg.TailSampling.DecisionWait = time.Second
+	cfg.TailSampling.Policies = []*config.TailSamplingPolicy{{Type: "error"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agnt := NewAgent(ctx, cfg)
+
+	now := time.Now()
+	for i, errored := range []bool{false, true} {
+		traceID := uint64(1 + i*10)
+		root := &pb.Span{TraceID: traceID, SpanID: 1, Service: "web", Name: "http.request", Resource: "GET /", Start: now.UnixNano(), Duration: 1e6}
+		child := &pb.Span{TraceID: traceID, SpanID: 2, ParentID: 1, Service: "web", Name: "sql.query", Resource: "SELECT", Start: now.UnixNano(), Duration: 1e6}
+		if errored {
+			child.Error = 1
+		}
+		chunk1 := testutil.TraceChunkWithSpan(root)
+		chunk2 := testutil.TraceChunkWithSpan(child)
+		for _, c := range []*pb.TraceChunk{chunk1, chunk2} {
+			c.Priority = int32(sampler.PriorityAutoDrop)
+			tp := testutil.TracerPayloadWithChunk(c)
+			tp.Env = "prod"
+			agnt.Process(&api.Payload{
+				TracerPayload: tp,
+				Source:        info.NewReceiverStats().GetTagStats(info.Tags{}),
+			})
+		}
+	}
+	assert.Len(t, agnt.TraceWriter.In, 0, "the chunks should be buffered")
+	assert.Len(t, agnt.TailSampler.traces, 2)
+
+	agnt.TailSampler.flush(now.Add(2*time.Second), false)
+	require.Len(t, agnt.TraceWriter.In, 2)
+	for i := 0; i < 2; i++ {
+		ss := <-agnt.TraceWriter.In
+		require.Len(t, ss.TracerPayload.Chunks, 1)
+		assert.Equal(t, "prod", ss.TracerPayload.Env)
+		assert.EqualValues(t, 11, ss.TracerPayload.Chunks[0].Spans[0].TraceID)
+		assert.Equal(t, "error", ss.TracerPayload.Chunks[0].Tags[tailPolicyKey])
+		assert.EqualValues(t, 1, ss.SpanCount)
+	}
+}
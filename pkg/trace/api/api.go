@@ -579,7 +579,12 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 		ClientComputedStats:    req.Header.Get(headerComputedStats) != "",
 		ClientDroppedP0s:       droppedTracesFromHeader(req.Header, ts),
 	}
+	r.send(payload)
+}
 
+// send sends the payload down to the agent. It never blocks the caller, the payload is
+// queued in a new goroutine when the channel is full.
+func (r *HTTPReceiver) send(payload *Payload) {
 	select {
 	case r.out <- payload:
 		// ok
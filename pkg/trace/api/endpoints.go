@@ -116,4 +116,14 @@ var endpoints = []Endpoint{
 		Pattern: "/debugger/v1/input",
 		Handler: func(r *HTTPReceiver) http.Handler { return r.debuggerProxyHandler() },
 	},
+	{
+		Pattern:   "/api/v2/spans",
+		Handler:   func(r *HTTPReceiver) http.Handler { return r.handleSpans(zipkinV2, decodeZipkinSpans) },
+		IsEnabled: func(cfg *config.AgentConfig) bool { return cfg.Zipkin.Enabled },
+	},
+	{
+		Pattern:   "/api/traces",
+		Handler:   func(r *HTTPReceiver) http.Handler { return r.handleSpans(jaegerThrift, decodeJaegerThriftSpans) },
+		IsEnabled: func(cfg *config.AgentConfig) bool { return cfg.Jaeger.Enabled },
+	},
 }
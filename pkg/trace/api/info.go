@@ -36,6 +36,8 @@ func (r *HTTPReceiver) makeInfoHandler() (hash string, handler http.HandlerFunc)
 		RemoveStackTraces    bool                         `json:"remove_stack_traces"`
 		Redis                bool                         `json:"redis"`
 		Memcached            bool                         `json:"memcached"`
+		GraphQL              bool                         `json:"graphql"`
+		JSONBody             bool                         `json:"json_body"`
 	}
 	type reducedConfig struct {
 		DefaultEnv             string                        `json:"default_env"`
@@ -62,6 +64,8 @@ func (r *HTTPReceiver) makeInfoHandler() (hash string, handler http.HandlerFunc)
 		oconf.RemoveStackTraces = o.RemoveStackTraces
 		oconf.Redis = o.Redis.Enabled
 		oconf.Memcached = o.Memcached.Enabled
+		oconf.GraphQL = o.GraphQL.Enabled
+		oconf.JSONBody = o.JSONBody.Enabled
 	}
 	txt, err := json.MarshalIndent(struct {
 		Version          string        `json:"version"`
@@ -47,6 +47,8 @@ func TestInfoHandler(t *testing.T) {
 		RemoveStackTraces: false,
 		Redis:             config.Enablable{Enabled: true},
 		Memcached:         config.Enablable{Enabled: false},
+		GraphQL:           config.GraphQLObfuscationConfig{Enabled: true},
+		JSONBody:          config.JSONObfuscationConfig{Enabled: false},
 	}
 	conf := &config.AgentConfig{
 		Enabled:    true,
@@ -164,7 +166,9 @@ func TestInfoHandler(t *testing.T) {
 			},
 			"remove_stack_traces": false,
 			"redis": true,
-			"memcached": false
+			"memcached": false,
+			"graphql": true,
+			"json_body": false
 		}
 	}
 }`,
@@ -223,7 +227,9 @@ func TestInfoHandler(t *testing.T) {
 			},
 			"remove_stack_traces": false,
 			"redis": true,
-			"memcached": false
+			"memcached": false,
+			"graphql": true,
+			"json_body": false
 		}
 	}
 }`,
@@ -0,0 +1,249 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/api/apiutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// This file holds the code shared by the receivers of spans in third party formats,
+// such as Zipkin and Jaeger. The spans are converted to Datadog spans and sent down
+// to the agent like the ones received from the Datadog tracers.
+
+// spansDecoder decodes the spans of a request body of the given media type.
+type spansDecoder func(mediaType string, body []byte) ([]*pb.Span, error)
+
+// handleSpans returns the handler of the endpoint receiving the spans of version v,
+// decoded by decode.
+func (r *HTTPReceiver) handleSpans(v Version, decode spansDecoder) http.Handler {
+	return r.handleWithVersion(v, func(v Version, w http.ResponseWriter, req *http.Request) {
+		ts := r.tagStats(v, req.Header)
+		start := time.Now()
+		spans, err := readSpans(req, decode, r.conf.MaxRequestBytes)
+		defer func(err error) {
+			tags := append(ts.AsTags(), fmt.Sprintf("success:%v", err == nil))
+			metrics.Histogram("datadog.trace_agent.receiver.serve_traces_ms", float64(time.Since(start))/float64(time.Millisecond), tags, 1)
+		}(err)
+		if err != nil {
+			httpDecodingError(err, []string{"handler:spans", fmt.Sprintf("v:%s", v)}, w)
+			switch err {
+			case apiutil.ErrLimitedReaderLimitReached:
+				atomic.AddInt64(&ts.TracesDropped.PayloadTooLarge, 1)
+			case io.EOF, io.ErrUnexpectedEOF:
+				atomic.AddInt64(&ts.TracesDropped.EOF, 1)
+			default:
+				atomic.AddInt64(&ts.TracesDropped.DecodingError, 1)
+			}
+			log.Errorf("Cannot decode %s spans payload: %v", v, err)
+			return
+		}
+		payload := newIngestPayload(ts, spans, req.Header.Get(headerContainerID))
+		if r.rateLimited(int64(len(payload.TracerPayload.Chunks))) {
+			w.WriteHeader(r.rateLimiterResponse)
+			atomic.AddInt64(&ts.PayloadRefused, 1)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+
+		atomic.AddInt64(&ts.TracesReceived, int64(len(payload.TracerPayload.Chunks)))
+		atomic.AddInt64(&ts.TracesBytes, req.Body.(*apiutil.LimitedReader).Count)
+		atomic.AddInt64(&ts.PayloadAccepted, 1)
+
+		if ctags := getContainerTags(r.conf.ContainerTags, payload.TracerPayload.ContainerID); ctags != "" {
+			payload.TracerPayload.Tags = map[string]string{tagContainersTags: ctags}
+		}
+		r.send(payload)
+	})
+}
+
+// readSpans reads the body of req, gzip compressed or not, and decodes its spans. The
+// decompressed body is limited to maxBytes, like the compressed one.
+func readSpans(req *http.Request, decode spansDecoder, maxBytes int64) ([]*pb.Span, error) {
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gzipr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipr.Close()
+		body = apiutil.NewLimitedReader(gzipr, maxBytes)
+	}
+	slurp, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	return decode(getMediaType(req), slurp)
+}
+
+// newIngestPayload returns a payload holding the spans, grouped by trace, received from the
+// source ts. As for the OTLP spans, the traces are kept by default, the sampling decision
+// having already been taken by the client.
+func newIngestPayload(ts *info.TagStats, spans []*pb.Span, containerID string) *Payload {
+	var env string
+	byID := make(map[uint64][]*pb.Span)
+	order := make([]uint64, 0, len(spans))
+	for _, s := range spans {
+		if _, ok := byID[s.TraceID]; !ok {
+			order = append(order, s.TraceID)
+		}
+		byID[s.TraceID] = append(byID[s.TraceID], s)
+		if env == "" {
+			env = s.Meta["env"]
+		}
+	}
+	chunks := make([]*pb.TraceChunk, 0, len(byID))
+	for _, id := range order {
+		chunks = append(chunks, &pb.TraceChunk{
+			Priority: int32(sampler.PriorityAutoKeep),
+			Spans:    byID[id],
+		})
+	}
+	return &Payload{
+		Source: ts,
+		TracerPayload: &pb.TracerPayload{
+			Chunks:          chunks,
+			Env:             traceutil.NormalizeTag(env),
+			ContainerID:     containerID,
+			LanguageName:    ts.Lang,
+			LanguageVersion: ts.LangVersion,
+			TracerVersion:   ts.TracerVersion,
+		},
+	}
+}
+
+// spanKindFromString returns the span kind named s, e.g. "SERVER" or "client".
+func spanKindFromString(s string) ptrace.SpanKind {
+	switch strings.ToLower(s) {
+	case "server":
+		return ptrace.SpanKindServer
+	case "client":
+		return ptrace.SpanKindClient
+	case "producer":
+		return ptrace.SpanKindProducer
+	case "consumer":
+		return ptrace.SpanKindConsumer
+	default:
+		return ptrace.SpanKindInternal
+	}
+}
+
+// finishSpan sets the error flag, name, resource and type of the span converted from a
+// third party format, unless they were already set from its tags. The name of the span is
+// made of the format and the kind of the span, e.g. "zipkin.server", and its resource
+// is deduced from its tags, or is its operation name.
+func finishSpan(span *pb.Span, format string, kind ptrace.SpanKind, operationName string) {
+	if v, ok := span.Meta["error"]; ok && v != "false" {
+		span.Error = 1
+		if _, ok := span.Meta["error.msg"]; !ok && v != "" && v != "true" {
+			// Zipkin holds the error message in the error tag
+			span.Meta["error.msg"] = v
+		}
+	} else if strings.EqualFold(span.Meta["otel.status_code"], "error") {
+		span.Error = 1
+	} else if code := httpStatusCode(span); code >= 500 {
+		span.Error = 1
+	}
+	if span.Error == 1 {
+		if _, ok := span.Meta["error.msg"]; !ok {
+			if code := httpStatusCode(span); code != 0 {
+				span.Meta["error.msg"] = strconv.Itoa(code)
+			}
+		}
+	}
+	if span.Name == "" {
+		span.Name = format + "." + spanKindName(kind)
+	}
+	if span.Resource == "" {
+		if r := resourceFromTags(span.Meta); r != "" {
+			span.Resource = r
+		} else {
+			span.Resource = operationName
+		}
+	}
+	if span.Type == "" {
+		span.Type = spanKind2Type(kind, span)
+	}
+}
+
+// httpStatusCode returns the HTTP status code of the span, from its tags or metrics, or 0.
+func httpStatusCode(span *pb.Span) int {
+	if v, ok := span.Metrics["http.status_code"]; ok {
+		return int(v)
+	}
+	code, _ := strconv.Atoi(span.Meta["http.status_code"])
+	return code
+}
+
+// spanEvent is an event of a span, such as a Zipkin annotation or a Jaeger log. The events
+// are encoded as JSON in the "events" tag of the spans, the same way as the OTLP span events.
+type spanEvent struct {
+	TimeUnixNano int64             `json:"time_unix_nano,omitempty"`
+	Name         string            `json:"name,omitempty"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+}
+
+// protoField is a field of a protobuf message. Value holds the value of the varint and fixed
+// size fields, Bytes the one of the length-delimited fields.
+type protoField struct {
+	Num   protowire.Number
+	Type  protowire.Type
+	Value uint64
+	Bytes []byte
+}
+
+// decodeProto calls fn with each field of the protobuf encoded message b. It is used to decode
+// the protobuf messages of the third party formats without depending on their generated code.
+func decodeProto(b []byte, fn func(f protoField) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		f := protoField{Num: num, Type: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.Value, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			f.Value, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			f.Value = uint64(v)
+		case protowire.BytesType:
+			f.Bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,544 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics/timing"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// jaegerThrift is the version of the Jaeger collector HTTP endpoint (/api/traces), receiving
+	// a batch of spans encoded with the Thrift binary protocol (application/x-thrift).
+	jaegerThrift Version = "jaeger_thrift"
+
+	// jaegerGRPC is the version of the Jaeger collector gRPC service.
+	jaegerGRPC Version = "jaeger_grpc"
+)
+
+// jaegerSpan is a span of the Jaeger model, as decoded from Thrift or protobuf.
+type jaegerSpan struct {
+	traceID       uint64 // the lower 64 bits of the trace ID
+	spanID        uint64
+	parentID      uint64
+	operationName string
+	start         int64 // epoch nanoseconds
+	duration      int64 // nanoseconds
+	tags          []jaegerTag
+	logs          []jaegerLog
+	process       *jaegerProcess // the process of the span, if it differs from the one of the batch
+}
+
+// jaegerTag is a key/value tag. Value holds a string, bool, int64, float64 or []byte.
+type jaegerTag struct {
+	key   string
+	value interface{}
+}
+
+// jaegerLog is a timestamped event of a span.
+type jaegerLog struct {
+	timestamp int64 // epoch nanoseconds
+	fields    []jaegerTag
+}
+
+// jaegerProcess is the service emitting the spans of a batch.
+type jaegerProcess struct {
+	serviceName string
+	tags        []jaegerTag
+}
+
+// jaegerBatch is a batch of spans emitted by a process.
+type jaegerBatch struct {
+	process *jaegerProcess
+	spans   []*jaegerSpan
+}
+
+// jaegerTags returns the tags identifying the source of the batches sent by the process p.
+// The Jaeger clients report their language and version in the "jaeger.version" tag, e.g.
+// "Go-2.30.0".
+func jaegerTags(v Version, p *jaegerProcess) info.Tags {
+	tags := info.Tags{EndpointVersion: string(v)}
+	if p == nil {
+		return tags
+	}
+	for _, t := range p.tags {
+		if v, ok := t.value.(string); ok && t.key == "jaeger.version" {
+			tags.Lang = strings.ToLower(strings.SplitN(v, "-", 2)[0])
+			tags.TracerVersion = "jaeger-" + v
+		}
+	}
+	return tags
+}
+
+// convertJaegerBatch converts the spans of the batch to Datadog spans.
+func convertJaegerBatch(batch *jaegerBatch) []*pb.Span {
+	spans := make([]*pb.Span, 0, len(batch.spans))
+	for _, s := range batch.spans {
+		process := batch.process
+		if s.process != nil {
+			process = s.process
+		}
+		spans = append(spans, convertJaegerSpan(s, process))
+	}
+	return spans
+}
+
+// convertJaegerSpan converts the Jaeger span in, emitted by process, to a Datadog span. The tags
+// of the process are added to the tags of the span.
+func convertJaegerSpan(in *jaegerSpan, process *jaegerProcess) *pb.Span {
+	span := &pb.Span{
+		TraceID:  in.traceID,
+		SpanID:   in.spanID,
+		ParentID: in.parentID,
+		Start:    in.start,
+		Duration: in.duration,
+		Meta:     make(map[string]string, len(in.tags)),
+		Metrics:  map[string]float64{},
+	}
+	if process != nil {
+		span.Service = process.serviceName
+		for _, t := range process.tags {
+			setJaegerTag(span, t)
+		}
+	}
+	var kind string
+	for _, t := range in.tags {
+		if t.key == "span.kind" {
+			kind, _ = t.value.(string)
+			continue
+		}
+		setJaegerTag(span, t)
+	}
+	if len(in.logs) > 0 {
+		setJaegerLogs(span, in.logs)
+	}
+	finishSpan(span, "jaeger", spanKindFromString(kind), in.operationName)
+	return span
+}
+
+// setJaegerTag sets the tag t on span s, as a metric for the numeric tags.
+func setJaegerTag(s *pb.Span, t jaegerTag) {
+	switch v := t.value.(type) {
+	case string:
+		setMetaOTLP(s, t.key, v)
+	case bool:
+		setMetaOTLP(s, t.key, strconv.FormatBool(v))
+	case int64:
+		setMetricOTLP(s, t.key, float64(v))
+	case float64:
+		setMetricOTLP(s, t.key, v)
+	case []byte:
+		setMetaOTLP(s, t.key, base64.StdEncoding.EncodeToString(v))
+	}
+}
+
+// jaegerTagString returns the value of t as a string.
+func jaegerTagString(t jaegerTag) string {
+	switch v := t.value.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	}
+	return ""
+}
+
+// setJaegerLogs sets the logs of a span as its events. Following the OpenTracing conventions,
+// the fields of the error logs set the error message, type and stack of the span.
+func setJaegerLogs(s *pb.Span, logs []jaegerLog) {
+	events := make([]spanEvent, 0, len(logs))
+	for _, l := range logs {
+		e := spanEvent{TimeUnixNano: l.timestamp, Attributes: make(map[string]string, len(l.fields))}
+		for _, f := range l.fields {
+			if f.key == "event" {
+				e.Name = jaegerTagString(f)
+				continue
+			}
+			e.Attributes[f.key] = jaegerTagString(f)
+		}
+		if e.Name == "error" {
+			for _, m := range [][2]string{
+				{"message", "error.msg"},
+				{"error.object", "error.msg"},
+				{"error.kind", "error.type"},
+				{"stack", "error.stack"},
+			} {
+				if v, ok := e.Attributes[m[0]]; ok {
+					if _, ok := s.Meta[m[1]]; !ok {
+						s.Meta[m[1]] = v
+					}
+				}
+			}
+		}
+		events = append(events, e)
+	}
+	if b, err := json.Marshal(events); err == nil {
+		s.Meta["events"] = string(b)
+	}
+}
+
+// JaegerReceiver implements the gRPC collector service of Jaeger, receiving the batches of
+// spans sent by the Jaeger agents.
+type JaegerReceiver struct {
+	wg      sync.WaitGroup      // waits for a graceful shutdown
+	grpcsrv *grpc.Server        // the running gRPC server on a started receiver, if enabled
+	out     chan<- *Payload     // the outgoing payload channel
+	conf    *config.AgentConfig // receiver config
+}
+
+// NewJaegerReceiver returns a new JaegerReceiver which sends any incoming traces down the out channel.
+func NewJaegerReceiver(out chan<- *Payload, cfg *config.AgentConfig) *JaegerReceiver {
+	return &JaegerReceiver{out: out, conf: cfg}
+}
+
+// Start starts the JaegerReceiver, if its port was configured.
+func (j *JaegerReceiver) Start() {
+	cfg := j.conf.Jaeger
+	if cfg.GRPCPort == 0 {
+		return
+	}
+	ln, err := net.Listen("tcp", fmt.Sprintf("%s:%d", cfg.BindHost, cfg.GRPCPort))
+	if err != nil {
+		log.Criticalf("Error starting Jaeger gRPC server: %v", err)
+		return
+	}
+	// CustomCodec is used rather than ForceServerCodec to build with the older gRPC version
+	// required by the Agent.
+	j.grpcsrv = grpc.NewServer(grpc.CustomCodec(rawCodec{})) //nolint:staticcheck
+	j.grpcsrv.RegisterService(&jaegerCollectorServiceDesc, j)
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		if err := j.grpcsrv.Serve(ln); err != nil {
+			log.Criticalf("Error starting Jaeger gRPC server: %v", err)
+		}
+	}()
+	log.Infof("Listening for Jaeger traces on gRPC port %s:%d", cfg.BindHost, cfg.GRPCPort)
+}
+
+// Stop stops the running server.
+func (j *JaegerReceiver) Stop() {
+	if j.grpcsrv != nil {
+		go j.grpcsrv.Stop()
+	}
+	j.wg.Wait()
+}
+
+// postSpans handles the PostSpansRequest message req.
+func (j *JaegerReceiver) postSpans(req []byte) error {
+	defer timing.Since("datadog.trace_agent.jaeger.process_grpc_request_ms", time.Now())
+	batch, err := unmarshalJaegerPostSpansRequest(req)
+	if err != nil {
+		metrics.Count("datadog.trace_agent.jaeger.error", 1, []string{"endpoint_version:" + string(jaegerGRPC), "reason:decode_proto"}, 1)
+		return err
+	}
+	ts := &info.TagStats{
+		Tags:  jaegerTags(jaegerGRPC, batch.process),
+		Stats: info.NewStats(),
+	}
+	tags := ts.AsTags()
+	metrics.Count("datadog.trace_agent.jaeger.payload", 1, tags, 1)
+	metrics.Count("datadog.trace_agent.jaeger.bytes", int64(len(req)), tags, 1)
+	metrics.Count("datadog.trace_agent.jaeger.spans", int64(len(batch.spans)), tags, 1)
+	payload := newIngestPayload(ts, convertJaegerBatch(batch), "")
+	select {
+	case j.out <- payload:
+	default:
+		log.Warn("Payload in channel full. Dropped 1 payload.")
+	}
+	return nil
+}
+
+// jaegerCollectorServiceDesc describes the jaeger.api_v2.CollectorService gRPC service. Its
+// messages are passed as is by rawCodec and decoded by the receiver.
+var jaegerCollectorServiceDesc = grpc.ServiceDesc{
+	ServiceName: "jaeger.api_v2.CollectorService",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "PostSpans",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+			var req []byte
+			if err := dec(&req); err != nil {
+				return nil, err
+			}
+			// the PostSpansResponse message is empty
+			return &[]byte{}, srv.(*JaegerReceiver).postSpans(req)
+		},
+	}},
+	Metadata: "model.proto",
+}
+
+// rawCodec is a gRPC codec passing the encoded messages as is, as *[]byte.
+type rawCodec struct{}
+
+// Marshal implements grpc.Codec.
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return *b, nil
+}
+
+// Unmarshal implements grpc.Codec.
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+// Name implements encoding.Codec.
+func (rawCodec) Name() string { return "proto" }
+
+// String implements grpc.Codec.
+func (rawCodec) String() string { return "proto" }
+
+// unmarshalJaegerPostSpansRequest decodes the PostSpansRequest message of the Jaeger api_v2
+// protobuf model, holding a batch of spans.
+func unmarshalJaegerPostSpansRequest(b []byte) (*jaegerBatch, error) {
+	batch := &jaegerBatch{}
+	err := decodeProto(b, func(f protoField) error {
+		if f.Num != 1 || f.Type != protowire.BytesType {
+			return nil
+		}
+		return decodeProto(f.Bytes, func(f protoField) error {
+			if f.Type != protowire.BytesType {
+				return nil
+			}
+			switch f.Num {
+			case 1:
+				span, err := unmarshalJaegerProtoSpan(f.Bytes)
+				if err != nil {
+					return err
+				}
+				batch.spans = append(batch.spans, span)
+			case 2:
+				p, err := unmarshalJaegerProtoProcess(f.Bytes)
+				if err != nil {
+					return err
+				}
+				batch.process = p
+			}
+			return nil
+		})
+	})
+	return batch, err
+}
+
+func unmarshalJaegerProtoSpan(b []byte) (*jaegerSpan, error) {
+	span := &jaegerSpan{}
+	var parentSet bool
+	err := decodeProto(b, func(f protoField) error {
+		switch {
+		case f.Num == 1 && f.Type == protowire.BytesType:
+			span.traceID = jaegerProtoID(f.Bytes)
+		case f.Num == 2 && f.Type == protowire.BytesType:
+			span.spanID = jaegerProtoID(f.Bytes)
+		case f.Num == 3 && f.Type == protowire.BytesType:
+			span.operationName = string(f.Bytes)
+		case f.Num == 4 && f.Type == protowire.BytesType:
+			var spanID, refType uint64
+			err := decodeProto(f.Bytes, func(f protoField) error {
+				switch {
+				case f.Num == 2 && f.Type == protowire.BytesType:
+					spanID = jaegerProtoID(f.Bytes)
+				case f.Num == 3 && f.Type == protowire.VarintType:
+					refType = f.Value
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			// the parent is the first CHILD_OF (0) reference
+			if refType == 0 && !parentSet {
+				span.parentID = spanID
+				parentSet = true
+			}
+		case f.Num == 6 && f.Type == protowire.BytesType:
+			ts, err := unmarshalProtoTimestamp(f.Bytes)
+			if err != nil {
+				return err
+			}
+			span.start = ts
+		case f.Num == 7 && f.Type == protowire.BytesType:
+			d, err := unmarshalProtoTimestamp(f.Bytes)
+			if err != nil {
+				return err
+			}
+			span.duration = d
+		case f.Num == 8 && f.Type == protowire.BytesType:
+			tag, err := unmarshalJaegerProtoKeyValue(f.Bytes)
+			if err != nil {
+				return err
+			}
+			span.tags = append(span.tags, tag)
+		case f.Num == 9 && f.Type == protowire.BytesType:
+			var l jaegerLog
+			err := decodeProto(f.Bytes, func(f protoField) error {
+				if f.Type != protowire.BytesType {
+					return nil
+				}
+				switch f.Num {
+				case 1:
+					ts, err := unmarshalProtoTimestamp(f.Bytes)
+					if err != nil {
+						return err
+					}
+					l.timestamp = ts
+				case 2:
+					tag, err := unmarshalJaegerProtoKeyValue(f.Bytes)
+					if err != nil {
+						return err
+					}
+					l.fields = append(l.fields, tag)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			span.logs = append(span.logs, l)
+		case f.Num == 10 && f.Type == protowire.BytesType:
+			p, err := unmarshalJaegerProtoProcess(f.Bytes)
+			if err != nil {
+				return err
+			}
+			span.process = p
+		}
+		return nil
+	})
+	return span, err
+}
+
+func unmarshalJaegerProtoProcess(b []byte) (*jaegerProcess, error) {
+	p := &jaegerProcess{}
+	err := decodeProto(b, func(f protoField) error {
+		if f.Type != protowire.BytesType {
+			return nil
+		}
+		switch f.Num {
+		case 1:
+			p.serviceName = string(f.Bytes)
+		case 2:
+			tag, err := unmarshalJaegerProtoKeyValue(f.Bytes)
+			if err != nil {
+				return err
+			}
+			p.tags = append(p.tags, tag)
+		}
+		return nil
+	})
+	return p, err
+}
+
+// jaegerProtoValueTypes holds the values of the ValueType enum.
+const (
+	jaegerProtoString = iota
+	jaegerProtoBool
+	jaegerProtoInt64
+	jaegerProtoFloat64
+	jaegerProtoBinary
+)
+
+func unmarshalJaegerProtoKeyValue(b []byte) (jaegerTag, error) {
+	var (
+		tag       jaegerTag
+		vType     uint64
+		str       string
+		bin       []byte
+		num       uint64
+		boolValue bool
+	)
+	err := decodeProto(b, func(f protoField) error {
+		switch {
+		case f.Num == 1 && f.Type == protowire.BytesType:
+			tag.key = string(f.Bytes)
+		case f.Num == 2 && f.Type == protowire.VarintType:
+			vType = f.Value
+		case f.Num == 3 && f.Type == protowire.BytesType:
+			str = string(f.Bytes)
+		case f.Num == 4 && f.Type == protowire.VarintType:
+			boolValue = f.Value != 0
+		case (f.Num == 5 && f.Type == protowire.VarintType) || (f.Num == 6 && f.Type == protowire.Fixed64Type):
+			num = f.Value
+		case f.Num == 7 && f.Type == protowire.BytesType:
+			bin = f.Bytes
+		}
+		return nil
+	})
+	switch vType {
+	case jaegerProtoBool:
+		tag.value = boolValue
+	case jaegerProtoInt64:
+		tag.value = int64(num)
+	case jaegerProtoFloat64:
+		tag.value = math.Float64frombits(num)
+	case jaegerProtoBinary:
+		tag.value = bin
+	default:
+		tag.value = str
+	}
+	return tag, err
+}
+
+// unmarshalProtoTimestamp decodes a google.protobuf.Timestamp or google.protobuf.Duration
+// message, which have the same fields, and returns it in nanoseconds.
+func unmarshalProtoTimestamp(b []byte) (int64, error) {
+	var seconds, nanos int64
+	err := decodeProto(b, func(f protoField) error {
+		if f.Type != protowire.VarintType {
+			return nil
+		}
+		switch f.Num {
+		case 1:
+			seconds = int64(f.Value)
+		case 2:
+			nanos = int64(int32(f.Value))
+		}
+		return nil
+	})
+	return seconds*int64(time.Second) + nanos, err
+}
+
+// jaegerProtoID returns the 64 bits ID b, or the lower 64 bits of the 128 bits trace ID b.
+func jaegerProtoID(b []byte) uint64 {
+	if len(b) > 8 {
+		b = b[len(b)-8:]
+	}
+	var id uint64
+	for _, c := range b {
+		id = id<<8 | uint64(c)
+	}
+	return id
+}
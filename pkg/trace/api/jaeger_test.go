@@ -0,0 +1,321 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// thriftWriter encodes values with the Thrift binary protocol.
+type thriftWriter struct{ bytes.Buffer }
+
+func (w *thriftWriter) field(typ byte, id int16) {
+	w.WriteByte(typ)
+	binary.Write(w, binary.BigEndian, id) //nolint:errcheck
+}
+
+func (w *thriftWriter) stop() { w.WriteByte(thriftStop) }
+
+func (w *thriftWriter) list(typ byte, n int) {
+	w.WriteByte(typ)
+	binary.Write(w, binary.BigEndian, int32(n)) //nolint:errcheck
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(thriftI32, id)
+	binary.Write(w, binary.BigEndian, v) //nolint:errcheck
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(thriftI64, id)
+	binary.Write(w, binary.BigEndian, v) //nolint:errcheck
+}
+
+func (w *thriftWriter) str(id int16, v string) {
+	w.field(thriftString, id)
+	binary.Write(w, binary.BigEndian, int32(len(v))) //nolint:errcheck
+	w.WriteString(v)
+}
+
+// tags writes a list of Jaeger tags, with the values of type string, bool, int64 or float64.
+func (w *thriftWriter) tags(id int16, tags [][2]interface{}) {
+	w.field(thriftList, id)
+	w.list(thriftStruct, len(tags))
+	for _, t := range tags {
+		w.str(1, t[0].(string))
+		switch v := t[1].(type) {
+		case string:
+			w.i32(2, jaegerThriftString)
+			w.str(3, v)
+		case float64:
+			w.i32(2, jaegerThriftDouble)
+			w.field(thriftDouble, 4)
+			binary.Write(w, binary.BigEndian, math.Float64bits(v)) //nolint:errcheck
+		case bool:
+			w.i32(2, jaegerThriftBool)
+			w.field(thriftBool, 5)
+			if v {
+				w.WriteByte(1)
+			} else {
+				w.WriteByte(0)
+			}
+		case int64:
+			w.i32(2, jaegerThriftLong)
+			w.i64(6, v)
+		}
+		w.stop()
+	}
+}
+
+// jaegerTestThriftBatch returns a batch of two spans of a Go service, a server span and a
+// failed client span.
+func jaegerTestThriftBatch() []byte {
+	var w thriftWriter
+	// process
+	w.field(thriftStruct, 1)
+	w.str(1, "frontend")
+	w.tags(2, [][2]interface{}{{"jaeger.version", "Go-2.30.0"}, {"hostname", "host-1"}})
+	w.stop()
+	// spans
+	w.field(thriftList, 2)
+	w.list(thriftStruct, 2)
+
+	w.i64(1, 0xabc)
+	w.i64(2, 0x5af7183fb1d4cf5f)
+	w.i64(3, 0xdef)
+	w.i64(4, 0)
+	w.str(5, "HTTP GET")
+	w.i32(7, 1)
+	w.i64(8, 1650000000000000)
+	w.i64(9, 1500)
+	w.tags(10, [][2]interface{}{{"span.kind", "server"}, {"http.method", "GET"}, {"http.route", "/users/{id}"}, {"http.status_code", int64(200)}, {"ratio", 0.5}})
+	w.field(thriftStruct, 12) // unknown field
+	w.str(1, "skipped")
+	w.stop()
+	w.stop()
+
+	w.i64(1, 0xabc)
+	w.i64(3, 0x123)
+	w.i64(4, 0)
+	w.str(5, "query")
+	w.field(thriftList, 6) // references
+	w.list(thriftStruct, 1)
+	w.i32(1, 0) // CHILD_OF
+	w.i64(2, 0xabc)
+	w.i64(3, 0)
+	w.i64(4, 0xdef)
+	w.stop()
+	w.i64(8, 1650000000000500)
+	w.i64(9, 200)
+	w.tags(10, [][2]interface{}{{"span.kind", "client"}, {"db.system", "redis"}, {"error", true}})
+	w.field(thriftList, 11) // logs
+	w.list(thriftStruct, 1)
+	w.i64(1, 1650000000000600)
+	w.tags(2, [][2]interface{}{{"event", "error"}, {"error.kind", "timeout"}, {"message", "no reply"}})
+	w.stop()
+	w.stop()
+
+	w.stop()
+	return w.Bytes()
+}
+
+func TestJaegerThrift(t *testing.T) {
+	assert := assert.New(t)
+	spans, err := decodeJaegerThriftSpans("application/x-thrift", jaegerTestThriftBatch())
+	require.NoError(t, err)
+	require.Len(t, spans, 2)
+
+	assert.Equal(&pb.Span{
+		Service:  "frontend",
+		Name:     "jaeger.server",
+		Resource: "GET /users/{id}",
+		TraceID:  0xabc,
+		SpanID:   0xdef,
+		Start:    1650000000000000000,
+		Duration: 1500000,
+		Meta: map[string]string{
+			"jaeger.version": "Go-2.30.0",
+			"hostname":       "host-1",
+			"http.method":    "GET",
+			"http.route":     "/users/{id}",
+		},
+		Metrics: map[string]float64{"http.status_code": 200, "ratio": 0.5},
+		Type:    "web",
+	}, spans[0])
+	assert.Equal(&pb.Span{
+		Service:  "frontend",
+		Name:     "jaeger.client",
+		Resource: "query",
+		TraceID:  0xabc,
+		SpanID:   0x123,
+		ParentID: 0xdef,
+		Start:    1650000000000500000,
+		Duration: 200000,
+		Error:    1,
+		Meta: map[string]string{
+			"jaeger.version": "Go-2.30.0",
+			"hostname":       "host-1",
+			"db.system":      "redis",
+			"error":          "true",
+			"error.msg":      "no reply",
+			"error.type":     "timeout",
+			"events":         `[{"time_unix_nano":1650000000000600000,"name":"error","attributes":{"error.kind":"timeout","message":"no reply"}}]`,
+		},
+		Metrics: map[string]float64{},
+		Type:    "cache",
+	}, spans[1])
+
+	batch := jaegerTestThriftBatch()
+	_, err = decodeJaegerThriftSpans("application/x-thrift", batch[:len(batch)-10])
+	assert.Error(err)
+	_, err = decodeJaegerThriftSpans("application/json", batch)
+	assert.Error(err)
+}
+
+func TestJaegerThriftEndpoint(t *testing.T) {
+	conf := newTestReceiverConfig()
+	conf.Jaeger.Enabled = true
+	r := newTestReceiverFromConfig(conf)
+
+	req := httptest.NewRequest("POST", "/api/traces", bytes.NewReader(jaegerTestThriftBatch()))
+	req.Header.Set("Content-Type", "application/x-thrift")
+	rec := httptest.NewRecorder()
+	r.handleSpans(jaegerThrift, decodeJaegerThriftSpans).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	select {
+	case p := <-r.out:
+		assert.Equal(t, "jaeger_thrift", p.Source.EndpointVersion)
+		require.Len(t, p.TracerPayload.Chunks, 1)
+		assert.Len(t, p.TracerPayload.Chunks[0].Spans, 2)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestJaegerReceiver(t *testing.T) {
+	t.Run("Start/nil", func(t *testing.T) {
+		j := NewJaegerReceiver(nil, config.New())
+		j.Start()
+		defer j.Stop()
+		assert.Nil(t, j.grpcsrv)
+	})
+
+	t.Run("PostSpans", func(t *testing.T) {
+		port := testutil.FreeTCPPort(t)
+		cfg := config.New()
+		cfg.Jaeger = config.JaegerConfig{BindHost: "localhost", GRPCPort: port}
+		out := make(chan *Payload, 1)
+		j := NewJaegerReceiver(out, cfg)
+		j.Start()
+		defer j.Stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn, err := grpc.DialContext(ctx, fmt.Sprintf("localhost:%d", port), grpc.WithInsecure(), grpc.WithBlock())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		req := jaegerTestPostSpansRequest()
+		var resp []byte
+		err = conn.Invoke(ctx, "/jaeger.api_v2.CollectorService/PostSpans", &req, &resp, grpc.ForceCodec(rawCodec{}))
+		require.NoError(t, err)
+
+		select {
+		case p := <-out:
+			assert.Equal(t, "jaeger_grpc", p.Source.EndpointVersion)
+			assert.Equal(t, "java", p.Source.Lang)
+			assert.Equal(t, "jaeger-Java-1.8.0", p.Source.TracerVersion)
+			require.Len(t, p.TracerPayload.Chunks, 1)
+			require.Len(t, p.TracerPayload.Chunks[0].Spans, 1)
+			assert.Equal(t, &pb.Span{
+				Service:  "backend",
+				Name:     "jaeger.client",
+				Resource: "GET",
+				TraceID:  0xabc,
+				SpanID:   0x123,
+				ParentID: 0xdef,
+				Start:    1650000000500000000,
+				Duration: 2000000,
+				Error:    1,
+				Meta: map[string]string{
+					"jaeger.version": "Java-1.8.0",
+					"http.method":    "GET",
+					"error.msg":      "502",
+				},
+				Metrics: map[string]float64{"http.status_code": 502},
+				Type:    "http",
+			}, p.TracerPayload.Chunks[0].Spans[0])
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+
+		invalid := []byte{0x0a, 0xff}
+		err = conn.Invoke(ctx, "/jaeger.api_v2.CollectorService/PostSpans", &invalid, &resp, grpc.ForceCodec(rawCodec{}))
+		assert.Error(t, err)
+	})
+}
+
+// jaegerTestPostSpansRequest returns a PostSpansRequest message holding a failed client span.
+func jaegerTestPostSpansRequest() []byte {
+	message := func(fields ...func([]byte) []byte) []byte {
+		var b []byte
+		for _, f := range fields {
+			b = f(b)
+		}
+		return b
+	}
+	bytesField := func(num protowire.Number, v []byte) func([]byte) []byte {
+		return func(b []byte) []byte {
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			return protowire.AppendBytes(b, v)
+		}
+	}
+	varintField := func(num protowire.Number, v uint64) func([]byte) []byte {
+		return func(b []byte) []byte {
+			b = protowire.AppendTag(b, num, protowire.VarintType)
+			return protowire.AppendVarint(b, v)
+		}
+	}
+	keyValue := func(key string, fields ...func([]byte) []byte) func([]byte) []byte {
+		return bytesField(8, message(append([]func([]byte) []byte{bytesField(1, []byte(key))}, fields...)...))
+	}
+	traceID := []byte{0x5a, 0xf7, 0x18, 0x3f, 0xb1, 0xd4, 0xcf, 0x5f, 0, 0, 0, 0, 0, 0, 0x0a, 0xbc}
+	span := message(
+		bytesField(1, traceID),
+		bytesField(2, []byte{0, 0, 0, 0, 0, 0, 0x01, 0x23}),
+		bytesField(3, []byte("get")),
+		bytesField(4, message(bytesField(1, traceID), bytesField(2, []byte{0, 0, 0, 0, 0, 0, 0x0d, 0xef}))),
+		bytesField(6, message(varintField(1, 1650000000), varintField(2, 500000000))),
+		bytesField(7, message(varintField(2, 2000000))),
+		keyValue("span.kind", bytesField(3, []byte("client"))),
+		keyValue("http.method", bytesField(3, []byte("GET"))),
+		keyValue("http.status_code", varintField(2, jaegerProtoInt64), varintField(5, 502)),
+	)
+	process := message(
+		bytesField(1, []byte("backend")),
+		bytesField(2, message(bytesField(1, []byte("jaeger.version")), bytesField(3, []byte("Java-1.8.0")))),
+	)
+	batch := message(bytesField(1, span), bytesField(2, process))
+	return message(bytesField(1, batch))
+}
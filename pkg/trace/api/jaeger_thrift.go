@@ -0,0 +1,341 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// The Thrift types of the binary protocol.
+const (
+	thriftStop   byte = 0
+	thriftBool   byte = 2
+	thriftByte   byte = 3
+	thriftDouble byte = 4
+	thriftI16    byte = 6
+	thriftI32    byte = 8
+	thriftI64    byte = 10
+	thriftString byte = 11
+	thriftStruct byte = 12
+	thriftMap    byte = 13
+	thriftSet    byte = 14
+	thriftList   byte = 15
+)
+
+// thriftMaxDepth is the maximum depth of the nested structures and containers.
+const thriftMaxDepth = 64
+
+var errThriftTruncated = errors.New("thrift: truncated payload")
+
+// thriftReader reads the values of a message encoded with the Thrift binary protocol. The first
+// error is kept and stops the reading, the next reads returning zero values.
+type thriftReader struct {
+	buf []byte
+	err error
+}
+
+func (r *thriftReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.buf) {
+		r.err = errThriftTruncated
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *thriftReader) readByte() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *thriftReader) readI16() int16 {
+	if b := r.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *thriftReader) readI32() int32 {
+	if b := r.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (r *thriftReader) readI64() int64 {
+	if b := r.next(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (r *thriftReader) readDouble() float64 {
+	return math.Float64frombits(uint64(r.readI64()))
+}
+
+func (r *thriftReader) readBinary() []byte {
+	return r.next(int(r.readI32()))
+}
+
+func (r *thriftReader) readString() string {
+	return string(r.readBinary())
+}
+
+// readStruct reads the fields of a structure, calling readField with the ID and type of each
+// of them. The fields for which readField returns false are skipped.
+func (r *thriftReader) readStruct(readField func(id int16, typ byte) bool) {
+	for r.err == nil {
+		typ := r.readByte()
+		if typ == thriftStop || r.err != nil {
+			return
+		}
+		id := r.readI16()
+		if !readField(id, typ) {
+			r.skip(typ, 0)
+		}
+	}
+}
+
+// readList reads the header of a list, and calls readElem for each of its elements, of type typ.
+func (r *thriftReader) readList(readElem func(typ byte)) {
+	typ := r.readByte()
+	n := int(r.readI32())
+	if n < 0 || n > len(r.buf) {
+		// each element is at least one byte long
+		r.fail(errThriftTruncated)
+		return
+	}
+	for i := 0; i < n && r.err == nil; i++ {
+		readElem(typ)
+	}
+}
+
+func (r *thriftReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// skip skips a value of the given type.
+func (r *thriftReader) skip(typ byte, depth int) {
+	if depth > thriftMaxDepth {
+		r.fail(errors.New("thrift: maximum depth exceeded"))
+		return
+	}
+	switch typ {
+	case thriftBool, thriftByte:
+		r.next(1)
+	case thriftI16:
+		r.next(2)
+	case thriftI32:
+		r.next(4)
+	case thriftI64, thriftDouble:
+		r.next(8)
+	case thriftString:
+		r.readBinary()
+	case thriftStruct:
+		r.readStruct(func(_ int16, typ byte) bool {
+			r.skip(typ, depth+1)
+			return true
+		})
+	case thriftMap:
+		ktyp, vtyp := r.readByte(), r.readByte()
+		n := int(r.readI32())
+		if n < 0 || n > len(r.buf) {
+			r.fail(errThriftTruncated)
+			return
+		}
+		for i := 0; i < n && r.err == nil; i++ {
+			r.skip(ktyp, depth+1)
+			r.skip(vtyp, depth+1)
+		}
+	case thriftSet, thriftList:
+		r.readList(func(typ byte) { r.skip(typ, depth+1) })
+	default:
+		r.fail(fmt.Errorf("thrift: unknown type %d", typ))
+	}
+}
+
+// decodeJaegerThriftSpans decodes a Jaeger batch encoded with the Thrift binary protocol and
+// converts its spans to Datadog spans.
+func decodeJaegerThriftSpans(mediaType string, body []byte) ([]*pb.Span, error) {
+	if mediaType != "application/x-thrift" && mediaType != "application/vnd.apache.thrift.binary" {
+		return nil, fmt.Errorf("unsupported media type %q", mediaType)
+	}
+	batch, err := unmarshalJaegerThriftBatch(body)
+	if err != nil {
+		return nil, err
+	}
+	return convertJaegerBatch(batch), nil
+}
+
+// unmarshalJaegerThriftBatch decodes the Batch structure of the Jaeger Thrift model.
+func unmarshalJaegerThriftBatch(b []byte) (*jaegerBatch, error) {
+	r := &thriftReader{buf: b}
+	batch := &jaegerBatch{}
+	r.readStruct(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftStruct:
+			batch.process = r.readJaegerProcess()
+		case id == 2 && typ == thriftList:
+			r.readList(func(typ byte) {
+				if typ != thriftStruct {
+					r.fail(fmt.Errorf("thrift: unexpected span type %d", typ))
+					return
+				}
+				batch.spans = append(batch.spans, r.readJaegerSpan())
+			})
+		default:
+			return false
+		}
+		return true
+	})
+	return batch, r.err
+}
+
+func (r *thriftReader) readJaegerProcess() *jaegerProcess {
+	p := &jaegerProcess{}
+	r.readStruct(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftString:
+			p.serviceName = r.readString()
+		case id == 2 && typ == thriftList:
+			p.tags = r.readJaegerTags()
+		default:
+			return false
+		}
+		return true
+	})
+	return p
+}
+
+func (r *thriftReader) readJaegerSpan() *jaegerSpan {
+	span := &jaegerSpan{}
+	r.readStruct(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftI64:
+			span.traceID = uint64(r.readI64())
+		case id == 3 && typ == thriftI64:
+			span.spanID = uint64(r.readI64())
+		case id == 4 && typ == thriftI64:
+			span.parentID = uint64(r.readI64())
+		case id == 5 && typ == thriftString:
+			span.operationName = r.readString()
+		case id == 6 && typ == thriftList:
+			r.readList(func(typ byte) {
+				var refType int32
+				var spanID uint64
+				r.readStruct(func(id int16, typ byte) bool {
+					switch {
+					case id == 1 && typ == thriftI32:
+						refType = r.readI32()
+					case id == 4 && typ == thriftI64:
+						spanID = uint64(r.readI64())
+					default:
+						return false
+					}
+					return true
+				})
+				// the parent is the first CHILD_OF (0) reference, when the parent span ID is not set
+				if refType == 0 && span.parentID == 0 {
+					span.parentID = spanID
+				}
+			})
+		case id == 8 && typ == thriftI64:
+			span.start = r.readI64() * 1000
+		case id == 9 && typ == thriftI64:
+			span.duration = r.readI64() * 1000
+		case id == 10 && typ == thriftList:
+			span.tags = r.readJaegerTags()
+		case id == 11 && typ == thriftList:
+			r.readList(func(typ byte) {
+				var l jaegerLog
+				r.readStruct(func(id int16, typ byte) bool {
+					switch {
+					case id == 1 && typ == thriftI64:
+						l.timestamp = r.readI64() * 1000
+					case id == 2 && typ == thriftList:
+						l.fields = r.readJaegerTags()
+					default:
+						return false
+					}
+					return true
+				})
+				span.logs = append(span.logs, l)
+			})
+		default:
+			return false
+		}
+		return true
+	})
+	return span
+}
+
+// The values of the TagType enum.
+const (
+	jaegerThriftString = iota
+	jaegerThriftDouble
+	jaegerThriftBool
+	jaegerThriftLong
+	jaegerThriftBinary
+)
+
+func (r *thriftReader) readJaegerTags() []jaegerTag {
+	var tags []jaegerTag
+	r.readList(func(typ byte) {
+		var (
+			tag    jaegerTag
+			vType  int32
+			values = make(map[int16]interface{}, 1)
+		)
+		r.readStruct(func(id int16, typ byte) bool {
+			switch {
+			case id == 1 && typ == thriftString:
+				tag.key = r.readString()
+			case id == 2 && typ == thriftI32:
+				vType = r.readI32()
+			case id == 3 && typ == thriftString:
+				values[id] = r.readString()
+			case id == 4 && typ == thriftDouble:
+				values[id] = r.readDouble()
+			case id == 5 && typ == thriftBool:
+				values[id] = r.readByte() != 0
+			case id == 6 && typ == thriftI64:
+				values[id] = r.readI64()
+			case id == 7 && typ == thriftString:
+				values[id] = r.readBinary()
+			default:
+				return false
+			}
+			return true
+		})
+		switch vType {
+		case jaegerThriftDouble:
+			tag.value = values[4]
+		case jaegerThriftBool:
+			tag.value = values[5]
+		case jaegerThriftLong:
+			tag.value = values[6]
+		case jaegerThriftBinary:
+			tag.value = values[7]
+		default:
+			tag.value = values[3]
+		}
+		tags = append(tags, tag)
+	})
+	return tags
+}
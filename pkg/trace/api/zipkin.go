@@ -0,0 +1,254 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// zipkinV2 is the version of the Zipkin v2 endpoint (/api/v2/spans), receiving a list of
+// Zipkin spans encoded as JSON or as protobuf (application/x-protobuf).
+const zipkinV2 Version = "zipkin_v2"
+
+// zipkinSpan is a span of the Zipkin v2 model.
+type zipkinSpan struct {
+	TraceID        string             `json:"traceId"`
+	ParentID       string             `json:"parentId"`
+	ID             string             `json:"id"`
+	Kind           string             `json:"kind"`
+	Name           string             `json:"name"`
+	Timestamp      uint64             `json:"timestamp"` // epoch microseconds
+	Duration       uint64             `json:"duration"`  // microseconds
+	LocalEndpoint  *zipkinEndpoint    `json:"localEndpoint"`
+	RemoteEndpoint *zipkinEndpoint    `json:"remoteEndpoint"`
+	Annotations    []zipkinAnnotation `json:"annotations"`
+	Tags           map[string]string  `json:"tags"`
+}
+
+// zipkinEndpoint is the network context of a Zipkin span.
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+	IPv4        string `json:"ipv4"`
+	IPv6        string `json:"ipv6"`
+	Port        int    `json:"port"`
+}
+
+// zipkinAnnotation is a timestamped event of a Zipkin span.
+type zipkinAnnotation struct {
+	Timestamp uint64 `json:"timestamp"` // epoch microseconds
+	Value     string `json:"value"`
+}
+
+// decodeZipkinSpans decodes a list of Zipkin v2 spans and converts them to Datadog spans.
+func decodeZipkinSpans(mediaType string, body []byte) ([]*pb.Span, error) {
+	var in []*zipkinSpan
+	switch mediaType {
+	case "application/x-protobuf", "application/protobuf":
+		var err error
+		if in, err = unmarshalZipkinProto(body); err != nil {
+			return nil, err
+		}
+	default:
+		if err := json.Unmarshal(body, &in); err != nil {
+			return nil, err
+		}
+	}
+	spans := make([]*pb.Span, 0, len(in))
+	for _, zs := range in {
+		span, err := convertZipkinSpan(zs)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, span)
+	}
+	return spans, nil
+}
+
+// convertZipkinSpan converts the Zipkin span in to a Datadog span. The trace ID of the span is
+// made of the lower 64 bits of the Zipkin trace ID, which can be 128 bits long.
+func convertZipkinSpan(in *zipkinSpan) (*pb.Span, error) {
+	traceID, err := parseZipkinID(in.TraceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trace ID %q: %v", in.TraceID, err)
+	}
+	spanID, err := parseZipkinID(in.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid span ID %q: %v", in.ID, err)
+	}
+	var parentID uint64
+	if in.ParentID != "" {
+		if parentID, err = parseZipkinID(in.ParentID); err != nil {
+			return nil, fmt.Errorf("invalid parent ID %q: %v", in.ParentID, err)
+		}
+	}
+	span := &pb.Span{
+		TraceID:  traceID,
+		SpanID:   spanID,
+		ParentID: parentID,
+		Start:    int64(in.Timestamp) * 1000,
+		Duration: int64(in.Duration) * 1000,
+		Meta:     make(map[string]string, len(in.Tags)),
+		Metrics:  map[string]float64{},
+	}
+	if in.LocalEndpoint != nil {
+		span.Service = in.LocalEndpoint.ServiceName
+	}
+	if e := in.RemoteEndpoint; e != nil {
+		if e.ServiceName != "" {
+			span.Meta["peer.service"] = e.ServiceName
+		}
+		if e.IPv4 != "" {
+			span.Meta["out.host"] = e.IPv4
+		} else if e.IPv6 != "" {
+			span.Meta["out.host"] = e.IPv6
+		}
+		if e.Port != 0 {
+			span.Meta["out.port"] = strconv.Itoa(e.Port)
+		}
+	}
+	if len(in.Annotations) > 0 {
+		events := make([]spanEvent, 0, len(in.Annotations))
+		for _, a := range in.Annotations {
+			events = append(events, spanEvent{TimeUnixNano: int64(a.Timestamp) * 1000, Name: a.Value})
+		}
+		if b, err := json.Marshal(events); err == nil {
+			span.Meta["events"] = string(b)
+		}
+	}
+	for k, v := range in.Tags {
+		setMetaOTLP(span, k, v)
+	}
+	finishSpan(span, "zipkin", spanKindFromString(in.Kind), in.Name)
+	return span, nil
+}
+
+// parseZipkinID parses a Zipkin ID, a 64 or 128 bits hexadecimal number, and returns its
+// lower 64 bits.
+func parseZipkinID(id string) (uint64, error) {
+	if len(id) > 16 {
+		id = id[len(id)-16:]
+	}
+	return strconv.ParseUint(id, 16, 64)
+}
+
+// unmarshalZipkinProto decodes the ListOfSpans message of the Zipkin v2 protobuf model.
+func unmarshalZipkinProto(b []byte) ([]*zipkinSpan, error) {
+	var spans []*zipkinSpan
+	err := decodeProto(b, func(f protoField) error {
+		if f.Num != 1 || f.Type != protowire.BytesType {
+			return nil
+		}
+		span, err := unmarshalZipkinProtoSpan(f.Bytes)
+		if err != nil {
+			return err
+		}
+		spans = append(spans, span)
+		return nil
+	})
+	return spans, err
+}
+
+// zipkinProtoKinds maps the values of the Span.Kind enum to the names of the kinds.
+var zipkinProtoKinds = map[uint64]string{
+	1: "CLIENT",
+	2: "SERVER",
+	3: "PRODUCER",
+	4: "CONSUMER",
+}
+
+func unmarshalZipkinProtoSpan(b []byte) (*zipkinSpan, error) {
+	span := &zipkinSpan{}
+	err := decodeProto(b, func(f protoField) error {
+		switch {
+		case f.Num == 1 && f.Type == protowire.BytesType:
+			span.TraceID = hex.EncodeToString(f.Bytes)
+		case f.Num == 2 && f.Type == protowire.BytesType:
+			span.ParentID = hex.EncodeToString(f.Bytes)
+		case f.Num == 3 && f.Type == protowire.BytesType:
+			span.ID = hex.EncodeToString(f.Bytes)
+		case f.Num == 4 && f.Type == protowire.VarintType:
+			span.Kind = zipkinProtoKinds[f.Value]
+		case f.Num == 5 && f.Type == protowire.BytesType:
+			span.Name = string(f.Bytes)
+		case f.Num == 6 && f.Type == protowire.Fixed64Type:
+			span.Timestamp = f.Value
+		case f.Num == 7 && f.Type == protowire.VarintType:
+			span.Duration = f.Value
+		case (f.Num == 8 || f.Num == 9) && f.Type == protowire.BytesType:
+			e, err := unmarshalZipkinProtoEndpoint(f.Bytes)
+			if err != nil {
+				return err
+			}
+			if f.Num == 8 {
+				span.LocalEndpoint = e
+			} else {
+				span.RemoteEndpoint = e
+			}
+		case f.Num == 10 && f.Type == protowire.BytesType:
+			var a zipkinAnnotation
+			err := decodeProto(f.Bytes, func(f protoField) error {
+				switch {
+				case f.Num == 1 && f.Type == protowire.Fixed64Type:
+					a.Timestamp = f.Value
+				case f.Num == 2 && f.Type == protowire.BytesType:
+					a.Value = string(f.Bytes)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			span.Annotations = append(span.Annotations, a)
+		case f.Num == 11 && f.Type == protowire.BytesType:
+			var k, v string
+			err := decodeProto(f.Bytes, func(f protoField) error {
+				switch {
+				case f.Num == 1 && f.Type == protowire.BytesType:
+					k = string(f.Bytes)
+				case f.Num == 2 && f.Type == protowire.BytesType:
+					v = string(f.Bytes)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if span.Tags == nil {
+				span.Tags = make(map[string]string)
+			}
+			span.Tags[k] = v
+		}
+		return nil
+	})
+	return span, err
+}
+
+func unmarshalZipkinProtoEndpoint(b []byte) (*zipkinEndpoint, error) {
+	e := &zipkinEndpoint{}
+	err := decodeProto(b, func(f protoField) error {
+		switch {
+		case f.Num == 1 && f.Type == protowire.BytesType:
+			e.ServiceName = string(f.Bytes)
+		case f.Num == 2 && f.Type == protowire.BytesType && len(f.Bytes) == net.IPv4len:
+			e.IPv4 = net.IP(f.Bytes).String()
+		case f.Num == 3 && f.Type == protowire.BytesType && len(f.Bytes) == net.IPv6len:
+			e.IPv6 = net.IP(f.Bytes).String()
+		case f.Num == 4 && f.Type == protowire.VarintType && f.Value <= math.MaxUint16:
+			e.Port = int(f.Value)
+		}
+		return nil
+	})
+	return e, err
+}
@@ -0,0 +1,229 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const zipkinTestSpans = `[
+	{
+		"traceId": "5af7183fb1d4cf5f0000000000000abc",
+		"id": "0000000000000def",
+		"kind": "SERVER",
+		"name": "get /users/{id}",
+		"timestamp": 1650000000000000,
+		"duration": 1500,
+		"localEndpoint": {"serviceName": "frontend", "ipv4": "10.0.0.1"},
+		"tags": {"http.method": "GET", "http.route": "/users/{id}", "http.status_code": "503", "env": "Prod"}
+	},
+	{
+		"traceId": "0000000000000abc",
+		"parentId": "0000000000000def",
+		"id": "0000000000000123",
+		"kind": "CLIENT",
+		"name": "select",
+		"timestamp": 1650000000000500,
+		"duration": 200,
+		"localEndpoint": {"serviceName": "frontend"},
+		"remoteEndpoint": {"serviceName": "mysql", "ipv4": "10.0.0.2", "port": 3306},
+		"annotations": [{"timestamp": 1650000000000600, "value": "wr"}],
+		"tags": {"db.system": "mysql", "error": "connection reset"}
+	}
+]`
+
+func TestZipkinConvertSpans(t *testing.T) {
+	assert := assert.New(t)
+	spans, err := decodeZipkinSpans("application/json", []byte(zipkinTestSpans))
+	require.NoError(t, err)
+	require.Len(t, spans, 2)
+
+	assert.Equal(&pb.Span{
+		Service:  "frontend",
+		Name:     "zipkin.server",
+		Resource: "GET /users/{id}",
+		TraceID:  0xabc,
+		SpanID:   0xdef,
+		Start:    1650000000000000000,
+		Duration: 1500000,
+		Error:    1,
+		Meta: map[string]string{
+			"http.method":      "GET",
+			"http.route":       "/users/{id}",
+			"http.status_code": "503",
+			"env":              "Prod",
+			"error.msg":        "503",
+		},
+		Metrics: map[string]float64{},
+		Type:    "web",
+	}, spans[0])
+	assert.Equal(&pb.Span{
+		Service:  "frontend",
+		Name:     "zipkin.client",
+		Resource: "select",
+		TraceID:  0xabc,
+		SpanID:   0x123,
+		ParentID: 0xdef,
+		Start:    1650000000000500000,
+		Duration: 200000,
+		Error:    1,
+		Meta: map[string]string{
+			"db.system":    "mysql",
+			"error":        "connection reset",
+			"error.msg":    "connection reset",
+			"peer.service": "mysql",
+			"out.host":     "10.0.0.2",
+			"out.port":     "3306",
+			"events":       `[{"time_unix_nano":1650000000000600000,"name":"wr"}]`,
+		},
+		Metrics: map[string]float64{},
+		Type:    "db",
+	}, spans[1])
+
+	_, err = decodeZipkinSpans("application/json", []byte(`[{"traceId": "xyz", "id": "1"}]`))
+	assert.Error(err)
+	_, err = decodeZipkinSpans("application/json", []byte(`[{"traceId": "1"}]`))
+	assert.Error(err)
+}
+
+func TestZipkinProto(t *testing.T) {
+	endpoint := func(service string, ip []byte, port uint64) []byte {
+		var b []byte
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, service)
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, ip)
+		b = protowire.AppendTag(b, 4, protowire.VarintType)
+		return protowire.AppendVarint(b, port)
+	}
+	var span []byte
+	span = protowire.AppendTag(span, 1, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0x5a, 0xf7, 0x18, 0x3f, 0xb1, 0xd4, 0xcf, 0x5f, 0, 0, 0, 0, 0, 0, 0x0a, 0xbc})
+	span = protowire.AppendTag(span, 2, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0x0d, 0xef})
+	span = protowire.AppendTag(span, 3, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0x01, 0x23})
+	span = protowire.AppendTag(span, 4, protowire.VarintType)
+	span = protowire.AppendVarint(span, 1) // CLIENT
+	span = protowire.AppendTag(span, 5, protowire.BytesType)
+	span = protowire.AppendString(span, "select")
+	span = protowire.AppendTag(span, 6, protowire.Fixed64Type)
+	span = protowire.AppendFixed64(span, 1650000000000500)
+	span = protowire.AppendTag(span, 7, protowire.VarintType)
+	span = protowire.AppendVarint(span, 200)
+	span = protowire.AppendTag(span, 8, protowire.BytesType)
+	span = protowire.AppendBytes(span, endpoint("frontend", nil, 0))
+	span = protowire.AppendTag(span, 9, protowire.BytesType)
+	span = protowire.AppendBytes(span, endpoint("mysql", []byte{10, 0, 0, 2}, 3306))
+	var annotation []byte
+	annotation = protowire.AppendTag(annotation, 1, protowire.Fixed64Type)
+	annotation = protowire.AppendFixed64(annotation, 1650000000000600)
+	annotation = protowire.AppendTag(annotation, 2, protowire.BytesType)
+	annotation = protowire.AppendString(annotation, "wr")
+	span = protowire.AppendTag(span, 10, protowire.BytesType)
+	span = protowire.AppendBytes(span, annotation)
+	for _, tag := range [][2]string{{"db.system", "mysql"}, {"error", "connection reset"}} {
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, tag[0])
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, tag[1])
+		span = protowire.AppendTag(span, 11, protowire.BytesType)
+		span = protowire.AppendBytes(span, entry)
+	}
+	var list []byte
+	list = protowire.AppendTag(list, 1, protowire.BytesType)
+	list = protowire.AppendBytes(list, span)
+
+	fromProto, err := decodeZipkinSpans("application/x-protobuf", list)
+	require.NoError(t, err)
+	fromJSON, err := decodeZipkinSpans("application/json", []byte(zipkinTestSpans))
+	require.NoError(t, err)
+	assert.Equal(t, fromJSON[1:], fromProto)
+
+	_, err = decodeZipkinSpans("application/x-protobuf", list[:len(list)-1])
+	assert.Error(t, err)
+}
+
+func TestZipkinEndpoint(t *testing.T) {
+	conf := newTestReceiverConfig()
+	conf.Zipkin.Enabled = true
+	r := newTestReceiverFromConfig(conf)
+	handler := r.handleSpans(zipkinV2, decodeZipkinSpans)
+
+	t.Run("accepted", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v2/spans", bytes.NewBufferString(zipkinTestSpans))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+
+		select {
+		case p := <-r.out:
+			assert.Equal(t, "zipkin_v2", p.Source.EndpointVersion)
+			assert.Equal(t, "prod", p.TracerPayload.Env)
+			require.Len(t, p.TracerPayload.Chunks, 1)
+			assert.Equal(t, int32(sampler.PriorityAutoKeep), p.TracerPayload.Chunks[0].Priority)
+			assert.Len(t, p.TracerPayload.Chunks[0].Spans, 2)
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		var buf bytes.Buffer
+		gzipw := gzip.NewWriter(&buf)
+		_, err := gzipw.Write([]byte(zipkinTestSpans))
+		require.NoError(t, err)
+		require.NoError(t, gzipw.Close())
+
+		req := httptest.NewRequest("POST", "/api/v2/spans", &buf)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		<-r.out
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v2/spans", bytes.NewBufferString(`{"not": "a list"}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Len(t, r.out, 0)
+	})
+
+	t.Run("too-large-decompressed", func(t *testing.T) {
+		// a small compressed payload decompressing beyond the max request size
+		var buf bytes.Buffer
+		gzipw := gzip.NewWriter(&buf)
+		_, err := gzipw.Write(bytes.Repeat([]byte(" "), int(conf.MaxRequestBytes)+1))
+		require.NoError(t, err)
+		require.NoError(t, gzipw.Close())
+		require.Less(t, int64(buf.Len()), conf.MaxRequestBytes)
+
+		req := httptest.NewRequest("POST", "/api/v2/spans", &buf)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		assert.Len(t, r.out, 0)
+	})
+}
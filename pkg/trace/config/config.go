@@ -36,6 +36,10 @@ type Endpoint struct {
 // TelemetryEndpointPrefix specifies the prefix of the telemetry endpoint URL.
 const TelemetryEndpointPrefix = "https://instrumentation-telemetry-intake."
 
+// MaxExtraAggregationTags is the maximum number of span tags which can be used as extra
+// dimensions of the APM stats.
+const MaxExtraAggregationTags = 5
+
 // OTLP holds the configuration for the OpenTelemetry receiver.
 type OTLP struct {
 	// BindHost specifies the host to bind the receiver to.
@@ -61,6 +65,83 @@ type OTLP struct {
 	MaxRequestBytes int64 `mapstructure:"-"`
 }
 
+// ZipkinConfig holds the configuration for the Zipkin receiver.
+type ZipkinConfig struct {
+	// Enabled reports whether the Zipkin v2 endpoint (/api/v2/spans) is served by the
+	// trace receiver.
+	Enabled bool
+}
+
+// TailSamplingConfig holds the configuration of the tail-based sampling mode, where the chunks
+// of a trace are buffered until the trace is complete and the sampling decision is taken for
+// the whole trace.
+type TailSamplingConfig struct {
+	// Enabled reports whether the tail-based sampling replaces the sampling of the chunks as
+	// they are received.
+	Enabled bool
+
+	// DecisionWait specifies how long the chunks of a trace are buffered, counting from the
+	// first chunk received, before the sampling decision is taken.
+	DecisionWait time.Duration
+
+	// MaxBufferBytes specifies the maximum size of the buffered chunks. When it is reached,
+	// the decision is taken early for the oldest traces.
+	MaxBufferBytes int64
+
+	// Policies lists the policies keeping the traces, evaluated in order. The traces matching
+	// none of them are dropped.
+	Policies []*TailSamplingPolicy
+}
+
+// The types of tail sampling policies.
+const (
+	// TailPolicyLatency keeps the traces lasting at least ThresholdMs milliseconds.
+	TailPolicyLatency = "latency"
+	// TailPolicyError keeps the traces with at least one error.
+	TailPolicyError = "error"
+	// TailPolicyTag keeps the traces with a span having the tag Key, set to one of Values if
+	// not empty.
+	TailPolicyTag = "tag"
+	// TailPolicyRate keeps a SampleRate ratio of the traces.
+	TailPolicyRate = "rate"
+)
+
+// TailSamplingPolicy describes a policy keeping the traces in tail-based sampling mode.
+type TailSamplingPolicy struct {
+	// Name identifies the policy. It is set on the kept chunks, as the _dd.tail_sampling.policy tag.
+	Name string `mapstructure:"name"`
+
+	// Type specifies the type of the policy, one of latency, error, tag or rate.
+	Type string `mapstructure:"type"`
+
+	// Service restricts the policy to the traces whose root span belongs to this service.
+	Service string `mapstructure:"service"`
+
+	// ThresholdMs specifies the minimum duration of the traces kept by a latency policy.
+	ThresholdMs int64 `mapstructure:"threshold_ms"`
+
+	// Key and Values specify the tag of the spans kept by a tag policy.
+	Key    string   `mapstructure:"key"`
+	Values []string `mapstructure:"values"`
+
+	// SampleRate specifies the ratio of the traces kept by a rate policy.
+	SampleRate float64 `mapstructure:"sample_rate"`
+}
+
+// JaegerConfig holds the configuration for the Jaeger receivers.
+type JaegerConfig struct {
+	// Enabled reports whether the Jaeger Thrift over HTTP endpoint (/api/traces) is served
+	// by the trace receiver.
+	Enabled bool
+
+	// BindHost specifies the host to bind the gRPC receiver to.
+	BindHost string
+
+	// GRPCPort specifies the port to use for the Jaeger gRPC collector receiver.
+	// If unset (or 0), the receiver will be off.
+	GRPCPort int
+}
+
 // ObfuscationConfig holds the configuration for obfuscating sensitive data
 // for various span types.
 type ObfuscationConfig struct {
@@ -80,6 +161,14 @@ type ObfuscationConfig struct {
 	// HTTP holds the obfuscation settings for HTTP URLs.
 	HTTP HTTPObfuscationConfig `mapstructure:"http"`
 
+	// GraphQL holds the obfuscation configuration for the GraphQL documents found in the
+	// "graphql.query" and "graphql.source" tags of the spans of type "graphql".
+	GraphQL GraphQLObfuscationConfig `mapstructure:"graphql"`
+
+	// JSONBody holds the obfuscation configuration for the JSON bodies found in the "http.request.body",
+	// "http.response.body", "aws.request.body" and "aws.response.body" tags of the web and HTTP spans.
+	JSONBody JSONObfuscationConfig `mapstructure:"json_body"`
+
 	// RemoveStackTraces specifies whether stack traces should be removed.
 	// More specifically "error.stack" tag values will be cleared.
 	RemoveStackTraces bool `mapstructure:"remove_stack_traces"`
@@ -116,6 +205,7 @@ func (o *ObfuscationConfig) Export() obfuscate.Config {
 			ReplaceDigits:    features.Has("quantize_sql_tables") || features.Has("replace_sql_digits"),
 			KeepSQLAlias:     features.Has("keep_sql_alias"),
 			DollarQuotedFunc: features.Has("dollar_quoted_func"),
+			CollapseLists:    features.Has("collapse_sql_lists"),
 			Cache:            features.Has("sql_cache"),
 		},
 		ES: obfuscate.JSONConfig{
@@ -142,6 +232,15 @@ func (o *ObfuscationConfig) Export() obfuscate.Config {
 			RemoveQueryString: o.HTTP.RemoveQueryString,
 			RemovePathDigits:  o.HTTP.RemovePathDigits,
 		},
+		GraphQL: obfuscate.GraphQLConfig{
+			Enabled:    o.GraphQL.Enabled,
+			KeepValues: o.GraphQL.KeepValues,
+		},
+		JSONBody: obfuscate.JSONConfig{
+			Enabled:            o.JSONBody.Enabled,
+			KeepValues:         o.JSONBody.KeepValues,
+			ObfuscateSQLValues: o.JSONBody.ObfuscateSQLValues,
+		},
 		Logger: new(debugLogger),
 	}
 }
@@ -173,6 +272,16 @@ type HTTPObfuscationConfig struct {
 	RemovePathDigits bool `mapstructure:"remove_paths_with_digits" json:"remove_path_digits"`
 }
 
+// GraphQLObfuscationConfig holds the configuration settings for GraphQL obfuscation.
+type GraphQLObfuscationConfig struct {
+	// Enabled will specify whether obfuscation should be enabled.
+	Enabled bool `mapstructure:"enabled"`
+
+	// KeepValues will specify a set of arguments and input object fields
+	// for which their values will not be obfuscated.
+	KeepValues []string `mapstructure:"keep_values"`
+}
+
 // Enablable can represent any option that has an "enabled" boolean sub-field.
 type Enablable struct {
 	Enabled bool `mapstructure:"enabled"`
@@ -217,6 +326,53 @@ type ReplaceRule struct {
 	Repl string `mapstructure:"repl"`
 }
 
+// The actions of the scrub rules.
+const (
+	// ScrubDropTag removes the tags whose key matches the rule.
+	ScrubDropTag = "drop_tag"
+	// ScrubHash replaces the values of the matching tags with their keyed HMAC-SHA256, in hexadecimal.
+	ScrubHash = "hash"
+	// ScrubTruncate truncates the values of the matching tags to MaxLength bytes.
+	ScrubTruncate = "truncate"
+	// ScrubMaskPII masks the personal data found in the values of the matching tags.
+	ScrubMaskPII = "mask_pii"
+	// ScrubDropSpan removes the matching spans, keeping the rest of their trace.
+	ScrubDropSpan = "drop_span"
+)
+
+// ScrubRule describes a rule scrubbing the spans. It applies to the spans matching Service
+// and Operation, when set.
+type ScrubRule struct {
+	// Name identifies the rule in the stats. It defaults to the action of the rule.
+	Name string `mapstructure:"name"`
+
+	// Action specifies what the rule does, one of drop_tag, hash, truncate, mask_pii or drop_span.
+	Action string `mapstructure:"action"`
+
+	// Service and Operation restrict the rule to the spans with this service and this
+	// operation name.
+	Service   string `mapstructure:"service"`
+	Operation string `mapstructure:"operation"`
+
+	// Key specifies a regexp pattern matching the keys of the tags the rule applies to. It is
+	// required by the drop_tag, hash and truncate actions. The mask_pii action applies to all
+	// the tags if it is not set.
+	Key string `mapstructure:"key"`
+
+	// KeyRe holds the compiled Key and is only used internally.
+	KeyRe *regexp.Regexp `mapstructure:"-" json:"-"`
+
+	// HMACKey specifies the secret key used by the hash action.
+	HMACKey string `mapstructure:"hmac_key" json:"-"`
+
+	// MaxLength specifies the maximum length of the values kept by the truncate action.
+	MaxLength int `mapstructure:"max_length"`
+
+	// PII lists the kinds of personal data masked by the mask_pii action, among email, iban,
+	// jwt and bearer. All of them are masked if it is empty.
+	PII []string `mapstructure:"pii"`
+}
+
 // WriterConfig specifies configuration for an API writer.
 type WriterConfig struct {
 	// ConnectionLimit specifies the maximum number of concurrent outgoing
@@ -288,6 +444,13 @@ type AgentConfig struct {
 	BucketInterval   time.Duration // the size of our pre-aggregation per bucket
 	ExtraAggregators []string
 
+	// ExtraAggregationTags lists the span tags used as additional dimensions of the APM stats,
+	// at most MaxExtraAggregationTags of them.
+	ExtraAggregationTags []string
+	// ExtraAggregationTagsMaxCardinality is the maximum number of distinct values of each extra
+	// aggregation tag in a stats bucket. Values over this limit are aggregated as "other".
+	ExtraAggregationTagsMaxCardinality int
+
 	// Sampler configuration
 	ExtraSampleRate    float64
 	TargetTPS          float64
@@ -296,6 +459,9 @@ type AgentConfig struct {
 	MaxEPS             float64
 	MaxRemoteTPS       float64
 
+	// TailSampling holds the configuration of the tail-based sampling mode.
+	TailSampling TailSamplingConfig
+
 	// Receiver
 	ReceiverHost    string
 	ReceiverPort    int
@@ -342,6 +508,9 @@ type AgentConfig struct {
 	// It maps tag keys to a set of replacements. Only supported in A6.
 	ReplaceTags []*ReplaceRule
 
+	// ScrubRules lists the rules scrubbing the tags of the spans, or dropping spans.
+	ScrubRules []*ScrubRule
+
 	// GlobalTags list metadata that will be added to all spans
 	GlobalTags map[string]string
 
@@ -364,6 +533,12 @@ type AgentConfig struct {
 	// OTLPReceiver holds the configuration for OpenTelemetry receiver.
 	OTLPReceiver *OTLP
 
+	// Zipkin holds the configuration for the Zipkin receiver.
+	Zipkin ZipkinConfig
+
+	// Jaeger holds the configuration for the Jaeger receivers.
+	Jaeger JaegerConfig
+
 	// ProfilingProxy specifies settings for the profiling proxy.
 	ProfilingProxy ProfilingProxyConfig
 
@@ -418,13 +593,18 @@ func New() *AgentConfig {
 		Site:                "datadoghq.com",
 		MaxCatalogEntries:   5000,
 
-		BucketInterval: time.Duration(10) * time.Second,
+		BucketInterval:                     time.Duration(10) * time.Second,
+		ExtraAggregationTagsMaxCardinality: 100,
 
 		ExtraSampleRate: 1.0,
 		TargetTPS:       10,
 		ErrorTPS:        10,
 		MaxEPS:          200,
 		MaxRemoteTPS:    100,
+		TailSampling: TailSamplingConfig{
+			DecisionWait:   30 * time.Second,
+			MaxBufferBytes: 100 * 1024 * 1024, // 100MB
+		},
 
 		ReceiverHost:           "localhost",
 		ReceiverPort:           8126,
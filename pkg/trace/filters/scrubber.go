@@ -0,0 +1,327 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
+)
+
+// scrubReportInterval specifies the frequency at which the matches of the rules are reported.
+const scrubReportInterval = 10 * time.Second
+
+// piiMask replaces the personal data masked by the mask_pii rules.
+const piiMask = "?"
+
+// piiPattern detects a kind of personal data.
+type piiPattern struct {
+	// hint is a substring of all the matches, used to skip the values quickly.
+	hint string
+	re   *regexp.Regexp
+	// repl is the replacement of the matches, in the regexp.Expand syntax.
+	repl string
+	// valid reports whether a match is a valid value, if set.
+	valid func(string) bool
+}
+
+// piiPatterns holds the kinds of personal data masked by the mask_pii rules.
+var piiPatterns = map[string]*piiPattern{
+	"email": {
+		hint: "@",
+		re:   regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9-]+(?:\.[a-zA-Z0-9-]+)*\.[a-zA-Z]{2,}`),
+		repl: piiMask,
+	},
+	"iban": {
+		re:    regexp.MustCompile(`\b[A-Z]{2}[0-9]{2}(?: ?[A-Z0-9]){11,30}\b`),
+		repl:  piiMask,
+		valid: validIBAN,
+	},
+	"jwt": {
+		hint: "eyJ",
+		re:   regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`),
+		repl: piiMask,
+	},
+	"bearer": {
+		re:   regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/-]+=*`),
+		repl: "${1}" + piiMask,
+	},
+}
+
+// mask returns v with the matches of the pattern masked, and whether any match was masked.
+func (p *piiPattern) mask(v string) (string, bool) {
+	if p.hint != "" && !strings.Contains(v, p.hint) {
+		return v, false
+	}
+	var masked bool
+	out := p.re.ReplaceAllStringFunc(v, func(m string) string {
+		if p.valid != nil && !p.valid(m) {
+			return m
+		}
+		masked = true
+		return p.re.ReplaceAllString(m, p.repl)
+	})
+	return out, masked
+}
+
+// validIBAN reports whether s, possibly containing spaces, is an IBAN with a valid checksum.
+func validIBAN(s string) bool {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) < 15 {
+		return false
+	}
+	// the country code and the check digits are moved to the end, and the letters are
+	// converted to numbers (A=10 ... Z=35): the number modulo 97 must be 1.
+	var mod int
+	for _, c := range s[4:] + s[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			mod = (mod*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			mod = (mod*100 + int(c-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return mod == 1
+}
+
+// CompileScrubRules validates the scrub rules and compiles their key patterns.
+func CompileScrubRules(rules []*config.ScrubRule) error {
+	for i, r := range rules {
+		if r.Name == "" {
+			r.Name = r.Action
+		}
+		if err := compileScrubRule(r); err != nil {
+			return fmt.Errorf("rule #%d (%s): %v", i, r.Name, err)
+		}
+	}
+	return nil
+}
+
+func compileScrubRule(r *config.ScrubRule) error {
+	switch r.Action {
+	case config.ScrubDropTag, config.ScrubHash, config.ScrubTruncate:
+		if r.Key == "" {
+			return errors.New(`a "key" pattern is required`)
+		}
+	case config.ScrubMaskPII:
+		for _, kind := range r.PII {
+			if _, ok := piiPatterns[kind]; !ok {
+				return fmt.Errorf("unknown kind of personal data %q", kind)
+			}
+		}
+	case config.ScrubDropSpan:
+		if r.Service == "" && r.Operation == "" {
+			return errors.New(`a "service" or an "operation" is required`)
+		}
+	default:
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+	if r.Action == config.ScrubHash && r.HMACKey == "" {
+		return errors.New(`a "hmac_key" is required`)
+	}
+	if r.Action == config.ScrubTruncate && r.MaxLength <= 0 {
+		return errors.New(`"max_length" must be positive`)
+	}
+	if r.Key != "" {
+		re, err := regexp.Compile(r.Key)
+		if err != nil {
+			return err
+		}
+		r.KeyRe = re
+	}
+	return nil
+}
+
+// scrubRule is a scrub rule along with the number of times it matched.
+type scrubRule struct {
+	matches int64 // atomic, first for 64 bits alignment
+	*config.ScrubRule
+	pii []*piiPattern
+}
+
+// Scrubber is a filter which scrubs the tags of the spans, or drops spans, according to its
+// rules. The rules must have been compiled with CompileScrubRules.
+type Scrubber struct {
+	rules []*scrubRule
+	exit  chan struct{}
+	done  chan struct{}
+}
+
+// NewScrubber returns a new Scrubber which will use the given set of rules.
+func NewScrubber(rules []*config.ScrubRule) *Scrubber {
+	s := &Scrubber{
+		rules: make([]*scrubRule, 0, len(rules)),
+		exit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	for _, r := range rules {
+		sr := &scrubRule{ScrubRule: r}
+		if r.Action == config.ScrubMaskPII {
+			kinds := r.PII
+			if len(kinds) == 0 {
+				kinds = []string{"email", "iban", "jwt", "bearer"}
+			}
+			for _, kind := range kinds {
+				sr.pii = append(sr.pii, piiPatterns[kind])
+			}
+		}
+		s.rules = append(s.rules, sr)
+	}
+	return s
+}
+
+// Start starts reporting the number of matches of the rules.
+func (s *Scrubber) Start() {
+	if len(s.rules) == 0 {
+		close(s.done)
+		return
+	}
+	go func() {
+		defer watchdog.LogOnPanic()
+		defer close(s.done)
+		tick := time.NewTicker(scrubReportInterval)
+		defer tick.Stop()
+		reported := make([]int64, len(s.rules))
+		for {
+			select {
+			case <-tick.C:
+				s.report(reported)
+			case <-s.exit:
+				s.report(reported)
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops reporting the matches of the rules.
+func (s *Scrubber) Stop() {
+	close(s.exit)
+	<-s.done
+}
+
+func (s *Scrubber) report(reported []int64) {
+	stats := make([]info.ScrubRuleInfo, len(s.rules))
+	for i, r := range s.rules {
+		n := atomic.LoadInt64(&r.matches)
+		stats[i] = info.ScrubRuleInfo{Name: r.Name, Matches: n}
+		if n > reported[i] {
+			metrics.Count("datadog.trace_agent.scrubber.matches", n-reported[i], []string{"rule:" + r.Name}, 1)
+		}
+		reported[i] = n
+	}
+	info.UpdateScrubRuleInfo(stats)
+}
+
+// Scrub applies the rules to the spans of a trace chunk, and returns the remaining spans. The
+// root span is never dropped: the children of the dropped spans are attached to their closest
+// remaining ancestor.
+func (s *Scrubber) Scrub(spans []*pb.Span, root *pb.Span) []*pb.Span {
+	for _, r := range s.rules {
+		if r.Action == config.ScrubDropSpan {
+			spans = r.dropSpans(spans, root)
+			continue
+		}
+		for _, span := range spans {
+			if r.appliesTo(span) {
+				r.scrubTags(span)
+			}
+		}
+	}
+	return spans
+}
+
+func (r *scrubRule) appliesTo(s *pb.Span) bool {
+	return (r.Service == "" || r.Service == s.Service) && (r.Operation == "" || r.Operation == s.Name)
+}
+
+// scrubTags applies the rule to the tags of the span s.
+func (r *scrubRule) scrubTags(s *pb.Span) {
+	for k, v := range s.Meta {
+		if r.KeyRe != nil && !r.KeyRe.MatchString(k) {
+			continue
+		}
+		switch r.Action {
+		case config.ScrubDropTag:
+			delete(s.Meta, k)
+		case config.ScrubHash:
+			mac := hmac.New(sha256.New, []byte(r.HMACKey))
+			mac.Write([]byte(v))
+			s.Meta[k] = hex.EncodeToString(mac.Sum(nil))
+		case config.ScrubTruncate:
+			if len(v) <= r.MaxLength {
+				continue
+			}
+			s.Meta[k] = traceutil.TruncateUTF8(v, r.MaxLength)
+		case config.ScrubMaskPII:
+			var masked bool
+			for _, p := range r.pii {
+				var ok bool
+				if v, ok = p.mask(v); ok {
+					masked = true
+				}
+			}
+			if !masked {
+				continue
+			}
+			s.Meta[k] = v
+		}
+		atomic.AddInt64(&r.matches, 1)
+	}
+}
+
+// dropSpans removes the spans matching the rule, except root, and attaches their children to
+// their parent.
+func (r *scrubRule) dropSpans(spans []*pb.Span, root *pb.Span) []*pb.Span {
+	var parents map[uint64]uint64 // parent IDs of the dropped spans, by span ID
+	n := 0
+	for _, s := range spans {
+		if s != root && r.appliesTo(s) {
+			if parents == nil {
+				parents = make(map[uint64]uint64)
+			}
+			parents[s.SpanID] = s.ParentID
+			continue
+		}
+		spans[n] = s
+		n++
+	}
+	if parents == nil {
+		return spans
+	}
+	atomic.AddInt64(&r.matches, int64(len(parents)))
+	// set everything at the back of the array to nil to avoid memory leaking
+	for i := n; i < len(spans); i++ {
+		spans[i] = nil
+	}
+	spans = spans[:n]
+	for _, s := range spans {
+		// the number of steps is bounded, in case of a cycle in the parent IDs
+		for i := 0; i < len(parents); i++ {
+			p, ok := parents[s.ParentID]
+			if !ok {
+				break
+			}
+			s.ParentID = p
+		}
+	}
+	return spans
+}
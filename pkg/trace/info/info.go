@@ -31,8 +31,10 @@ var (
 
 	// TODO: move from package globals to a clean single struct
 
-	traceWriterInfo TraceWriterInfo
-	statsWriterInfo StatsWriterInfo
+	traceWriterInfo  TraceWriterInfo
+	statsWriterInfo  StatsWriterInfo
+	tailSamplingInfo TailSamplingInfo
+	scrubRuleInfo    []ScrubRuleInfo
 
 	watchdogInfo     watchdog.Info
 	rateByService    map[string]float64
@@ -77,6 +79,21 @@ const (
   {{if lt .Status.RateLimiter.TargetRate 1.0}}
   WARNING: Rate-limiter keep percentage: {{percent .Status.RateLimiter.TargetRate}} %
   {{end}}
+  {{if .Status.Config.TailSampling.Enabled}}
+  --- Tail sampling ---
+
+  Buffered: {{.Status.TailSampling.BufferedTraces}} traces, {{.Status.TailSampling.BufferedSpans}} spans, {{.Status.TailSampling.BufferedBytes}} bytes
+  Decisions: {{.Status.TailSampling.TracesKept}} traces kept, {{.Status.TailSampling.TracesDropped}} traces dropped
+  {{if gt .Status.TailSampling.TracesEvicted 0}}WARNING: Traces decided early as the buffer was full: {{.Status.TailSampling.TracesEvicted}}{{end}}
+  {{if gt .Status.TailSampling.LateChunks 0}}Chunks received after the decision: {{.Status.TailSampling.LateChunks}}{{end}}
+  {{end}}
+  {{if .Status.ScrubRules}}
+  --- Scrub rules ---
+
+  {{ range $i, $r := .Status.ScrubRules }}
+  Rule '{{ $r.Name }}': {{ $r.Matches }} matches
+  {{end}}
+  {{end}}
 
   --- Writer stats (1 min) ---
 
@@ -219,6 +236,8 @@ func InitInfo(conf *config.AgentConfig) error {
 		expvar.Publish("ratebyservice", expvar.Func(publishRateByService))
 		expvar.Publish("watchdog", expvar.Func(publishWatchdogInfo))
 		expvar.Publish("ratelimiter", expvar.Func(publishRateLimiterStats))
+		expvar.Publish("tail_sampling", expvar.Func(publishTailSamplingInfo))
+		expvar.Publish("scrub_rules", expvar.Func(publishScrubRuleInfo))
 
 		// copy the config to ensure we don't expose sensitive data such as API keys
 		c := *conf
@@ -276,6 +295,8 @@ type StatusInfo struct {
 	StatsWriter   StatsWriterInfo    `json:"stats_writer"`
 	Watchdog      watchdog.Info      `json:"watchdog"`
 	RateLimiter   RateLimiterStats   `json:"ratelimiter"`
+	TailSampling  TailSamplingInfo   `json:"tail_sampling"`
+	ScrubRules    []ScrubRuleInfo    `json:"scrub_rules"`
 	Config        config.AgentConfig `json:"config"`
 }
 
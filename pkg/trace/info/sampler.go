@@ -0,0 +1,36 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package info
+
+// TailSamplingInfo represents statistics from the tail-based sampler.
+type TailSamplingInfo struct {
+	// BufferedTraces, BufferedSpans and BufferedBytes describe the traces currently waiting for
+	// a sampling decision.
+	BufferedTraces int64
+	BufferedSpans  int64
+	BufferedBytes  int64
+	// TracesKept and TracesDropped count the sampling decisions taken since the start.
+	TracesKept    int64
+	TracesDropped int64
+	// TracesEvicted counts the traces decided before the end of the decision window, because
+	// the buffer was full.
+	TracesEvicted int64
+	// LateChunks counts the chunks received after the decision on their trace.
+	LateChunks int64
+}
+
+// UpdateTailSamplingInfo updates internal tail sampler stats
+func UpdateTailSamplingInfo(tsi TailSamplingInfo) {
+	infoMu.Lock()
+	defer infoMu.Unlock()
+	tailSamplingInfo = tsi
+}
+
+func publishTailSamplingInfo() interface{} {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	return tailSamplingInfo
+}
@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package info
+
+// ScrubRuleInfo represents statistics from a span scrubbing rule.
+type ScrubRuleInfo struct {
+	Name string
+	// Matches counts the tags, or the spans for the drop_span rules, matched since the start.
+	Matches int64
+}
+
+// UpdateScrubRuleInfo updates internal span scrubber stats
+func UpdateScrubRuleInfo(sri []ScrubRuleInfo) {
+	infoMu.Lock()
+	defer infoMu.Unlock()
+	scrubRuleInfo = sri
+}
+
+func publishScrubRuleInfo() interface{} {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	return scrubRuleInfo
+}
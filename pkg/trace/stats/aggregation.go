@@ -33,6 +33,9 @@ type BucketsAggregationKey struct {
 	Type       string
 	StatusCode uint32
 	Synthetics bool
+	// ExtraTags holds the configured extra aggregation dimensions, as normalized "key:value"
+	// tags sorted by key and joined by commas.
+	ExtraTags string
 }
 
 // PayloadAggregationKey specifies the key by which a payload is aggregated.
@@ -81,6 +84,7 @@ func NewAggregationFromGroup(g pb.ClientGroupedStats) Aggregation {
 			Name:       g.Name,
 			StatusCode: g.HTTPStatusCode,
 			Synthetics: g.Synthetics,
+			ExtraTags:  strings.Join(g.ExtraTags, extraTagsSeparator),
 		},
 	}
 }
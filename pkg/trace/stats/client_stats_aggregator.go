@@ -6,6 +6,7 @@
 package stats
 
 import (
+	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
@@ -45,6 +46,11 @@ type ClientStatsAggregator struct {
 	agentEnv      string
 	agentHostname string
 
+	// extraTags bounds the cardinality of the extra aggregation dimensions of the stats, its
+	// limits being reset every clientBucketDuration, at lastExtraTagsReset.
+	extraTags          *extraTagsLimiter
+	lastExtraTagsReset time.Time
+
 	exit chan struct{}
 	done chan struct{}
 }
@@ -59,6 +65,7 @@ func NewClientStatsAggregator(conf *config.AgentConfig, out chan pb.StatsPayload
 		agentEnv:      conf.DefaultEnv,
 		agentHostname: conf.Hostname,
 		oldestTs:      alignAggTs(time.Now().Add(bucketDuration - oldestBucketStart)),
+		extraTags:     newExtraTagsLimiter(conf.ExtraAggregationTags, conf.ExtraAggregationTagsMaxCardinality),
 		exit:          make(chan struct{}),
 		done:          make(chan struct{}),
 	}
@@ -99,6 +106,10 @@ func (a *ClientStatsAggregator) flushOnTime(now time.Time) {
 		}
 	}
 	a.oldestTs = flushTs
+	if now.Sub(a.lastExtraTagsReset) >= clientBucketDuration {
+		a.extraTags.reset()
+		a.lastExtraTagsReset = now
+	}
 }
 
 func (a *ClientStatsAggregator) flushAll() {
@@ -133,6 +144,11 @@ func (a *ClientStatsAggregator) add(now time.Time, p pb.ClientStatsPayload) {
 			b = &bucket{ts: ts}
 			a.buckets[ts.Unix()] = b
 		}
+		if a.extraTags != nil {
+			for i, g := range clientBucket.Stats {
+				clientBucket.Stats[i].ExtraTags = splitExtraTags(a.extraTags.fromTags(g.ExtraTags))
+			}
+		}
 		p.Stats = []pb.ClientStatsBucket{clientBucket}
 		a.flush(b.add(p))
 	}
@@ -207,7 +223,7 @@ func (b *bucket) aggregateCounts(p pb.ClientStatsPayload) {
 			aggKey := newBucketAggregationKey(sb)
 			agg, ok := payloadAgg[aggKey]
 			if !ok {
-				agg = &aggregatedCounts{}
+				agg = &aggregatedCounts{extraTags: sb.ExtraTags}
 				payloadAgg[aggKey] = agg
 			}
 			agg.hits += sb.Hits
@@ -236,6 +252,7 @@ func (b *bucket) aggregationToPayloads() []pb.ClientStatsPayload {
 				HTTPStatusCode: aggrKey.StatusCode,
 				Type:           aggrKey.Type,
 				Synthetics:     aggrKey.Synthetics,
+				ExtraTags:      counts.extraTags,
 				Hits:           counts.hits,
 				Errors:         counts.errors,
 				Duration:       counts.duration,
@@ -270,6 +287,7 @@ func newBucketAggregationKey(b pb.ClientGroupedStats) BucketsAggregationKey {
 		Type:       b.Type,
 		Synthetics: b.Synthetics,
 		StatusCode: b.HTTPStatusCode,
+		ExtraTags:  strings.Join(b.ExtraTags, extraTagsSeparator),
 	}
 }
 
@@ -290,4 +308,6 @@ func trimCounts(p pb.ClientStatsPayload) pb.ClientStatsPayload {
 // Distributions and TopLevelCount will stay on the initial payload
 type aggregatedCounts struct {
 	hits, errors, duration uint64
+	// extraTags holds the extra aggregation tags of the aggregated stats.
+	extraTags []string
 }
@@ -6,6 +6,7 @@
 package stats
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -56,6 +57,7 @@ func payloadWithCounts(ts time.Time, k BucketsAggregationKey, hits, errors, dura
 						HTTPStatusCode: k.StatusCode,
 						Type:           k.Type,
 						Synthetics:     k.Synthetics,
+						ExtraTags:      splitExtraTags(k.ExtraTags),
 						Hits:           hits,
 						Errors:         errors,
 						Duration:       duration,
@@ -289,6 +291,11 @@ func TestCountAggregation(t *testing.T) {
 			pb.ClientGroupedStats{HTTPStatusCode: 10},
 			"status",
 		},
+		{
+			BucketsAggregationKey{ExtraTags: "region:eu,tier:gold"},
+			pb.ClientGroupedStats{ExtraTags: []string{"region:eu", "tier:gold"}},
+			"extra tags",
+		},
 	}
 	for _, tc := range tts {
 		t.Run(tc.name, func(t *testing.T) {
@@ -332,6 +339,31 @@ func TestCountAggregation(t *testing.T) {
 	}
 }
 
+func TestAggregatorExtraTags(t *testing.T) {
+	assert := assert.New(t)
+	a := newTestAggregator()
+	a.extraTags = newExtraTagsLimiter([]string{"region"}, 1)
+	testTime := time.Unix(time.Now().Unix(), 0)
+
+	for _, tags := range [][]string{{"region:eu", "env:prod"}, {"region:eu"}, {"region:us"}, {"region:ap"}} {
+		a.add(testTime, payloadWithCounts(testTime, BucketsAggregationKey{Service: "s", ExtraTags: strings.Join(tags, ",")}, 1, 0, 10))
+	}
+	assert.Len(a.out, 3)
+	var extraTags [][]string
+	for i := 0; i < 3; i++ {
+		for _, p := range (<-a.out).Stats {
+			extraTags = append(extraTags, p.Stats[0].Stats[0].ExtraTags)
+		}
+	}
+	assert.Equal([][]string{{"region:eu"}, {"region:eu"}, {"region:other"}, {"region:other"}}, extraTags)
+	a.flushOnTime(testTime.Add(oldestBucketStart + time.Nanosecond))
+	aggCounts := <-a.out
+	assert.ElementsMatch(aggCounts.Stats[0].Stats[0].Stats, []pb.ClientGroupedStats{
+		{Service: "s", ExtraTags: []string{"region:eu"}, Hits: 2, Duration: 20},
+		{Service: "s", ExtraTags: []string{"region:other"}, Hits: 2, Duration: 20},
+	})
+}
+
 func deepCopy(p pb.ClientStatsPayload) pb.ClientStatsPayload {
 	new := p
 	new.Stats = deepCopyStatsBucket(p.Stats)
@@ -46,6 +46,11 @@ type Concentrator struct {
 	mu            sync.Mutex
 	agentEnv      string
 	agentHostname string
+	// extraTags holds the configured extra aggregation dimensions of the spans, and bucketExtraTags
+	// computes them for each bucket, so that the cardinality limits apply to each bucket.
+	// Guarded by mu.
+	extraTags       *extraTagsLimiter
+	bucketExtraTags map[int64]*extraTagsLimiter
 }
 
 // NewConcentrator initializes a new concentrator ready to be started
@@ -58,12 +63,14 @@ func NewConcentrator(conf *config.AgentConfig, out chan pb.StatsPayload, now tim
 		// override buckets which could have been sent before an Agent restart.
 		oldestTs: alignTs(now.UnixNano(), bsize),
 		// TODO: Move to configuration.
-		bufferLen:     defaultBufferLen,
-		In:            make(chan Input, 100),
-		Out:           out,
-		exit:          make(chan struct{}),
-		agentEnv:      conf.DefaultEnv,
-		agentHostname: conf.Hostname,
+		bufferLen:       defaultBufferLen,
+		In:              make(chan Input, 100),
+		Out:             out,
+		exit:            make(chan struct{}),
+		agentEnv:        conf.DefaultEnv,
+		agentHostname:   conf.Hostname,
+		extraTags:       newExtraTagsLimiter(conf.ExtraAggregationTags, conf.ExtraAggregationTagsMaxCardinality),
+		bucketExtraTags: make(map[int64]*extraTagsLimiter),
 	}
 	return &c
 }
@@ -178,8 +185,9 @@ func (c *Concentrator) addNow(pt *traceutil.ProcessedTrace, containerID string)
 		if !ok {
 			b = NewRawBucket(uint64(btime), uint64(c.bsize))
 			c.buckets[btime] = b
+			c.bucketExtraTags[btime] = c.extraTags.clone()
 		}
-		b.HandleSpan(s, weight, isTop, pt.TraceChunk.Origin, aggKey)
+		b.handleSpan(s, weight, isTop, pt.TraceChunk.Origin, aggKey, c.bucketExtraTags[btime].fromSpan(s))
 	}
 }
 
@@ -204,6 +212,7 @@ func (c *Concentrator) flushNow(now int64) pb.StatsPayload {
 			m[k] = append(m[k], b)
 		}
 		delete(c.buckets, ts)
+		delete(c.bucketExtraTags, ts)
 	}
 	// After flushing, update the oldest timestamp allowed to prevent having stats for
 	// an already-flushed bucket.
@@ -528,3 +528,73 @@ func TestIgnoresPartialSpans(t *testing.T) {
 	stats := c.flushNow(now.UnixNano() + int64(c.bufferLen)*testBucketInterval)
 	assert.Empty(stats.GetStats())
 }
+
+// TestConcentratorExtraTags tests that the spans are aggregated by the configured extra tags,
+// the values over the cardinality limit being folded into "other".
+func TestConcentratorExtraTags(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	c := NewTestConcentrator(now)
+	c.extraTags = newExtraTagsLimiter([]string{"region", "customer_tier"}, 2)
+
+	var spans []*pb.Span
+	for i, region := range []string{"us-east", "eu-west", "us-east", "ap-south", "", "us-west"} {
+		s := testSpan(uint64(i+1), 0, 50, 5, "A1", "resource1", 0)
+		s.Meta = map[string]string{"customer_tier": "Gold", "other": "ignored"}
+		if region != "" {
+			s.Meta["region"] = region
+		}
+		spans = append(spans, s)
+	}
+	traceutil.ComputeTopLevel(spans)
+	c.addNow(toProcessedTrace(spans, "none", ""), "")
+
+	stats := c.flushNow(now.UnixNano() + int64(c.bufferLen)*testBucketInterval)
+	hits := make(map[string]uint64)
+	for _, g := range stats.Stats[0].Stats[0].Stats {
+		hits[fmt.Sprint(g.ExtraTags)] += g.Hits
+	}
+	assert.Equal(map[string]uint64{
+		"[customer_tier:gold region:us-east]": 2,
+		"[customer_tier:gold region:eu-west]": 1,
+		"[customer_tier:gold region:other]":   2,
+		"[customer_tier:gold]":                1,
+	}, hits)
+	assert.Empty(c.bucketExtraTags, "the cardinality limits of the flushed buckets should be dropped")
+}
+
+// TestConcentratorExtraTagsAcrossFlushes tests that the cardinality limits of the extra tags apply
+// to a bucket receiving spans across several flushes.
+func TestConcentratorExtraTagsAcrossFlushes(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	c := NewTestConcentrator(now)
+	c.extraTags = newExtraTagsLimiter([]string{"region"}, 2)
+
+	addRegions := func(spanID uint64, regions ...string) {
+		var spans []*pb.Span
+		for i, region := range regions {
+			s := testSpan(spanID+uint64(i), 0, 50, 0, "A1", "resource1", 0)
+			s.Meta = map[string]string{"region": region}
+			spans = append(spans, s)
+		}
+		traceutil.ComputeTopLevel(spans)
+		c.addNow(toProcessedTrace(spans, "none", ""), "")
+	}
+
+	addRegions(1, "us-east", "eu-west")
+	// the current bucket is kept open by the flush
+	assert.Empty(c.flushNow(now.UnixNano()).Stats)
+	addRegions(10, "ap-south", "us-east")
+
+	stats := c.flushNow(now.UnixNano() + int64(c.bufferLen)*testBucketInterval)
+	hits := make(map[string]uint64)
+	for _, g := range stats.Stats[0].Stats[0].Stats {
+		hits[fmt.Sprint(g.ExtraTags)] += g.Hits
+	}
+	assert.Equal(map[string]uint64{
+		"[region:us-east]": 2,
+		"[region:eu-west]": 1,
+		"[region:other]":   1,
+	}, hits)
+}
@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// extraTagsOther is the value replacing the values of an extra aggregation tag once the
+// cardinality limit of this tag is reached.
+const extraTagsOther = "other"
+
+// extraTagsSeparator separates the tags of the ExtraTags aggregation key. Tags are normalized,
+// so they never contain it.
+const extraTagsSeparator = ","
+
+// extraTagsLimiter computes the extra aggregation dimensions of the stats, made of the values
+// of a configured list of span tags. The number of distinct values of each tag is bounded:
+// once the limit is reached, new values are folded into the "other" value until the next reset.
+// It is not safe for concurrent use.
+type extraTagsLimiter struct {
+	keys           []string // sorted
+	maxCardinality int
+	seen           map[string]map[string]struct{} // seen values, by key
+}
+
+// newExtraTagsLimiter returns a limiter for the given tag keys, or nil if keys is empty.
+func newExtraTagsLimiter(keys []string, maxCardinality int) *extraTagsLimiter {
+	if len(keys) == 0 {
+		return nil
+	}
+	sorted := make([]string, 0, len(keys))
+	seen := make(map[string]map[string]struct{}, len(keys))
+	for _, k := range keys {
+		if _, ok := seen[k]; ok || k == "" {
+			continue
+		}
+		seen[k] = make(map[string]struct{})
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	return &extraTagsLimiter{
+		keys:           sorted,
+		maxCardinality: maxCardinality,
+		seen:           seen,
+	}
+}
+
+// clone returns a limiter for the same tag keys and cardinality limit which hasn't seen any value,
+// or nil if l is nil.
+func (l *extraTagsLimiter) clone() *extraTagsLimiter {
+	if l == nil {
+		return nil
+	}
+	seen := make(map[string]map[string]struct{}, len(l.keys))
+	for _, k := range l.keys {
+		seen[k] = make(map[string]struct{})
+	}
+	return &extraTagsLimiter{
+		keys:           l.keys,
+		maxCardinality: l.maxCardinality,
+		seen:           seen,
+	}
+}
+
+// fromSpan returns the extra aggregation key of the span s, made of the configured tags found
+// in its meta.
+func (l *extraTagsLimiter) fromSpan(s *pb.Span) string {
+	if l == nil || len(s.Meta) == 0 {
+		return ""
+	}
+	tags := make([]string, 0, len(l.keys))
+	for _, k := range l.keys {
+		if v, ok := s.Meta[k]; ok {
+			tags = append(tags, l.tag(k, v))
+		}
+	}
+	return strings.Join(tags, extraTagsSeparator)
+}
+
+// fromTags returns the extra aggregation key of grouped stats, given their "key:value" extra
+// tags. The tags which are not configured are dropped.
+func (l *extraTagsLimiter) fromTags(extraTags []string) string {
+	if l == nil || len(extraTags) == 0 {
+		return ""
+	}
+	values := make(map[string]string, len(extraTags))
+	for _, t := range extraTags {
+		if i := strings.IndexByte(t, ':'); i > 0 {
+			values[t[:i]] = t[i+1:]
+		}
+	}
+	tags := make([]string, 0, len(l.keys))
+	for _, k := range l.keys {
+		if v, ok := values[k]; ok {
+			tags = append(tags, l.tag(k, v))
+		}
+	}
+	return strings.Join(tags, extraTagsSeparator)
+}
+
+// tag returns the normalized "key:value" tag for the value v of the key k, or "key:other" if
+// the cardinality limit of k is reached.
+func (l *extraTagsLimiter) tag(k, v string) string {
+	tag := traceutil.NormalizeTag(k + ":" + v)
+	seen := l.seen[k]
+	if _, ok := seen[tag]; ok {
+		return tag
+	}
+	if len(seen) >= l.maxCardinality {
+		return traceutil.NormalizeTag(k + ":" + extraTagsOther)
+	}
+	seen[tag] = struct{}{}
+	return tag
+}
+
+// reset forgets the values seen so far.
+func (l *extraTagsLimiter) reset() {
+	if l == nil {
+		return
+	}
+	for k := range l.seen {
+		l.seen[k] = make(map[string]struct{})
+	}
+}
+
+// splitExtraTags returns the tags of the extra aggregation key k.
+func splitExtraTags(k string) []string {
+	if k == "" {
+		return nil
+	}
+	return strings.Split(k, extraTagsSeparator)
+}
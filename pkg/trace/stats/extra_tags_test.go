@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtraTagsLimiter(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		var l *extraTagsLimiter
+		assert.Nil(t, newExtraTagsLimiter(nil, 10))
+		assert.Equal(t, "", l.fromSpan(&pb.Span{Meta: map[string]string{"region": "eu"}}))
+		assert.Equal(t, "", l.fromTags([]string{"region:eu"}))
+		l.reset()
+		assert.Nil(t, l.clone())
+	})
+
+	t.Run("span", func(t *testing.T) {
+		l := newExtraTagsLimiter([]string{"region", "tier", "region", ""}, 10)
+		assert.Equal(t, []string{"region", "tier"}, l.keys)
+		assert.Equal(t, "region:eu,tier:gold", l.fromSpan(&pb.Span{Meta: map[string]string{
+			"tier":   "Gold",
+			"region": "eu",
+			"env":    "prod",
+		}}))
+		assert.Equal(t, "tier:a_b", l.fromSpan(&pb.Span{Meta: map[string]string{"tier": "a,b"}}))
+		assert.Equal(t, "", l.fromSpan(&pb.Span{}))
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		l := newExtraTagsLimiter([]string{"region", "tier"}, 10)
+		assert.Equal(t, "region:eu,tier:gold", l.fromTags([]string{"tier:gold", "env:prod", "region:eu", "invalid"}))
+		assert.Equal(t, []string{"region:eu", "tier:gold"}, splitExtraTags("region:eu,tier:gold"))
+		assert.Nil(t, splitExtraTags(""))
+	})
+
+	t.Run("cardinality", func(t *testing.T) {
+		l := newExtraTagsLimiter([]string{"region", "tier"}, 2)
+		for _, tt := range []struct{ in, out string }{
+			{"eu", "region:eu"},
+			{"us", "region:us"},
+			{"ap", "region:other"},
+			{"eu", "region:eu"},
+			{"sa", "region:other"},
+		} {
+			assert.Equal(t, tt.out, l.tag("region", tt.in))
+		}
+		assert.Equal(t, "tier:gold", l.tag("tier", "gold"))
+		l.reset()
+		assert.Equal(t, "region:ap", l.tag("region", "ap"))
+
+		clone := l.clone()
+		assert.Equal(t, "region:sa", clone.tag("region", "sa"))
+		assert.Equal(t, "region:us", clone.tag("region", "us"))
+		assert.Equal(t, "region:other", clone.tag("region", "ap"))
+		assert.Equal(t, "region:ap", l.tag("region", "ap"), "the clone shouldn't share the seen values")
+	})
+}
@@ -121,6 +121,12 @@ public:
     */
     virtual void cancelCheck(RtLoaderPyObject *check) = 0;
 
+    //! Pure virtual interruptCheck member.
+    /*!
+      \param check The python object pointer to the check whose current run we wish to interrupt.
+    */
+    virtual void interruptCheck(RtLoaderPyObject *check) = 0;
+
     //! Pure virtual getCheckWarnings member.
     /*!
       \param check The python object pointer to the check we wish to collect existing warnings for.
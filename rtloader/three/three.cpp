@@ -442,7 +442,10 @@ char *Three::runCheck(RtLoaderPyObject *check)
     char run[] = "run";
     PyObject *result = NULL;
 
+    // the GIL is held while the map is accessed, see interruptCheck
+    _runningChecks[py_check] = PyThreadState_Get()->thread_id;
     result = PyObject_CallMethod(py_check, run, NULL);
+    _runningChecks.erase(py_check);
     if (result == NULL || !PyUnicode_Check(result)) {
         setError("error invoking 'run' method: " + _fetchPythonError());
         goto done;
@@ -479,6 +482,23 @@ void Three::cancelCheck(RtLoaderPyObject *check)
     Py_XDECREF(result);
 }
 
+void Three::interruptCheck(RtLoaderPyObject *check)
+{
+    if (check == NULL) {
+        return;
+    }
+
+    PyObject *py_check = reinterpret_cast<PyObject *>(check);
+
+    std::map<PyObject *, unsigned long>::iterator it = _runningChecks.find(py_check);
+    if (it == _runningChecks.end()) {
+        return;
+    }
+
+    // the exception is raised in the thread running the check the next time it executes Python code
+    PyThreadState_SetAsyncExc(it->second, PyExc_TimeoutError);
+}
+
 char **Three::getCheckWarnings(RtLoaderPyObject *check)
 {
     if (check == NULL) {
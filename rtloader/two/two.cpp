@@ -435,7 +435,10 @@ char *Two::runCheck(RtLoaderPyObject *check)
     char run[] = "run";
     PyObject *result = NULL;
 
+    // the GIL is held while the map is accessed, see interruptCheck
+    _runningChecks[py_check] = PyThreadState_Get()->thread_id;
     result = PyObject_CallMethod(py_check, run, NULL);
+    _runningChecks.erase(py_check);
     if (result == NULL) {
         setError("error invoking 'run' method: " + _fetchPythonError());
         goto done;
@@ -475,6 +478,23 @@ void Two::cancelCheck(RtLoaderPyObject *check)
     Py_XDECREF(result);
 }
 
+void Two::interruptCheck(RtLoaderPyObject *check)
+{
+    if (check == NULL) {
+        return;
+    }
+
+    PyObject *py_check = reinterpret_cast<PyObject *>(check);
+
+    std::map<PyObject *, long>::iterator it = _runningChecks.find(py_check);
+    if (it == _runningChecks.end()) {
+        return;
+    }
+
+    // the exception is raised in the thread running the check the next time it executes Python code
+    PyThreadState_SetAsyncExc(it->second, PyExc_RuntimeError);
+}
+
 char **Two::getCheckWarnings(RtLoaderPyObject *check)
 {
     if (check == NULL) {